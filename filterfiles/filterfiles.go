@@ -3,21 +3,46 @@ package filterfiles
 import (
 	"bufio"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"skim/structured"
+	"strconv"
 	"strings"
 )
 
 /*
 Example Filter File:
 <?xml version="1.0" encoding="utf-8" standalone="yes"?>
-<TextAnalysisTool.NET version="2023-04-25" showOnlyFilteredLines="False">
+<TextAnalysisTool.NET version="2023-04-25" showOnlyFilteredLines="False" timeFormat="02/Jan/2006:15:04:05 -0700">
   <filters>
     <filter enabled="y" excluding="n" description="" backColor="87cefa" type="matches_text" case_sensitive="n" regex="y" text="^debug" />
+    <filter enabled="y" excluding="n" description="" backColor="ff6347" type="matches_text" case_sensitive="n" regex="y" text="^error$" field="level" contextBefore="20" contextAfter="2" />
+    <filter enabled="y" excluding="n" description="" backColor="98fb98" type="matches_text" case_sensitive="n" regex="y" text="^BEGIN job=" endText="^END job=" maxBlockLines="500" />
   </filters>
+  <columns>
+    <column field="ts" width="24" />
+    <column field="level" title="Lvl" width="5" />
+    <column field="msg" />
+  </columns>
 </TextAnalysisTool.NET>
+
+The field attribute and the <columns> element are skim extensions for
+structured (JSON-lines/logfmt) logs, both optional: a filter with a field
+matches against that one field's value rather than the whole line, and
+<columns> chooses which fields the Log pane shows as columns.
+contextBefore/contextAfter override, for one filter's matches, how many
+lines of context are shown around them when unmatched lines are hidden.
+endText makes a block filter, matching every line from one matching text
+through the next matching endText (see Matcher), and maxBlockLines caps
+how long such a block can run. timeFormat is another, for logs whose
+timestamps aren't in a format skim detects on its own (see
+timestamps.Parser.Layout), and gapThreshold/spikeThreshold tune what
+counts as a silence or a burst in this kind of log. All of them are
+omitted on save when unused, so a file that never uses them round-trips
+byte-for-byte as plain TAT.
 */
 
 // Structs for unmarshaling the XML filter file
@@ -25,8 +50,45 @@ type TextAnalysisToolSettings struct {
 	XMLName               xml.Name `xml:"TextAnalysisTool.NET"`
 	Version               string   `xml:"version,attr"`
 	ShowOnlyFilteredLines string   `xml:"showOnlyFilteredLines,attr"`
+	// Go time layout the log's timestamps are written in, "" to detect
+	// common formats automatically
+	TimeFormat string `xml:"timeFormat,attr,omitempty"`
+	// Gap and burst detection for this log (see logview.LogView's
+	// GapThreshold and SpikeThreshold): a time.ParseDuration string and a
+	// lines-per-second count, "" for skim's defaults
+	GapThreshold   string `xml:"gapThreshold,attr,omitempty"`
+	SpikeThreshold string `xml:"spikeThreshold,attr,omitempty"`
 	// Array of all Filters in the file
 	Filters []FilterXML `xml:"filters>filter"`
+	// Structured log mode's columns (see ColumnsXML), nil if the file has
+	// no <columns> element
+	Columns *ColumnsXML `xml:"columns"`
+}
+
+// ColumnsXML is a filter file's <columns> element. It's a pointer-held
+// struct rather than a plain "columns>column" slice path so a file without
+// one is written back without one: encoding/xml emits the parent of a path
+// tag even when the slice is empty, but omits a nil pointer.
+type ColumnsXML struct {
+	Columns []ColumnXML `xml:"column"`
+}
+
+// ColumnList returns s's columns, in display order, or nil if it has none.
+func (s TextAnalysisToolSettings) ColumnList() []ColumnXML {
+	if s.Columns == nil {
+		return nil
+	}
+	return s.Columns.Columns
+}
+
+// ColumnXML is one <column> of a filter file's <columns>: a structured log
+// field to show as its own Log pane column. Title defaults to Field, and a
+// Width of 0 picks a default (see logview.Column).
+type ColumnXML struct {
+	XMLName xml.Name `xml:"column"`
+	Field   string   `xml:"field,attr"`
+	Title   string   `xml:"title,attr,omitempty"`
+	Width   int      `xml:"width,attr,omitempty"`
 }
 
 type FilterXML struct {
@@ -39,6 +101,11 @@ type FilterXML struct {
 	CaseSensitive string   `xml:"case_sensitive,attr"`
 	Regex         string   `xml:"regex,attr"`
 	Text          string   `xml:"text,attr"`
+	Field         string   `xml:"field,attr,omitempty"`
+	ContextBefore string   `xml:"contextBefore,attr,omitempty"`
+	ContextAfter  string   `xml:"contextAfter,attr,omitempty"`
+	EndText       string   `xml:"endText,attr,omitempty"`
+	MaxBlockLines string   `xml:"maxBlockLines,attr,omitempty"`
 }
 
 type Filter struct {
@@ -48,6 +115,148 @@ type Filter struct {
 	CaseSensitive bool
 	Excluding     bool
 	BackColor     string
+
+	// Field, if set, names the structured log field (see structured.Parse)
+	// whose value Regex is matched against instead of the whole line. A
+	// line that doesn't parse, or has no such field, never matches.
+	Field string
+
+	// ContextBefore and ContextAfter, if set, are how many lines of context
+	// to show before and after this filter's matches when unmatched lines
+	// are hidden, in place of the view's own counts -- 20 lines leading up
+	// to an error, say, but none around a noisy debug filter. nil means
+	// the view's count applies. They're never modified in place, only
+	// replaced, so copies of a Filter can share them.
+	ContextBefore *int
+	ContextAfter  *int
+
+	// EndRegex is compiled from XML.EndText, which makes this a block
+	// filter (see IsBlock): rather than the lines Regex matches, it
+	// matches whole blocks of lines, each from a line matching Regex
+	// through the next line matching EndRegex, or MaxBlockLines lines if
+	// that comes first (see Matcher). MaxBlockLines of 0 means
+	// DefaultMaxBlockLines.
+	EndRegex      regexp.Regexp
+	MaxBlockLines int
+}
+
+// DefaultMaxBlockLines caps a block filter's blocks when it doesn't set its
+// own MaxBlockLines: long enough for any request or job worth reading as
+// a block, short enough that a start line whose end never comes doesn't
+// swallow the rest of the log.
+const DefaultMaxBlockLines = 1000
+
+// IsBlock reports whether f is a block filter, i.e. has an end pattern.
+func (f Filter) IsBlock() bool {
+	return f.XML.EndText != ""
+}
+
+// maxBlockLines returns f's block length cap, DefaultMaxBlockLines if it
+// doesn't set one.
+func (f Filter) maxBlockLines() int {
+	if f.MaxBlockLines > 0 {
+		return f.MaxBlockLines
+	}
+	return DefaultMaxBlockLines
+}
+
+// SetCaseSensitive switches f's case sensitivity, recompiling its regex
+// (and a block filter's end regex) to match. A pattern that doesn't compile
+// keeps its previous regex, as when it was edited.
+func (f *Filter) SetCaseSensitive(caseSensitive bool) {
+	f.CaseSensitive = caseSensitive
+	if regex, err := CompileRegex(f.XML.Text, caseSensitive); err == nil {
+		f.Regex = regex
+	}
+	if f.IsBlock() {
+		if regex, err := CompileRegex(f.XML.EndText, caseSensitive); err == nil {
+			f.EndRegex = regex
+		}
+	}
+}
+
+// FormatMaxBlockLines is ParseMaxBlockLines' inverse.
+func FormatMaxBlockLines(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// ParseMaxBlockLines reads a filter's maxBlockLines value: a positive
+// number of lines, or "" for DefaultMaxBlockLines (0).
+func ParseMaxBlockLines(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("maximum block length %q: want a positive number of lines, or nothing for %d", s, DefaultMaxBlockLines)
+	}
+	return n, nil
+}
+
+// ParseContextCount reads a filter's contextBefore/contextAfter value: a
+// whole number of lines, or "" for none set (nil).
+func ParseContextCount(s string) (*int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("context line count %q: want a whole number of lines, or nothing for the view's", s)
+	}
+	return &n, nil
+}
+
+// FormatContextCount is ParseContextCount's inverse.
+func FormatContextCount(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+// lineMatcher matches filters against one line, parsing it into fields
+// (see structured.Parse) only the first time a Field-targeting filter needs
+// them, so a filter set with no such filters never pays for parsing.
+type lineMatcher struct {
+	line   string
+	parsed bool
+	record structured.Record
+}
+
+func (lm *lineMatcher) matches(f Filter) bool {
+	return lm.matchesRegex(f, &f.Regex)
+}
+
+// matchesEnd is matches for a block filter's EndRegex.
+func (lm *lineMatcher) matchesEnd(f Filter) bool {
+	return lm.matchesRegex(f, &f.EndRegex)
+}
+
+func (lm *lineMatcher) matchesRegex(f Filter, re *regexp.Regexp) bool {
+	if f.Field == "" {
+		return re.MatchString(lm.line)
+	}
+	if !lm.parsed {
+		lm.record, _ = structured.Parse(lm.line)
+		lm.parsed = true
+	}
+	value, ok := lm.record.Get(f.Field)
+	return ok && re.MatchString(value)
+}
+
+// MatchString reports whether f's regex matches line -- or, for a filter
+// with a Field, that field's value in line. It ignores IsEnabled and
+// Excluding, which are the caller's business, and for a block filter only
+// tests its start pattern: whether a line is inside a block depends on the
+// lines before it (see Matcher).
+func (f Filter) MatchString(line string) bool {
+	lm := lineMatcher{line: line}
+	return lm.matches(f)
 }
 
 // neverMatchRegex never matches anything, including the empty string (no
@@ -108,6 +317,9 @@ func ReadFilterFile(filter_file_path string) (TextAnalysisToolSettings, error) {
 // load (see CompileFilterRegularExpressions and the issue this fixed).
 // f.XML.Text keeps the original, still-invalid pattern text so the filter
 // editor shows it as-is for the user to fix.
+// A block filter's end regex (see Filter.IsBlock) gets the same treatment.
+// An invalid contextBefore/contextAfter/maxBlockLines is reported the same
+// way, but only drops that setting (see ParseContextCount).
 func makeFilter(index int, XML FilterXML) (Filter, error) {
 
 	var f Filter
@@ -117,22 +329,54 @@ func makeFilter(index int, XML FilterXML) (Filter, error) {
 	f.CaseSensitive = f.XML.CaseSensitive == "y"
 	f.Excluding = f.XML.Excluding == "y"
 	f.BackColor = fmt.Sprintf("#%s", strings.ToUpper(f.XML.BackColor))
+	f.Field = f.XML.Field
+
+	desc := XML.Description
+	if desc == "" {
+		desc = fmt.Sprintf("filter #%d", index+1)
+	} else {
+		desc = fmt.Sprintf("filter #%d (%q)", index+1, desc)
+	}
+
+	// A bad context count only loses that override, not the filter: the
+	// filter still matches what it was written to match.
+	var contextErr error
+	if n, err := ParseContextCount(XML.ContextBefore); err == nil {
+		f.ContextBefore = n
+	} else {
+		contextErr = fmt.Errorf("%s: ignoring contextBefore: %w", desc, err)
+	}
+	if n, err := ParseContextCount(XML.ContextAfter); err == nil {
+		f.ContextAfter = n
+	} else {
+		contextErr = errors.Join(contextErr, fmt.Errorf("%s: ignoring contextAfter: %w", desc, err))
+	}
+
+	if n, err := ParseMaxBlockLines(XML.MaxBlockLines); err == nil {
+		f.MaxBlockLines = n
+	} else {
+		contextErr = errors.Join(contextErr, fmt.Errorf("%s: ignoring maxBlockLines: %w", desc, err))
+	}
 
 	regex, err := CompileRegex(XML.Text, f.CaseSensitive)
 	if err != nil {
 		f.IsEnabled = false
 		f.Regex = *neverMatchRegex
-		desc := XML.Description
-		if desc == "" {
-			desc = fmt.Sprintf("filter #%d", index+1)
-		} else {
-			desc = fmt.Sprintf("filter #%d (%q)", index+1, desc)
-		}
-		return f, fmt.Errorf("%s: disabled, invalid regex %q: %w", desc, XML.Text, err)
+		return f, errors.Join(fmt.Errorf("%s: disabled, invalid regex %q: %w", desc, XML.Text, err), contextErr)
 	}
 	f.Regex = regex
 
-	return f, nil
+	if f.IsBlock() {
+		end, err := CompileRegex(XML.EndText, f.CaseSensitive)
+		if err != nil {
+			f.IsEnabled = false
+			f.EndRegex = *neverMatchRegex
+			return f, errors.Join(fmt.Errorf("%s: disabled, invalid end regex %q: %w", desc, XML.EndText, err), contextErr)
+		}
+		f.EndRegex = end
+	}
+
+	return f, contextErr
 }
 
 // CompileFilterRegularExpressions compiles every filter in filterSettings.
@@ -194,6 +438,11 @@ func filterToXML(f Filter) FilterXML {
 		CaseSensitive: caseSensitive,
 		Regex:         regexAttr,
 		Text:          f.XML.Text,
+		Field:         f.Field,
+		ContextBefore: FormatContextCount(f.ContextBefore),
+		ContextAfter:  FormatContextCount(f.ContextAfter),
+		EndText:       f.XML.EndText,
+		MaxBlockLines: FormatMaxBlockLines(f.MaxBlockLines),
 	}
 }
 
@@ -205,14 +454,16 @@ func HideUnmatchedByDefault(meta TextAnalysisToolSettings) bool {
 	return strings.EqualFold(meta.ShowOnlyFilteredLines, "True")
 }
 
-// WriteFilterFile serializes filters back to a .tat file at path, the
-// inverse of ReadFilterFile + CompileFilterRegularExpressions. meta supplies
-// the root element's version/showOnlyFilteredLines attributes (normally the
+// BuildFilterSettings converts filters' live state back into the
+// TextAnalysisToolSettings document WriteFilterFile serializes, for callers
+// that embed a filter set in a larger document of their own (e.g. a session
+// file) rather than writing a standalone .tat file. meta supplies the root
+// element's version/showOnlyFilteredLines attributes (normally the
 // TextAnalysisToolSettings the filters were originally loaded from, so a
-// save preserves them); if either is empty, a reasonable default is used so
+// save preserves them, along with its Columns); if either is empty, a reasonable default is used so
 // a filter set built entirely from scratch in the UI still produces a valid
-// file.
-func WriteFilterFile(path string, meta TextAnalysisToolSettings, filters []Filter) error {
+// document.
+func BuildFilterSettings(meta TextAnalysisToolSettings, filters []Filter) TextAnalysisToolSettings {
 	version := meta.Version
 	if version == "" {
 		version = "2023-04-25"
@@ -225,10 +476,22 @@ func WriteFilterFile(path string, meta TextAnalysisToolSettings, filters []Filte
 	settings := TextAnalysisToolSettings{
 		Version:               version,
 		ShowOnlyFilteredLines: showOnlyFilteredLines,
+		TimeFormat:            meta.TimeFormat,
+		GapThreshold:          meta.GapThreshold,
+		SpikeThreshold:        meta.SpikeThreshold,
+		Columns:               meta.Columns,
 	}
 	for _, f := range filters {
 		settings.Filters = append(settings.Filters, filterToXML(f))
 	}
+	return settings
+}
+
+// WriteFilterFile serializes filters back to a .tat file at path, the
+// inverse of ReadFilterFile + CompileFilterRegularExpressions. See
+// BuildFilterSettings for how meta's attributes are carried over.
+func WriteFilterFile(path string, meta TextAnalysisToolSettings, filters []Filter) error {
+	settings := BuildFilterSettings(meta, filters)
 
 	body, err := xml.MarshalIndent(settings, "", "  ")
 	if err != nil {
@@ -243,10 +506,10 @@ func WriteFilterFile(path string, meta TextAnalysisToolSettings, filters []Filte
 }
 
 // GetMatchingFilterIndex is GetMatchingFilter, but returns the index into
-// filters of the matching filter instead of a copy of it, for callers (like
-// a per-line match cache) that need to attribute a match back to its
-// position rather than its value.
+// filters of the matching filter instead of a copy of it, for callers that
+// need to attribute a match back to its position rather than its value.
 func GetMatchingFilterIndex(filters []Filter, line string) (int, bool) {
+	lm := lineMatcher{line: line}
 	for i, filter := range filters {
 		// Only continue if this filter is enabled and not an exclusion filter
 		if !filter.IsEnabled || filter.Excluding {
@@ -254,7 +517,7 @@ func GetMatchingFilterIndex(filters []Filter, line string) (int, bool) {
 		}
 
 		// Check whether the line matches the filter's regex
-		if filter.Regex.MatchString(line) {
+		if lm.matches(filter) {
 			return i, true
 		}
 	}
@@ -266,7 +529,9 @@ func GetMatchingFilterIndex(filters []Filter, line string) (int, bool) {
 // returned here: they mean "hide this line" rather than "color this line"
 // and are handled separately by IsExcluded, which callers should check
 // first (an excluded line should never be shown, regardless of whether it
-// would also match a highlighting filter).
+// would also match a highlighting filter). It looks at line on its own, so
+// a block filter only matches its start lines; use a Matcher to follow
+// blocks across a whole log.
 func GetMatchingFilter(filters []Filter, line string) (Filter, bool) {
 	idx, ok := GetMatchingFilterIndex(filters, line)
 	if !ok {
@@ -282,11 +547,12 @@ func GetMatchingFilter(filters []Filter, line string) (Filter, bool) {
 // of filter order: unlike GetMatchingFilter's first-match-wins highlighting,
 // exclusion is checked against every enabled excluding filter.
 func IsExcluded(filters []Filter, line string) bool {
+	lm := lineMatcher{line: line}
 	for _, filter := range filters {
 		if !filter.IsEnabled || !filter.Excluding {
 			continue
 		}
-		if filter.Regex.MatchString(line) {
+		if lm.matches(filter) {
 			return true
 		}
 	}
@@ -302,20 +568,103 @@ func IsExcluded(filters []Filter, line string) bool {
 // highlighting attribution for them.
 func CountMatches(filters []Filter, lines []string) []int {
 	counts := make([]int, len(filters))
+	m := NewMatcher(filters)
 	for _, line := range lines {
-		for i, filter := range filters {
-			if !filter.IsEnabled || filter.Excluding {
-				continue
-			}
-			if filter.Regex.MatchString(line) {
-				counts[i]++
-				break
-			}
+		if idx, _ := m.Next(line); idx >= 0 {
+			counts[idx]++
 		}
 	}
 	return counts
 }
 
+// Matcher attributes a log's lines to filters one at a time, in order --
+// the same first-enabled-filter-wins highlighting as GetMatchingFilterIndex
+// and exclusion as IsExcluded, but following block filters (see
+// Filter.IsBlock) from each line to the next, which looking at one line at
+// a time can't.
+//
+// A block opens on a line matching the filter's Regex and takes in every
+// line after it through the first one matching its EndRegex, or until it's
+// MaxBlockLines long, whichever comes first; a block cut off by the cap
+// just ends there. Blocks of the same filter never nest or overlap: a start
+// line inside an open block is simply part of it, and the first end line
+// closes it. Different block filters track their blocks independently, so
+// their blocks can overlap, with the earlier filter coloring shared lines
+// as usual. An excluding block filter hides its blocks whole.
+type Matcher struct {
+	filters []Filter
+	blocks  []blockState // per filter; only block filters' are used
+	inBlock []bool       // per filter, for the current line
+}
+
+// blockState is one block filter's open block, if any, while a Matcher
+// scans the log.
+type blockState struct {
+	open   bool
+	length int // lines in the open block so far, its start included
+}
+
+// NewMatcher returns a Matcher for filters positioned before the first
+// line of a log.
+func NewMatcher(filters []Filter) *Matcher {
+	return &Matcher{
+		filters: filters,
+		blocks:  make([]blockState, len(filters)),
+		inBlock: make([]bool, len(filters)),
+	}
+}
+
+// Next attributes the log's next line: the index of the filter that
+// highlights it (-1 if none) and whether an excluding filter hides it.
+func (m *Matcher) Next(line string) (int, bool) {
+	lm := lineMatcher{line: line}
+	for i, f := range m.filters {
+		if f.IsEnabled && f.IsBlock() {
+			m.inBlock[i] = m.blocks[i].step(f, &lm)
+		}
+	}
+
+	idx, excluded := -1, false
+	for i, f := range m.filters {
+		if !f.IsEnabled || (idx >= 0 && !f.Excluding) || (excluded && f.Excluding) {
+			continue
+		}
+		var match bool
+		if f.IsBlock() {
+			match = m.inBlock[i]
+		} else {
+			match = lm.matches(f)
+		}
+		if !match {
+			continue
+		}
+		if f.Excluding {
+			excluded = true
+		} else {
+			idx = i
+		}
+	}
+	return idx, excluded
+}
+
+// step advances b past one line and reports whether the line is in a
+// block of f's.
+func (b *blockState) step(f Filter, lm *lineMatcher) bool {
+	if b.open {
+		b.length++
+	} else if lm.matches(f) {
+		b.open, b.length = true, 1
+	} else {
+		return false
+	}
+	// The start line itself is never taken as the end, so a start and an
+	// end pattern that both match it (e.g. "job=42") still make a block.
+	if (b.length > 1 && lm.matchesEnd(f)) || b.length >= f.maxBlockLines() {
+		b.open = false
+	}
+	return true
+}
+
 func GetMatchingLines(filters []Filter, scanner *bufio.Scanner) {
 
 	// Read line-by-line
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -422,6 +423,24 @@ func TestWriteFilterFileFillsDefaultsWhenMetaIsEmpty(t *testing.T) {
 	}
 }
 
+func TestBuildFilterSettingsMatchesLiveState(t *testing.T) {
+	f := mustFilter(t, "ERROR", false, true, "#FF0000")
+	settings := BuildFilterSettings(TextAnalysisToolSettings{Version: "v1"}, []Filter{f})
+
+	if settings.Version != "v1" {
+		t.Errorf("Version = %q, want %q (preserved from meta)", settings.Version, "v1")
+	}
+	if settings.ShowOnlyFilteredLines != "False" {
+		t.Errorf("ShowOnlyFilteredLines = %q, want the %q default", settings.ShowOnlyFilteredLines, "False")
+	}
+	if len(settings.Filters) != 1 {
+		t.Fatalf("got %d filters, want 1", len(settings.Filters))
+	}
+	if got := settings.Filters[0]; got.Text != "ERROR" || got.Enabled != "y" || got.BackColor != "ff0000" {
+		t.Errorf("Filters[0] = %+v, want the filter's live text/enabled/color", got)
+	}
+}
+
 func TestWriteFilterFileInvalidPath(t *testing.T) {
 	if err := WriteFilterFile("/nonexistent/dir/out.tat", TextAnalysisToolSettings{}, nil); err == nil {
 		t.Fatal("WriteFilterFile with an unwritable path returned no error")
@@ -536,3 +555,332 @@ func TestGetMatchingLines(t *testing.T) {
 		t.Errorf("expected both matching lines in output, got: %q", output)
 	}
 }
+
+func TestFieldFilterMatchesOnlyThatField(t *testing.T) {
+	f := mustFilter(t, "^error$", false, true, "#FF0000")
+	f.Field = "level"
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`{"level":"error","msg":"disk full"}`, true},
+		{`level=ERROR msg="disk full"`, true},
+		{`{"level":"info","msg":"error"}`, false}, // "error" elsewhere in the line doesn't count
+		{`{"msg":"no level here"}`, false},
+		{`error`, false}, // unparseable lines never match a field filter
+	}
+	for _, tt := range tests {
+		if got := f.MatchString(tt.line); got != tt.want {
+			t.Errorf("MatchString(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFieldFiltersInMatchingAndExclusion(t *testing.T) {
+	level := mustFilter(t, "warn", false, true, "#FFFF00")
+	level.Field = "level"
+	noisy := mustFilter(t, "^health$", false, true, "#000000")
+	noisy.Field = "path"
+	noisy.Excluding = true
+	filters := []Filter{noisy, level}
+
+	if idx, ok := GetMatchingFilterIndex(filters, `level=warn path=/api msg=slow`); !ok || idx != 1 {
+		t.Errorf("GetMatchingFilterIndex = %d, %v, want the level filter (1)", idx, ok)
+	}
+	if !IsExcluded(filters, `level=info path=health`) {
+		t.Error("IsExcluded = false for path=health, want true")
+	}
+	if IsExcluded(filters, `level=info path=/health/deep msg=health`) {
+		t.Error("IsExcluded = true, want false: the path field doesn't match ^health$ and msg isn't the targeted field")
+	}
+	if got := CountMatches(filters, []string{`level=warn a=1`, `msg=warn b=2`}); got[1] != 1 {
+		t.Errorf("CountMatches = %v, want only the line whose level field is warn counted", got)
+	}
+}
+
+func TestFieldAndColumnsRoundTrip(t *testing.T) {
+	f := mustFilter(t, "^error$", false, true, "#FF0000")
+	f.Field = "level"
+	meta := TextAnalysisToolSettings{Columns: &ColumnsXML{Columns: []ColumnXML{{Field: "ts", Width: 24}, {Field: "level", Title: "Lvl"}}}}
+
+	path := t.TempDir() + "/out.tat"
+	if err := WriteFilterFile(path, meta, []Filter{f}); err != nil {
+		t.Fatalf("WriteFilterFile returned unexpected error: %v", err)
+	}
+	settings, err := ReadFilterFile(path)
+	if err != nil {
+		t.Fatalf("ReadFilterFile returned unexpected error: %v", err)
+	}
+
+	if cols := settings.ColumnList(); len(cols) != 2 || cols[0].Field != "ts" || cols[0].Width != 24 || cols[1].Title != "Lvl" {
+		t.Errorf("ColumnList() = %+v, want the two configured columns preserved", cols)
+	}
+	got, _ := CompileFilterRegularExpressions(settings)
+	if len(got) != 1 || got[0].Field != "level" {
+		t.Errorf("filters = %+v, want the field attribute preserved", got)
+	}
+}
+
+func TestWriteFilterFileOmitsUnusedExtensions(t *testing.T) {
+	path := t.TempDir() + "/out.tat"
+	if err := WriteFilterFile(path, TextAnalysisToolSettings{}, []Filter{mustFilter(t, "x", false, true, "#FF0000")}); err != nil {
+		t.Fatalf("WriteFilterFile returned unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if bytes.Contains(data, []byte("columns")) || bytes.Contains(data, []byte("field=")) || bytes.Contains(data, []byte("timeFormat")) ||
+		bytes.Contains(data, []byte("Threshold")) || bytes.Contains(data, []byte("context")) {
+		t.Errorf("written file = %s, want no <columns> element or other skim attribute when unused", data)
+	}
+}
+
+func TestTimeSettingsRoundTrip(t *testing.T) {
+	meta := TextAnalysisToolSettings{TimeFormat: "02/Jan/2006:15:04:05 -0700", GapThreshold: "45s", SpikeThreshold: "100"}
+
+	path := t.TempDir() + "/out.tat"
+	if err := WriteFilterFile(path, meta, nil); err != nil {
+		t.Fatalf("WriteFilterFile returned unexpected error: %v", err)
+	}
+	settings, err := ReadFilterFile(path)
+	if err != nil {
+		t.Fatalf("ReadFilterFile returned unexpected error: %v", err)
+	}
+	if settings.TimeFormat != meta.TimeFormat || settings.GapThreshold != meta.GapThreshold || settings.SpikeThreshold != meta.SpikeThreshold {
+		t.Errorf("time settings = %q, %q, %q, want %q, %q, %q", settings.TimeFormat, settings.GapThreshold, settings.SpikeThreshold,
+			meta.TimeFormat, meta.GapThreshold, meta.SpikeThreshold)
+	}
+}
+
+func TestContextOverridesRoundTrip(t *testing.T) {
+	before, after := 20, 0
+	f := mustFilter(t, "ERROR", false, true, "#FF0000")
+	f.ContextBefore, f.ContextAfter = &before, &after
+	g := mustFilter(t, "WARN", false, true, "#FFFF00")
+	g.ContextAfter = &before
+
+	path := t.TempDir() + "/out.tat"
+	if err := WriteFilterFile(path, TextAnalysisToolSettings{}, []Filter{f, g}); err != nil {
+		t.Fatalf("WriteFilterFile returned unexpected error: %v", err)
+	}
+	settings, err := ReadFilterFile(path)
+	if err != nil {
+		t.Fatalf("ReadFilterFile returned unexpected error: %v", err)
+	}
+	got, warnings := CompileFilterRegularExpressions(settings)
+	if len(warnings) != 0 {
+		t.Fatalf("CompileFilterRegularExpressions returned unexpected warnings: %v", warnings)
+	}
+
+	if got[0].ContextBefore == nil || *got[0].ContextBefore != 20 || got[0].ContextAfter == nil || *got[0].ContextAfter != 0 {
+		t.Errorf("filter 1 context = %v, %v, want 20 before and an explicit 0 after", got[0].ContextBefore, got[0].ContextAfter)
+	}
+	if got[1].ContextBefore != nil || got[1].ContextAfter == nil || *got[1].ContextAfter != 20 {
+		t.Errorf("filter 2 context = %v, %v, want only 20 after set", got[1].ContextBefore, got[1].ContextAfter)
+	}
+}
+
+func TestInvalidContextOverrideKeepsFilter(t *testing.T) {
+	settings := TextAnalysisToolSettings{
+		Filters: []FilterXML{{Enabled: "y", Description: "errors", BackColor: "ff0000", Text: "ERROR", ContextBefore: "lots", ContextAfter: "3"}},
+	}
+
+	filters, warnings := CompileFilterRegularExpressions(settings)
+	if !filters[0].IsEnabled || filters[0].ContextBefore != nil {
+		t.Errorf("filter = enabled %v, ContextBefore %v, want it enabled with the bad override dropped", filters[0].IsEnabled, filters[0].ContextBefore)
+	}
+	if filters[0].ContextAfter == nil || *filters[0].ContextAfter != 3 {
+		t.Errorf("ContextAfter = %v, want the valid override kept", filters[0].ContextAfter)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "errors") || !strings.Contains(warnings[0].Error(), "contextBefore") {
+		t.Errorf("warnings = %v, want one naming the filter and the bad attribute", warnings)
+	}
+}
+
+func TestParseContextCount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		set     bool
+		wantErr bool
+	}{
+		{"", 0, false, false},
+		{" 0 ", 0, true, false},
+		{"20", 20, true, false},
+		{"-1", 0, false, true},
+		{"x", 0, false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseContextCount(tt.in)
+		if (err != nil) != tt.wantErr || (got != nil) != tt.set || (got != nil && *got != tt.want) {
+			t.Errorf("ParseContextCount(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+// blockFilter returns an enabled block filter from start through end.
+func blockFilter(t *testing.T, start, end string, maxLines int) Filter {
+	t.Helper()
+	f := mustFilter(t, start, false, true, "#98FB98")
+	f.XML.EndText = end
+	re, err := CompileRegex(end, false)
+	if err != nil {
+		t.Fatalf("CompileRegex(%q) failed: %v", end, err)
+	}
+	f.EndRegex = re
+	f.MaxBlockLines = maxLines
+	return f
+}
+
+// matchAll runs a Matcher over lines, returning each line's highlighting
+// filter index, with an excluded line as "x".
+func matchAll(filters []Filter, lines []string) []string {
+	m := NewMatcher(filters)
+	var got []string
+	for _, line := range lines {
+		idx, excluded := m.Next(line)
+		if excluded {
+			got = append(got, "x")
+		} else {
+			got = append(got, strconv.Itoa(idx))
+		}
+	}
+	return got
+}
+
+func TestMatcherBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		max   int
+		want  string
+	}{
+		{
+			name:  "start through end",
+			lines: []string{"a", "BEGIN 1", "b", "END 1", "c"},
+			want:  "-1 0 0 0 -1",
+		},
+		{
+			name:  "a start inside a block doesn't nest",
+			lines: []string{"BEGIN 1", "BEGIN 2", "END 2", "x", "END 1"},
+			want:  "0 0 0 -1 -1",
+		},
+		{
+			name:  "cut off at the maximum length",
+			lines: []string{"BEGIN 1", "a", "b", "c", "END 1"},
+			max:   3,
+			want:  "0 0 0 -1 -1",
+		},
+		{
+			name:  "a new block can start right after one ends",
+			lines: []string{"BEGIN 1", "END 1", "BEGIN 2", "END 2"},
+			want:  "0 0 0 0",
+		},
+		{
+			name:  "no end runs to the end of the log",
+			lines: []string{"a", "BEGIN 1", "b", "c"},
+			want:  "-1 0 0 0",
+		},
+		{
+			name:  "the start line is never its own end",
+			lines: []string{"BEGIN END", "a", "END"},
+			want:  "0 0 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := []Filter{blockFilter(t, "^BEGIN", "END", tt.max)}
+			if got := strings.Join(matchAll(filters, tt.lines), " "); got != tt.want {
+				t.Errorf("matches = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherBlocksAmongOtherFilters(t *testing.T) {
+	lines := []string{"BEGIN job", "ERROR a", "health", "END job", "ERROR b"}
+
+	// An earlier plain filter takes its lines out of the block's color, and
+	// the block takes the rest, including lines it alone would match.
+	errors := mustFilter(t, "ERROR", false, true, "#FF0000")
+	block := blockFilter(t, "^BEGIN", "^END", 0)
+	if got := strings.Join(matchAll([]Filter{errors, block}, lines), " "); got != "1 0 1 1 0" {
+		t.Errorf("matches = %s, want the ERRORs to the earlier filter and the rest of the block to the block filter", got)
+	}
+
+	// Excluding still wins inside a block.
+	noise := mustFilter(t, "health", false, true, "#000000")
+	noise.Excluding = true
+	if got := strings.Join(matchAll([]Filter{block, noise}, lines), " "); got != "0 0 x 0 -1" {
+		t.Errorf("matches = %s, want the health line inside the block excluded", got)
+	}
+
+	// An excluding block filter hides its whole block.
+	block.Excluding = true
+	if got := strings.Join(matchAll([]Filter{errors, block}, lines), " "); got != "x x x x 0" {
+		t.Errorf("matches = %s, want the whole block excluded", got)
+	}
+
+	// A disabled block filter tracks nothing.
+	block.Excluding, block.IsEnabled = false, false
+	if got := strings.Join(matchAll([]Filter{block}, lines), " "); got != "-1 -1 -1 -1 -1" {
+		t.Errorf("matches = %s, want nothing from a disabled block filter", got)
+	}
+}
+
+func TestCountMatchesFollowsBlocks(t *testing.T) {
+	lines := []string{"BEGIN", "a", "END", "b", "BEGIN", "END"}
+	if got := CountMatches([]Filter{blockFilter(t, "^BEGIN", "^END", 0)}, lines); got[0] != 5 {
+		t.Errorf("CountMatches = %v, want every line of both blocks counted", got)
+	}
+}
+
+func TestBlockFilterRoundTrip(t *testing.T) {
+	f := blockFilter(t, "^BEGIN job=", "^END job=", 500)
+
+	path := t.TempDir() + "/out.tat"
+	if err := WriteFilterFile(path, TextAnalysisToolSettings{}, []Filter{f}); err != nil {
+		t.Fatalf("WriteFilterFile returned unexpected error: %v", err)
+	}
+	settings, err := ReadFilterFile(path)
+	if err != nil {
+		t.Fatalf("ReadFilterFile returned unexpected error: %v", err)
+	}
+	got, warnings := CompileFilterRegularExpressions(settings)
+	if len(warnings) != 0 {
+		t.Fatalf("CompileFilterRegularExpressions returned unexpected warnings: %v", warnings)
+	}
+	if !got[0].IsBlock() || got[0].EndRegex.String() != "(?i)^END job=" || got[0].MaxBlockLines != 500 {
+		t.Errorf("filter = block %v, end %q, max %d, want the block settings preserved", got[0].IsBlock(), got[0].EndRegex.String(), got[0].MaxBlockLines)
+	}
+}
+
+func TestInvalidBlockSettings(t *testing.T) {
+	settings := TextAnalysisToolSettings{
+		Filters: []FilterXML{
+			{Enabled: "y", Description: "jobs", BackColor: "98fb98", Text: "^BEGIN", EndText: "([unclosed"},
+			{Enabled: "y", Description: "requests", BackColor: "98fb98", Text: "^REQ", EndText: "^RESP", MaxBlockLines: "0"},
+		},
+	}
+
+	filters, warnings := CompileFilterRegularExpressions(settings)
+	if filters[0].IsEnabled || filters[0].EndRegex.MatchString("anything") {
+		t.Error("block filter with an invalid end regex is enabled or matches, want it disabled with a never-match end")
+	}
+	if !filters[1].IsEnabled || filters[1].MaxBlockLines != 0 {
+		t.Errorf("filter with a bad maxBlockLines = enabled %v, max %d, want it enabled with the default", filters[1].IsEnabled, filters[1].MaxBlockLines)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0].Error(), "end regex") || !strings.Contains(warnings[1].Error(), "maxBlockLines") {
+		t.Errorf("warnings = %v, want one for each bad setting", warnings)
+	}
+}
+
+func TestSetCaseSensitiveRecompilesEndRegex(t *testing.T) {
+	f := blockFilter(t, "^begin", "^end", 0)
+	f.SetCaseSensitive(true)
+	if f.Regex.String() != "^begin" || f.EndRegex.String() != "^end" {
+		t.Errorf("regexes = %q, %q, want both recompiled case-sensitively", f.Regex.String(), f.EndRegex.String())
+	}
+}
@@ -0,0 +1,97 @@
+// Package history reads and writes the text prompts' history: what was
+// entered at the "/" search prompt, the ":" jump prompt and the filter
+// editor's regex fields, so earlier entries can be recalled (up/down,
+// ctrl+r) in this and later runs. It's kept in the user's config
+// directory, next to keybindings.json, and is shared by every log.
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Prompt names one prompt's history.
+type Prompt string
+
+const (
+	Search   Prompt = "search"    // the "/" search prompt
+	JumpLine Prompt = "jump_line" // the ":" jump-to-line prompt
+	Regex    Prompt = "regex"     // the filter editor's regex and end regex fields
+)
+
+// MaxEntries is how many entries each prompt's history keeps; Add drops
+// the oldest beyond it.
+const MaxEntries = 500
+
+// History holds each prompt's entries, oldest first.
+type History map[Prompt][]string
+
+// Add records entry as the prompt's newest, moving it there if it was
+// already entered before rather than keeping a duplicate. Empty entries
+// aren't recorded.
+func (h History) Add(prompt Prompt, entry string) {
+	if entry == "" {
+		return
+	}
+	entries := h[prompt]
+	kept := make([]string, 0, len(entries)+1)
+	for _, e := range entries {
+		if e != entry {
+			kept = append(kept, e)
+		}
+	}
+	kept = append(kept, entry)
+	if len(kept) > MaxEntries {
+		kept = kept[len(kept)-MaxEntries:]
+	}
+	h[prompt] = kept
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "skim", "history.json"), nil
+}
+
+// Load returns the saved history. If the config directory can't be
+// resolved or nothing has been saved yet, it returns an empty one.
+func Load() (History, error) {
+	h := History{}
+
+	path, err := configPath()
+	if err != nil {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return h, err
+	}
+
+	if err := json.Unmarshal(data, &h); err != nil {
+		return History{}, err
+	}
+	return h, nil
+}
+
+// Save persists h to the user's config directory, creating it if needed.
+func Save(h History) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
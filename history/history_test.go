@@ -0,0 +1,85 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddMovesRepeatsToTheEnd(t *testing.T) {
+	h := History{}
+	for _, entry := range []string{"timeout", "ERROR", "", "timeout"} {
+		h.Add(Search, entry)
+	}
+	if want := []string{"ERROR", "timeout"}; !reflect.DeepEqual(h[Search], want) {
+		t.Errorf("h[Search] = %q, want %q", h[Search], want)
+	}
+	if len(h[JumpLine]) != 0 {
+		t.Errorf("h[JumpLine] = %q, want the other prompts' histories untouched", h[JumpLine])
+	}
+}
+
+func TestAddDropsTheOldestPastMaxEntries(t *testing.T) {
+	h := History{}
+	for i := 0; i <= MaxEntries; i++ {
+		h.Add(JumpLine, string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	if len(h[JumpLine]) != MaxEntries || h[JumpLine][0] != "ba" {
+		t.Errorf("len = %d, oldest = %q, want %d entries starting from the second one added", len(h[JumpLine]), h[JumpLine][0], MaxEntries)
+	}
+}
+
+func TestLoadReturnsEmptyWhenNothingSaved(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	h, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	}
+	if h == nil || len(h) != 0 {
+		t.Errorf("Load() with no history file = %v, want an empty, usable History", h)
+	}
+}
+
+func TestSaveThenLoadRoundTrips(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	h := History{}
+	h.Add(Search, "timeout")
+	h.Add(Regex, `^\d+ ERROR`)
+	if err := Save(h); err != nil {
+		t.Fatalf("Save() returned unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "skim", "history.json")); err != nil {
+		t.Errorf("history file not next to keybindings.json: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, h) {
+		t.Errorf("Load() after Save() = %v, want %v", loaded, h)
+	}
+}
+
+func TestLoadRejectsMalformedFile(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	if err := os.MkdirAll(filepath.Join(configDir, "skim"), 0o755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "skim", "history.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("failed to write history file: %v", err)
+	}
+
+	h, err := Load()
+	if err == nil {
+		t.Error("Load() of a malformed file returned no error")
+	}
+	if h == nil {
+		t.Error("Load() of a malformed file returned a nil History, want an empty one to carry on with")
+	}
+}
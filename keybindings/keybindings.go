@@ -31,12 +31,68 @@ const (
 	SaveFilters           Action = "save_filters"
 	IncreaseContext       Action = "increase_context"
 	DecreaseContext       Action = "decrease_context"
+	IncreaseContextBefore Action = "increase_context_before"
+	DecreaseContextBefore Action = "decrease_context_before"
+	IncreaseContextAfter  Action = "increase_context_after"
+	DecreaseContextAfter  Action = "decrease_context_after"
 	ToggleHelp            Action = "toggle_help"
 	JumpToTop             Action = "jump_to_top"
 	JumpToBottom          Action = "jump_to_bottom"
 	JumpToLine            Action = "jump_to_line"
+	SaveSession           Action = "save_session"
+	NextFilterMatch       Action = "next_filter_match"
+	PrevFilterMatch       Action = "prev_filter_match"
+	JumpFilter1           Action = "jump_filter_1"
+	JumpFilter2           Action = "jump_filter_2"
+	JumpFilter3           Action = "jump_filter_3"
+	JumpFilter4           Action = "jump_filter_4"
+	JumpFilter5           Action = "jump_filter_5"
+	JumpFilter6           Action = "jump_filter_6"
+	JumpFilter7           Action = "jump_filter_7"
+	JumpFilter8           Action = "jump_filter_8"
+	JumpFilter9           Action = "jump_filter_9"
+	ToggleWrap            Action = "toggle_wrap"
+	ToggleDetail          Action = "toggle_detail"
+	ToggleColumns         Action = "toggle_columns"
+	JumpToTime            Action = "jump_to_time"
+	SetTimeRange          Action = "set_time_range"
+	NextAnomaly           Action = "next_anomaly"
+	PrevAnomaly           Action = "prev_anomaly"
+	ToggleDensity         Action = "toggle_density"
+	NextBucket            Action = "next_bucket"
+	PrevBucket            Action = "prev_bucket"
+	ToggleMinimap         Action = "toggle_minimap"
+	ExpandHidden          Action = "expand_hidden"
+	ExpandHiddenStep      Action = "expand_hidden_step"
+	HideRevealed          Action = "hide_revealed"
+	SelectLines           Action = "select_lines"
+	ClearSelection        Action = "clear_selection"
+	FoldSelection         Action = "fold_selection"
+	Unfold                Action = "unfold"
+	ListFolds             Action = "list_folds"
+	CopySelection         Action = "copy_selection"
+	PipeSelection         Action = "pipe_selection"
+	TransformLog          Action = "transform_log"
+	ToggleTransform       Action = "toggle_transform"
+	ListMatches           Action = "list_matches"
+	JumpBack              Action = "jump_back"
+	JumpForward           Action = "jump_forward"
+	HalfPageUp            Action = "half_page_up"
+	HalfPageDown          Action = "half_page_down"
+	PageUp                Action = "page_up"
+	PageDown              Action = "page_down"
+	ToggleCentered        Action = "toggle_centered"
+	CommandPalette        Action = "command_palette"
 )
 
+// JumpFilterActions lists JumpFilter1..JumpFilter9 in order, so
+// JumpFilterActions[i] targets the filter at position i (0-based) in the
+// Filters pane.
+var JumpFilterActions = []Action{
+	JumpFilter1, JumpFilter2, JumpFilter3, JumpFilter4, JumpFilter5,
+	JumpFilter6, JumpFilter7, JumpFilter8, JumpFilter9,
+}
+
 // Scope limits which focused view an action's keys are considered in.
 // ScopeGlobal actions are checked in every focus; ScopeFilterView /
 // ScopeLogView actions are only checked while that view is focused, and are
@@ -63,8 +119,8 @@ var Registry = []Spec{
 	{Quit, ScopeGlobal, "quit", []string{"ctrl+c", "q"}},
 	{CursorUp, ScopeGlobal, "move cursor up", []string{"up", "k"}},
 	{CursorDown, ScopeGlobal, "move cursor down", []string{"down", "j"}},
-	{CursorLeft, ScopeGlobal, "move column left", []string{"left", "h"}},
-	{CursorRight, ScopeGlobal, "move column right", []string{"right", "l"}},
+	{CursorLeft, ScopeGlobal, "move column left / scroll left", []string{"left", "h"}},
+	{CursorRight, ScopeGlobal, "move column right / scroll right", []string{"right", "l"}},
 	{Toggle, ScopeGlobal, "toggle selection", []string{"enter", " "}},
 	{SwitchFocus, ScopeGlobal, "switch focus", []string{"tab"}},
 	{ToggleHideUnmatched, ScopeLogView, "hide unmatched lines", []string{"h"}},
@@ -80,10 +136,58 @@ var Registry = []Spec{
 	{SaveFilters, ScopeGlobal, "save filters to file", []string{"s"}},
 	{IncreaseContext, ScopeLogView, "show more context around matches", []string{"+"}},
 	{DecreaseContext, ScopeLogView, "show less context around matches", []string{"-"}},
+	{IncreaseContextBefore, ScopeLogView, "show more context before matches", []string{"B"}},
+	{DecreaseContextBefore, ScopeLogView, "show less context before matches", []string{"b"}},
+	{IncreaseContextAfter, ScopeLogView, "show more context after matches", []string{"A"}},
+	{DecreaseContextAfter, ScopeLogView, "show less context after matches", []string{"a"}},
 	{ToggleHelp, ScopeGlobal, "show/hide keybindings help", []string{"?"}},
 	{JumpToTop, ScopeLogView, "jump to top", []string{"g"}},
 	{JumpToBottom, ScopeLogView, "jump to bottom", []string{"G"}},
-	{JumpToLine, ScopeLogView, "jump to line number", []string{":"}},
+	{JumpToLine, ScopeLogView, "command line (or jump to line number)", []string{":"}},
+	{SaveSession, ScopeGlobal, "save session to file", []string{"S"}},
+	{NextFilterMatch, ScopeGlobal, "next match of selected filter", []string{"}"}},
+	{PrevFilterMatch, ScopeGlobal, "prev match of selected filter", []string{"{"}},
+	{JumpFilter1, ScopeGlobal, "next match of filter 1", []string{"1"}},
+	{JumpFilter2, ScopeGlobal, "next match of filter 2", []string{"2"}},
+	{JumpFilter3, ScopeGlobal, "next match of filter 3", []string{"3"}},
+	{JumpFilter4, ScopeGlobal, "next match of filter 4", []string{"4"}},
+	{JumpFilter5, ScopeGlobal, "next match of filter 5", []string{"5"}},
+	{JumpFilter6, ScopeGlobal, "next match of filter 6", []string{"6"}},
+	{JumpFilter7, ScopeGlobal, "next match of filter 7", []string{"7"}},
+	{JumpFilter8, ScopeGlobal, "next match of filter 8", []string{"8"}},
+	{JumpFilter9, ScopeGlobal, "next match of filter 9", []string{"9"}},
+	{ToggleWrap, ScopeLogView, "wrap long lines", []string{"w"}},
+	{ToggleDetail, ScopeLogView, "show/hide line detail pane", []string{"enter"}},
+	{ToggleColumns, ScopeLogView, "show structured fields as columns", []string{"c"}},
+	{JumpToTime, ScopeLogView, "jump to time", []string{"@"}},
+	{SetTimeRange, ScopeLogView, "restrict to a time range", []string{"T"}},
+	{NextAnomaly, ScopeLogView, "jump to next gap or spike", []string{"]"}},
+	{PrevAnomaly, ScopeLogView, "jump to previous gap or spike", []string{"["}},
+	{ToggleDensity, ScopeLogView, "show/hide match density panel", []string{"H"}},
+	{NextBucket, ScopeLogView, "jump to next density bucket", []string{">"}},
+	{PrevBucket, ScopeLogView, "jump to previous density bucket", []string{"<"}},
+	{ToggleMinimap, ScopeLogView, "show/hide minimap", []string{"m"}},
+	{ExpandHidden, ScopeLogView, "expand hidden lines next to cursor", []string{"e"}},
+	{ExpandHiddenStep, ScopeLogView, "expand a few hidden lines next to cursor", []string{"E"}},
+	{HideRevealed, ScopeLogView, "re-hide expanded lines", []string{"X"}},
+	{SelectLines, ScopeLogView, "start/stop selecting lines", []string{"V"}},
+	{ClearSelection, ScopeLogView, "cancel line selection", []string{"esc"}},
+	{FoldSelection, ScopeLogView, "fold selected lines", []string{"z"}},
+	{Unfold, ScopeLogView, "unfold fold under cursor", []string{"Z"}},
+	{ListFolds, ScopeLogView, "list folds", []string{"F"}},
+	{CopySelection, ScopeLogView, "copy selected lines to clipboard", []string{"y"}},
+	{PipeSelection, ScopeLogView, "pipe selected lines to a command", []string{"|"}},
+	{TransformLog, ScopeLogView, "run the log through a command", []string{"!"}},
+	{ToggleTransform, ScopeLogView, "switch between transformed/original lines", []string{"O"}},
+	{ListMatches, ScopeGlobal, "list lines matching search/selected filter", []string{"L"}},
+	{JumpBack, ScopeLogView, "jump back to where the cursor was", []string{"ctrl+o"}},
+	{JumpForward, ScopeLogView, "jump forward again", []string{"ctrl+n"}},
+	{HalfPageUp, ScopeLogView, "move up half a page", []string{"ctrl+u"}},
+	{HalfPageDown, ScopeLogView, "move down half a page", []string{"ctrl+d"}},
+	{PageUp, ScopeLogView, "move up a page", []string{"pgup", "ctrl+b"}},
+	{PageDown, ScopeLogView, "move down a page", []string{"pgdown", "ctrl+f"}},
+	{ToggleCentered, ScopeLogView, "keep cursor centered", []string{"M"}},
+	{CommandPalette, ScopeGlobal, "open command palette", []string{"ctrl+p"}},
 }
 
 // SpecFor returns the registry entry for an action.
@@ -96,6 +200,10 @@ func SpecFor(action Action) (Spec, bool) {
 	return Spec{}, false
 }
 
+// KeyMap holds each action's bindings. A binding is usually one key, named
+// as Bubble Tea names it ("q", "ctrl+u", "pgup"), but can be a sequence of
+// keys pressed in turn, in vim's notation: "gg", "]e", "<space>f" (see the
+// ui package's parseKeys).
 type KeyMap map[Action][]string
 
 // Defaults returns a fresh KeyMap populated with each action's default keys.
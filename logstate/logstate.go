@@ -0,0 +1,89 @@
+// Package logstate reads and writes a log's state file: the things skim
+// remembers about one log file by itself, whatever filters or session it's
+// opened with -- currently the line ranges folded out of the way (see
+// logview.LogView.FoldLines). It sits next to the log as <log>.skim-state
+// and is written as soon as that state changes, unlike a session file,
+// which is only ever saved on request.
+package logstate
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+)
+
+/*
+Example State File (app.log.skim-state):
+<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<skimLogState version="1">
+  <fold from="12" to="3011"></fold>
+</skimLogState>
+*/
+
+// CurrentVersion is the version attribute Write stamps on every state
+// file, so a future format change can tell old files apart.
+const CurrentVersion = "1"
+
+// Suffix is appended to a log's path to name its state file.
+const Suffix = ".skim-state"
+
+type State struct {
+	XMLName xml.Name `xml:"skimLogState"`
+	Version string   `xml:"version,attr"`
+	Folds   []Fold   `xml:"fold"`
+}
+
+// Fold is one folded line range, From through To, 1-indexed and inclusive
+// -- the line numbers the Log pane shows, so the file reads naturally.
+type Fold struct {
+	From int `xml:"from,attr"`
+	To   int `xml:"to,attr"`
+}
+
+// Path returns the state file for the log at logPath, or "" for a log read
+// from stdin (or no log at all), which has nowhere to keep one.
+func Path(logPath string) string {
+	if logPath == "" || logPath == "-" {
+		return ""
+	}
+	return logPath + Suffix
+}
+
+// Read parses the state file at path. A missing file is reported with an
+// error satisfying os.IsNotExist, so callers can treat it as "nothing
+// remembered yet" rather than a failure.
+func Read(path string) (State, error) {
+	var s State
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := xml.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// Write serializes s to path -- or, if s holds nothing, removes any state
+// file already there rather than leaving an empty one next to the log.
+func Write(path string, s State) error {
+	if len(s.Folds) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	s.Version = CurrentVersion
+	body, err := xml.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out := []byte(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>` + "\n")
+	out = append(out, body...)
+	out = append(out, '\n')
+
+	return os.WriteFile(path, out, 0o644)
+}
@@ -0,0 +1,67 @@
+package logstate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		logPath string
+		want    string
+	}{
+		{"/logs/app.log", "/logs/app.log.skim-state"},
+		{"-", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Path(tt.logPath); got != tt.want {
+			t.Errorf("Path(%q) = %q, want %q", tt.logPath, got, tt.want)
+		}
+	}
+}
+
+func TestWriteThenReadRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log"+Suffix)
+	want := []Fold{{From: 12, To: 3011}, {From: 4000, To: 4000}}
+
+	if err := Write(path, State{Folds: want}); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read returned unexpected error: %v", err)
+	}
+	if got.Version != CurrentVersion {
+		t.Errorf("Version = %q, want %q", got.Version, CurrentVersion)
+	}
+	if !reflect.DeepEqual(got.Folds, want) {
+		t.Errorf("Folds = %+v, want %+v", got.Folds, want)
+	}
+}
+
+func TestWriteEmptyStateRemovesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log"+Suffix)
+	if err := Write(path, State{Folds: []Fold{{From: 1, To: 2}}}); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+
+	if err := Write(path, State{}); err != nil {
+		t.Fatalf("Write of an empty state returned unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stat after writing an empty state: err = %v, want the file removed", err)
+	}
+	if err := Write(path, State{}); err != nil {
+		t.Errorf("Write of an empty state with no file there returned %v, want nil", err)
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	_, err := Read(filepath.Join(t.TempDir(), "nope"+Suffix))
+	if !os.IsNotExist(err) {
+		t.Errorf("Read of a missing file: err = %v, want one satisfying os.IsNotExist", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Package rcfile reads skim's startup files: ":" commands (see the ui
+// package's command line), one per line, run at every launch from the one
+// in the user's config directory, next to keybindings.json, or on demand
+// from any other with ":source". Blank lines and lines starting with '#'
+// or '"' (vim's comment character) are skipped.
+package rcfile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Line is one command read from a startup file, with its 1-indexed line
+// number in the file, for error messages.
+type Line struct {
+	Number  int
+	Command string
+}
+
+// Path returns where the startup file run at every launch lives.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "skim", "skimrc"), nil
+}
+
+// Load returns the commands in the startup file at Path. If the config
+// directory can't be resolved or there's no startup file, there's nothing
+// to run, and it returns none.
+func Load() ([]Line, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, nil
+	}
+	lines, err := Read(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return lines, err
+}
+
+// Read returns the commands in the startup file at path.
+func Read(path string) ([]Line, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []Line
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, `"`) {
+			continue
+		}
+		lines = append(lines, Line{Number: n, Command: strings.TrimPrefix(text, ":")})
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,53 @@
+package rcfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadSkipsBlankAndCommentLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "team.skimrc")
+	content := "# shared triage setup\nset context=5\n\n\" vim-style comment\n  :filter add ERROR #ff0000  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write startup file: %v", err)
+	}
+
+	lines, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() returned unexpected error: %v", err)
+	}
+	want := []Line{{2, "set context=5"}, {5, "filter add ERROR #ff0000"}}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Read() = %+v, want %+v", lines, want)
+	}
+}
+
+func TestLoadReturnsNothingWithoutAStartupFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	lines, err := Load()
+	if err != nil || lines != nil {
+		t.Errorf("Load() with no startup file = %v, %v, want nothing and no error", lines, err)
+	}
+}
+
+func TestLoadReadsTheConfigDirectorysStartupFile(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	if err := os.MkdirAll(filepath.Join(configDir, "skim"), 0o755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "skim", "skimrc"), []byte("hide\n"), 0o644); err != nil {
+		t.Fatalf("failed to write startup file: %v", err)
+	}
+
+	lines, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned unexpected error: %v", err)
+	}
+	if want := []Line{{1, "hide"}}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("Load() = %+v, want %+v", lines, want)
+	}
+}
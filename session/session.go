@@ -0,0 +1,169 @@
+// Package session reads and writes skim session files: a snapshot of one
+// investigation's state (which log, which filters -- stored inline, so a
+// single file is all that needs sharing -- and the view settings and cursor
+// position on top of them) that skim -session restores exactly.
+package session
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"skim/filterfiles"
+)
+
+/*
+Example Session File:
+<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<skimSession version="1" log="checkout-service.log" filterFile="triage.tat" hideUnmatched="true" contextLines="2" search="req-42" line="120" filterLine="1" focus="log" unsavedFilterChanges="false">
+  <TextAnalysisTool.NET version="2023-04-25" showOnlyFilteredLines="False">
+    <filters>
+      <filter enabled="y" excluding="n" description="" backColor="87cefa" type="matches_text" case_sensitive="n" regex="y" text="^debug" />
+    </filters>
+  </TextAnalysisTool.NET>
+</skimSession>
+*/
+
+// CurrentVersion is the version attribute Write stamps on every session
+// file, so a future format change can tell old files apart.
+const CurrentVersion = "1"
+
+// Focus values for Session.Focus.
+const (
+	FocusLog     = "log"
+	FocusFilters = "filters"
+)
+
+type Session struct {
+	XMLName xml.Name `xml:"skimSession"`
+	Version string   `xml:"version,attr"`
+
+	// LogPath and FilterFile are stored relative to the session file's own
+	// directory where possible (see Write), and handed back resolved
+	// against it (see Read), so a session saved next to its log keeps
+	// working wherever the pair is moved or copied to. FilterFile is only
+	// where SaveFilters writes back to: the filters themselves always come
+	// from the inline Filters document below, not from re-reading it.
+	LogPath    string `xml:"log,attr"`
+	FilterFile string `xml:"filterFile,attr,omitempty"`
+
+	HideUnmatched bool `xml:"hideUnmatched,attr"`
+
+	// ContextLines is how many lines of context were shown before each
+	// match, and after it too unless ContextAfter says otherwise -- it's
+	// only written when the two differ, so a symmetric context reads the
+	// same as it did before they could.
+	ContextLines int  `xml:"contextLines,attr"`
+	ContextAfter *int `xml:"contextAfter,attr,omitempty"`
+
+	Search     string `xml:"search,attr,omitempty"` // raw text of the last search, "" if none
+	Line       int    `xml:"line,attr"`             // 1-indexed log cursor line
+	FilterLine int    `xml:"filterLine,attr"`       // 1-indexed Filters pane cursor row
+	Focus      string `xml:"focus,attr"`            // FocusLog or FocusFilters
+
+	// SearchOptions lists, comma-separated, the options Search was made
+	// with ("smart-case", "literal", "word", "shown"); "" for none, as
+	// searches were before there were any.
+	SearchOptions string `xml:"searchOptions,attr,omitempty"`
+
+	// HideColumns records that the Log pane had Structured mode's field
+	// columns (configured in Filters' <columns>) toggled off. It's the
+	// negative so a session saved before columns existed restores them the
+	// way a fresh start would: on, whenever there are any.
+	HideColumns bool `xml:"hideColumns,attr,omitempty"`
+
+	// TimeRange is the Log pane's time restriction in the FROM..TO form
+	// timestamps.ParseRange reads, "" if there was none, and TimeFormat the
+	// timestamp layout it was interpreted with, "" for auto-detection. The
+	// format is kept here as well as in Filters because it may have come
+	// from -time-format rather than the filter file.
+	TimeRange  string `xml:"timeRange,attr,omitempty"`
+	TimeFormat string `xml:"timeFormat,attr,omitempty"`
+
+	// Transform is the shell command the log was being shown through (see
+	// the -transform flag), "" if it was shown as read. It's run again on
+	// restore, so the session opens on the same transformed lines.
+	Transform string `xml:"transform,attr,omitempty"`
+
+	// UnsavedFilterChanges records whether Filters had diverged from
+	// FilterFile when the session was saved, so the restored UI keeps
+	// reporting "unsaved filter changes" for tweaks never written back.
+	UnsavedFilterChanges bool `xml:"unsavedFilterChanges,attr"`
+
+	Filters filterfiles.TextAnalysisToolSettings `xml:"TextAnalysisTool.NET"`
+}
+
+// Read parses the session file at path, resolving its relative LogPath and
+// FilterFile against path's directory. A missing file is reported with an
+// error satisfying os.IsNotExist, so callers can treat it as "start a new
+// session here" rather than a failure.
+func Read(path string) (Session, error) {
+	var s Session
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := xml.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+
+	dir := filepath.Dir(path)
+	s.LogPath = resolve(dir, s.LogPath)
+	s.FilterFile = resolve(dir, s.FilterFile)
+	return s, nil
+}
+
+// Write serializes s to path, storing LogPath and FilterFile relative to
+// path's directory when they can be expressed that way (see Session).
+func Write(path string, s Session) error {
+	dir := filepath.Dir(path)
+	s.Version = CurrentVersion
+	s.LogPath = relativize(dir, s.LogPath)
+	s.FilterFile = relativize(dir, s.FilterFile)
+
+	body, err := xml.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out := []byte(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>` + "\n")
+	out = append(out, body...)
+	out = append(out, '\n')
+
+	return os.WriteFile(path, out, 0o644)
+}
+
+// stdinPath is the -log value meaning "read the log from stdin"; it names a
+// stream rather than a file, so it's stored and restored verbatim.
+const stdinPath = "-"
+
+// resolve returns path as-is if it's empty, absolute, or stdinPath, and
+// otherwise joins it onto dir.
+func resolve(dir, path string) string {
+	if path == "" || path == stdinPath || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// relativize is resolve's inverse: it rewrites path relative to dir, falling
+// back to path unchanged (e.g. on Windows, across volumes) if there is no
+// such relative path.
+func relativize(dir, path string) string {
+	if path == "" || path == stdinPath {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return path
+	}
+	return rel
+}
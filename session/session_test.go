@@ -0,0 +1,143 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"skim/filterfiles"
+	"strings"
+	"testing"
+)
+
+func TestWriteThenReadRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "incident-42.skim")
+
+	want := Session{
+		LogPath:              filepath.Join(dir, "service.log"),
+		FilterFile:           filepath.Join(dir, "triage.tat"),
+		HideUnmatched:        true,
+		ContextLines:         3,
+		Search:               "req-[0-9]+",
+		Line:                 120,
+		FilterLine:           2,
+		Focus:                FocusFilters,
+		UnsavedFilterChanges: true,
+		HideColumns:          true,
+		TimeRange:            "2024-01-02 14:02:00..2024-01-02 14:05:59",
+		TimeFormat:           "Jan _2 15:04:05",
+		Filters: filterfiles.TextAnalysisToolSettings{
+			Version:               "2023-04-25",
+			ShowOnlyFilteredLines: "False",
+			Filters: []filterfiles.FilterXML{
+				{Enabled: "y", Excluding: "n", BackColor: "87cefa", Type: "matches_text", CaseSensitive: "n", Regex: "y", Text: "^debug"},
+			},
+		},
+	}
+
+	if err := Write(path, want); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read returned unexpected error: %v", err)
+	}
+
+	if got.Version != CurrentVersion {
+		t.Errorf("Version = %q, want %q", got.Version, CurrentVersion)
+	}
+	if got.LogPath != want.LogPath || got.FilterFile != want.FilterFile {
+		t.Errorf("paths = %q, %q, want %q, %q", got.LogPath, got.FilterFile, want.LogPath, want.FilterFile)
+	}
+	if got.HideUnmatched != want.HideUnmatched || got.ContextLines != want.ContextLines || got.Search != want.Search {
+		t.Errorf("view settings = %+v, want %+v", got, want)
+	}
+	if got.Line != want.Line || got.FilterLine != want.FilterLine || got.Focus != want.Focus {
+		t.Errorf("cursor state = line %d, filterLine %d, focus %q, want %d, %d, %q", got.Line, got.FilterLine, got.Focus, want.Line, want.FilterLine, want.Focus)
+	}
+	if !got.UnsavedFilterChanges {
+		t.Error("UnsavedFilterChanges = false, want true")
+	}
+	if !got.HideColumns {
+		t.Error("HideColumns = false, want true")
+	}
+	if got.TimeRange != want.TimeRange || got.TimeFormat != want.TimeFormat {
+		t.Errorf("time settings = %q, %q, want %q, %q", got.TimeRange, got.TimeFormat, want.TimeRange, want.TimeFormat)
+	}
+	if len(got.Filters.Filters) != 1 || got.Filters.Filters[0].Text != "^debug" {
+		t.Errorf("inline filters = %+v, want the single ^debug filter", got.Filters.Filters)
+	}
+}
+
+func TestWriteStoresPathsRelativeToSessionFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.skim")
+
+	if err := Write(path, Session{LogPath: filepath.Join(dir, "logs", "a.log")}); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read written session: %v", err)
+	}
+	if want := `log="` + filepath.Join("logs", "a.log") + `"`; !strings.Contains(string(data), want) {
+		t.Errorf("written session = %s, want it to contain %s", data, want)
+	}
+}
+
+func TestReadResolvesPathsAgainstSessionDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.skim")
+	xml := `<skimSession version="1" log="a.log" filterFile="/abs/f.tat"></skimSession>`
+	if err := os.WriteFile(path, []byte(xml), 0o644); err != nil {
+		t.Fatalf("failed to write test fixture: %v", err)
+	}
+
+	s, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read returned unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "a.log"); s.LogPath != want {
+		t.Errorf("LogPath = %q, want %q (resolved against the session's dir)", s.LogPath, want)
+	}
+	if s.FilterFile != "/abs/f.tat" {
+		t.Errorf("FilterFile = %q, want an absolute path left unchanged", s.FilterFile)
+	}
+}
+
+func TestStdinLogPathIsKeptVerbatim(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.skim")
+	if err := Write(path, Session{LogPath: "-"}); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+	s, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read returned unexpected error: %v", err)
+	}
+	if s.LogPath != "-" {
+		t.Errorf("LogPath = %q, want %q", s.LogPath, "-")
+	}
+}
+
+func TestReadMissingFileIsNotExist(t *testing.T) {
+	_, err := Read(filepath.Join(t.TempDir(), "missing.skim"))
+	if !os.IsNotExist(err) {
+		t.Errorf("Read of a missing file returned %v, want an os.IsNotExist error", err)
+	}
+}
+
+func TestReadInvalidXML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.skim")
+	if err := os.WriteFile(path, []byte("not xml"), 0o644); err != nil {
+		t.Fatalf("failed to write test fixture: %v", err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Fatal("Read of an invalid file returned no error")
+	}
+}
+
+func TestWriteInvalidPath(t *testing.T) {
+	if err := Write("/nonexistent/dir/s.skim", Session{}); err == nil {
+		t.Fatal("Write to an unwritable path returned no error")
+	}
+}
@@ -7,7 +7,11 @@ import (
 	"io"
 	"os"
 	"skim/filterfiles"
+	"skim/session"
+	"skim/timestamps"
 	"skim/ui"
+	"strings"
+	"time"
 )
 
 // stdinPath is the -log value that means "read the log from stdin"
@@ -27,18 +31,25 @@ func openLogSource(logFile string) (io.ReadCloser, bool, error) {
 	return f, false, err
 }
 
-// isLogFlagSet reports whether -log was explicitly passed on the command
-// line (as opposed to left at its default), by checking fs's set-flags list.
-func isLogFlagSet(fs *flag.FlagSet) bool {
+// isFlagSet reports whether the flag called name was explicitly passed on
+// the command line (as opposed to left at its default), by checking fs's
+// set-flags list.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
 	set := false
 	fs.Visit(func(f *flag.Flag) {
-		if f.Name == "log" {
+		if f.Name == name {
 			set = true
 		}
 	})
 	return set
 }
 
+// isLogFlagSet reports whether -log was explicitly passed on the command
+// line.
+func isLogFlagSet(fs *flag.FlagSet) bool {
+	return isFlagSet(fs, "log")
+}
+
 // stdinIsPiped reports whether r is fed by a pipe or redirect (e.g.
 // `cmd | skim` or `skim < file`) rather than left as an interactive
 // terminal, in which case there's nothing useful to read from it.
@@ -66,23 +77,133 @@ func resolveLogFile(logFile string, fs *flag.FlagSet, stdin *os.File) string {
 // full happy path without handing a real terminal to Bubble Tea.
 var runUI = ui.RunUI
 
+// runOptions is everything run() needs from the command line.
+type runOptions struct {
+	filterFile  string
+	logFile     string
+	sessionFile string // -session path, "" if not given
+
+	// filterSet/logSet record whether -filter/-log were explicitly given,
+	// in which case they override the paths stored in a -session file
+	// (e.g. to replay a shared session against a local copy of the log).
+	filterSet bool
+	logSet    bool
+
+	// since/until are the -since/-until times as typed ("" if not given),
+	// and timeFormat the -time-format layout, overriding the filter file's.
+	since      string
+	until      string
+	timeFormat string
+
+	// gap and spike are the -gap/-spike gap and burst detection settings
+	// as typed, "" if not given (see ui.CheckTimelineSettings).
+	gap   string
+	spike string
+
+	// transform is the -transform command the log is run through, "" if
+	// not given.
+	transform string
+
+	// commands are the -c commands, in the order given, run once the UI
+	// is set up.
+	commands []string
+}
+
+// commandFlags is -c, which can be given any number of times, collecting
+// each value in order.
+type commandFlags []string
+
+func (c *commandFlags) String() string {
+	return strings.Join(*c, "; ")
+}
+
+func (c *commandFlags) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// checkTimeFlags reports whether -since/-until parse, so a typo fails
+// startup with a clear message instead of a status-line note inside the
+// TUI. They can only really be resolved once the log is loaded -- a bare
+// time of day is taken to be on the log's first day -- so this checks them
+// against today's date, which accepts and rejects exactly the same input.
+func checkTimeFlags(opts runOptions) error {
+	if opts.since == "" && opts.until == "" {
+		return nil
+	}
+	if _, err := timestamps.ParseRange(opts.since+".."+opts.until, time.Now(), nil); err != nil {
+		return fmt.Errorf("-since/-until: %w", err)
+	}
+	return nil
+}
+
+// loadSession reads opts.sessionFile, if one was given. A session file that
+// doesn't exist yet isn't an error -- it's where a new session will be
+// saved to -- so it yields a nil session, same as no -session flag at all.
+func loadSession(opts runOptions) (*session.Session, error) {
+	if opts.sessionFile == "" {
+		return nil, nil
+	}
+	s, err := session.Read(opts.sessionFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
 // run loads the filter file and log, then launches the TUI, returning the
 // process exit code the caller (main, via runFn) should use. A filter whose
 // regex fails to compile is a recoverable problem -- it's disabled and
 // logged as a warning, and run still launches the TUI, still returning 0 --
-// but an unreadable filter or log file is not: run logs it once and returns
-// 1 without ever calling runUI, so scripts/CI checking skim's exit code can
-// actually tell startup failed (see the issue this fixed: previously every
-// failure here printed and returned with exit code 0).
-func run(filter_file string, log_file string) int {
-
-	// Read filter settings from the XML file
-	filterSettings, err := filterfiles.ReadFilterFile(filter_file)
+// but an unreadable filter, session or log file (or a malformed -since/
+// -until, -gap or -spike) is not: run logs it once
+// and returns 1 without ever calling runUI, so scripts/CI checking skim's
+// exit code can actually tell startup failed (see the issue this fixed:
+// previously every failure here printed and returned with exit code 0).
+//
+// With an existing -session file, the filters come from the session's own
+// inline copy instead of the filter file, and its log and filter file paths
+// stand in for -log/-filter unless those were explicitly given.
+func run(opts runOptions) int {
+
+	if err := checkTimeFlags(opts); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if err := ui.CheckTimelineSettings(opts.gap, opts.spike); err != nil {
+		fmt.Printf("-gap/-spike: %v\n", err)
+		return 1
+	}
+
+	sess, err := loadSession(opts)
 	if err != nil {
 		fmt.Println(err)
 		return 1
 	}
 
+	filter_file := opts.filterFile
+	log_file := opts.logFile
+	var filterSettings filterfiles.TextAnalysisToolSettings
+	if sess != nil {
+		if !opts.filterSet && sess.FilterFile != "" {
+			filter_file = sess.FilterFile
+		}
+		if !opts.logSet && sess.LogPath != "" {
+			log_file = sess.LogPath
+		}
+		filterSettings = sess.Filters
+	} else {
+		// Read filter settings from the XML file
+		filterSettings, err = filterfiles.ReadFilterFile(filter_file)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
 	// Compile the extracted filters into regular expressions. A filter with
 	// an invalid regex is disabled (not fatal); its warning is logged once
 	// here and passed through to the UI so it can be surfaced there too.
@@ -100,7 +221,18 @@ func run(filter_file string, log_file string) int {
 	defer logfile.Close()
 	scanner := bufio.NewScanner(logfile)
 
-	runUI(filters, scanner, filter_file, filterSettings, usingStdinLog, warnings)
+	runUI(filters, scanner, filter_file, filterSettings, usingStdinLog, warnings, ui.Options{
+		LogPath:        log_file,
+		SessionPath:    opts.sessionFile,
+		Session:        sess,
+		TimeFormat:     opts.timeFormat,
+		Since:          opts.since,
+		Until:          opts.until,
+		GapThreshold:   opts.gap,
+		SpikeThreshold: opts.spike,
+		Transform:      opts.transform,
+		Commands:       opts.commands,
+	})
 	return 0
 }
 
@@ -117,10 +249,33 @@ func mainWithExitCode() int {
 	// Parse Command Line Options
 	filter_file := flag.String("filter", "./examples/simple_filter_two.tat", "supply the path to a TAT filter file")
 	log_file := flag.String("log", "./examples/simple_longer.log", "supply the path to the input log file, or - to read from stdin")
+	session_file := flag.String("session", "", "supply the path to a skim session file to restore, and to save the session to")
+	since := flag.String("since", "", "only show lines at or after this time (e.g. 14:02, or 2024-01-02 14:02:00)")
+	until := flag.String("until", "", "only show lines at or before this time, inclusive (e.g. 14:05)")
+	time_format := flag.String("time-format", "", "Go time layout the log's timestamps use, if skim doesn't detect them (e.g. \"02/Jan/2006:15:04:05 -0700\")")
+	gap := flag.String("gap", "", "mark silences longer than this in the log (e.g. 30s, 5m; 0 for off; default 1m)")
+	spike := flag.String("spike", "", "flag bursts of this many lines/s above the recent rate (0 for off; default 20)")
+	transform := flag.String("transform", "", "shell command to run the log through before showing it (e.g. \"jq -c .\", c++filt)")
+	var commands commandFlags
+	flag.Var(&commands, "c", "run this \":\" command at startup, after the skimrc file (e.g. \"set context=5\"; repeatable)")
 	flag.Parse()
 
 	// Run the program
-	return runFn(*filter_file, resolveLogFile(*log_file, flag.CommandLine, os.Stdin))
+	logFile := resolveLogFile(*log_file, flag.CommandLine, os.Stdin)
+	return runFn(runOptions{
+		filterFile:  *filter_file,
+		logFile:     logFile,
+		sessionFile: *session_file,
+		filterSet:   isFlagSet(flag.CommandLine, "filter"),
+		logSet:      isLogFlagSet(flag.CommandLine) || logFile == stdinPath,
+		since:       *since,
+		until:       *until,
+		timeFormat:  *time_format,
+		gap:         *gap,
+		spike:       *spike,
+		transform:   *transform,
+		commands:    commands,
+	})
 }
 
 func main() {
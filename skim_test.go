@@ -6,7 +6,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"skim/filterfiles"
+	"skim/session"
+	"skim/ui"
 	"strings"
 	"testing"
 )
@@ -117,7 +120,7 @@ func captureStdout(t *testing.T, fn func()) string {
 func TestRunPrintsErrorAndReturnsNonZeroOnUnreadableFilterFile(t *testing.T) {
 	var code int
 	out := captureStdout(t, func() {
-		code = run("/nonexistent/path/to/filters.tat", "./examples/simple_longer.log")
+		code = run(runOptions{filterFile: "/nonexistent/path/to/filters.tat", logFile: "./examples/simple_longer.log"})
 	})
 	if out == "" {
 		t.Error("run() with a missing filter file printed nothing, want an error message")
@@ -152,7 +155,7 @@ func TestRunDisablesInvalidFilterRegexAndStillLaunchesUI(t *testing.T) {
 	var called bool
 	var gotFilters []filterfiles.Filter
 	var gotWarnings []error
-	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error) {
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
 		called = true
 		gotFilters = filters
 		gotWarnings = warnings
@@ -160,7 +163,7 @@ func TestRunDisablesInvalidFilterRegexAndStillLaunchesUI(t *testing.T) {
 
 	var code int
 	out := captureStdout(t, func() {
-		code = run(path, "./examples/simple_longer.log")
+		code = run(runOptions{filterFile: path, logFile: "./examples/simple_longer.log"})
 	})
 
 	if code != 0 {
@@ -193,7 +196,7 @@ func TestRunDisablesInvalidFilterRegexAndStillLaunchesUI(t *testing.T) {
 func TestRunPrintsErrorAndReturnsNonZeroOnUnreadableLogFile(t *testing.T) {
 	var code int
 	out := captureStdout(t, func() {
-		code = run("./examples/simple_filter_two.tat", "/nonexistent/path/to.log")
+		code = run(runOptions{filterFile: "./examples/simple_filter_two.tat", logFile: "/nonexistent/path/to.log"})
 	})
 	if out == "" {
 		t.Error("run() with a missing log file printed nothing, want an error message")
@@ -297,7 +300,7 @@ func TestRunSuccessPathCallsRunUI(t *testing.T) {
 	defer func() { runUI = origRunUI }()
 
 	var called bool
-	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error) {
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
 		called = true
 		if len(filters) != 3 {
 			t.Errorf("got %d filters, want 3 (from examples/simple_filter_two.tat)", len(filters))
@@ -310,7 +313,7 @@ func TestRunSuccessPathCallsRunUI(t *testing.T) {
 		}
 	}
 
-	if code := run("./examples/simple_filter_two.tat", "./examples/simple_longer.log"); code != 0 {
+	if code := run(runOptions{filterFile: "./examples/simple_filter_two.tat", logFile: "./examples/simple_longer.log"}); code != 0 {
 		t.Errorf("run() with valid filter and log files returned exit code %d, want 0", code)
 	}
 
@@ -333,9 +336,9 @@ func TestMainParsesFlagsAndCallsRunFn(t *testing.T) {
 	os.Args = []string{"skim", "-filter", "myfilters.tat", "-log", "mylog.log"}
 
 	var gotFilter, gotLog string
-	runFn = func(filterFile, logFile string) int {
-		gotFilter = filterFile
-		gotLog = logFile
+	runFn = func(opts runOptions) int {
+		gotFilter = opts.filterFile
+		gotLog = opts.logFile
 		return 0
 	}
 
@@ -366,9 +369,299 @@ func TestMainWithExitCodePropagatesRunFnExitCode(t *testing.T) {
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	os.Args = []string{"skim", "-filter", "myfilters.tat", "-log", "mylog.log"}
 
-	runFn = func(filterFile, logFile string) int { return 1 }
+	runFn = func(opts runOptions) int { return 1 }
 
 	if code := mainWithExitCode(); code != 1 {
 		t.Errorf("mainWithExitCode() = %d, want 1 when runFn fails", code)
 	}
 }
+
+// writeTestSession saves a session whose inline filter set (a single
+// "^debug" filter) differs from examples/simple_filter_two.tat's three, so
+// tests can tell which one run() actually used.
+func writeTestSession(t *testing.T, logPath string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "incident.skim")
+	s := session.Session{
+		LogPath:       logPath,
+		FilterFile:    "/somewhere/filters.tat",
+		HideUnmatched: true,
+		Line:          3,
+		Filters: filterfiles.TextAnalysisToolSettings{
+			Filters: []filterfiles.FilterXML{{Enabled: "y", BackColor: "87cefa", Text: "^debug"}},
+		},
+	}
+	if err := session.Write(path, s); err != nil {
+		t.Fatalf("failed to write test session: %v", err)
+	}
+	return path
+}
+
+func TestRunRestoresSessionFiltersAndPaths(t *testing.T) {
+	origRunUI := runUI
+	defer func() { runUI = origRunUI }()
+
+	logPath, err := filepath.Abs("./examples/simple_longer.log")
+	if err != nil {
+		t.Fatalf("filepath.Abs failed: %v", err)
+	}
+	sessionPath := writeTestSession(t, logPath)
+
+	var called bool
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
+		called = true
+		if len(filters) != 1 || filters[0].XML.Text != "^debug" {
+			t.Errorf("filters = %+v, want the session's single inline ^debug filter", filters)
+		}
+		if filterFilePath != "/somewhere/filters.tat" {
+			t.Errorf("filterFilePath = %q, want the session's filter file", filterFilePath)
+		}
+		if options.LogPath != logPath {
+			t.Errorf("options.LogPath = %q, want the session's log %q", options.LogPath, logPath)
+		}
+		if options.SessionPath != sessionPath {
+			t.Errorf("options.SessionPath = %q, want %q", options.SessionPath, sessionPath)
+		}
+		if options.Session == nil || !options.Session.HideUnmatched || options.Session.Line != 3 {
+			t.Errorf("options.Session = %+v, want the restored session", options.Session)
+		}
+	}
+
+	// filterFile points at a missing file: with a session, it must not be read.
+	code := run(runOptions{filterFile: "/nonexistent/filters.tat", logFile: "/nonexistent/default.log", sessionFile: sessionPath})
+	if code != 0 {
+		t.Errorf("run() with a valid session returned exit code %d, want 0", code)
+	}
+	if !called {
+		t.Error("run() with a valid session did not call runUI")
+	}
+}
+
+func TestRunExplicitLogFlagOverridesSessionLog(t *testing.T) {
+	origRunUI := runUI
+	defer func() { runUI = origRunUI }()
+
+	sessionPath := writeTestSession(t, "/nonexistent/original.log")
+
+	var gotLog string
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
+		gotLog = options.LogPath
+	}
+
+	code := run(runOptions{logFile: "./examples/simple_longer.log", logSet: true, sessionFile: sessionPath})
+	if code != 0 {
+		t.Errorf("run() returned exit code %d, want 0", code)
+	}
+	if gotLog != "./examples/simple_longer.log" {
+		t.Errorf("log path = %q, want the explicit -log to win over the session's", gotLog)
+	}
+}
+
+func TestRunWithNewSessionPathStartsFromFlags(t *testing.T) {
+	origRunUI := runUI
+	defer func() { runUI = origRunUI }()
+
+	sessionPath := filepath.Join(t.TempDir(), "new.skim")
+
+	var called bool
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
+		called = true
+		if len(filters) != 3 {
+			t.Errorf("got %d filters, want 3 (from the -filter file, since the session doesn't exist yet)", len(filters))
+		}
+		if options.Session != nil {
+			t.Errorf("options.Session = %+v, want nil for a session file that doesn't exist yet", options.Session)
+		}
+		if options.SessionPath != sessionPath {
+			t.Errorf("options.SessionPath = %q, want %q so a save creates it", options.SessionPath, sessionPath)
+		}
+	}
+
+	if code := run(runOptions{filterFile: "./examples/simple_filter_two.tat", logFile: "./examples/simple_longer.log", sessionFile: sessionPath}); code != 0 {
+		t.Errorf("run() returned exit code %d, want 0", code)
+	}
+	if !called {
+		t.Error("run() did not call runUI")
+	}
+}
+
+func TestRunReturnsNonZeroOnUnreadableSessionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.skim")
+	if err := os.WriteFile(path, []byte("not xml"), 0o644); err != nil {
+		t.Fatalf("failed to write test fixture: %v", err)
+	}
+
+	var code int
+	out := captureStdout(t, func() {
+		code = run(runOptions{filterFile: "./examples/simple_filter_two.tat", logFile: "./examples/simple_longer.log", sessionFile: path})
+	})
+	if out == "" {
+		t.Error("run() with an invalid session file printed nothing, want an error message")
+	}
+	if code != 1 {
+		t.Errorf("run() with an invalid session file returned exit code %d, want 1", code)
+	}
+}
+
+func TestMainPassesSessionFlag(t *testing.T) {
+	origArgs := os.Args
+	origRunFn := runFn
+	origCommandLine := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		runFn = origRunFn
+		flag.CommandLine = origCommandLine
+	}()
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	os.Args = []string{"skim", "-session", "incident-42.skim", "-log", "mylog.log"}
+
+	var got runOptions
+	runFn = func(opts runOptions) int {
+		got = opts
+		return 0
+	}
+
+	if code := mainWithExitCode(); code != 0 {
+		t.Errorf("mainWithExitCode() = %d, want 0", code)
+	}
+	if got.sessionFile != "incident-42.skim" {
+		t.Errorf("sessionFile = %q, want %q", got.sessionFile, "incident-42.skim")
+	}
+	if !got.logSet {
+		t.Error("logSet = false, want true for an explicit -log")
+	}
+	if got.filterSet {
+		t.Error("filterSet = true, want false when -filter was omitted")
+	}
+}
+
+func TestMainPassesTimeFlags(t *testing.T) {
+	origArgs := os.Args
+	origRunFn := runFn
+	origCommandLine := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		runFn = origRunFn
+		flag.CommandLine = origCommandLine
+	}()
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	os.Args = []string{"skim", "-log", "mylog.log", "-since", "14:02", "-until", "14:05", "-time-format", "Jan _2 15:04:05", "-gap", "30s", "-spike", "50", "-transform", "tr a-z A-Z"}
+
+	var got runOptions
+	runFn = func(opts runOptions) int {
+		got = opts
+		return 0
+	}
+
+	if code := mainWithExitCode(); code != 0 {
+		t.Errorf("mainWithExitCode() = %d, want 0", code)
+	}
+	if got.since != "14:02" || got.until != "14:05" || got.timeFormat != "Jan _2 15:04:05" {
+		t.Errorf("since, until, timeFormat = %q, %q, %q, want the flag values", got.since, got.until, got.timeFormat)
+	}
+	if got.gap != "30s" || got.spike != "50" {
+		t.Errorf("gap, spike = %q, %q, want the flag values", got.gap, got.spike)
+	}
+	if got.transform != "tr a-z A-Z" {
+		t.Errorf("transform = %q, want the flag value", got.transform)
+	}
+}
+
+func TestMainCollectsRepeatedCommandFlags(t *testing.T) {
+	origArgs := os.Args
+	origRunFn := runFn
+	origCommandLine := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		runFn = origRunFn
+		flag.CommandLine = origCommandLine
+	}()
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	os.Args = []string{"skim", "-log", "mylog.log", "-c", "set context=5", "-c", "filter add ERROR #ff0000"}
+
+	var got runOptions
+	runFn = func(opts runOptions) int {
+		got = opts
+		return 0
+	}
+
+	if code := mainWithExitCode(); code != 0 {
+		t.Errorf("mainWithExitCode() = %d, want 0", code)
+	}
+	want := []string{"set context=5", "filter add ERROR #ff0000"}
+	if !reflect.DeepEqual(got.commands, want) {
+		t.Errorf("commands = %q, want %q, in order", got.commands, want)
+	}
+}
+
+func TestRunPassesTimeFlagsToUI(t *testing.T) {
+	origRunUI := runUI
+	defer func() { runUI = origRunUI }()
+
+	var got ui.Options
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
+		got = options
+	}
+
+	code := run(runOptions{filterFile: "./examples/simple_filter_two.tat", logFile: "./examples/simple_longer.log", since: "14:02", timeFormat: "15:04", gap: "5m", transform: "cat", commands: []string{"hide"}})
+	if code != 0 {
+		t.Fatalf("run() returned exit code %d, want 0", code)
+	}
+	if got.Since != "14:02" || got.Until != "" || got.TimeFormat != "15:04" || got.GapThreshold != "5m" {
+		t.Errorf("options = %+v, want -since, -time-format and -gap passed through", got)
+	}
+	if got.Transform != "cat" {
+		t.Errorf("Transform = %q, want -transform passed through", got.Transform)
+	}
+	if !reflect.DeepEqual(got.Commands, []string{"hide"}) {
+		t.Errorf("Commands = %q, want -c passed through", got.Commands)
+	}
+}
+
+func TestRunRejectsBadTimeFlags(t *testing.T) {
+	origRunUI := runUI
+	defer func() { runUI = origRunUI }()
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
+		t.Error("runUI was called despite an invalid -since/-until")
+	}
+
+	for _, opts := range []runOptions{
+		{since: "teatime"},
+		{until: "25:99"},
+		{since: "14:05", until: "14:02"},
+	} {
+		opts.filterFile = "./examples/simple_filter_two.tat"
+		opts.logFile = "./examples/simple_longer.log"
+
+		var code int
+		out := captureStdout(t, func() { code = run(opts) })
+		if code != 1 || !strings.Contains(out, "-since/-until") {
+			t.Errorf("run(since=%q, until=%q) = %d, printed %q; want exit code 1 and an error naming the flags", opts.since, opts.until, code, out)
+		}
+	}
+}
+
+func TestRunRejectsBadTimelineFlags(t *testing.T) {
+	origRunUI := runUI
+	defer func() { runUI = origRunUI }()
+	runUI = func(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options ui.Options) {
+		t.Error("runUI was called despite an invalid -gap/-spike")
+	}
+
+	for _, opts := range []runOptions{
+		{gap: "a while"},
+		{spike: "-3"},
+	} {
+		opts.filterFile = "./examples/simple_filter_two.tat"
+		opts.logFile = "./examples/simple_longer.log"
+
+		var code int
+		out := captureStdout(t, func() { code = run(opts) })
+		if code != 1 || !strings.Contains(out, "-gap/-spike") {
+			t.Errorf("run(gap=%q, spike=%q) = %d, printed %q; want exit code 1 and an error naming the flags", opts.gap, opts.spike, code, out)
+		}
+	}
+}
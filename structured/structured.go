@@ -0,0 +1,237 @@
+// Package structured recognizes the structured payloads commonly embedded
+// in log lines -- a JSON object or array, or logfmt key=value pairs --
+// usually after a plain-text prefix such as a timestamp.
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// FindJSON locates the first JSON object embedded in line -- or, if it has
+// none, the first JSON array -- returning the text before it, the JSON value
+// itself (verbatim, with its original key order) and whatever follows it. ok
+// is false if line has no '{' or '[' that starts a valid JSON value.
+//
+// Objects are preferred because a prefix like "worker[3]:" or "[INFO]" is
+// often itself a valid array, and the payload worth showing is the object
+// after it. A bare number or string never counts: that's far more likely to
+// be ordinary log text than a deliberate JSON payload.
+func FindJSON(line string) (prefix string, payload string, suffix string, ok bool) {
+	for _, open := range []byte{'{', '['} {
+		if prefix, payload, suffix, ok = findJSONValue(line, open); ok {
+			return prefix, payload, suffix, true
+		}
+	}
+	return "", "", "", false
+}
+
+// findJSONValue is FindJSON restricted to values starting with open.
+func findJSONValue(line string, open byte) (prefix string, payload string, suffix string, ok bool) {
+	for start := 0; start < len(line); start++ {
+		if line[start] != open {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(line[start:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			continue
+		}
+		end := start + int(dec.InputOffset())
+		return line[:start], line[start:end], line[end:], true
+	}
+	return "", "", "", false
+}
+
+// Indent pretty-prints a JSON payload (as returned by FindJSON) with
+// two-space indentation, preserving its key order. An invalid payload is
+// returned unchanged.
+func Indent(payload string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(payload), "", "  "); err != nil {
+		return payload
+	}
+	return buf.String()
+}
+
+// Field is one key=value pair of a logfmt line, with any surrounding quotes
+// removed from Value (and its escapes resolved).
+type Field struct {
+	Key   string
+	Value string
+}
+
+// ParseLogfmt splits line into logfmt key=value fields. Tokens before the
+// first pair that aren't themselves pairs (typically a timestamp and/or
+// level) are returned joined as prefix, rather than disqualifying the whole
+// line. ok requires at least two pairs, so ordinary prose that happens to
+// contain a single "=" isn't mistaken for logfmt.
+func ParseLogfmt(line string) (prefix string, fields []Field, ok bool) {
+	var prefixTokens []string
+	pairs := 0
+	for _, tok := range tokenize(line) {
+		key, value, isPair := splitPair(tok)
+		if !isPair {
+			if len(fields) == 0 {
+				prefixTokens = append(prefixTokens, tok)
+				continue
+			}
+			// A bare word after the pairs have started: treat it as a
+			// valueless key, the way most logfmt parsers do.
+			fields = append(fields, Field{Key: tok})
+			continue
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+		pairs++
+	}
+
+	if pairs < 2 {
+		return "", nil, false
+	}
+	return strings.Join(prefixTokens, " "), fields, true
+}
+
+// tokenize splits line on unquoted whitespace, keeping a double-quoted run
+// (with backslash escapes) together as part of its token.
+func tokenize(line string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuotes, escaped := false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// splitPair splits a key=value token, unquoting a quoted value. It reports
+// false for tokens with no '=' or whose key isn't a plausible identifier.
+func splitPair(tok string) (key string, value string, ok bool) {
+	i := strings.IndexByte(tok, '=')
+	if i <= 0 {
+		return "", "", false
+	}
+	key, value = tok[:i], tok[i+1:]
+	if !isKey(key) {
+		return "", "", false
+	}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		var unquoted string
+		if err := json.Unmarshal([]byte(value), &unquoted); err == nil {
+			value = unquoted
+		} else {
+			value = value[1 : len(value)-1]
+		}
+	}
+	return key, value, true
+}
+
+// isKey reports whether s looks like a logfmt key: letters, digits, and
+// "_", ".", "-", "/", starting with a letter or "_".
+func isKey(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case i > 0 && ((r >= '0' && r <= '9') || r == '.' || r == '-' || r == '/'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Record is a structured line's fields in their original order, as Parse
+// extracts them. Nested JSON objects are flattened, their keys joined with
+// "." (so {"http":{"status":500}} has a field "http.status").
+type Record []Field
+
+// Get returns the value of the first field named key, and whether there
+// was one.
+func (r Record) Get(key string) (string, bool) {
+	for _, f := range r {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parse extracts line's fields: from its embedded JSON object if it has one
+// (see FindJSON; a top-level array has no field names, so doesn't count),
+// otherwise from its logfmt pairs (see ParseLogfmt). ok is false for a line
+// that's neither, which callers should treat as plain text.
+//
+// JSON values are converted to the text a user would type to match them:
+// strings unquoted, null as "", and numbers, booleans and arrays as their
+// compact JSON.
+func Parse(line string) (rec Record, ok bool) {
+	if _, payload, _, found := FindJSON(line); found && payload[0] == '{' {
+		if rec, err := flattenObject(json.RawMessage(payload), "", nil); err == nil {
+			return rec, true
+		}
+	}
+	if _, fields, found := ParseLogfmt(line); found {
+		return Record(fields), true
+	}
+	return nil, false
+}
+
+// flattenObject appends raw's fields (raw must be a JSON object) to rec in
+// document order, each key prefixed with prefix.
+func flattenObject(raw json.RawMessage, prefix string, rec Record) (Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil { // the opening '{'
+		return nil, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := prefix + tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		switch value[0] {
+		case '{':
+			if rec, err = flattenObject(value, key+".", rec); err != nil {
+				return nil, err
+			}
+			continue
+		case '"':
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return nil, err
+			}
+			rec = append(rec, Field{Key: key, Value: s})
+		case 'n':
+			rec = append(rec, Field{Key: key})
+		default:
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, value); err != nil {
+				return nil, err
+			}
+			rec = append(rec, Field{Key: key, Value: buf.String()})
+		}
+	}
+	return rec, nil
+}
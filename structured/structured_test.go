@@ -0,0 +1,165 @@
+package structured
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindJSON(t *testing.T) {
+	tests := []struct {
+		name                    string
+		line                    string
+		prefix, payload, suffix string
+		ok                      bool
+	}{
+		{"bare object", `{"a":1}`, "", `{"a":1}`, "", true},
+		{"after a timestamp", `2024-01-02 10:00:00 {"level":"info"} trailing`, "2024-01-02 10:00:00 ", `{"level":"info"}`, " trailing", true},
+		{"array", `got [1, 2, 3]`, "got ", `[1, 2, 3]`, "", true},
+		{"an object wins over an earlier array", `worker[3]: {"a":1}`, "worker[3]: ", `{"a":1}`, "", true},
+		{"skips an invalid brace for a later valid one", `[INFO] {"a":true}`, "[INFO] ", `{"a":true}`, "", true},
+		{"no json", `plain text line`, "", "", "", false},
+		{"unterminated", `{"a": 1`, "", "", "", false},
+		{"bare number doesn't count", `took 42 ms`, "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, payload, suffix, ok := FindJSON(tt.line)
+			if ok != tt.ok || prefix != tt.prefix || payload != tt.payload || suffix != tt.suffix {
+				t.Errorf("FindJSON(%q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+					tt.line, prefix, payload, suffix, ok, tt.prefix, tt.payload, tt.suffix, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIndentPreservesKeyOrder(t *testing.T) {
+	got := Indent(`{"z":1,"a":{"b":[true,null]}}`)
+	want := "{\n  \"z\": 1,\n  \"a\": {\n    \"b\": [\n      true,\n      null\n    ]\n  }\n}"
+	if got != want {
+		t.Errorf("Indent() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestIndentLeavesInvalidPayloadUnchanged(t *testing.T) {
+	if got := Indent(`{nope`); got != `{nope` {
+		t.Errorf("Indent() = %q, want the input unchanged", got)
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		prefix string
+		fields []Field
+		ok     bool
+	}{
+		{
+			name:   "simple pairs",
+			line:   `level=info msg=started port=8080`,
+			fields: []Field{{"level", "info"}, {"msg", "started"}, {"port", "8080"}},
+			ok:     true,
+		},
+		{
+			name:   "quoted value with spaces and escapes",
+			line:   `ts=1 msg="hello \"world\" again"`,
+			fields: []Field{{"ts", "1"}, {"msg", `hello "world" again`}},
+			ok:     true,
+		},
+		{
+			name:   "non-pair prefix tokens",
+			line:   `2024-01-02T10:00:00Z INFO user=bob action=login`,
+			prefix: "2024-01-02T10:00:00Z INFO",
+			fields: []Field{{"user", "bob"}, {"action", "login"}},
+			ok:     true,
+		},
+		{
+			name:   "bare word after pairs is a valueless key",
+			line:   `a=1 b=2 debug`,
+			fields: []Field{{"a", "1"}, {"b", "2"}, {"debug", ""}},
+			ok:     true,
+		},
+		{
+			name:   "empty value",
+			line:   `a= b=2`,
+			fields: []Field{{"a", ""}, {"b", "2"}},
+			ok:     true,
+		},
+		{"single pair is prose", `retrying with timeout=5s`, "", nil, false},
+		{"no pairs", `just some words`, "", nil, false},
+		{"operator-looking text", `if x == y then 1=2`, "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, fields, ok := ParseLogfmt(tt.line)
+			if ok != tt.ok || prefix != tt.prefix || !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("ParseLogfmt(%q) = %q, %+v, %v, want %q, %+v, %v",
+					tt.line, prefix, fields, ok, tt.prefix, tt.fields, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Record
+		ok   bool
+	}{
+		{
+			name: "json lines keep key order and flatten nested objects",
+			line: `{"ts":"2024-01-02T10:00:00Z","level":"error","http":{"status":500,"path":"/x"},"ok":false,"tags":["a", "b"],"err":null}`,
+			want: Record{
+				{"ts", "2024-01-02T10:00:00Z"}, {"level", "error"},
+				{"http.status", "500"}, {"http.path", "/x"},
+				{"ok", "false"}, {"tags", `["a","b"]`}, {"err", ""},
+			},
+			ok: true,
+		},
+		{
+			name: "json after a prefix",
+			line: `10:00:00 app[42]: {"msg":"hi"}`,
+			want: Record{{"msg", "hi"}},
+			ok:   true,
+		},
+		{
+			name: "logfmt",
+			line: `level=info msg="a b"`,
+			want: Record{{"level", "info"}, {"msg", "a b"}},
+			ok:   true,
+		},
+		{
+			name: "a top-level json array falls back to logfmt",
+			line: `[1,2] a=1 b=2`,
+			want: Record{{"a", "1"}, {"b", "2"}},
+			ok:   true,
+		},
+		{"plain text", `connection reset by peer`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse(tt.line)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRecordGet(t *testing.T) {
+	rec := Record{{"a", "1"}, {"b", ""}, {"a", "2"}}
+
+	if v, ok := rec.Get("a"); !ok || v != "1" {
+		t.Errorf(`Get("a") = %q, %v, want the first occurrence "1", true`, v, ok)
+	}
+	if v, ok := rec.Get("b"); !ok || v != "" {
+		t.Errorf(`Get("b") = %q, %v, want "", true for a present but empty field`, v, ok)
+	}
+	if _, ok := rec.Get("c"); ok {
+		t.Error(`Get("c") ok = true, want false for a missing field`)
+	}
+}
@@ -0,0 +1,287 @@
+// Package timestamps finds the time a log line was written -- in any of the
+// common formats logs use, or a layout configured for one particular log --
+// and parses the times and time ranges a user types to navigate by them.
+package timestamps
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parser extracts timestamps from log lines. The zero Parser auto-detects
+// the formats described on Parse, in the local time zone.
+type Parser struct {
+	// Layout, if set, is a Go time layout (see time.Parse) the log's
+	// timestamps are written in, used instead of auto-detection. The
+	// timestamp must start the line, optionally after a "[".
+	Layout string
+
+	// Location is the zone for timestamps that don't carry their own
+	// offset; nil means time.Local.
+	Location *time.Location
+
+	// Year is the year for timestamps that don't carry one (syslog's
+	// "Jan _2 15:04:05", or a Layout without a year); 0 means the current
+	// year.
+	Year int
+}
+
+// detectPattern matches the auto-detected formats, leftmost first, one
+// capture group each:
+//  1. ISO 8601 / RFC 3339 date-times, with "T" or " " between date and time,
+//     an optional fraction (after "." or ",") and an optional offset --
+//     which covers both RFC3339 and "2006-01-02 15:04:05.000"
+//  2. syslog's "Jan _2 15:04:05", with an optional fraction
+//  3. epoch milliseconds: a standalone 13-digit number starting with 1
+//     (2001-09-09 to 2033-05-18), so ordinary ids and counters rarely qualify
+var detectPattern = regexp.MustCompile(
+	`(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)` +
+		`|((?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d+)?)` +
+		`|(?:^|[^\d.])(1\d{12})(?:\D|$)`)
+
+// Parse returns the timestamp in line and whether there was one. Without a
+// Layout it recognizes, wherever they first appear in the line: RFC 3339
+// and "2006-01-02 15:04:05.000"-style date-times, syslog's
+// "Jan _2 15:04:05", and epoch milliseconds.
+func (p Parser) Parse(line string) (time.Time, bool) {
+	if p.Layout != "" {
+		return p.parseLayout(line)
+	}
+
+	m := detectPattern.FindStringSubmatchIndex(line)
+	if m == nil {
+		return time.Time{}, false
+	}
+	switch {
+	case m[2] >= 0:
+		return p.parseISO(line[m[2]:m[3]])
+	case m[4] >= 0:
+		return p.parseSyslog(line[m[4]:m[5]])
+	default:
+		ms, err := strconv.ParseInt(line[m[6]:m[7]], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(ms).In(p.location()), true
+	}
+}
+
+func (p Parser) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+	return p.Location
+}
+
+func (p Parser) year() int {
+	if p.Year == 0 {
+		return time.Now().Year()
+	}
+	return p.Year
+}
+
+// parseISO parses a detectPattern group-1 match.
+func (p Parser) parseISO(s string) (time.Time, bool) {
+	s = strings.Replace(s, " ", "T", 1)
+	s = strings.Replace(s, ",", ".", 1)
+
+	// The date-time itself is a fixed 19 bytes; anything after it is the
+	// optional fraction and then the optional offset.
+	rest := s[19:]
+	offset := ""
+	if i := strings.IndexAny(rest, "Z+-"); i >= 0 {
+		offset = rest[i:]
+	}
+	if offset == "" {
+		t, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", s, p.location())
+		return t, err == nil
+	}
+	if len(offset) == 5 { // "+hhmm": RFC 3339 wants "+hh:mm"
+		s = s[:len(s)-2] + ":" + s[len(s)-2:]
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// parseSyslog parses a detectPattern group-2 match.
+func (p Parser) parseSyslog(s string) (time.Time, bool) {
+	t, err := time.ParseInLocation("Jan _2 15:04:05.999999999", s, p.location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return withYear(t, p.year()), true
+}
+
+// layoutSlack is how far a timestamp written in Layout may differ in length
+// from Layout itself (e.g. "Jan 2" vs "Jan 12", or ".000" fractions) --
+// parseLayout tries every prefix of the line within this many bytes of it.
+const layoutSlack = 6
+
+// parseLayout parses a timestamp written in p.Layout at the start of line.
+func (p Parser) parseLayout(line string) (time.Time, bool) {
+	line = strings.TrimLeft(line, "[ ")
+	for n := min(len(p.Layout)+layoutSlack, len(line)); n >= max(len(p.Layout)-layoutSlack, 1); n-- {
+		t, err := time.ParseInLocation(p.Layout, line[:n], p.location())
+		if err != nil {
+			continue
+		}
+		if t.Year() == 0 {
+			t = withYear(t, p.year())
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func withYear(t time.Time, year int) time.Time {
+	return time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// queryLayouts are the forms ParseQuery accepts, each with the span of time
+// it names (so "14:05" means the whole minute). time.Parse also accepts a
+// fractional second after a layout's seconds, so the seconds layouts cover
+// "14:05:59.5" too; parseQuery narrows their span to the exact instant when
+// there is one.
+var queryLayouts = []struct {
+	layout    string
+	precision time.Duration
+	timeOnly  bool
+}{
+	{"2006-01-02T15:04:05", time.Second, false},
+	{"2006-01-02 15:04:05", time.Second, false},
+	{"2006-01-02T15:04", time.Minute, false},
+	{"2006-01-02 15:04", time.Minute, false},
+	{"2006-01-02", 24 * time.Hour, false},
+	{"15:04:05", time.Second, true},
+	{"15:04", time.Minute, true},
+}
+
+// ErrBadTime is wrapped by every error ParseQuery and ParseRange return for
+// input they don't understand.
+var ErrBadTime = errors.New("expected a time like 14:03, 14:03:00, 2024-01-02 14:03 or RFC 3339")
+
+// ParseQuery parses a time the user typed (e.g. into a jump-to-time prompt
+// or a -since flag): a full RFC 3339 time, a date and time without a zone
+// (in loc), a date alone, or a time of day alone -- which is taken to be on
+// ref's date, normally the log's first timestamp, so "14:03" means 14:03 on
+// the day the log covers rather than today. loc nil means time.Local.
+func ParseQuery(s string, ref time.Time, loc *time.Location) (time.Time, error) {
+	t, _, err := parseQuery(s, ref, loc)
+	return t, err
+}
+
+// parseQuery is ParseQuery, also returning the span of time s names (see
+// queryLayouts), which ParseRange uses to make an upper bound inclusive.
+func parseQuery(s string, ref time.Time, loc *time.Location) (time.Time, time.Duration, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s = strings.TrimSpace(s)
+
+	// An explicit offset is honored, but the result is moved into loc so
+	// every time a query yields -- and so Range.String, which prints each
+	// end without a zone -- is in the same zone as the log's own times.
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.In(loc), time.Nanosecond, nil
+	}
+	for _, q := range queryLayouts {
+		t, err := time.ParseInLocation(q.layout, s, loc)
+		if err != nil {
+			continue
+		}
+		precision := q.precision
+		if t.Nanosecond() != 0 {
+			precision = time.Nanosecond
+		}
+		if q.timeOnly {
+			if ref.IsZero() {
+				ref = time.Now()
+			}
+			ref = ref.In(loc)
+			t = time.Date(ref.Year(), ref.Month(), ref.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		}
+		return t, precision, nil
+	}
+	return time.Time{}, 0, fmt.Errorf("%q: %w", s, ErrBadTime)
+}
+
+// Range is an inclusive span of time; a zero Since or Until leaves that end
+// open, and the zero Range contains everything.
+type Range struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero reports whether r is unrestricted.
+func (r Range) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Contains reports whether t falls within r. A zero t (a line with no
+// timestamp at all) is only within a Range with no Since.
+func (r Range) Contains(t time.Time) bool {
+	if !r.Since.IsZero() && (t.IsZero() || t.Before(r.Since)) {
+		return false
+	}
+	if !r.Until.IsZero() && t.After(r.Until) {
+		return false
+	}
+	return true
+}
+
+// rangeLayout is how Range.String formats each end.
+const rangeLayout = "2006-01-02 15:04:05"
+
+// String formats r the way ParseRange reads it back, "" for the zero Range.
+func (r Range) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	var since, until string
+	if !r.Since.IsZero() {
+		since = r.Since.Format(rangeLayout)
+	}
+	if !r.Until.IsZero() {
+		until = r.Until.Format(rangeLayout)
+	}
+	return since + ".." + until
+}
+
+// ParseRange parses a range the user typed: "FROM..TO", "FROM.." or
+// "..TO", each end in any form ParseQuery accepts. TO is inclusive of the
+// whole span it names -- "..14:05" runs to 14:05:59.999999999 -- so a range
+// reads the way people say it. Empty input is the zero Range.
+func ParseRange(s string, ref time.Time, loc *time.Location) (Range, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Range{}, nil
+	}
+	from, to, ok := strings.Cut(s, "..")
+	if !ok {
+		return Range{}, fmt.Errorf("%q: expected FROM..TO, FROM.. or ..TO", s)
+	}
+
+	var r Range
+	if strings.TrimSpace(from) != "" {
+		t, err := ParseQuery(from, ref, loc)
+		if err != nil {
+			return Range{}, err
+		}
+		r.Since = t
+	}
+	if strings.TrimSpace(to) != "" {
+		t, precision, err := parseQuery(to, ref, loc)
+		if err != nil {
+			return Range{}, err
+		}
+		r.Until = t.Add(precision - time.Nanosecond)
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && r.Until.Before(r.Since) {
+		return Range{}, fmt.Errorf("%q: the range ends before it starts", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,183 @@
+package timestamps
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(y int, mo time.Month, d, h, mi, s, ns int) time.Time {
+	return time.Date(y, mo, d, h, mi, s, ns, time.UTC)
+}
+
+func TestParseDetectsCommonFormats(t *testing.T) {
+	p := Parser{Location: time.UTC, Year: 2024}
+	plus2 := time.FixedZone("", 2*60*60)
+
+	tests := []struct {
+		name string
+		line string
+		want time.Time
+	}{
+		{"rfc3339 utc", `2024-01-02T14:03:00Z GET /`, date(2024, 1, 2, 14, 3, 0, 0)},
+		{"rfc3339 nano with offset", `2024-01-02T14:03:00.123456+02:00 x`, time.Date(2024, 1, 2, 14, 3, 0, 123456000, plus2)},
+		{"offset without colon", `2024-01-02T14:03:00+0200 x`, time.Date(2024, 1, 2, 14, 3, 0, 0, plus2)},
+		{"space separated with millis", `2024-01-02 14:03:00.250 INFO start`, date(2024, 1, 2, 14, 3, 0, 250000000)},
+		{"comma millis (log4j)", `2024-01-02 14:03:00,250 INFO start`, date(2024, 1, 2, 14, 3, 0, 250000000)},
+		{"after a prefix", `[worker-3] 2024-01-02 14:03:00 done`, date(2024, 1, 2, 14, 3, 0, 0)},
+		{"syslog", `Jan  2 14:03:00 host sshd[42]: accepted`, date(2024, 1, 2, 14, 3, 0, 0)},
+		{"syslog two-digit day", `Dec 24 23:59:59 host cron: ok`, date(2024, 12, 24, 23, 59, 59, 0)},
+		{"epoch millis", `1704204180000 request done`, date(2024, 1, 2, 14, 3, 0, 0)},
+		{"epoch millis in json", `{"ts":1704204180500,"msg":"x"}`, date(2024, 1, 2, 14, 3, 0, 500000000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.Parse(tt.line)
+			if !ok || !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, %v, want %v, true", tt.line, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsLinesWithoutATimestamp(t *testing.T) {
+	p := Parser{Location: time.UTC}
+	for _, line := range []string{
+		"",
+		"at com.example.Foo.bar(Foo.java:42)",
+		"request id 123456789012 failed", // 12 digits: not epoch millis
+		"order 12345678901234 shipped",   // 14 digits
+		"version 2.1704204180000",        // part of a decimal
+	} {
+		if got, ok := p.Parse(line); ok {
+			t.Errorf("Parse(%q) = %v, want no timestamp", line, got)
+		}
+	}
+}
+
+func TestParseWithLayout(t *testing.T) {
+	p := Parser{Layout: "02/Jan/2006:15:04:05 -0700", Location: time.UTC}
+	got, ok := p.Parse(`[02/Jan/2024:14:03:00 +0000] "GET / HTTP/1.1" 200`)
+	if !ok || !got.Equal(date(2024, 1, 2, 14, 3, 0, 0)) {
+		t.Errorf("Parse() = %v, %v, want 2024-01-02 14:03:00 UTC", got, ok)
+	}
+
+	noYear := Parser{Layout: "01/02 15:04:05", Location: time.UTC, Year: 2023}
+	got, ok = noYear.Parse(`01/02 14:03:00 hello`)
+	if !ok || !got.Equal(date(2023, 1, 2, 14, 3, 0, 0)) {
+		t.Errorf("Parse() = %v, %v, want the configured Year filled in", got, ok)
+	}
+
+	if _, ok := p.Parse(`2024-01-02T14:03:00Z in a different format`); ok {
+		t.Error("Parse() found a timestamp that doesn't match Layout; a Layout should replace auto-detection")
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	ref := date(2024, 1, 2, 9, 0, 0, 0)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"14:03", date(2024, 1, 2, 14, 3, 0, 0)},
+		{" 14:03:07 ", date(2024, 1, 2, 14, 3, 7, 0)},
+		{"14:03:07.5", date(2024, 1, 2, 14, 3, 7, 500000000)},
+		{"2024-03-04 05:06", date(2024, 3, 4, 5, 6, 0, 0)},
+		{"2024-03-04T05:06:07", date(2024, 3, 4, 5, 6, 7, 0)},
+		{"2024-03-04", date(2024, 3, 4, 0, 0, 0, 0)},
+		{"2024-03-04T05:06:07Z", date(2024, 3, 4, 5, 6, 7, 0)},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.in, ref, time.UTC)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseQuery(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseQuery("yesterday", ref, time.UTC); !errors.Is(err, ErrBadTime) {
+		t.Errorf("ParseQuery(\"yesterday\") error = %v, want ErrBadTime", err)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	ref := date(2024, 1, 2, 0, 0, 0, 0)
+
+	r, err := ParseRange("14:02..14:05", ref, time.UTC)
+	if err != nil {
+		t.Fatalf("ParseRange returned unexpected error: %v", err)
+	}
+	if !r.Since.Equal(date(2024, 1, 2, 14, 2, 0, 0)) {
+		t.Errorf("Since = %v, want 14:02:00", r.Since)
+	}
+	if !r.Until.Equal(date(2024, 1, 2, 14, 5, 59, 999999999)) {
+		t.Errorf("Until = %v, want the end of the 14:05 minute", r.Until)
+	}
+
+	if r, err := ParseRange("..14:05:59", ref, time.UTC); err != nil || !r.Until.Equal(date(2024, 1, 2, 14, 5, 59, 999999999)) {
+		t.Errorf("ParseRange(\"..14:05:59\") = %+v, %v, want the whole second included", r, err)
+	}
+	if r, err := ParseRange("..14:05:59.5", ref, time.UTC); err != nil || !r.Until.Equal(date(2024, 1, 2, 14, 5, 59, 500000000)) {
+		t.Errorf("ParseRange(\"..14:05:59.5\") = %+v, %v, want exactly 14:05:59.5", r, err)
+	}
+	if r, err := ParseRange("14:02..", ref, time.UTC); err != nil || !r.Until.IsZero() || r.Since.IsZero() {
+		t.Errorf("ParseRange(\"14:02..\") = %+v, %v, want an open end", r, err)
+	}
+	if r, err := ParseRange("..14:05", ref, time.UTC); err != nil || !r.Since.IsZero() || r.Until.IsZero() {
+		t.Errorf("ParseRange(\"..14:05\") = %+v, %v, want an open start", r, err)
+	}
+	if r, err := ParseRange("  ", ref, time.UTC); err != nil || !r.IsZero() {
+		t.Errorf("ParseRange(blank) = %+v, %v, want the zero Range", r, err)
+	}
+
+	for _, bad := range []string{"14:02", "14:05..14:02", "soon..later"} {
+		if _, err := ParseRange(bad, ref, time.UTC); err == nil {
+			t.Errorf("ParseRange(%q) returned no error", bad)
+		}
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	r := Range{Since: date(2024, 1, 2, 14, 0, 0, 0), Until: date(2024, 1, 2, 15, 0, 0, 0)}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"inside", date(2024, 1, 2, 14, 30, 0, 0), true},
+		{"at since", r.Since, true},
+		{"at until", r.Until, true},
+		{"before", date(2024, 1, 2, 13, 59, 59, 0), false},
+		{"after", date(2024, 1, 2, 15, 0, 1, 0), false},
+		{"no timestamp", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		if got := r.Contains(tt.t); got != tt.want {
+			t.Errorf("%s: Contains(%v) = %v, want %v", tt.name, tt.t, got, tt.want)
+		}
+	}
+
+	if !(Range{Until: r.Until}).Contains(time.Time{}) {
+		t.Error("a Range without Since should contain untimestamped lines")
+	}
+	if !(Range{}).Contains(time.Time{}) {
+		t.Error("the zero Range should contain everything")
+	}
+}
+
+func TestRangeStringRoundTrips(t *testing.T) {
+	r, err := ParseRange("2024-01-02 14:02:00..2024-01-02 14:05:59", time.Time{}, time.UTC)
+	if err != nil {
+		t.Fatalf("ParseRange returned unexpected error: %v", err)
+	}
+	if got := r.String(); got != "2024-01-02 14:02:00..2024-01-02 14:05:59" {
+		t.Errorf("String() = %q", got)
+	}
+	back, err := ParseRange(r.String(), time.Time{}, time.UTC)
+	if err != nil || back != r {
+		t.Errorf("ParseRange(String()) = %+v, %v, want %+v", back, err, r)
+	}
+	if (Range{}).String() != "" {
+		t.Error("zero Range String() should be empty")
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// MaxClipboardBytes caps how much copySelection will send through OSC 52.
+// Terminals silently drop (or truncate) clipboard writes past their own
+// limit, which is often about this size once base64-encoded, so a bigger
+// selection is refused up front with a pointer to piping it instead.
+const MaxClipboardBytes = 74 * 1024
+
+// selectionText returns the raw text of the selected lines (see
+// logview.LogView.SelectedLines), one per line with a trailing newline, and
+// how many lines that is.
+func (m model) selectionText() (string, int) {
+	lines := m.log.SelectedLines()
+	var b strings.Builder
+	for _, i := range lines {
+		b.WriteString(m.log.Lines[i])
+		b.WriteByte('\n')
+	}
+	return b.String(), len(lines)
+}
+
+// clipboardMsg reports how writing a clipboard sequence to the terminal
+// went.
+type clipboardMsg struct {
+	lines int
+	err   error
+}
+
+// copySelection copies the selected lines' raw text -- no line numbers,
+// borders or styling -- to the system clipboard, and ends the selection.
+// The copy goes through the terminal itself as an OSC 52 escape sequence
+// (see clipboardSequence), so it lands on the clipboard of the machine the
+// terminal runs on, even when skim is running over SSH.
+func (m *model) copySelection() tea.Cmd {
+	text, n := m.selectionText()
+	if n == 0 {
+		m.saveStatus = "nothing to copy"
+		return nil
+	}
+	if len(text) > MaxClipboardBytes {
+		m.saveStatus = fmt.Sprintf("selection too large to copy (%d bytes, max %d): pipe it to a command instead", len(text), MaxClipboardBytes)
+		return nil
+	}
+	m.log.ClearSelection()
+	seq := clipboardSequence(text, os.Getenv)
+	w := m.terminal
+	return func() tea.Msg {
+		_, err := io.WriteString(w, seq)
+		return clipboardMsg{lines: n, err: err}
+	}
+}
+
+// clipboardSequence returns the OSC 52 sequence setting the system
+// clipboard to text, wrapped for tmux or GNU screen when running inside one
+// (per getenv), which would otherwise swallow it rather than pass it on to
+// the terminal. tmux also needs set-clipboard (or allow-passthrough) on.
+func clipboardSequence(text string, getenv func(string) string) string {
+	seq := ansi.SetSystemClipboard(text)
+	switch {
+	case getenv("TMUX") != "":
+		return ansi.TmuxPassthrough(seq)
+	case strings.HasPrefix(getenv("TERM"), "screen"):
+		// screen caps a passthrough string's length, so it's split into
+		// chunks it'll accept.
+		return ansi.ScreenPassthrough(seq, 768)
+	}
+	return seq
+}
+
+// clipboardStatus describes msg for the status line.
+func clipboardStatus(msg clipboardMsg) string {
+	switch {
+	case msg.err != nil:
+		return fmt.Sprintf("copy failed: %v", msg.err)
+	case msg.lines == 1:
+		return "copied 1 line to the clipboard"
+	default:
+		return fmt.Sprintf("copied %d lines to the clipboard", msg.lines)
+	}
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCopySelectionWritesOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	m := newTestModel(t, nil, "one\n\ttwo\nthree\n")
+	m.hideUnmatched = false
+	var out bytes.Buffer
+	m.terminal = &out
+	m = update(t, m, tea_WindowSize())
+	m.View()
+	m = update(t, m, keyMsg("V"), keyMsg("j"))
+	m.View()
+
+	newModel, cmd := m.Update(keyMsg("y"))
+	m = newModel.(model)
+	if cmd == nil {
+		t.Fatal("y returned no command to write the clipboard sequence")
+	}
+	if m.log.Selecting() {
+		t.Error("Selecting() = true after y, want the selection over")
+	}
+	m = update(t, m, cmd())
+
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("one\n\ttwo\n")) + "\x07"
+	if out.String() != want {
+		t.Errorf("terminal got %q, want %q", out.String(), want)
+	}
+	if m.saveStatus != "copied 2 lines to the clipboard" {
+		t.Errorf("status = %q after copying", m.saveStatus)
+	}
+}
+
+func TestCopySelectionRefusesOversizedSelection(t *testing.T) {
+	line := strings.Repeat("x", 1000)
+	m := newTestModel(t, nil, strings.Repeat(line+"\n", 100))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m.View()
+	m = update(t, m, keyMsg("V"), keyMsg("G"))
+	m.View()
+
+	newModel, cmd := m.Update(keyMsg("y"))
+	m = newModel.(model)
+	if cmd != nil || !strings.HasPrefix(m.saveStatus, "selection too large to copy") {
+		t.Errorf("status = %q (cmd %v), want a 100KB selection refused", m.saveStatus, cmd != nil)
+	}
+}
+
+func TestClipboardSequenceWrapsForMultiplexers(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	plain := clipboardSequence("hi", env(nil))
+	if plain != "\x1b]52;c;aGk=\x07" {
+		t.Errorf("plain sequence = %q", plain)
+	}
+	if got := clipboardSequence("hi", env(map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"})); !strings.HasPrefix(got, "\x1bPtmux;") {
+		t.Errorf("sequence under tmux = %q, want a tmux passthrough", got)
+	}
+	if got := clipboardSequence("hi", env(map[string]string{"TERM": "screen-256color"})); !strings.HasPrefix(got, "\x1bP") || strings.HasPrefix(got, "\x1bPtmux;") {
+		t.Errorf("sequence under screen = %q, want a screen passthrough", got)
+	}
+}
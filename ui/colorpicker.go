@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"skim/ui/views/inputview"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -52,9 +53,9 @@ type colorPickerState struct {
 	open   bool
 	cursor int // index into colorPalette
 
-	customEditing bool   // typing a custom hex value instead of using the grid
-	customBuf     string // in-progress hex digits typed so far, no leading '#'
-	customErr     string // set if customBuf failed to validate on enter
+	customEditing bool            // typing a custom hex value instead of using the grid
+	customInput   inputview.Input // in-progress hex digits typed so far, no leading '#'
+	customErr     string          // set if customInput failed to validate on enter
 }
 
 // updateColorPicker handles key presses while the color picker is open,
@@ -76,7 +77,7 @@ func (m model) updateColorPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "c":
 		cp.customEditing = true
-		cp.customBuf = ""
+		cp.customInput = inputview.Input{Accept: isHexDigit, MaxLen: 6}
 		cp.customErr = ""
 
 	case "up", "k":
@@ -104,40 +105,32 @@ func (m model) updateColorPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 // updateColorPickerCustomInput handles key presses while typing a custom hex
-// color (after pressing "c" in the grid): hex digits are appended to
-// customBuf (capped at 6), backspace removes the last one, esc returns to
-// the grid without applying anything, and enter validates and applies it.
+// color (after pressing "c" in the grid): esc returns to the grid without
+// applying anything, enter validates and applies it, and anything else
+// edits it, hex digits only and at most 6 of them.
 func (m model) updateColorPickerCustomInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	cp := &m.filterEditor.colorPicker
 
 	switch msg.String() {
 	case "esc":
 		cp.customEditing = false
-		cp.customBuf = ""
+		cp.customInput.Reset()
 		cp.customErr = ""
 
 	case "enter":
-		if len(cp.customBuf) != 6 {
+		hex := cp.customInput.Value()
+		if len(hex) != 6 {
 			cp.customErr = "hex color must be 6 digits, e.g. 87CEFA"
 			break
 		}
-		m.applyPickedColor("#" + strings.ToUpper(cp.customBuf))
+		m.applyPickedColor("#" + strings.ToUpper(hex))
 		cp.customEditing = false
-		cp.customBuf = ""
+		cp.customInput.Reset()
 		cp.customErr = ""
 		cp.open = false
 
-	case "backspace":
-		if len(cp.customBuf) > 0 {
-			cp.customBuf = cp.customBuf[:len(cp.customBuf)-1]
-		}
-
 	default:
-		for _, r := range msg.Runes {
-			if isHexDigit(r) && len(cp.customBuf) < 6 {
-				cp.customBuf += string(r)
-			}
-		}
+		cp.customInput.Update(msg)
 	}
 
 	return m, nil
@@ -301,7 +294,7 @@ func (m model) renderColorPickerCustomInput() string {
 
 	var b strings.Builder
 	b.WriteString("Custom Hex Color  —  enter: apply   esc: back to grid\n\n")
-	b.WriteString(fmt.Sprintf("  #%s\n", cp.customBuf))
+	b.WriteString(fmt.Sprintf("  #%s\n", cp.customInput.View()))
 	if cp.customErr != "" {
 		b.WriteString(fmt.Sprintf("\n  %s\n", cp.customErr))
 	}
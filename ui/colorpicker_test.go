@@ -171,8 +171,8 @@ func TestUpdateColorPickerCustomInputEscReturnsToGrid(t *testing.T) {
 	if !m.filterEditor.colorPicker.open {
 		t.Error("esc from custom hex entry also closed the picker, want it to stay open on the grid")
 	}
-	if m.filterEditor.colorPicker.customBuf != "" {
-		t.Errorf("customBuf = %q after esc, want cleared", m.filterEditor.colorPicker.customBuf)
+	if m.filterEditor.colorPicker.customInput.Value() != "" {
+		t.Errorf("customBuf = %q after esc, want cleared", m.filterEditor.colorPicker.customInput.Value())
 	}
 }
 
@@ -183,13 +183,13 @@ func TestUpdateColorPickerCustomInputBackspace(t *testing.T) {
 	m.filterEditor = filterEditorState{cursor: fieldColor}
 	m = update(t, m, keyMsg("enter"), keyMsg("c"), keyMsg("a"), keyMsg("b"), keyMsg("backspace"))
 
-	if got := m.filterEditor.colorPicker.customBuf; got != "a" {
+	if got := m.filterEditor.colorPicker.customInput.Value(); got != "a" {
 		t.Errorf("customBuf after backspace = %q, want %q", got, "a")
 	}
 
 	// Backspacing an already-empty buffer must not underflow/panic.
 	m = update(t, m, keyMsg("backspace"), keyMsg("backspace"))
-	if got := m.filterEditor.colorPicker.customBuf; got != "" {
+	if got := m.filterEditor.colorPicker.customInput.Value(); got != "" {
 		t.Errorf("customBuf after over-backspacing = %q, want empty", got)
 	}
 }
@@ -201,7 +201,7 @@ func TestUpdateColorPickerCustomInputIgnoresNonHexRunes(t *testing.T) {
 	m.filterEditor = filterEditorState{cursor: fieldColor}
 	m = update(t, m, keyMsg("enter"), keyMsg("c"), keyMsg("z"), keyMsg("1"))
 
-	if got := m.filterEditor.colorPicker.customBuf; got != "1" {
+	if got := m.filterEditor.colorPicker.customInput.Value(); got != "1" {
 		t.Errorf("customBuf = %q after typing a non-hex rune then a hex rune, want %q", got, "1")
 	}
 }
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"skim/filterfiles"
+	logview "skim/ui/views/logview"
+)
+
+// columnsFromSettings converts a filter file's <columns> (see
+// filterfiles.ColumnsXML) into the Log pane's Structured mode columns.
+func columnsFromSettings(meta filterfiles.TextAnalysisToolSettings) []logview.Column {
+	var cols []logview.Column
+	for _, c := range meta.ColumnList() {
+		if c.Field == "" {
+			continue
+		}
+		cols = append(cols, logview.Column{Field: c.Field, Title: c.Title, Width: c.Width})
+	}
+	return cols
+}
+
+// columnsToSettings is columnsFromSettings' inverse, for recording
+// detected columns in m.fileMeta so the next filter or session save keeps
+// them.
+func columnsToSettings(cols []logview.Column) *filterfiles.ColumnsXML {
+	if len(cols) == 0 {
+		return nil
+	}
+	xmlCols := make([]filterfiles.ColumnXML, len(cols))
+	for i, c := range cols {
+		xmlCols[i] = filterfiles.ColumnXML{Field: c.Field, Title: c.Title, Width: c.Width}
+	}
+	return &filterfiles.ColumnsXML{Columns: xmlCols}
+}
+
+// toggleColumns flips the Log pane between Structured mode and the plain
+// "#"/"Line" layout. With no columns configured in the filter file, the
+// first toggle detects some from the log itself (see
+// logview.LogView.DetectColumns) and remembers them in m.fileMeta; if the
+// log has no JSON or logfmt lines to detect from, it says so instead.
+func (m *model) toggleColumns() {
+	if len(m.log.Columns) == 0 {
+		cols := m.log.DetectColumns()
+		if len(cols) == 0 {
+			m.saveStatus = "no JSON or logfmt lines to take columns from"
+			return
+		}
+		m.log.Columns = cols
+		m.fileMeta.Columns = columnsToSettings(cols)
+		m.log.Structured = false // flipped on below
+	}
+	m.log.Structured = !m.log.Structured
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"bufio"
+	"path/filepath"
+	"skim/filterfiles"
+	"skim/session"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestConfiguredColumnsStartShown(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	meta := filterfiles.TextAnalysisToolSettings{Columns: &filterfiles.ColumnsXML{Columns: []filterfiles.ColumnXML{
+		{Field: "level", Title: "Lvl", Width: 5},
+		{Field: ""}, // no field to show: skipped
+		{Field: "msg"},
+	}}}
+	scanner := bufio.NewScanner(strings.NewReader("level=info msg=hello\n"))
+	m := initialModel(nil, scanner, filepath.Join(t.TempDir(), "f.tat"), meta, nil)
+
+	if !m.log.Structured || len(m.log.Columns) != 2 {
+		t.Fatalf("Structured = %v, Columns = %+v, want the two configured columns shown", m.log.Structured, m.log.Columns)
+	}
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	if view := m.View(); !strings.Contains(view, "Lvl") || !strings.Contains(view, "hello") {
+		t.Errorf("View() = %q, want the Lvl/msg columns", view)
+	}
+
+	m = update(t, m, keyMsg("c"))
+	if m.log.Structured {
+		t.Error("Structured = true after pressing c, want the plain layout")
+	}
+}
+
+func TestToggleColumnsDetectsFieldsWhenNoneConfigured(t *testing.T) {
+	m := newTestModel(t, nil, "boot\n{\"ts\":\"t1\",\"level\":\"info\",\"msg\":\"hi\"}\n")
+
+	m = update(t, m, keyMsg("c"))
+
+	if !m.log.Structured {
+		t.Fatal("Structured = false after pressing c on a JSON log, want true")
+	}
+	if len(m.log.Columns) != 3 || m.log.Columns[0].Field != "ts" {
+		t.Errorf("Columns = %+v, want ts/level/msg detected from the first JSON line", m.log.Columns)
+	}
+	if cols := m.fileMeta.ColumnList(); len(cols) != 3 {
+		t.Errorf("fileMeta columns = %+v, want the detected columns remembered for the next save", cols)
+	}
+
+	m = update(t, m, keyMsg("c"), keyMsg("c"))
+	if !m.log.Structured || len(m.log.Columns) != 3 {
+		t.Errorf("Structured = %v, Columns = %+v after toggling off and on, want the same columns back", m.log.Structured, m.log.Columns)
+	}
+}
+
+func TestToggleColumnsOnPlainLogReportsNothingToShow(t *testing.T) {
+	m := newTestModel(t, nil, "just\nplain text\n")
+
+	m = update(t, m, keyMsg("c"))
+
+	if m.log.Structured {
+		t.Error("Structured = true on a log with no structured lines, want false")
+	}
+	if !strings.Contains(m.saveStatus, "no JSON or logfmt") {
+		t.Errorf("saveStatus = %q, want it to explain why nothing happened", m.saveStatus)
+	}
+}
+
+func TestSessionRemembersHiddenColumns(t *testing.T) {
+	m := newTestModel(t, nil, "a=1 b=2\n")
+	m = update(t, m, keyMsg("c"), keyMsg("c")) // detect, then hide
+
+	s := m.sessionSnapshot()
+	if !s.HideColumns {
+		t.Fatal("HideColumns = false in the snapshot, want true")
+	}
+	if cols := s.Filters.ColumnList(); len(cols) != 2 {
+		t.Errorf("snapshot columns = %+v, want the detected columns inline", cols)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	restored := initialModel(nil, bufio.NewScanner(strings.NewReader("a=1 b=2\n")), "", s.Filters, nil)
+	if !restored.log.Structured {
+		t.Fatal("precondition: the restored columns start shown before the session is applied")
+	}
+	restored.applySession(s)
+	if restored.log.Structured {
+		t.Error("Structured = true after applying a session with hidden columns, want false")
+	}
+
+	restored.applySession(session.Session{})
+	if restored.log.Structured {
+		t.Error("applying a session without hideColumns turned columns on; it should leave them as they are")
+	}
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"skim/keybindings"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// countTimeout is how long a lone digit typed in the Log pane that's bound
+// to an action of its own waits to see whether it's a count after all (see
+// heldDigit).
+const countTimeout = time.Second
+
+// maxCount caps a count, so holding a digit down can't ask for a billion
+// repeats of a search.
+const maxCount = 1_000_000
+
+// countedActions are the actions a count applies to: motions, which move
+// that many times over, and search repeats, which go to that many matches
+// on. g and G go to the line numbered by the count instead, as in vim.
+// Filter match jumps don't take one, so with the default keys 2 then }
+// still jumps to filter 2's next match (see flushCount) and on to the one
+// after.
+var countedActions = map[keybindings.Action]bool{
+	keybindings.CursorUp:     true,
+	keybindings.CursorDown:   true,
+	keybindings.CursorLeft:   true,
+	keybindings.CursorRight:  true,
+	keybindings.HalfPageUp:   true,
+	keybindings.HalfPageDown: true,
+	keybindings.PageUp:       true,
+	keybindings.PageDown:     true,
+	keybindings.SearchNext:   true,
+	keybindings.SearchPrev:   true,
+	keybindings.JumpBack:     true,
+	keybindings.JumpForward:  true,
+	keybindings.JumpToTop:    true,
+	keybindings.JumpToBottom: true,
+}
+
+// countState is a vim-style count being typed in the Log pane: the digits
+// so far, and which of their timeouts is current (see countTimeoutMsg).
+type countState struct {
+	digits string
+	seq    int
+}
+
+// countTimeoutMsg is sent countTimeout after a count's latest digit. Only
+// the one for the count's last digit (seq) does anything.
+type countTimeoutMsg struct {
+	seq int
+}
+
+// timeout waits out countTimeout for the count's latest digit.
+func (c countState) timeout() tea.Cmd {
+	seq := c.seq
+	return tea.Tick(countTimeout, func(time.Time) tea.Msg { return countTimeoutMsg{seq: seq} })
+}
+
+// readCount takes key as the next digit of a count before an action in
+// countedActions, as in vim's 25j or 3n, reporting whether it was one. A
+// count can't start with 0.
+//
+// By default 1-9 also jump to a filter's next match (see
+// keybindings.JumpFilterActions), so until the key after it shows whether
+// a lone digit was a count -- or countTimeout passes without one -- it's
+// held back, and then does what it's bound to after all (see flushCount).
+func (m *model) readCount(key string) bool {
+	if len(key) != 1 || key[0] < '0' || key[0] > '9' || (key == "0" && m.count.digits == "") {
+		return false
+	}
+	m.count.digits += key
+	m.count.seq++
+	return true
+}
+
+// heldDigit reports whether the count typed so far is a lone digit bound
+// to an action of its own, which countTimeout runs if no key follows (see
+// flushCount). Any other count is only ever a count, so, as in vim, it
+// waits for the key it's for however long that takes.
+func (m model) heldDigit() bool {
+	if len(m.count.digits) != 1 {
+		return false
+	}
+	_, ok := resolveAction(m.keyMap, activeScopes(m.focus), m.count.digits)
+	return ok
+}
+
+// takeCount returns the count typed and clears it, for the action it's
+// been applied to.
+func (m *model) takeCount() int {
+	n, err := strconv.Atoi(m.count.digits)
+	m.count.digits = ""
+	if err != nil {
+		return maxCount
+	}
+	return min(n, maxCount)
+}
+
+// flushCount ends a count that isn't followed by an action it applies to.
+// A single digit does what it's bound to, as if readCount had never held
+// it back; a longer count is dropped, as vim drops one before an action
+// that doesn't take it.
+func (m model) flushCount() (tea.Model, tea.Cmd) {
+	digits := m.count.digits
+	m.count.digits = ""
+	if len(digits) == 1 {
+		if action, ok := resolveAction(m.keyMap, activeScopes(m.focus), digits); ok {
+			return m.runAction(action, 0)
+		}
+	}
+	return m, nil
+}
+
+// runCounted runs action, if ok -- the action bound to the key or keys
+// just pressed -- applying the count typed before it if it takes one, or
+// otherwise ending the count (see flushCount).
+func (m model) runCounted(action keybindings.Action, ok bool) (tea.Model, tea.Cmd) {
+	count := 0
+	var flushed tea.Cmd
+	if m.count.digits != "" {
+		if ok && countedActions[action] {
+			count = m.takeCount()
+		} else {
+			// The digits weren't a count after all (see flushCount).
+			var next tea.Model
+			next, flushed = m.flushCount()
+			m = next.(model)
+		}
+	}
+	if !ok {
+		return m, flushed
+	}
+	next, cmd := m.runAction(action, count)
+	return next, tea.Batch(flushed, cmd)
+}
+
+// findRepeated calls find count times over, each from where the last left
+// the cursor, returning the last line it found -- so 3n is the third match
+// on -- and putting the cursor back, for the caller to jump there in one
+// go (see jumpTo).
+func (m *model) findRepeated(count int, find func() (int, bool)) (int, bool) {
+	origin := m.log.Cursor
+	idx, found := origin, false
+	for range count {
+		next, ok := find()
+		if !ok {
+			break
+		}
+		idx, found = next, true
+		m.log.Cursor = next
+	}
+	m.log.Cursor = origin
+	return idx, found
+}
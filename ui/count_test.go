@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"skim/filterfiles"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCountRepeatsMotionsAndSearches(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(100))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+
+	m = update(t, m, keyMsg("2"), keyMsg("5"))
+	if !strings.Contains(renderStatusLine(m), "count: 25") {
+		t.Errorf("status = %q, want the pending count shown", renderStatusLine(m))
+	}
+	m = update(t, m, keyMsg("j"))
+	if m.log.Cursor != 25 || m.count.digits != "" {
+		t.Fatalf("Cursor after 25j = %d (count %q), want 25 with the count used up", m.log.Cursor, m.count.digits)
+	}
+	m = update(t, m, keyMsg("1"), keyMsg("0"), keyMsg("k"))
+	if m.log.Cursor != 15 {
+		t.Errorf("Cursor after 10k = %d, want 15", m.log.Cursor)
+	}
+
+	m = search(t, m, "ERROR")
+	if m.log.Cursor != 20 {
+		t.Fatalf("Cursor after /ERROR = %d, want 20", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("3"), keyMsg("n"))
+	if m.log.Cursor != 50 {
+		t.Errorf("Cursor after 3n = %d, want the third match on, 50", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("ctrl+o"))
+	if m.log.Cursor != 20 {
+		t.Errorf("Cursor after ctrl+o = %d, want 3n to have been one jump, back to 20", m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("4"), keyMsg("2"), keyMsg("G"))
+	if m.log.Cursor != 41 {
+		t.Errorf("Cursor after 42G = %d, want line 42, 41", m.log.Cursor)
+	}
+}
+
+func TestCountDoesNotTimeOut(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(100))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+
+	m = update(t, m, keyMsg("2"), keyMsg("5"))
+	m = update(t, m, countTimeoutMsg{seq: m.count.seq})
+	if m.count.digits != "25" {
+		t.Fatalf("count = %q after a pause, want it kept", m.count.digits)
+	}
+	m = update(t, m, keyMsg("j"))
+	if m.log.Cursor != 25 {
+		t.Errorf("Cursor after 25, a pause, then j = %d, want 25", m.log.Cursor)
+	}
+}
+
+func TestLoneDigitStillJumpsToFilter(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "alpha"), mustFilter(t, "beta")}
+	m := newTestModel(t, filters, "alpha\nbeta one\nalpha\nbeta two\n")
+
+	m = update(t, m, keyMsg("2"))
+	if m.log.Cursor != 0 {
+		t.Fatalf("Cursor = %d right after 2, want it waiting to see if it's a count", m.log.Cursor)
+	}
+	stale := countTimeoutMsg{seq: m.count.seq - 1}
+	m = update(t, m, stale)
+	if m.count.digits != "2" {
+		t.Errorf("count = %q after an earlier digit's timeout, want it still pending", m.count.digits)
+	}
+
+	// A key that doesn't take a count shows the digit wasn't one.
+	m = update(t, m, keyMsg("}"))
+	if m.filters.Cursor != 1 || m.log.Cursor != 3 {
+		t.Errorf("cursors after 2} = %d, %d, want filter 2's first match and then its next, 1 and 3", m.filters.Cursor, m.log.Cursor)
+	}
+
+	// A longer count before one that doesn't take it is dropped.
+	m = update(t, m, keyMsg("1"), keyMsg("2"), keyMsg("w"))
+	if m.filters.Cursor != 1 || m.count.digits != "" || !m.log.Wrap {
+		t.Errorf("filter cursor = %d, count = %q, Wrap = %v after 12w, want the count dropped and w done", m.filters.Cursor, m.count.digits, m.log.Wrap)
+	}
+}
+
+func TestPageMotions(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(200))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m.View()
+	page := m.log.PageHeight()
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlD})
+	if m.log.Cursor != page/2 {
+		t.Errorf("Cursor after ctrl+d = %d, want half a page, %d", m.log.Cursor, page/2)
+	}
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	if m.log.Cursor != page/2+page {
+		t.Errorf("Cursor after pgdown = %d, want %d", m.log.Cursor, page/2+page)
+	}
+	m = update(t, m, keyMsg("2"), tea.KeyMsg{Type: tea.KeyCtrlU})
+	if m.log.Cursor != page/2+page-2*(page/2) {
+		t.Errorf("Cursor after 2 ctrl+u = %d, want %d", m.log.Cursor, page/2+page-2*(page/2))
+	}
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlB}, tea.KeyMsg{Type: tea.KeyCtrlB})
+	if m.log.Cursor != 0 {
+		t.Errorf("Cursor after two ctrl+b = %d, want it stopped at the top", m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("M"))
+	if !m.log.Centered {
+		t.Error("Centered = false after M, want it on")
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+	"skim/ui/views/densityview"
+	"skim/ui/views/logview"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// densityWidth is the width inside the density panel's border, which
+// decides how many buckets it's split into (see densityview.Buckets).
+func (m model) densityWidth() int {
+	return m.windowWidth - baseStyle.GetHorizontalFrameSize()
+}
+
+// density returns the log's match density at the panel's current bucket
+// count (see logview.LogView.Density).
+func (m model) density() logview.Density {
+	return m.log.Density(m.filters.Filters, densityview.Buckets(m.densityWidth()))
+}
+
+// renderDensity renders the density panel's content: one sparkline per
+// enabled, non-excluding filter -- the ones that can highlight a line, and
+// so have matches to count -- labelled with its number (see JumpFilter1)
+// and description, or regex if it has none.
+func (m model) renderDensity() string {
+	d := m.density()
+
+	var rows []densityview.Row
+	for i, f := range m.filters.Filters {
+		if !f.IsEnabled || f.Excluding {
+			continue
+		}
+		label := f.XML.Description
+		if label == "" {
+			label = f.XML.Text
+		}
+		rows = append(rows, densityview.Row{
+			Label:  strconv.Itoa(i+1) + " " + label,
+			Color:  f.BackColor,
+			Counts: d.Counts[i],
+		})
+	}
+
+	title := "Match density"
+	var left, right string
+	switch {
+	case d.Lines == 0:
+		title += " (no lines in the time range)"
+	case d.ByTime:
+		title += fmt.Sprintf(" by time, %s per column", d.BucketSpan())
+		layout := "15:04:05"
+		if d.Start.YearDay() != d.End.YearDay() || d.Start.Year() != d.End.Year() {
+			layout = "01-02 15:04"
+		}
+		left, right = d.Start.Format(layout), d.End.Format(layout)
+	default:
+		title += fmt.Sprintf(" by position, %s per column", d.BucketSpan())
+		left, right = "line "+strconv.Itoa(d.First[0]+1), "line "+strconv.Itoa(len(m.log.Lines))
+	}
+
+	cursor := -1
+	if idx, ok := m.log.SelectedLine(); ok {
+		if b, ok := m.log.Bucket(d, idx); ok {
+			cursor = b
+		}
+	}
+	return densityview.Render(title, rows, left, right, cursor, m.densityWidth())
+}
+
+// stepBucket moves the log cursor to the start of the next (or previous)
+// density bucket that has anything in it (see logview.LogView.
+// NextBucketStart). A bucket whose first line is hidden is entered at the
+// first shown line after it; as with jumpToAnomaly, a bucket that would
+// land back on the cursor line is skipped.
+func (m *model) stepBucket(backwards bool) {
+	d := m.density()
+	step := m.log.NextBucketStart
+	if backwards {
+		step = m.log.PrevBucketStart
+	}
+	for probe := m.log.Cursor; ; {
+		line, ok := step(d, probe)
+		if !ok {
+			return
+		}
+		if target := m.log.ShownAtOrAfter(line); target != m.log.Cursor {
+			m.log.Cursor = target
+			return
+		}
+		probe = line
+	}
+}
+
+// selectBucket moves the log cursor to the first line of bucket b, or the
+// first shown line after it.
+func (m *model) selectBucket(b int) {
+	d := m.density()
+	if b < 0 || b >= d.Buckets() || d.First[b] < 0 {
+		return
+	}
+	m.log.Cursor = m.log.ShownAtOrAfter(d.First[b])
+}
+
+// densityClick handles a left click while the density panel is shown,
+// selecting the bucket under it if it landed on one of the panel's rows.
+// The panel sits right below the Log pane, so its rows start where the Log
+// pane's last render ended -- which is what m.log.View still returns until
+// the next MakeTable.
+func (m *model) densityClick(msg tea.MouseMsg) {
+	top := lipgloss.Height(m.paneStyle(LogFocus).Render(m.log.View())) +
+		baseStyle.GetBorderTopSize() + 1 // the panel's header row
+	if msg.Y < top || msg.Y >= top+densityview.Height() {
+		return
+	}
+	if b, ok := densityview.BucketAt(msg.X-baseStyle.GetBorderLeftSize(), m.densityWidth()); ok {
+		m.selectBucket(b)
+	}
+}
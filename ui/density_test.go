@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"skim/filterfiles"
+	"skim/ui/views/densityview"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// positionLines is a log without timestamps, so the density panel buckets
+// it by line position.
+func positionLines(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		level := "INFO"
+		if i%10 == 0 {
+			level = "ERROR"
+		}
+		fmt.Fprintf(&b, "%s line %d\n", level, i)
+	}
+	return b.String()
+}
+
+func TestToggleDensityShowsPanelWithinWindow(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "ERROR")}, positionLines(400))
+	m.hideUnmatched = false
+
+	for _, height := range []int{14, 30, 60} {
+		m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: height})
+		m.showDensity = false
+		without := len(strings.Split(m.View(), "\n"))
+
+		m = update(t, m, keyMsg("H"))
+		view := m.View()
+		if got := len(strings.Split(view, "\n")); got != without {
+			t.Errorf("height %d: View() has %d lines with the density panel, want %d", height, got, without)
+		}
+		if height >= 30 && !strings.Contains(view, "Match density by position") {
+			t.Errorf("height %d: View() doesn't show the density panel", height)
+		}
+	}
+}
+
+func TestDensityPanelDroppedOnShortTerminal(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(50))
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 14}, keyMsg("H"))
+	if strings.Contains(m.View(), "Match density") {
+		t.Error("View() shows the density panel with no room for it and the Log pane")
+	}
+}
+
+func TestDensityPanelLabelsFiltersAndMarksCursor(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "ERROR")}, positionLines(400))
+	m.hideUnmatched = false
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 40}, keyMsg("H"))
+	view := ansi.Strip(m.View())
+
+	if !strings.Contains(view, "1 ERROR") {
+		t.Errorf("View() doesn't label the ERROR filter's row:\n%s", view)
+	}
+	if !strings.Contains(view, "line 1") || !strings.Contains(view, "line 400") {
+		t.Errorf("View() doesn't label the axis with the first and last lines:\n%s", view)
+	}
+	if !strings.Contains(view, "▲") {
+		t.Errorf("View() doesn't mark the cursor's bucket:\n%s", view)
+	}
+}
+
+func TestStepBucketMovesByBucket(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(400))
+	m.hideUnmatched = false
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 40}, keyMsg("H"))
+	m.View()
+
+	d := m.density()
+	m = update(t, m, keyMsg(">"))
+	if m.log.Cursor != d.First[1] {
+		t.Errorf("Cursor = %d after >, want bucket 1's first line %d", m.log.Cursor, d.First[1])
+	}
+	m = update(t, m, keyMsg(">"), keyMsg(">"), keyMsg("<"))
+	if m.log.Cursor != d.First[2] {
+		t.Errorf("Cursor = %d after > > <, want bucket 2's first line %d", m.log.Cursor, d.First[2])
+	}
+}
+
+func TestStepBucketLandsOnShownLines(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "ERROR")}, positionLines(400))
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 40}, keyMsg("H"))
+
+	seen := map[int]bool{}
+	for i := 0; i < 20; i++ {
+		m.View()
+		m = update(t, m, keyMsg(">"))
+		if !strings.HasPrefix(m.log.Lines[m.log.Cursor], "ERROR") {
+			t.Fatalf("Cursor = %d (%q), want a shown ERROR line", m.log.Cursor, m.log.Lines[m.log.Cursor])
+		}
+		seen[m.log.Cursor] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("> visited only %d distinct lines in 20 presses, want it to keep moving", len(seen))
+	}
+}
+
+func TestClickingDensityBucketMovesCursor(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(400))
+	m.hideUnmatched = false
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 40}, keyMsg("H"))
+	view := ansi.Strip(m.View())
+
+	// Find the panel's axis row on screen, and click a sparkline row above
+	// it, over bucket 30.
+	rows := strings.Split(view, "\n")
+	axisY := -1
+	for y, r := range rows {
+		if strings.Contains(r, "▲") {
+			axisY = y
+		}
+	}
+	if axisY < 0 {
+		t.Fatalf("no axis row in View():\n%s", view)
+	}
+	x := 1 + densityview.LabelWidth + 30
+	m = update(t, m, tea.MouseMsg{X: x, Y: axisY - 1, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+
+	d := m.density()
+	if b, ok := m.log.Bucket(d, m.log.Cursor); !ok || b != 30 || m.log.Cursor != d.First[30] {
+		t.Errorf("Cursor = %d (bucket %d) after clicking bucket 30, want its first line %d", m.log.Cursor, b, d.First[30])
+	}
+
+	before := m.log.Cursor
+	m.View()
+	m = update(t, m, tea.MouseMsg{X: x, Y: 0, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if m.log.Cursor != before {
+		t.Error("clicking outside the density panel moved the cursor")
+	}
+}
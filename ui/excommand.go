@@ -0,0 +1,595 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"skim/filterfiles"
+	"skim/history"
+	"skim/rcfile"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exCommand is one of the ":" command line's commands, as typed there, in
+// a startup file (see rcfile) or with -c. A line number on its own is
+// short for goto (see runCommand).
+type exCommand struct {
+	name    string
+	aliases []string // shorter names for it, e.g. "w" for write
+	usage   string   // its arguments, for error messages
+	// complete lists what the argument at index arg (0 is the first after
+	// the name) could be, for tab completion; nil if there's nothing to offer.
+	complete func(m model, arg int, args []string) []string
+	run      func(m *model, args []string) (tea.Cmd, error)
+}
+
+// exCommands are the command line's commands, in the order completion
+// offers them.
+var exCommands []exCommand
+
+func init() {
+	// Assigned here rather than in exCommands' declaration because source
+	// runs commands itself, which would make the initialization refer to
+	// itself.
+	exCommands = []exCommand{
+		{name: "goto", usage: "goto LINE|TIME", run: runGoto},
+		{name: "set", usage: "set [OPTION | noOPTION | OPTION! | OPTION=N]...", complete: completeSet, run: runSet},
+		{name: "hide", usage: "hide", run: func(m *model, args []string) (tea.Cmd, error) {
+			m.hideUnmatched = true
+			return nil, nil
+		}},
+		{name: "show", usage: "show", run: func(m *model, args []string) (tea.Cmd, error) {
+			m.hideUnmatched = false
+			return nil, nil
+		}},
+		{name: "filter", usage: "filter add REGEX [#RRGGBB] [DESCRIPTION] | filter delete|enable|disable N", complete: completeFilter, run: runFilter},
+		{name: "write", aliases: []string{"w"}, usage: "write [FILE]", complete: completePath, run: runWrite},
+		{name: "save", usage: "save [FILE]", complete: completePath, run: runSave},
+		{name: "quit", aliases: []string{"q"}, usage: "quit", run: func(m *model, args []string) (tea.Cmd, error) {
+			return tea.Quit, nil
+		}},
+		{name: "wq", aliases: []string{"x"}, usage: "wq", run: func(m *model, args []string) (tea.Cmd, error) {
+			if _, err := runWrite(m, nil); err != nil {
+				return nil, err
+			}
+			return tea.Quit, nil
+		}},
+		{name: "source", usage: "source FILE", complete: completePath, run: runSource},
+	}
+}
+
+// lookupCommand finds the command called name, by its name or an alias.
+func lookupCommand(name string) (exCommand, bool) {
+	for _, c := range exCommands {
+		if c.name == name {
+			return c, true
+		}
+		for _, alias := range c.aliases {
+			if alias == name {
+				return c, true
+			}
+		}
+	}
+	return exCommand{}, false
+}
+
+// runCommand runs one command line. The returned tea.Cmd is whatever the
+// command needs Bubble Tea to do next (tea.Quit, for quit), if anything.
+func (m *model) runCommand(line string) (tea.Cmd, error) {
+	args, err := splitCommandLine(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if isNumber(args[0]) {
+		return runGoto(m, args)
+	}
+	c, ok := lookupCommand(args[0])
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q", args[0])
+	}
+	return c.run(m, args[1:])
+}
+
+// runStartupCommands runs commands read from source -- a startup file, or
+// -c -- before the UI starts, as if each had been typed at the command
+// line in turn. The first one that fails is reported in the status line,
+// and the rest still run, so one typo doesn't lose a whole setup.
+func (m *model) runStartupCommands(source string, lines []rcfile.Line) tea.Cmd {
+	var cmds []tea.Cmd
+	var failed error
+	for _, line := range lines {
+		cmd, err := m.runCommand(line.Command)
+		if err != nil && failed == nil {
+			failed = err
+			m.saveStatus = fmt.Sprintf("%s:%d: %v", source, line.Number, err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
+
+// runStartupFile runs the startup file in the user's config directory (see
+// rcfile.Path), if there is one, reporting its first failing line, or
+// that it couldn't be read, in the status line.
+func (m *model) runStartupFile() tea.Cmd {
+	lines, err := rcfile.Load()
+	if err != nil {
+		m.saveStatus = fmt.Sprintf("skimrc: %v", err)
+		return nil
+	}
+	if path, err := rcfile.Path(); err == nil {
+		if info, err := os.Stat(path); err == nil {
+			done := m.startSourcing(info)
+			defer done()
+		}
+	}
+	return m.runStartupCommands("skimrc", lines)
+}
+
+// startSourcing records that the file info describes is being run, for
+// runSource to refuse to run it again from inside itself, until the
+// returned func is called.
+func (m *model) startSourcing(info os.FileInfo) (done func()) {
+	m.sourcing = append(m.sourcing, info)
+	return func() { m.sourcing = m.sourcing[:len(m.sourcing)-1] }
+}
+
+// splitCommandLine splits a command line into its words at spaces. Single
+// or double quotes keep spaces in a word ("connection reset"); a backslash
+// only escapes the quote that would otherwise end a double-quoted word, so
+// a regex like \d+ needs no doubling.
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0 && r == '\\' && quote == '"' && i+1 < len(runes) && runes[i+1] == '"':
+			word.WriteRune('"')
+			i++
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("missing closing %c", quote)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// isNumber reports whether s is all ASCII digits.
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// runGoto moves the cursor to a 1-indexed line number, clamped to the
+// log's bounds, or to the first line at or after a time (see jumpToTime).
+func runGoto(m *model, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		return nil, errors.New("usage: goto LINE|TIME")
+	}
+	target := strings.Join(args, " ")
+	if !isNumber(target) {
+		return nil, m.jumpToTime(target)
+	}
+	n, err := strconv.Atoi(target)
+	if err != nil {
+		return nil, errors.New("not a number")
+	}
+	if last := m.log.GetMaxCursor(); last >= 0 {
+		m.jumpTo(clampIndex(n-1, last))
+	}
+	return nil, nil
+}
+
+// setOption is one of the options set changes: either on/off (flag), or a
+// number (numbers, every one of which it sets).
+type setOption struct {
+	name    string
+	flag    func(m *model) *bool
+	numbers func(m *model) []*int
+}
+
+// setOptions are the options set knows, in the order it lists them.
+var setOptions = []setOption{
+	{name: "hide", flag: func(m *model) *bool { return &m.hideUnmatched }},
+	{name: "wrap", flag: func(m *model) *bool { return &m.log.Wrap }},
+	{name: "centered", flag: func(m *model) *bool { return &m.log.Centered }},
+	{name: "minimap", flag: func(m *model) *bool { return &m.log.Minimap }},
+	{name: "detail", flag: func(m *model) *bool { return &m.showDetail }},
+	{name: "density", flag: func(m *model) *bool { return &m.showDensity }},
+	{name: "help", flag: func(m *model) *bool { return &m.showHelp }},
+	{name: "context", numbers: func(m *model) []*int { return []*int{&m.context.Before, &m.context.After} }},
+	{name: "before", numbers: func(m *model) []*int { return []*int{&m.context.Before} }},
+	{name: "after", numbers: func(m *model) []*int { return []*int{&m.context.After} }},
+}
+
+// lookupSetOption finds the option called name.
+func lookupSetOption(name string) (setOption, bool) {
+	for _, o := range setOptions {
+		if o.name == name {
+			return o, true
+		}
+	}
+	return setOption{}, false
+}
+
+// describe shows o's current value the way set takes it: "wrap" or
+// "nowrap", "context=2", or "context=2,5" for an option whose numbers
+// differ.
+func (o setOption) describe(m *model) string {
+	if o.flag != nil {
+		if *o.flag(m) {
+			return o.name
+		}
+		return "no" + o.name
+	}
+	numbers := o.numbers(m)
+	values := make([]string, len(numbers))
+	for i, p := range numbers {
+		values[i] = strconv.Itoa(*p)
+	}
+	if !slices.ContainsFunc(values, func(v string) bool { return v != values[0] }) {
+		values = values[:1]
+	}
+	return o.name + "=" + strings.Join(values, ",")
+}
+
+// runSet changes options, vim-style: "wrap" turns one on, "nowrap" off,
+// "wrap!" flips it, "context=5" sets a number -- "context=2,5" each of an
+// option's numbers in turn -- and "wrap?" or a number's bare name shows
+// its value. With no arguments it shows them all.
+func runSet(m *model, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		values := make([]string, len(setOptions))
+		for i, o := range setOptions {
+			values[i] = o.describe(m)
+		}
+		m.saveStatus = strings.Join(values, " ")
+		return nil, nil
+	}
+
+	var shown []string
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		query := strings.HasSuffix(name, "?")
+		toggle := strings.HasSuffix(name, "!")
+		name = strings.TrimRight(name, "?!")
+		on := true
+		o, ok := lookupSetOption(name)
+		if !ok && strings.HasPrefix(name, "no") {
+			if o, ok = lookupSetOption(strings.TrimPrefix(name, "no")); ok && o.flag == nil {
+				ok = false
+			}
+			on = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+
+		switch {
+		case query || (o.numbers != nil && !hasValue):
+			shown = append(shown, o.describe(m))
+		case o.flag != nil && hasValue:
+			return nil, fmt.Errorf("%s is on or off: set %s or set no%s", o.name, o.name, o.name)
+		case o.flag != nil && toggle:
+			*o.flag(m) = !*o.flag(m)
+		case o.flag != nil:
+			*o.flag(m) = on
+		default:
+			numbers := o.numbers(m)
+			values := strings.Split(value, ",")
+			if len(values) != 1 && len(values) != len(numbers) {
+				return nil, fmt.Errorf("%s takes 1 or %d numbers, not %q", o.name, len(numbers), value)
+			}
+			ns := make([]int, len(values))
+			for i, v := range values {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("%s needs a number of lines, not %q", o.name, value)
+				}
+				ns[i] = n
+			}
+			for i, p := range numbers {
+				*p = ns[min(i, len(ns)-1)]
+			}
+		}
+	}
+	if len(shown) > 0 {
+		m.saveStatus = strings.Join(shown, " ")
+	}
+	return nil, nil
+}
+
+// runFilter adds, deletes, enables or disables a filter. Filters are
+// numbered from 1, as the Filters pane lists them; a new one goes at the
+// end, enabled, colored by its #RRGGBB argument if it has one.
+func runFilter(m *model, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		return nil, errors.New("usage: filter add REGEX [#RRGGBB] [DESCRIPTION] | filter delete|enable|disable N")
+	}
+	sub, args := args[0], args[1:]
+	if sub == "add" {
+		return nil, m.addFilter(args)
+	}
+
+	if len(args) != 1 || !isNumber(args[0]) {
+		return nil, fmt.Errorf("usage: filter %s N", sub)
+	}
+	n, _ := strconv.Atoi(args[0])
+	if n < 1 || n > len(m.filters.Filters) {
+		return nil, fmt.Errorf("no filter %d (there are %d)", n, len(m.filters.Filters))
+	}
+	switch sub {
+	case "delete":
+		m.filters.Cursor = n - 1
+		m.filters.Delete()
+	case "enable", "disable":
+		m.filters.Filters[n-1].IsEnabled = sub == "enable"
+	default:
+		return nil, fmt.Errorf("unknown filter command %q", sub)
+	}
+	m.filtersDirty = true
+	return nil, nil
+}
+
+// addFilter is filter add: args are the regex, then an optional #RRGGBB
+// color, then an optional description, which may be several words.
+func (m *model) addFilter(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: filter add REGEX [#RRGGBB] [DESCRIPTION]")
+	}
+	regex, err := filterfiles.CompileRegex(args[0], false)
+	if err != nil {
+		return err
+	}
+	f := filterfiles.Filter{
+		XML:       filterfiles.FilterXML{Text: args[0]},
+		Regex:     regex,
+		IsEnabled: true,
+		BackColor: colorPalette[len(m.filters.Filters)%len(colorPalette)],
+	}
+	rest := args[1:]
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "#") {
+		hex := strings.TrimPrefix(rest[0], "#")
+		if len(hex) != 6 || strings.IndexFunc(hex, func(r rune) bool { return !isHexDigit(r) }) >= 0 {
+			return fmt.Errorf("color must be #RRGGBB, not %q", rest[0])
+		}
+		f.BackColor = "#" + strings.ToUpper(hex)
+		rest = rest[1:]
+	}
+	f.XML.Description = strings.Join(rest, " ")
+	m.remember(history.Regex, args[0])
+
+	m.filters.Filters = append(m.filters.Filters, f)
+	m.filtersDirty = true
+	return nil
+}
+
+// runWrite saves the filters to the filter file, as SaveFilters does, or
+// a copy of them to FILE, leaving the filter file as it was.
+func runWrite(m *model, args []string) (tea.Cmd, error) {
+	switch len(args) {
+	case 0:
+		return nil, m.saveFiltersTo(m.filterFilePath)
+	case 1:
+		return nil, m.saveFiltersTo(args[0])
+	}
+	return nil, errors.New("usage: write [FILE]")
+}
+
+// runSave saves the filters to FILE and makes it the filter file from then
+// on, so later saves go there too; with no FILE it's write.
+func runSave(m *model, args []string) (tea.Cmd, error) {
+	switch len(args) {
+	case 0:
+		return runWrite(m, nil)
+	case 1:
+		if err := m.saveFiltersTo(args[0]); err != nil {
+			return nil, err
+		}
+		m.filterFilePath = args[0]
+		m.filtersDirty = false
+		return nil, nil
+	}
+	return nil, errors.New("usage: save [FILE]")
+}
+
+// saveFiltersTo writes the filters to path, reporting it in the status
+// line. They count as saved only if path is the filter file.
+func (m *model) saveFiltersTo(path string) error {
+	if err := filterfiles.WriteFilterFile(path, m.fileMeta, m.filters.Filters); err != nil {
+		return err
+	}
+	m.saveStatus = fmt.Sprintf("saved to %s", path)
+	if path == m.filterFilePath {
+		m.filtersDirty = false
+	}
+	return nil
+}
+
+// runSource runs the commands in a startup file, as if typed in turn. A
+// file that's already being run -- one that sources itself, or two that
+// source each other -- is refused rather than run again, forever.
+func runSource(m *model, args []string) (tea.Cmd, error) {
+	if len(args) != 1 {
+		return nil, errors.New("usage: source FILE")
+	}
+	info, err := os.Stat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for i, running := range m.sourcing {
+		if !os.SameFile(info, running) {
+			continue
+		}
+		if i == len(m.sourcing)-1 {
+			return nil, fmt.Errorf("source: %s sources itself", args[0])
+		}
+		return nil, fmt.Errorf("source: %s is already being sourced", args[0])
+	}
+	lines, err := rcfile.Read(args[0])
+	if err != nil {
+		return nil, err
+	}
+	done := m.startSourcing(info)
+	defer done()
+
+	var cmds []tea.Cmd
+	for _, line := range lines {
+		cmd, err := m.runCommand(line.Command)
+		if err != nil {
+			return tea.Batch(cmds...), fmt.Errorf("%s:%d: %v", args[0], line.Number, err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...), nil
+}
+
+// completeSet offers set's options: each flag on and off, and each number
+// with its "=" ready for the value.
+func completeSet(m model, arg int, args []string) []string {
+	var names []string
+	for _, o := range setOptions {
+		if o.flag != nil {
+			names = append(names, o.name, "no"+o.name)
+		} else {
+			names = append(names, o.name+"=")
+		}
+	}
+	return names
+}
+
+// completeFilter offers filter's subcommands.
+func completeFilter(m model, arg int, args []string) []string {
+	if arg == 0 {
+		return []string{"add", "delete", "enable", "disable"}
+	}
+	return nil
+}
+
+// completePath offers the files and directories that what's been typed so
+// far could be the start of, directories with a trailing "/" to carry on
+// into.
+func completePath(m model, arg int, args []string) []string {
+	if arg != 0 {
+		return nil
+	}
+	typed := ""
+	if len(args) > 0 {
+		typed = args[0]
+	}
+	dir, _ := filepath.Split(typed)
+	entries, err := os.ReadDir(dir + ".")
+	if dir == "" {
+		entries, err = os.ReadDir(".")
+	}
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		path := dir + e.Name()
+		if e.IsDir() {
+			path += "/"
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// completeCommand is tab at the command line: it completes the word
+// before the cursor -- a command's name, or its argument -- as far as
+// every candidate starting with it agrees, listing them all in the prompt
+// when there's more than one (see renderJumpLinePrompt), and leaves
+// whatever's after the cursor as it is. A unique completion gets a space
+// after it, ready for the next word, unless it ends with "=" or "/", which
+// have more to come, or a space follows it already.
+func (m *model) completeCommand() {
+	text := []rune(m.jumpLineInput.Value())
+	before, after := string(text[:m.jumpLineInput.Cursor()]), string(text[m.jumpLineInput.Cursor():])
+	words := strings.Split(before, " ")
+	word := words[len(words)-1]
+
+	var candidates []string
+	if len(words) == 1 {
+		for _, c := range exCommands {
+			candidates = append(candidates, c.name)
+		}
+	} else if c, ok := lookupCommand(words[0]); ok && c.complete != nil {
+		arg := len(words) - 2
+		candidates = c.complete(*m, arg, words[1:])
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	m.completions = nil
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		word = matches[0]
+		if !strings.HasSuffix(word, "=") && !strings.HasSuffix(word, "/") && !strings.HasPrefix(after, " ") {
+			word += " "
+		}
+	default:
+		word = commonPrefix(matches)
+		m.completions = matches
+	}
+	words[len(words)-1] = word
+	before = strings.Join(words, " ")
+	m.jumpLineInput.SetValue(before + after)
+	m.jumpLineInput.SetCursor(utf8.RuneCountInString(before))
+}
+
+// commonPrefix returns the longest prefix every one of words starts with.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
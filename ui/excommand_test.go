@@ -0,0 +1,370 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"skim/filterfiles"
+	"skim/rcfile"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runCommandLine types text at the ":" prompt and presses enter, returning
+// the model and the tea.Cmd enter returned.
+func runCommandLine(t *testing.T, m model, text string) (model, tea.Cmd) {
+	t.Helper()
+	m = update(t, m, keyMsg(":"))
+	m = update(t, m, typeText(text)...)
+	newModel, cmd := m.Update(keyMsg("enter"))
+	return newModel.(model), cmd
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"set context=5", []string{"set", "context=5"}},
+		{"  goto   14:03:00 ", []string{"goto", "14:03:00"}},
+		{`filter add "connection reset" #ff0000`, []string{"filter", "add", "connection reset", "#ff0000"}},
+		{`filter add 'say "hi"'`, []string{"filter", "add", `say "hi"`}},
+		{`filter add "a\"b" \d+`, []string{"filter", "add", `a"b`, `\d+`}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, %v, want %q", tt.line, got, err, tt.want)
+		}
+	}
+
+	if _, err := splitCommandLine(`filter add "oops`); err == nil {
+		t.Error("splitCommandLine with an unclosed quote succeeded, want an error")
+	}
+}
+
+func TestCommandLineNumberAndGoto(t *testing.T) {
+	m := newTestModel(t, nil, timedLines)
+
+	m, _ = runCommandLine(t, m, "3")
+	if m.jumpingToLine || m.log.Cursor != 2 {
+		t.Fatalf("after :3, jumpingToLine, Cursor = %v, %d, want false, 2", m.jumpingToLine, m.log.Cursor)
+	}
+	m, _ = runCommandLine(t, m, "goto 14:04")
+	if m.log.Cursor != 3 {
+		t.Errorf("after :goto 14:04, Cursor = %d, want 3", m.log.Cursor)
+	}
+	m, _ = runCommandLine(t, m, "goto 1")
+	if m.log.Cursor != 0 {
+		t.Errorf("after :goto 1, Cursor = %d, want 0", m.log.Cursor)
+	}
+	if want := []int{0, 2, 3}; !reflect.DeepEqual(m.jumps.lines, want) {
+		t.Errorf("jumps = %v, want %v (each goto recorded)", m.jumps.lines, want)
+	}
+}
+
+func TestCommandLineUnknownCommandKeepsPromptOpen(t *testing.T) {
+	m := newTestModel(t, nil, "one\ntwo\n")
+
+	m, _ = runCommandLine(t, m, "frobnicate")
+	if !m.jumpingToLine || !strings.Contains(m.jumpLineErr, "frobnicate") {
+		t.Fatalf("jumpingToLine, jumpLineErr = %v, %q, want the prompt open naming the command", m.jumpingToLine, m.jumpLineErr)
+	}
+	if got := renderJumpLinePrompt(m); !strings.Contains(got, m.jumpLineErr) {
+		t.Errorf("prompt = %q, want it to show the error", got)
+	}
+
+	m = update(t, m, keyMsg("esc"))
+	if m.jumpingToLine || m.jumpLineErr != "" {
+		t.Errorf("after esc, jumpingToLine, jumpLineErr = %v, %q, want closed and cleared", m.jumpingToLine, m.jumpLineErr)
+	}
+}
+
+func TestCommandLineSet(t *testing.T) {
+	m := newTestModel(t, nil, "one\ntwo\n")
+
+	m, _ = runCommandLine(t, m, "set context=5 wrap hide")
+	if m.context.Before != 5 || m.context.After != 5 {
+		t.Errorf("context = %+v, want 5 before and after", m.context)
+	}
+	if !m.log.Wrap || !m.hideUnmatched {
+		t.Errorf("Wrap, hideUnmatched = %v, %v, want both on", m.log.Wrap, m.hideUnmatched)
+	}
+
+	m, _ = runCommandLine(t, m, "set after=1 nowrap hide!")
+	if m.context.Before != 5 || m.context.After != 1 {
+		t.Errorf("context = %+v, want 5 before and 1 after", m.context)
+	}
+	if m.log.Wrap || m.hideUnmatched {
+		t.Errorf("Wrap, hideUnmatched = %v, %v, want both off", m.log.Wrap, m.hideUnmatched)
+	}
+
+	m, _ = runCommandLine(t, m, "set context wrap?")
+	if m.saveStatus != "context=5,1 nowrap" {
+		t.Errorf("saveStatus = %q, want %q", m.saveStatus, "context=5,1 nowrap")
+	}
+
+	m, _ = runCommandLine(t, m, "set context=2,3")
+	if m.context.Before != 2 || m.context.After != 3 {
+		t.Errorf("context = %+v after set context=2,3, want 2 before and 3 after", m.context)
+	}
+	m, _ = runCommandLine(t, m, "set context=4 before?")
+	if m.saveStatus != "before=4" {
+		t.Errorf("saveStatus = %q, want %q", m.saveStatus, "before=4")
+	}
+	m, _ = runCommandLine(t, m, "set context")
+	if m.saveStatus != "context=4" {
+		t.Errorf("saveStatus = %q, want %q", m.saveStatus, "context=4")
+	}
+
+	for _, bad := range []string{"set bogus", "set nocontext", "set wrap=3", "set context=x", "set context=1,2,3", "set context=1,x"} {
+		m, _ = runCommandLine(t, m, bad)
+		if m.jumpLineErr == "" {
+			t.Errorf(":%s succeeded, want an error", bad)
+		}
+		m = update(t, m, keyMsg("esc"))
+	}
+}
+
+func TestCommandLineHideAndShow(t *testing.T) {
+	m := newTestModel(t, nil, "one\n")
+
+	m, _ = runCommandLine(t, m, "hide")
+	if !m.hideUnmatched {
+		t.Error("hideUnmatched = false after :hide")
+	}
+	m, _ = runCommandLine(t, m, "show")
+	if m.hideUnmatched {
+		t.Error("hideUnmatched = true after :show")
+	}
+}
+
+func TestCommandLineFilterCommands(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "a\nERROR b\n")
+
+	m, _ = runCommandLine(t, m, "filter add ERROR #ff0000 the errors")
+	if len(m.filters.Filters) != 2 {
+		t.Fatalf("got %d filters after :filter add, want 2", len(m.filters.Filters))
+	}
+	f := m.filters.Filters[1]
+	if f.XML.Text != "ERROR" || f.BackColor != "#FF0000" || f.XML.Description != "the errors" || !f.IsEnabled {
+		t.Errorf("added filter = %+v, want an enabled ERROR filter, #FF0000, described", f)
+	}
+	if !m.filtersDirty {
+		t.Error("filtersDirty = false after :filter add")
+	}
+
+	m, _ = runCommandLine(t, m, "filter disable 2")
+	if m.filters.Filters[1].IsEnabled {
+		t.Error("filter 2 still enabled after :filter disable 2")
+	}
+	m, _ = runCommandLine(t, m, "filter delete 1")
+	if len(m.filters.Filters) != 1 || m.filters.Filters[0].XML.Text != "ERROR" {
+		t.Errorf("filters after :filter delete 1 = %+v, want just ERROR", m.filters.Filters)
+	}
+
+	for _, bad := range []string{"filter add (", "filter add x #red", "filter delete 5", "filter frob 1"} {
+		m, _ = runCommandLine(t, m, bad)
+		if m.jumpLineErr == "" {
+			t.Errorf(":%s succeeded, want an error", bad)
+		}
+		m = update(t, m, keyMsg("esc"))
+	}
+}
+
+func TestCommandLineWriteAndSave(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "a\n")
+	m.filterFilePath = filepath.Join(dir, "filters.tat")
+	m.filtersDirty = true
+
+	copyPath := filepath.Join(dir, "copy.tat")
+	m, _ = runCommandLine(t, m, "w "+copyPath)
+	if _, err := os.Stat(copyPath); err != nil {
+		t.Fatalf(":w %s didn't write it: %v", copyPath, err)
+	}
+	if !m.filtersDirty || m.filterFilePath != filepath.Join(dir, "filters.tat") {
+		t.Errorf("after :w FILE, filtersDirty, filterFilePath = %v, %q, want the filter file still unsaved", m.filtersDirty, m.filterFilePath)
+	}
+
+	otherPath := filepath.Join(dir, "other.tat")
+	m, _ = runCommandLine(t, m, "save "+otherPath)
+	if m.filtersDirty || m.filterFilePath != otherPath {
+		t.Errorf("after :save FILE, filtersDirty, filterFilePath = %v, %q, want saved to %q", m.filtersDirty, m.filterFilePath, otherPath)
+	}
+	settings, err := filterfiles.ReadFilterFile(otherPath)
+	if err != nil || len(settings.Filters) != 1 {
+		t.Errorf("ReadFilterFile(%q) = %d filters, %v, want the one filter", otherPath, len(settings.Filters), err)
+	}
+}
+
+func TestCommandLineQuit(t *testing.T) {
+	for _, line := range []string{"q", "quit"} {
+		m := newTestModel(t, nil, "one\n")
+		_, cmd := runCommandLine(t, m, line)
+		if cmd == nil {
+			t.Fatalf(":%s returned no command, want tea.Quit", line)
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf(":%s returned a command that doesn't quit", line)
+		}
+	}
+}
+
+func TestCommandLineTabCompletion(t *testing.T) {
+	tests := []struct {
+		typed       string
+		want        string
+		completions []string
+	}{
+		{"se", "set ", nil},
+		{"set con", "set context=", nil},
+		{"set noh", "set noh", []string{"nohide", "nohelp"}},
+		{"set w", "set wrap ", nil},
+		{"filter e", "filter enable ", nil},
+		{"zz", "zz", nil},
+	}
+	for _, tt := range tests {
+		m := newTestModel(t, nil, "one\n")
+		m = update(t, m, keyMsg(":"))
+		m = update(t, m, typeText(tt.typed)...)
+		m = update(t, m, keyMsg("tab"))
+		if got := m.jumpLineInput.Value(); got != tt.want {
+			t.Errorf("%q + tab = %q, want %q", tt.typed, got, tt.want)
+		}
+		if !reflect.DeepEqual(m.completions, tt.completions) {
+			t.Errorf("%q + tab lists %q, want %q", tt.typed, m.completions, tt.completions)
+		}
+	}
+}
+
+func TestCommandLineTabCompletesWordBeforeCursor(t *testing.T) {
+	m := newTestModel(t, nil, "one\n")
+	m = update(t, m, keyMsg(":"))
+	m = update(t, m, typeText("set w hide")...)
+	for range len(" hide") {
+		m = update(t, m, keyMsg("left"))
+	}
+	m = update(t, m, keyMsg("tab"))
+	if got, cursor := m.jumpLineInput.Value(), m.jumpLineInput.Cursor(); got != "set wrap hide" || cursor != len("set wrap") {
+		t.Errorf("tab with the cursor after \"set w\" = %q, cursor %d, want %q, cursor %d", got, cursor, "set wrap hide", len("set wrap"))
+	}
+}
+
+func TestCommandLineTabCompletesPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "errors.tat"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t, nil, "one\n")
+	m = update(t, m, keyMsg(":"))
+	m = update(t, m, typeText("save "+dir+"/e")...)
+	m = update(t, m, keyMsg("tab"))
+	if want := "save " + dir + "/errors.tat "; m.jumpLineInput.Value() != want {
+		t.Errorf("completed to %q, want %q", m.jumpLineInput.Value(), want)
+	}
+
+	m = update(t, m, keyMsg("esc"), keyMsg(":"))
+	m = update(t, m, typeText("source "+dir+"/s")...)
+	m = update(t, m, keyMsg("tab"))
+	if want := "source " + dir + "/sub/"; m.jumpLineInput.Value() != want {
+		t.Errorf("completed to %q, want %q", m.jumpLineInput.Value(), want)
+	}
+}
+
+func TestRunStartupCommandsReportsFirstErrorAndCarriesOn(t *testing.T) {
+	m := newTestModel(t, nil, "one\ntwo\n")
+
+	m.runStartupCommands("skimrc", []rcfile.Line{
+		{Number: 1, Command: "set bogus"},
+		{Number: 3, Command: "hide"},
+		{Number: 4, Command: "frob"},
+	})
+	if !m.hideUnmatched {
+		t.Error("hideUnmatched = false, want the command after the failing one run")
+	}
+	if !strings.HasPrefix(m.saveStatus, "skimrc:1: ") {
+		t.Errorf("saveStatus = %q, want the first failure, by line", m.saveStatus)
+	}
+}
+
+func TestCommandLineSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "setup")
+	rc := "# shared triage setup\nset context=2\n:filter add ERROR\nhide\n"
+	if err := os.WriteFile(path, []byte(rc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t, nil, "one\nERROR two\n")
+	m, _ = runCommandLine(t, m, "source "+path)
+	if m.jumpLineErr != "" {
+		t.Fatalf(":source failed: %s", m.jumpLineErr)
+	}
+	if m.context.Before != 2 || len(m.filters.Filters) != 1 || !m.hideUnmatched {
+		t.Errorf("context, filters, hideUnmatched = %+v, %d, %v, want the file's commands run", m.context, len(m.filters.Filters), m.hideUnmatched)
+	}
+
+	if err := os.WriteFile(path, []byte("hide\nbogus\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = runCommandLine(t, m, "source "+path)
+	if !strings.Contains(m.jumpLineErr, path+":2:") {
+		t.Errorf("jumpLineErr = %q, want the failing line named", m.jumpLineErr)
+	}
+}
+
+func TestCommandLineSourceRefusesFilesAlreadyBeingSourced(t *testing.T) {
+	dir := t.TempDir()
+	self := filepath.Join(dir, "self")
+	if err := os.WriteFile(self, []byte("hide\nsource "+self+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := os.WriteFile(a, []byte("source "+b+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("source "+a+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t, nil, "one\n")
+	m, _ = runCommandLine(t, m, "source "+self)
+	if !strings.Contains(m.jumpLineErr, self+" sources itself") || !m.hideUnmatched {
+		t.Errorf("jumpLineErr, hideUnmatched = %q, %v, want the lines before it run and the loop refused", m.jumpLineErr, m.hideUnmatched)
+	}
+	m = update(t, m, keyMsg("esc"))
+
+	m, _ = runCommandLine(t, m, "source "+a)
+	if !strings.Contains(m.jumpLineErr, a+" is already being sourced") {
+		t.Errorf("jumpLineErr = %q, want the loop between a and b refused", m.jumpLineErr)
+	}
+	if len(m.sourcing) != 0 {
+		t.Errorf("sourcing = %v after the commands finished, want none", m.sourcing)
+	}
+}
+
+func TestStartupFileThatSourcesItself(t *testing.T) {
+	m := newTestModel(t, nil, "one\n")
+	path, err := rcfile.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("source "+path+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m.runStartupFile()
+	if !strings.Contains(m.saveStatus, "sources itself") {
+		t.Errorf("saveStatus = %q, want the loop reported", m.saveStatus)
+	}
+}
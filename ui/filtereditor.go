@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"skim/filterfiles"
+	"skim/history"
+	"skim/ui/views/inputview"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,10 +20,15 @@ type filterEditorField int
 const (
 	fieldDescription filterEditorField = iota
 	fieldRegex
+	fieldEndRegex      // a block filter's end pattern ("" = not a block filter)
+	fieldMaxBlockLines // a block filter's length cap ("" = the default)
+	fieldMatchField    // which structured log field the regex targets ("" = the whole line)
 	fieldCaseSensitive
 	fieldExcluding
 	fieldEnabled
 	fieldColor
+	fieldContextBefore // this filter's own context line counts ("" = the view's)
+	fieldContextAfter
 	maxFilterEditorField // unused, represents the total number of fields
 )
 
@@ -33,9 +41,10 @@ const (
 type filterEditorState struct {
 	cursor filterEditorField
 
-	editingText bool   // capturing text for fieldDescription or fieldRegex
-	textBuf     string // in-progress text for the field being edited
-	regexErr    string // set if textBuf failed to compile as a regex (fieldRegex only)
+	editingText bool            // capturing text for any field but the checkboxes and color
+	input       inputview.Input // in-progress text for the field being edited
+	regexErr    string          // set if input failed to compile as a regex (fieldRegex/fieldEndRegex only)
+	countErr    string          // set if input isn't a line count (fieldMaxBlockLines/fieldContextBefore/After only)
 
 	colorPicker colorPickerState
 }
@@ -94,6 +103,10 @@ func (m model) openExternalEditorForHoveredField() (tea.Model, tea.Cmd) {
 		initialText = filter.XML.Description
 	case fieldRegex:
 		initialText = filter.XML.Text
+	case fieldEndRegex:
+		initialText = filter.XML.EndText
+	case fieldMatchField:
+		initialText = filter.Field
 	default:
 		return m, nil
 	}
@@ -102,7 +115,7 @@ func (m model) openExternalEditorForHoveredField() (tea.Model, tea.Cmd) {
 }
 
 // activateFilterEditorField applies the action for whichever field is
-// currently selected: text fields (description/regex) start an inline edit,
+// currently selected: text fields (description/regexes/field/counts) start an inline edit,
 // checkboxes toggle immediately, and the color field opens the color picker.
 func (m model) activateFilterEditorField() (tea.Model, tea.Cmd) {
 	if len(m.filters.Filters) == 0 {
@@ -111,21 +124,46 @@ func (m model) activateFilterEditorField() (tea.Model, tea.Cmd) {
 	}
 	filter := &m.filters.Filters[m.filters.Cursor]
 
+	// Only the regex fields have a history to recall (see history.go).
+	m.startRecall("")
 	switch m.filterEditor.cursor {
 	case fieldDescription:
 		m.filterEditor.editingText = true
-		m.filterEditor.textBuf = filter.XML.Description
+		m.filterEditor.input.SetValue(filter.XML.Description)
 
 	case fieldRegex:
 		m.filterEditor.editingText = true
-		m.filterEditor.textBuf = filter.XML.Text
+		m.filterEditor.input.SetValue(filter.XML.Text)
 		m.filterEditor.regexErr = ""
+		m.startRecall(history.Regex)
+
+	case fieldEndRegex:
+		m.filterEditor.editingText = true
+		m.filterEditor.input.SetValue(filter.XML.EndText)
+		m.filterEditor.regexErr = ""
+		m.startRecall(history.Regex)
+
+	case fieldMaxBlockLines:
+		m.filterEditor.editingText = true
+		m.filterEditor.input.SetValue(filterfiles.FormatMaxBlockLines(filter.MaxBlockLines))
+		m.filterEditor.countErr = ""
+
+	case fieldMatchField:
+		m.filterEditor.editingText = true
+		m.filterEditor.input.SetValue(filter.Field)
+
+	case fieldContextBefore:
+		m.filterEditor.editingText = true
+		m.filterEditor.input.SetValue(filterfiles.FormatContextCount(filter.ContextBefore))
+		m.filterEditor.countErr = ""
+
+	case fieldContextAfter:
+		m.filterEditor.editingText = true
+		m.filterEditor.input.SetValue(filterfiles.FormatContextCount(filter.ContextAfter))
+		m.filterEditor.countErr = ""
 
 	case fieldCaseSensitive:
-		filter.CaseSensitive = !filter.CaseSensitive
-		if regex, err := filterfiles.CompileRegex(filter.XML.Text, filter.CaseSensitive); err == nil {
-			filter.Regex = regex
-		}
+		filter.SetCaseSensitive(!filter.CaseSensitive)
 		m.filtersDirty = true
 		m.saveStatus = ""
 
@@ -150,77 +188,144 @@ func (m model) activateFilterEditorField() (tea.Model, tea.Cmd) {
 }
 
 // updateFilterEditorTextInput handles key presses while typing into the
-// description or regex field, mirroring updateSearchInput's free-typing +
+// description or regex field, mirroring updateSearchInput's editing +
 // enter-to-confirm / esc-to-discard pattern. A regex that fails to compile
 // stays in edit mode with regexErr set (rendered by renderFilterEditor)
 // rather than being discarded, so the user can see why and fix it. ctrl+e
-// suspends the UI and hands textBuf to $EDITOR, for fields too long to
+// suspends the UI and hands the text to $EDITOR, for fields too long to
 // comfortably type on one terminal line (see openFilterFieldEditorCmd).
+// In the regex fields, up/down and ctrl+r recall earlier regexes (see
+// recallHistory).
 func (m model) updateFilterEditorTextInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.recallHistory(msg, &m.filterEditor.input) {
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "esc":
 		m.filterEditor.editingText = false
-		m.filterEditor.textBuf = ""
+		m.filterEditor.input.Reset()
 		m.filterEditor.regexErr = ""
+		m.filterEditor.countErr = ""
 
 	case "enter":
 		m.commitFilterEditorTextField()
 
 	case "ctrl+e":
-		return m, openFilterFieldEditorCmd(m.filterEditor.cursor, m.filterEditor.textBuf)
-
-	case "backspace":
-		if len(m.filterEditor.textBuf) > 0 {
-			r := []rune(m.filterEditor.textBuf)
-			m.filterEditor.textBuf = string(r[:len(r)-1])
-		}
-
-	case " ":
-		m.filterEditor.textBuf += " "
+		return m, openFilterFieldEditorCmd(m.filterEditor.cursor, m.filterEditor.input.Value())
 
 	default:
-		if len(msg.Runes) > 0 {
-			m.filterEditor.textBuf += string(msg.Runes)
-		}
+		m.filterEditor.input.Update(msg)
 	}
 
 	return m, nil
 }
 
-// commitFilterEditorTextField applies m.filterEditor.textBuf as the new
-// value of whichever text field m.filterEditor.cursor points at
-// (fieldDescription or fieldRegex). It's used by plain enter, by ctrl+e's
+// commitFilterEditorTextField applies m.filterEditor.input's text as the
+// new value of whichever text field m.filterEditor.cursor points at
+// (any but the checkboxes and color). It's used by plain enter, by ctrl+e's
 // external-editor return path from mid-edit, and by ctrl+e's return path
 // from a hovered (not yet being edited) row -- see filterFieldEditorFinishedMsg
 // and openExternalEditorForHoveredField -- so all three behave identically.
 // A regex that fails to compile drops into (or stays in) edit mode with
 // regexErr set instead of being silently discarded, so the user can see why
 // and fix it rather than losing what they typed -- including when ctrl+e
-// was pressed from hover, which never set editingText itself.
+// was pressed from hover, which never set editingText itself. An end regex
+// is handled the same way, as is a count that isn't a whole number.
 func (m *model) commitFilterEditorTextField() {
 	filter := &m.filters.Filters[m.filters.Cursor]
+	text := m.filterEditor.input.Value()
 	switch m.filterEditor.cursor {
 	case fieldDescription:
-		filter.XML.Description = m.filterEditor.textBuf
+		filter.XML.Description = text
 		m.filtersDirty = true
 		m.saveStatus = ""
 		m.filterEditor.editingText = false
-		m.filterEditor.textBuf = ""
+		m.filterEditor.input.Reset()
 
 	case fieldRegex:
-		regex, err := filterfiles.CompileRegex(m.filterEditor.textBuf, filter.CaseSensitive)
+		regex, err := filterfiles.CompileRegex(text, filter.CaseSensitive)
 		if err != nil {
+			if !m.filterEditor.editingText {
+				m.startRecall(history.Regex)
+			}
 			m.filterEditor.editingText = true
 			m.filterEditor.regexErr = err.Error()
 			return
 		}
-		filter.XML.Text = m.filterEditor.textBuf
+		filter.XML.Text = text
 		filter.Regex = regex
+		m.remember(history.Regex, text)
+		m.filtersDirty = true
+		m.saveStatus = ""
+		m.filterEditor.editingText = false
+		m.filterEditor.input.Reset()
+		m.filterEditor.regexErr = ""
+
+	case fieldEndRegex:
+		// Clearing the end pattern turns a block filter back into a plain
+		// one, so there's nothing to compile.
+		var regex regexp.Regexp
+		if text != "" {
+			var err error
+			if regex, err = filterfiles.CompileRegex(text, filter.CaseSensitive); err != nil {
+				if !m.filterEditor.editingText {
+					m.startRecall(history.Regex)
+				}
+				m.filterEditor.editingText = true
+				m.filterEditor.regexErr = err.Error()
+				return
+			}
+		}
+		filter.XML.EndText = text
+		filter.EndRegex = regex
+		m.remember(history.Regex, text)
 		m.filtersDirty = true
 		m.saveStatus = ""
 		m.filterEditor.editingText = false
-		m.filterEditor.textBuf = ""
+		m.filterEditor.input.Reset()
 		m.filterEditor.regexErr = ""
+
+	case fieldMaxBlockLines:
+		n, err := filterfiles.ParseMaxBlockLines(text)
+		if err != nil {
+			m.filterEditor.editingText = true
+			m.filterEditor.countErr = err.Error()
+			return
+		}
+		filter.MaxBlockLines = n
+		m.filtersDirty = true
+		m.saveStatus = ""
+		m.filterEditor.editingText = false
+		m.filterEditor.input.Reset()
+		m.filterEditor.countErr = ""
+
+	case fieldMatchField:
+		// Surrounding whitespace is never part of a field name, and is
+		// easy to type by accident; an empty name means the whole line.
+		filter.Field = strings.TrimSpace(text)
+		m.filtersDirty = true
+		m.saveStatus = ""
+		m.filterEditor.editingText = false
+		m.filterEditor.input.Reset()
+
+	case fieldContextBefore, fieldContextAfter:
+		n, err := filterfiles.ParseContextCount(text)
+		if err != nil {
+			m.filterEditor.editingText = true
+			m.filterEditor.countErr = err.Error()
+			return
+		}
+		if m.filterEditor.cursor == fieldContextBefore {
+			filter.ContextBefore = n
+		} else {
+			filter.ContextAfter = n
+		}
+		m.filtersDirty = true
+		m.saveStatus = ""
+		m.filterEditor.editingText = false
+		m.filterEditor.input.Reset()
+		m.filterEditor.countErr = ""
 	}
 }
 
@@ -280,7 +385,10 @@ func (m model) renderFilterEditor() string {
 	switch {
 	case m.filterEditor.editingText:
 		header = "Edit Filter  —  enter: confirm   ctrl+e: edit in $EDITOR   esc: discard"
-	case m.filterEditor.cursor == fieldDescription || m.filterEditor.cursor == fieldRegex:
+		if m.recall.prompt != "" {
+			header = "Edit Filter  —  enter: confirm   up/down, ctrl+r: history   ctrl+e: edit in $EDITOR   esc: discard"
+		}
+	case m.filterEditor.cursor == fieldDescription || m.filterEditor.cursor == fieldRegex || m.filterEditor.cursor == fieldEndRegex || m.filterEditor.cursor == fieldMatchField:
 		header = "Edit Filter  —  up/down: select field   enter: edit   ctrl+e: edit in $EDITOR   esc: close"
 	}
 
@@ -293,10 +401,15 @@ func (m model) renderFilterEditor() string {
 	}{
 		{fieldDescription, "Description"},
 		{fieldRegex, "Regex"},
+		{fieldEndRegex, "End regex"},
+		{fieldMaxBlockLines, "Max block lines"},
+		{fieldMatchField, "Field"},
 		{fieldCaseSensitive, "Case sensitive"},
 		{fieldExcluding, "Excluding"},
 		{fieldEnabled, "Enabled"},
 		{fieldColor, "Color"},
+		{fieldContextBefore, "Context before"},
+		{fieldContextAfter, "Context after"},
 	}
 
 	for _, row := range rows {
@@ -314,6 +427,29 @@ func (m model) renderFilterEditor() string {
 			if m.filterEditor.editingText && m.filterEditor.cursor == fieldRegex && m.filterEditor.regexErr != "" {
 				value += fmt.Sprintf("  (invalid regex: %s)", m.filterEditor.regexErr)
 			}
+		case fieldEndRegex:
+			value = m.renderFilterEditorTextValue(fieldEndRegex, filter.XML.EndText)
+			editing := m.filterEditor.editingText && m.filterEditor.cursor == fieldEndRegex
+			switch {
+			case editing && m.filterEditor.regexErr != "":
+				value += fmt.Sprintf("  (invalid regex: %s)", m.filterEditor.regexErr)
+			case !editing && filter.XML.EndText == "":
+				value += "  (single lines, not blocks)"
+			}
+		case fieldMaxBlockLines:
+			value = m.renderFilterEditorTextValue(fieldMaxBlockLines, filterfiles.FormatMaxBlockLines(filter.MaxBlockLines))
+			editing := m.filterEditor.editingText && m.filterEditor.cursor == fieldMaxBlockLines
+			switch {
+			case editing && m.filterEditor.countErr != "":
+				value += "  (" + m.filterEditor.countErr + ")"
+			case !editing && filter.MaxBlockLines == 0:
+				value += fmt.Sprintf("  (default: %d)", filterfiles.DefaultMaxBlockLines)
+			}
+		case fieldMatchField:
+			value = m.renderFilterEditorTextValue(fieldMatchField, filter.Field)
+			if !(m.filterEditor.editingText && m.filterEditor.cursor == fieldMatchField) && filter.Field == "" {
+				value += "  (whole line)"
+			}
 		case fieldCaseSensitive:
 			value = renderFilterEditorCheckbox(filter.CaseSensitive)
 		case fieldExcluding:
@@ -323,6 +459,10 @@ func (m model) renderFilterEditor() string {
 		case fieldColor:
 			swatch := lipgloss.NewStyle().Background(lipgloss.Color(filter.BackColor)).Render("    ")
 			value = fmt.Sprintf("%s %s", swatch, filter.BackColor)
+		case fieldContextBefore:
+			value = m.renderFilterEditorContextValue(fieldContextBefore, filter.ContextBefore, m.context.Before)
+		case fieldContextAfter:
+			value = m.renderFilterEditorContextValue(fieldContextAfter, filter.ContextAfter, m.context.After)
 		}
 
 		b.WriteString(fmt.Sprintf("%s%-16s%s\n", cursor, row.label+":", value))
@@ -333,18 +473,34 @@ func (m model) renderFilterEditor() string {
 
 // renderFilterEditorTextValue renders a text field's bracketed value: the
 // in-progress buffer while it's being edited, otherwise its last-committed
-// value. It deliberately doesn't render its own cursor glyph -- like
-// renderSearchPrompt/renderJumpLinePrompt, it relies on the terminal's own
-// cursor for that (a synthetic mark here previously used U+258F, which
-// several terminal/font combinations render as blank space, making it look
-// like a stray trailing space in the buffer).
+// value. It deliberately doesn't add a cursor glyph of its own -- like
+// renderSearchPrompt/renderJumpLinePrompt, it marks the cursor only by
+// reversing the character under it (see inputview.Input.View), and leaves a
+// cursor at the end to the terminal (a synthetic mark here previously used
+// U+258F, which several terminal/font combinations render as blank space,
+// making it look like a stray trailing space in the buffer).
 func (m model) renderFilterEditorTextValue(field filterEditorField, committed string) string {
 	if m.filterEditor.editingText && m.filterEditor.cursor == field {
-		return "[" + m.filterEditor.textBuf + "]"
+		return m.recallPrefix() + "[" + m.filterEditor.input.View() + "]"
 	}
 	return "[" + committed + "]"
 }
 
+// renderFilterEditorContextValue renders a context count field: like a
+// text field, plus the view's own count it falls back to when unset, or why
+// an in-progress value was rejected.
+func (m model) renderFilterEditorContextValue(field filterEditorField, count *int, view int) string {
+	editing := m.filterEditor.editingText && m.filterEditor.cursor == field
+	value := m.renderFilterEditorTextValue(field, filterfiles.FormatContextCount(count))
+	switch {
+	case editing && m.filterEditor.countErr != "":
+		value += "  (" + m.filterEditor.countErr + ")"
+	case !editing && count == nil:
+		value += fmt.Sprintf("  (view's: %d)", view)
+	}
+	return value
+}
+
 func renderFilterEditorCheckbox(checked bool) string {
 	if checked {
 		return "[x]"
@@ -129,8 +129,8 @@ func TestFilterEditorTextInputEscDiscardsInProgressEdit(t *testing.T) {
 	if m.filterEditor.editingText {
 		t.Error("esc did not exit text-edit mode")
 	}
-	if m.filterEditor.textBuf != "" {
-		t.Errorf("textBuf = %q after esc, want cleared", m.filterEditor.textBuf)
+	if m.filterEditor.input.Value() != "" {
+		t.Errorf("textBuf = %q after esc, want cleared", m.filterEditor.input.Value())
 	}
 	if m.filters.Filters[0].XML.Description != "" {
 		t.Errorf("Description = %q after discarding an in-progress edit, want unchanged empty", m.filters.Filters[0].XML.Description)
@@ -144,8 +144,8 @@ func TestFilterEditorTextInputBackspace(t *testing.T) {
 	m.filterEditor = filterEditorState{cursor: fieldDescription}
 	m = update(t, m, keyMsg("enter"), keyMsg("h"), keyMsg("i"), keyMsg("backspace"))
 
-	if m.filterEditor.textBuf != "h" {
-		t.Errorf("textBuf after backspace = %q, want %q", m.filterEditor.textBuf, "h")
+	if m.filterEditor.input.Value() != "h" {
+		t.Errorf("textBuf after backspace = %q, want %q", m.filterEditor.input.Value(), "h")
 	}
 }
 
@@ -259,3 +259,125 @@ func TestOpenFilterFieldEditorCmdErrorsWhenTempFileCannotBeCreated(t *testing.T)
 func tea_WindowSize() tea.WindowSizeMsg {
 	return tea.WindowSizeMsg{Width: 120, Height: 40}
 }
+
+func TestFilterEditorSetsTargetField(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "^error$")}
+	m := newTestModel(t, filters, "level=error msg=a\nlevel=info msg=error\n")
+	m.hideUnmatched = false
+	m.editingFilter = true
+	m.filterEditor = filterEditorState{cursor: fieldMatchField}
+
+	if !strings.Contains(m.renderFilterEditor(), "(whole line)") {
+		t.Error("renderFilterEditor() doesn't describe an empty Field as matching the whole line")
+	}
+
+	m = update(t, m, keyMsg("enter"), keyMsg(" "))
+	for _, r := range "level " {
+		m = update(t, m, keyMsg(string(r)))
+	}
+	m = update(t, m, keyMsg("enter"))
+
+	if got := m.filters.Filters[0].Field; got != "level" {
+		t.Errorf("Field = %q after typing it in, want %q (trimmed)", got, "level")
+	}
+	if !m.filtersDirty {
+		t.Error("filtersDirty = false after setting the field, want true")
+	}
+	if counts := m.log.MatchCounts(m.filters.Filters); counts[0] != 1 {
+		t.Errorf("match count = %d, want 1: only the line whose level field is error", counts[0])
+	}
+}
+
+func TestFilterEditorSetsContextOverrides(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR")}
+	m := newTestModel(t, filters, positionLines(30))
+	m = update(t, m, tea_WindowSize(), keyMsg("+"))
+	m.editingFilter = true
+	m.filterEditor = filterEditorState{cursor: fieldContextBefore}
+
+	if !strings.Contains(m.renderFilterEditor(), "(view's: 1)") {
+		t.Error("renderFilterEditor() doesn't show the view's context an unset count falls back to")
+	}
+
+	m = update(t, m, keyMsg("enter"), keyMsg("x"), keyMsg("enter"))
+	if m.filters.Filters[0].ContextBefore != nil || !m.filterEditor.editingText || m.filterEditor.countErr == "" {
+		t.Fatalf("ContextBefore = %v, editing %v, countErr %q, want an invalid count rejected and still editing",
+			m.filters.Filters[0].ContextBefore, m.filterEditor.editingText, m.filterEditor.countErr)
+	}
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace}, keyMsg("3"), keyMsg("enter"))
+	if got := m.filters.Filters[0].ContextBefore; got == nil || *got != 3 {
+		t.Fatalf("ContextBefore = %v, want 3", got)
+	}
+	if !m.filtersDirty {
+		t.Error("filtersDirty = false after setting a context count, want true")
+	}
+
+	m.filterEditor.cursor = fieldContextAfter
+	m = update(t, m, keyMsg("enter"), keyMsg("0"), keyMsg("enter"))
+	if got := m.filters.Filters[0].ContextAfter; got == nil || *got != 0 {
+		t.Fatalf("ContextAfter = %v, want an explicit 0", got)
+	}
+
+	// ERRORs are on lines 1, 11 and 21: each gets up to 3 lines before it
+	// from the override, and none after despite the view's +.
+	m.editingFilter = false
+	m.View()
+	if got := m.log.ShownCount; got != 1+4+4 {
+		t.Errorf("ShownCount = %d, want 9: the ERRORs with up to 3 lines before each", got)
+	}
+
+	// Clearing the field goes back to the view's count.
+	m.editingFilter = true
+	m.filterEditor.cursor = fieldContextBefore
+	m = update(t, m, keyMsg("enter"), tea.KeyMsg{Type: tea.KeyBackspace}, keyMsg("enter"))
+	if got := m.filters.Filters[0].ContextBefore; got != nil {
+		t.Errorf("ContextBefore = %d after clearing it, want unset", *got)
+	}
+}
+
+func TestFilterEditorMakesBlockFilter(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "^BEGIN")}
+	m := newTestModel(t, filters, "noise\nBEGIN job=1\nstep\nEND job=1\nnoise\n")
+	m.editingFilter = true
+	m.filterEditor = filterEditorState{cursor: fieldEndRegex}
+
+	if !strings.Contains(m.renderFilterEditor(), "(single lines, not blocks)") {
+		t.Error("renderFilterEditor() doesn't say a filter without an end regex matches single lines")
+	}
+
+	m = update(t, m, keyMsg("enter"), keyMsg("("), keyMsg("enter"))
+	if m.filters.Filters[0].IsBlock() || m.filterEditor.regexErr == "" {
+		t.Fatalf("IsBlock() = %v, regexErr = %q, want an invalid end regex rejected", m.filters.Filters[0].IsBlock(), m.filterEditor.regexErr)
+	}
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	for _, r := range "^END" {
+		m = update(t, m, keyMsg(string(r)))
+	}
+	m = update(t, m, keyMsg("enter"))
+	if !m.filters.Filters[0].IsBlock() || !m.filtersDirty {
+		t.Fatalf("IsBlock() = %v, filtersDirty = %v, want a block filter", m.filters.Filters[0].IsBlock(), m.filtersDirty)
+	}
+	if counts := m.log.MatchCounts(m.filters.Filters); counts[0] != 3 {
+		t.Errorf("match count = %d, want the 3 lines of the block", counts[0])
+	}
+
+	m.filterEditor.cursor = fieldMaxBlockLines
+	m = update(t, m, keyMsg("enter"), keyMsg("2"), keyMsg("enter"))
+	if got := m.filters.Filters[0].MaxBlockLines; got != 2 {
+		t.Errorf("MaxBlockLines = %d, want 2", got)
+	}
+	if counts := m.log.MatchCounts(m.filters.Filters); counts[0] != 2 {
+		t.Errorf("match count = %d, want the block cut off at 2 lines", counts[0])
+	}
+
+	// Clearing the end regex makes it a plain filter again.
+	m.filterEditor.cursor = fieldEndRegex
+	m = update(t, m, keyMsg("enter"))
+	for range "^END" {
+		m = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m = update(t, m, keyMsg("enter"))
+	if m.filters.Filters[0].IsBlock() {
+		t.Error("IsBlock() = true after clearing the end regex")
+	}
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"skim/keybindings"
+	"skim/logstate"
+	"skim/ui/views/logview"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// loadLogState restores the folds remembered in the log's state file (see
+// logstate), if it has one. A log read from stdin has none, and a missing
+// file just means nothing's been folded yet; an unreadable one is reported
+// in the status line rather than failing startup over what's only a
+// convenience.
+func (m *model) loadLogState() {
+	path := logstate.Path(m.logPath)
+	if path == "" {
+		return
+	}
+	s, err := logstate.Read(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			m.saveStatus = fmt.Sprintf("log state: %v", err)
+		}
+		return
+	}
+	folds := make([]logview.Fold, len(s.Folds))
+	for i, f := range s.Folds {
+		folds[i] = logview.Fold{Start: f.From - 1, End: f.To}
+	}
+	m.log.SetFolds(folds)
+}
+
+// saveLogState writes the current folds to the log's state file, as soon
+// as they change, so they're there the next time the log is opened. A log
+// read from stdin has nowhere to keep them, so they only last the run, and
+// neither do folds in a transform's output that isn't line-for-line the
+// log's (see foldsSavable).
+func (m *model) saveLogState() {
+	path := logstate.Path(m.logPath)
+	if path == "" || !m.foldsSavable() {
+		return
+	}
+	var s logstate.State
+	for _, f := range m.log.Folds() {
+		s.Folds = append(s.Folds, logstate.Fold{From: f.Start + 1, To: f.End})
+	}
+	if err := logstate.Write(path, s); err != nil {
+		m.saveStatus = fmt.Sprintf("folds not saved: %v", err)
+	}
+}
+
+// toggleSelection starts selecting lines to fold at the cursor, or, if a
+// selection's already going, drops it.
+func (m *model) toggleSelection() {
+	if m.log.ClearSelection() {
+		m.saveStatus = ""
+		return
+	}
+	m.log.StartSelection()
+	if m.log.Selecting() {
+		m.saveStatus = fmt.Sprintf("selecting lines: move the cursor, then %s to fold them", displayKeys(m.keyMap[keybindings.FoldSelection], "/"))
+	}
+}
+
+// foldSelection folds the selected lines into a single row (see
+// logview.LogView.FoldSelection) and remembers it in the log's state file.
+func (m *model) foldSelection() {
+	f, ok := m.log.FoldSelection()
+	if !ok {
+		m.saveStatus = fmt.Sprintf("nothing selected: %s starts selecting lines to fold", displayKeys(m.keyMap[keybindings.SelectLines], "/"))
+		return
+	}
+	m.saveStatus = fmt.Sprintf("folded %s", foldLabel(f))
+	m.saveLogState()
+}
+
+// unfold removes the fold under the cursor, leaving the cursor on its
+// first line.
+func (m *model) unfold() {
+	i, ok := m.log.SelectedLine()
+	if !ok {
+		return
+	}
+	f, ok := m.log.Unfold(i)
+	if !ok {
+		m.saveStatus = "no fold under the cursor"
+		return
+	}
+	m.log.Cursor = f.Start
+	m.saveStatus = fmt.Sprintf("unfolded %s", foldLabel(f))
+	m.saveLogState()
+}
+
+// foldLabel describes f for the status line and the folds list, e.g.
+// "lines 12-3011 (3000 lines)".
+func foldLabel(f logview.Fold) string {
+	if f.Len() == 1 {
+		return fmt.Sprintf("line %d", f.Start+1)
+	}
+	return fmt.Sprintf("lines %d-%d (%d lines)", f.Start+1, f.End, f.Len())
+}
+
+// openFoldsList shows the folds screen (see renderFoldsScreen), with the
+// last fold at or before the cursor selected.
+func (m *model) openFoldsList() {
+	folds := m.log.Folds()
+	if len(folds) == 0 {
+		m.saveStatus = "no folds"
+		return
+	}
+	m.listingFolds = true
+	m.foldCursor = 0
+	for k, f := range folds {
+		if f.Start <= m.log.Cursor {
+			m.foldCursor = k
+		}
+	}
+}
+
+// updateFoldsScreen handles key presses on the folds screen: up/down pick
+// a fold, enter jumps to it, d unfolds it, and esc/q close the screen.
+func (m model) updateFoldsScreen(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	folds := m.log.Folds()
+	switch msg.String() {
+	case "esc", "q":
+		m.listingFolds = false
+
+	case "up", "k":
+		if m.foldCursor > 0 {
+			m.foldCursor--
+		}
+
+	case "down", "j":
+		if m.foldCursor < len(folds)-1 {
+			m.foldCursor++
+		}
+
+	case "enter":
+		if m.foldCursor < len(folds) {
+			m.log.Cursor = folds[m.foldCursor].Start
+		}
+		m.listingFolds = false
+
+	case "d":
+		if m.foldCursor < len(folds) {
+			f, _ := m.log.Unfold(folds[m.foldCursor].Start)
+			m.saveStatus = fmt.Sprintf("unfolded %s", foldLabel(f))
+			m.saveLogState()
+		}
+		if n := len(m.log.Folds()); n == 0 {
+			m.listingFolds = false
+		} else if m.foldCursor >= n {
+			m.foldCursor = n - 1
+		}
+	}
+	return m, nil
+}
+
+// renderFoldsScreen lists every fold with its first line, scrolled to keep
+// the selected one on screen.
+func (m model) renderFoldsScreen() string {
+	rows := []string{"Folds  —  up/down: select fold   enter: jump to it   d: unfold   esc/q: close", ""}
+
+	// The header and its blank line, and the border around it all, take up
+	// the rest of the window.
+	folds := m.log.Folds()
+	height := max(m.windowHeight-len(rows)-baseStyle.GetVerticalFrameSize(), 1)
+	top := max(m.foldCursor-height+1, 0)
+	for k := top; k < len(folds) && k < top+height; k++ {
+		f := folds[k]
+		cursor := "  "
+		if k == m.foldCursor {
+			cursor = "> "
+		}
+		row := fmt.Sprintf("%s%-32s %s", cursor, foldLabel(f), m.log.DisplayLine(f.Start))
+		if m.windowWidth > 0 {
+			row = ansi.Truncate(row, m.windowWidth-2, "…")
+		}
+		rows = append(rows, row)
+	}
+
+	return baseStyle.Render(strings.Join(rows, "\n"))
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"skim/logstate"
+	"skim/ui/views/logview"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// foldModel is a 20-line log, all of it shown, with a state file location
+// to save folds to.
+func foldModel(t *testing.T) model {
+	t.Helper()
+	m := newTestModel(t, nil, positionLines(20))
+	m.hideUnmatched = false
+	m.logPath = filepath.Join(t.TempDir(), "app.log")
+	m = update(t, m, tea_WindowSize())
+	m.View()
+	return m
+}
+
+func TestFoldSelectionKeys(t *testing.T) {
+	m := foldModel(t)
+	m = update(t, m, keyMsg("j"), keyMsg("j"))
+	m.View()
+	m = update(t, m, keyMsg("V"), keyMsg("j"), keyMsg("j"), keyMsg("j"))
+	m.View()
+	if !strings.Contains(renderStatusLine(m), "selected: 4 lines") {
+		t.Errorf("status line = %q, want the selection's size", renderStatusLine(m))
+	}
+
+	m = update(t, m, keyMsg("z"))
+	if m.saveStatus != "folded lines 3-6 (4 lines)" {
+		t.Errorf("status = %q after z", m.saveStatus)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "▸ 4 lines folded · 3–6") {
+		t.Errorf("View() doesn't show the fold's summary row:\n%s", view)
+	}
+	s, err := logstate.Read(logstate.Path(m.logPath))
+	if err != nil {
+		t.Fatalf("logstate.Read returned unexpected error: %v", err)
+	}
+	if want := []logstate.Fold{{From: 3, To: 6}}; !reflect.DeepEqual(s.Folds, want) {
+		t.Errorf("saved folds = %+v, want %+v", s.Folds, want)
+	}
+
+	m = update(t, m, keyMsg("Z"))
+	if m.saveStatus != "unfolded lines 3-6 (4 lines)" || len(m.log.Folds()) != 0 {
+		t.Errorf("status = %q, folds = %+v after Z, want the fold gone", m.saveStatus, m.log.Folds())
+	}
+	if _, err := os.Stat(logstate.Path(m.logPath)); !os.IsNotExist(err) {
+		t.Errorf("state file still there with no folds left (err = %v)", err)
+	}
+}
+
+func TestFoldWithoutSelection(t *testing.T) {
+	m := foldModel(t)
+	m = update(t, m, keyMsg("z"))
+	if !strings.HasPrefix(m.saveStatus, "nothing selected") {
+		t.Errorf("status = %q after z with nothing selected", m.saveStatus)
+	}
+	m = update(t, m, keyMsg("Z"))
+	if m.saveStatus != "no fold under the cursor" {
+		t.Errorf("status = %q after Z off a fold", m.saveStatus)
+	}
+}
+
+func TestEscCancelsSelection(t *testing.T) {
+	m := foldModel(t)
+	m = update(t, m, keyMsg("V"))
+	if !m.log.Selecting() {
+		t.Fatal("Selecting() = false after V")
+	}
+	m = update(t, m, keyMsg("esc"))
+	if m.log.Selecting() {
+		t.Error("Selecting() = true after esc, want the selection dropped")
+	}
+}
+
+func TestFoldsList(t *testing.T) {
+	m := foldModel(t)
+	m = update(t, m, keyMsg("F"))
+	if m.listingFolds || m.saveStatus != "no folds" {
+		t.Errorf("listingFolds = %v, status = %q after F with no folds", m.listingFolds, m.saveStatus)
+	}
+
+	m.log.FoldLines(2, 5)
+	m.log.FoldLines(10, 15)
+	m = update(t, m, keyMsg("F"))
+	if !m.listingFolds {
+		t.Fatal("listingFolds = false after F")
+	}
+	view := m.View()
+	if !strings.Contains(view, "> lines 3-5 (3 lines)") || !strings.Contains(view, "lines 11-15 (5 lines)") {
+		t.Errorf("folds screen doesn't list both folds:\n%s", view)
+	}
+
+	m = update(t, m, keyMsg("j"), keyMsg("enter"))
+	if m.listingFolds || m.log.Cursor != 10 {
+		t.Errorf("listingFolds = %v, cursor = %d after enter, want the screen closed on the second fold", m.listingFolds, m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("F"), keyMsg("d"))
+	if got := m.log.Folds(); len(got) != 1 || got[0] != (logview.Fold{Start: 2, End: 5}) {
+		t.Errorf("folds = %+v after d, want only the first left", got)
+	}
+	m = update(t, m, keyMsg("d"))
+	if m.listingFolds {
+		t.Error("listingFolds = true with no folds left, want the screen closed")
+	}
+}
+
+func TestLoadLogStateRestoresFolds(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(20))
+	m.logPath = filepath.Join(t.TempDir(), "app.log")
+	if err := logstate.Write(logstate.Path(m.logPath), logstate.State{Folds: []logstate.Fold{{From: 3, To: 6}, {From: 18, To: 40}}}); err != nil {
+		t.Fatalf("logstate.Write returned unexpected error: %v", err)
+	}
+
+	m.loadLogState()
+	want := []logview.Fold{{Start: 2, End: 6}, {Start: 17, End: 20}}
+	if got := m.log.Folds(); !reflect.DeepEqual(got, want) {
+		t.Errorf("folds = %+v, want %+v (the second clipped to the log)", got, want)
+	}
+
+	m.logPath = "-"
+	m.log.SetFolds(nil)
+	m.loadLogState()
+	if len(m.log.Folds()) != 0 {
+		t.Error("stdin log picked up folds, want none")
+	}
+}
+
+func TestFoldsScreenFitsWindow(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(200))
+	m.hideUnmatched = false
+	m.logPath = filepath.Join(t.TempDir(), "app.log")
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.View()
+	for start := 0; start < 200; start += 4 {
+		m.log.FoldLines(start, start+2)
+	}
+	m = update(t, m, keyMsg("F"))
+	for range 40 {
+		m = update(t, m, keyMsg("j"))
+	}
+
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) > 20 {
+		t.Errorf("folds screen is %d lines, want at most the window's 20:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(lines[1], "Folds") {
+		t.Errorf("line 1 = %q, want the header still on screen", lines[1])
+	}
+	if !strings.Contains(m.View(), "> lines 161-162") {
+		t.Errorf("folds screen doesn't show the selected fold:\n%s", m.View())
+	}
+}
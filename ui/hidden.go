@@ -0,0 +1,38 @@
+package ui
+
+import "fmt"
+
+// ExpandHiddenStep is how many lines ExpandHiddenStep reveals at each end
+// of a hidden run: a screenful's worth of context around a spot without
+// pulling in the thousands of lines the run might hold.
+const ExpandHiddenStep = 10
+
+// expandHidden reveals the run of hidden lines next to the cursor -- all of
+// it, or ExpandHiddenStep lines at each end if step is set (see
+// logview.LogView.ExpandHidden) -- and says how many in the status line.
+// contextLines is left alone: this widens the view at one spot, not around
+// every match.
+func (m *model) expandHidden(step bool) {
+	n := 0
+	if step {
+		n = ExpandHiddenStep
+	}
+	revealed := m.log.ExpandHidden(n)
+	switch revealed {
+	case 0:
+		m.saveStatus = "no hidden lines next to the cursor"
+	case 1:
+		m.saveStatus = "revealed 1 line"
+	default:
+		m.saveStatus = fmt.Sprintf("revealed %d lines", revealed)
+	}
+}
+
+// hideRevealed undoes every expandHidden.
+func (m *model) hideRevealed() {
+	if m.log.HideRevealed() {
+		m.saveStatus = "expanded lines hidden again"
+	} else {
+		m.saveStatus = "no expanded lines to hide"
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"skim/filterfiles"
+	"skim/ui/views/logview"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHiddenRunsMarkedByDefault(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "ERROR")}, positionLines(100))
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "··· 9 lines hidden ···") {
+		t.Errorf("View() doesn't mark the lines hidden between matches:\n%s", view)
+	}
+}
+
+func TestExpandHiddenKeys(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "ERROR")}, positionLines(100))
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	m.View()
+	before := m.log.ShownCount
+
+	m = update(t, m, keyMsg("e"))
+	if m.saveStatus != "revealed 9 lines" {
+		t.Errorf("status = %q after e, want the 9 lines after line 1 revealed", m.saveStatus)
+	}
+	if m.context != (logview.Context{}) {
+		t.Errorf("context = %+v after e, want it untouched", m.context)
+	}
+	m.View()
+	if got := m.log.ShownCount; got != before+9 {
+		t.Errorf("ShownCount = %d after e, want %d", got, before+9)
+	}
+
+	m = update(t, m, keyMsg("X"))
+	m.View()
+	if got := m.log.ShownCount; got != before {
+		t.Errorf("ShownCount = %d after X, want %d", got, before)
+	}
+
+	m = update(t, m, keyMsg("j"))
+	m.View()
+	m = update(t, m, keyMsg("E"))
+	if m.saveStatus != "revealed 9 lines" {
+		t.Errorf("status = %q after E on a run of 9, want all of it", m.saveStatus)
+	}
+
+	m.hideUnmatched = false
+	m.View()
+	m = update(t, m, keyMsg("e"))
+	if m.saveStatus != "no hidden lines next to the cursor" {
+		t.Errorf("status = %q after e with nothing hidden", m.saveStatus)
+	}
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"skim/history"
+	"skim/ui/views/inputview"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyRecall is where the open prompt is in its history (see the
+// history package): up/down step through the entries, keeping what was
+// being typed as a draft to come back to, and ctrl+r searches them
+// backwards for what's typed, the way a shell's reverse-i-search does.
+// Only one prompt is ever open at a time, so one of these serves them all;
+// each prompt resets it as it opens (see startRecall).
+type historyRecall struct {
+	prompt history.Prompt // whose history; "" for a prompt without one
+	pos    int            // entries[pos] is showing; len(entries) means the draft is
+	draft  string         // what was typed before stepping into the history
+
+	// Reverse-i-search (ctrl+r) state: the text being searched for, the
+	// entry it last matched (-1 before anything has), whether the query
+	// as it stands matches nothing, and the prompt's text before the
+	// search started, restored on esc.
+	searching bool
+	query     string
+	match     int
+	failing   bool
+	before    string
+}
+
+// startRecall resets history recall for a prompt that's just opened: the
+// one whose history is prompt, or, if prompt is "", one without any.
+func (m *model) startRecall(prompt history.Prompt) {
+	m.recall = historyRecall{prompt: prompt, pos: len(m.history[prompt])}
+}
+
+// remember records entry, just entered at the prompt whose history is
+// prompt, as its newest entry. Best-effort, like keybindings.Save: if it
+// can't be saved, it's still recalled for the rest of this run.
+func (m *model) remember(prompt history.Prompt, entry string) {
+	m.history.Add(prompt, entry)
+	history.Save(m.history)
+}
+
+// recallHistory handles the history keys of whichever prompt is open, in
+// being what's typed in it: up/down recall older/newer entries, and ctrl+r
+// starts (or, pressed again, continues to an older match) a
+// reverse-i-search, which takes over typing and backspace until another
+// key accepts the match -- going on to do what that key does, so enter
+// accepts and submits it -- or esc abandons it. It reports whether the key
+// was used up here.
+func (m *model) recallHistory(msg tea.KeyMsg, in *inputview.Input) bool {
+	r := &m.recall
+	if r.prompt == "" {
+		return false
+	}
+	entries := m.history[r.prompt]
+
+	if r.searching {
+		switch msg.String() {
+		case "ctrl+r":
+			if !r.failing {
+				r.find(entries, r.match-1)
+			}
+		case "backspace":
+			if q := []rune(r.query); len(q) > 0 {
+				r.query = string(q[:len(q)-1])
+			}
+			r.find(entries, r.from(entries))
+		case "esc", "ctrl+g":
+			r.searching = false
+			in.SetValue(r.before)
+			return true
+		default:
+			if msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace {
+				// Accept the match, with up/down carrying on from it.
+				r.searching = false
+				if r.match >= 0 {
+					r.pos = r.match
+					r.draft = r.before
+				}
+				return m.recallHistory(msg, in)
+			}
+			if msg.Type == tea.KeySpace {
+				r.query += " "
+			} else {
+				r.query += string(msg.Runes)
+			}
+			r.find(entries, r.from(entries))
+		}
+		if r.match >= 0 {
+			in.SetValue(entries[r.match])
+		}
+		return true
+	}
+
+	switch msg.String() {
+	case "up":
+		if r.pos == 0 {
+			return true
+		}
+		if r.pos == len(entries) {
+			r.draft = in.Value()
+		}
+		r.pos--
+		in.SetValue(entries[r.pos])
+		return true
+
+	case "down":
+		if r.pos >= len(entries) {
+			return true
+		}
+		r.pos++
+		if r.pos == len(entries) {
+			in.SetValue(r.draft)
+		} else {
+			in.SetValue(entries[r.pos])
+		}
+		return true
+
+	case "ctrl+r":
+		r.searching = true
+		r.query = ""
+		r.match = -1
+		r.failing = false
+		r.before = in.Value()
+		return true
+	}
+	return false
+}
+
+// from is where a reverse-i-search looks from after its query changes:
+// the entry showing, which a longer query may still match and a shorter
+// one certainly does, or the newest if nothing's matched yet.
+func (r historyRecall) from(entries []string) int {
+	if r.match < 0 {
+		return len(entries) - 1
+	}
+	return r.match
+}
+
+// find moves a reverse-i-search to the newest entry at or before from
+// that contains its query. If there isn't one, the search is failing, and
+// stays on the last entry it matched. An empty query matches nothing, so
+// the search doesn't jump anywhere until something's typed.
+func (r *historyRecall) find(entries []string, from int) {
+	r.failing = r.query != ""
+	if r.query == "" {
+		return
+	}
+	for i := min(from, len(entries)-1); i >= 0; i-- {
+		if strings.Contains(entries[i], r.query) {
+			r.match = i
+			r.failing = false
+			return
+		}
+	}
+}
+
+// recallPrefix is what the open prompt shows before its text while a
+// reverse-i-search is under way, as a shell would: the query, and whether
+// anything matches it. It's "" the rest of the time.
+func (m model) recallPrefix() string {
+	r := m.recall
+	switch {
+	case !r.searching:
+		return ""
+	case r.failing:
+		return fmt.Sprintf("(failing reverse-i-search)`%s': ", r.query)
+	}
+	return fmt.Sprintf("(reverse-i-search)`%s': ", r.query)
+}
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"os"
+	"reflect"
+	"skim/filterfiles"
+	"skim/history"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// search types pattern at the search prompt and enters it.
+func search(t *testing.T, m model, pattern string) model {
+	t.Helper()
+	m = update(t, m, keyMsg("/"))
+	m = update(t, m, typeText(pattern)...)
+	return update(t, m, keyMsg("enter"))
+}
+
+func TestSearchHistoryRecallsWithUpAndDown(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(40))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m = search(t, m, "ERR")
+	m = search(t, m, "line 3")
+
+	m = update(t, m, keyMsg("/"), keyMsg("l"))
+	steps := []struct {
+		key  string
+		want string
+	}{
+		{"up", "line 3"},
+		{"up", "ERR"},
+		{"up", "ERR"}, // already the oldest
+		{"down", "line 3"},
+		{"down", "l"}, // back to what was being typed
+		{"down", "l"},
+	}
+	for _, step := range steps {
+		m = update(t, m, keyMsg(step.key))
+		if m.searchInput.Value() != step.want {
+			t.Fatalf("searchText after %s = %q, want %q", step.key, m.searchInput.Value(), step.want)
+		}
+	}
+
+	m = update(t, m, keyMsg("esc"))
+	m.log.Cursor = 15
+	m = update(t, m, keyMsg("/"), keyMsg("up"), keyMsg("up"))
+	if m.log.Cursor != 20 {
+		t.Errorf("Cursor = %d after recalling ERR, want it previewing the first match after the cursor, 20", m.log.Cursor)
+	}
+}
+
+func TestSearchHistoryIsSaved(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(10))
+	m = search(t, m, "ERR")
+	m = search(t, m, "[") // doesn't compile, so isn't kept
+	m = update(t, m, keyMsg("esc"))
+
+	saved, err := history.Load()
+	if err != nil {
+		t.Fatalf("history.Load() returned unexpected error: %v", err)
+	}
+	if want := []string{"ERR"}; !reflect.DeepEqual(saved[history.Search], want) {
+		t.Errorf("saved search history = %q, want %q", saved[history.Search], want)
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	reopened := newTestModel(t, nil, positionLines(10))
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	reopened.history, _ = history.Load()
+	reopened = update(t, reopened, keyMsg("/"), keyMsg("up"))
+	if reopened.searchInput.Value() != "ERR" {
+		t.Errorf("searchText after up in a new run = %q, want the saved ERR", reopened.searchInput.Value())
+	}
+}
+
+func TestSearchHistoryReverseSearch(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(40))
+	m.hideUnmatched = false
+	for _, pattern := range []string{"line 2", "ERROR line 1", "INFO", "ERROR line 3"} {
+		m = search(t, m, pattern)
+	}
+
+	m = update(t, m, keyMsg("/"), keyMsg("ctrl+r"))
+	m = update(t, m, typeText("ERR")...)
+	if m.searchInput.Value() != "ERROR line 3" || !strings.Contains(renderSearchPrompt(m), "(reverse-i-search)`ERR': /ERROR line 3") {
+		t.Errorf("searchText = %q, prompt = %q, want the newest match", m.searchInput.Value(), renderSearchPrompt(m))
+	}
+	m = update(t, m, keyMsg("ctrl+r"))
+	if m.searchInput.Value() != "ERROR line 1" {
+		t.Errorf("searchText after another ctrl+r = %q, want the next older match", m.searchInput.Value())
+	}
+	m = update(t, m, typeText("x")...)
+	if !strings.Contains(renderSearchPrompt(m), "failing reverse-i-search") || m.searchInput.Value() != "ERROR line 1" {
+		t.Errorf("prompt = %q, want it failing and still showing the last match", renderSearchPrompt(m))
+	}
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.searchInput.Value() != "ERROR line 1" {
+		t.Errorf("searchText after backspace = %q, want the match kept", m.searchInput.Value())
+	}
+	m = update(t, m, keyMsg("enter"))
+	if m.searching || m.lastSearchText != "ERROR line 1" {
+		t.Errorf("searching = %v, lastSearchText = %q after enter, want the match accepted and searched for", m.searching, m.lastSearchText)
+	}
+}
+
+func TestSearchHistoryReverseSearchEscRestoresText(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(10))
+	m = search(t, m, "ERR")
+
+	m = update(t, m, keyMsg("/"), keyMsg("I"), keyMsg("ctrl+r"), keyMsg("E"))
+	if m.searchInput.Value() != "ERR" {
+		t.Fatalf("searchText = %q, want the match", m.searchInput.Value())
+	}
+	m = update(t, m, keyMsg("esc"))
+	if !m.searching || m.searchInput.Value() != "I" || renderSearchPrompt(m) != "/I  (10 matches)" {
+		t.Errorf("searching = %v, prompt = %q after esc, want the prompt still open with what was typed before", m.searching, renderSearchPrompt(m))
+	}
+}
+
+func TestJumpLineHistory(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(40))
+	m.hideUnmatched = false
+	m = update(t, m, keyMsg(":"), keyMsg("2"), keyMsg("5"), keyMsg("enter"))
+	m.log.Cursor = 0
+
+	m = update(t, m, keyMsg(":"), keyMsg("up"), keyMsg("enter"))
+	if m.log.Cursor != 24 {
+		t.Errorf("Cursor = %d after recalling 25, want 24", m.log.Cursor)
+	}
+	if got := m.history[history.JumpLine]; !reflect.DeepEqual(got, []string{"25"}) {
+		t.Errorf("jump history = %q, want just 25", got)
+	}
+}
+
+func TestFilterEditorRegexHistory(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "a"), mustFilter(t, "b")}
+	m := newTestModel(t, filters, "line\n")
+	m.editingFilter = true
+	m.filterEditor = filterEditorState{cursor: fieldRegex}
+
+	m = update(t, m, keyMsg("enter"))
+	m = update(t, m, typeText("rror")...)
+	m = update(t, m, keyMsg("enter"))
+	if m.filters.Filters[0].XML.Text != "arror" {
+		t.Fatalf("regex = %q, want arror", m.filters.Filters[0].XML.Text)
+	}
+
+	// The description field has no history: up does nothing there.
+	m.filterEditor.cursor = fieldDescription
+	m = update(t, m, keyMsg("enter"), keyMsg("up"))
+	if m.filterEditor.input.Value() != "" {
+		t.Errorf("description after up = %q, want no history recalled", m.filterEditor.input.Value())
+	}
+	m = update(t, m, keyMsg("esc"))
+
+	m.filters.Cursor = 1
+	m.filterEditor.cursor = fieldRegex
+	m = update(t, m, keyMsg("enter"), keyMsg("up"))
+	if m.filterEditor.input.Value() != "arror" {
+		t.Errorf("second filter's regex after up = %q, want the first's recalled", m.filterEditor.input.Value())
+	}
+	m = update(t, m, keyMsg("down"))
+	if m.filterEditor.input.Value() != "b" {
+		t.Errorf("regex after down = %q, want its own back", m.filterEditor.input.Value())
+	}
+}
+
+func TestSearchHistoryUpAfterReverseSearchCarriesOnFromTheMatch(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(10))
+	for _, pattern := range []string{"line 1", "line 2", "ERR", "line 3"} {
+		m = search(t, m, pattern)
+	}
+
+	m = update(t, m, keyMsg("/"), keyMsg("x"), keyMsg("ctrl+r"), keyMsg("2"), keyMsg("up"))
+	if m.recall.searching || m.searchInput.Value() != "line 1" {
+		t.Errorf("searching = %v, searchText = %q after ctrl+r 2, up, want the entry before line 2", m.recall.searching, m.searchInput.Value())
+	}
+	m = update(t, m, keyMsg("down"), keyMsg("down"), keyMsg("down"), keyMsg("down"))
+	if m.searchInput.Value() != "x" {
+		t.Errorf("searchText after stepping back down = %q, want what was typed before ctrl+r", m.searchInput.Value())
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import "slices"
+
+// maxJumps caps how many positions the jump list remembers; past it the
+// oldest are dropped.
+const maxJumps = 100
+
+// jumpList is the Log pane's cursor history, as vim keeps one: before a
+// big move -- a search, n/N, a ":" or "@" jump, g/G, a filter match, a
+// pick from the results list -- the line the cursor was on is recorded
+// (see jumpTo), and JumpBack/JumpForward step back and forth through
+// them. Positions are line indices into the lines showing, so they're
+// dropped when the Log pane swaps to lines that don't line up with them
+// (see showTransformed).
+type jumpList struct {
+	lines []int
+	// pos is the entry the cursor was last sent to by JumpBack/JumpForward;
+	// len(lines) means it's moved on from all of them.
+	pos int
+}
+
+// record adds line as the newest position, dropping anything stepped back
+// past (as a browser's history does) and any older entry for the same
+// line, so bouncing between two lines doesn't fill the list with them.
+func (j *jumpList) record(line int) {
+	j.lines = slices.DeleteFunc(j.lines[:min(j.pos+1, len(j.lines))], func(l int) bool { return l == line })
+	j.lines = append(j.lines, line)
+	if len(j.lines) > maxJumps {
+		j.lines = j.lines[len(j.lines)-maxJumps:]
+	}
+	j.pos = len(j.lines)
+}
+
+// jumpTo moves the Log pane's cursor to line as a big move, recording
+// where it was first, unless that's where it already is.
+func (m *model) jumpTo(line int) {
+	if line != m.log.Cursor {
+		m.jumps.record(m.log.Cursor)
+	}
+	m.log.Cursor = line
+}
+
+// jumpBack moves the cursor to the position recorded before the last big
+// move, or the one before that if pressed again. The first step back
+// records where the cursor is, so jumpForward can come back to it.
+func (m *model) jumpBack() {
+	j := &m.jumps
+	if j.pos == len(j.lines) {
+		if len(j.lines) == 0 || j.lines[len(j.lines)-1] != m.log.Cursor {
+			j.record(m.log.Cursor)
+		}
+		j.pos--
+	}
+	if j.pos == 0 {
+		m.saveStatus = "at the oldest jump"
+		return
+	}
+	j.pos--
+	m.log.Cursor = clampIndex(j.lines[j.pos], m.log.GetMaxCursor())
+}
+
+// jumpForward undoes a jumpBack.
+func (m *model) jumpForward() {
+	j := &m.jumps
+	if j.pos >= len(j.lines)-1 {
+		m.saveStatus = "at the newest jump"
+		return
+	}
+	j.pos++
+	m.log.Cursor = clampIndex(j.lines[j.pos], m.log.GetMaxCursor())
+}
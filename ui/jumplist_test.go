@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestJumpListGoesBackAndForward(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(100))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m.log.Cursor = 5
+
+	m = update(t, m, keyMsg("G"))
+	m = update(t, m, keyMsg(":"), keyMsg("4"), keyMsg("2"), keyMsg("enter"))
+	m = search(t, m, "ERROR line 7")
+
+	steps := []struct {
+		key  string
+		want int
+	}{
+		{"ctrl+o", 41},
+		{"ctrl+o", 99},
+		{"ctrl+o", 5},
+		{"ctrl+o", 5}, // already the oldest
+		{"ctrl+n", 99},
+		{"ctrl+n", 41},
+		{"ctrl+n", 70},
+		{"ctrl+n", 70}, // already the newest
+	}
+	for i, step := range steps {
+		m = update(t, m, keyMsg(step.key))
+		if m.log.Cursor != step.want {
+			t.Fatalf("step %d: Cursor after %s = %d, want %d", i, step.key, m.log.Cursor, step.want)
+		}
+	}
+}
+
+func TestJumpListDropsForwardEntriesOnANewJump(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(100))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+
+	m = update(t, m, keyMsg("G"), keyMsg("g"))
+	m = update(t, m, keyMsg("ctrl+o"))
+	if m.log.Cursor != 99 {
+		t.Fatalf("Cursor after ctrl+o = %d, want 99", m.log.Cursor)
+	}
+	m.log.Cursor = 50 // an ordinary move isn't recorded
+	m = update(t, m, keyMsg("g"), keyMsg("ctrl+o"))
+	if m.log.Cursor != 50 {
+		t.Errorf("Cursor after g, ctrl+o = %d, want back at 50", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("ctrl+o"), keyMsg("ctrl+o"))
+	if m.log.Cursor != 99 {
+		t.Errorf("Cursor after two more ctrl+o = %d, want the oldest jump, 99", m.log.Cursor)
+	}
+	// Each line is in the list once, however often it's been jumped from.
+	if want := []int{99, 50, 0}; !slices.Equal(m.jumps.lines, want) {
+		t.Errorf("jump list = %v, want %v", m.jumps.lines, want)
+	}
+}
+
+func TestJumpListClearedWhenTransformReshapesLines(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(20))
+	m = update(t, m, keyMsg("G"))
+	m.transform.lines = []string{"a"}
+	m.transform.original = m.log.Lines
+	m.showTransformed(true)
+	if len(m.jumps.lines) != 0 {
+		t.Errorf("jump list = %v after a transform that isn't line-for-line, want it emptied", m.jumps.lines)
+	}
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlO})
+	if m.log.Cursor != 0 {
+		t.Errorf("Cursor after ctrl+o = %d, want it kept in range", m.log.Cursor)
+	}
+}
@@ -0,0 +1,284 @@
+package ui
+
+import (
+	"fmt"
+	"skim/keybindings"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keySeqTimeout is how long a key that starts a longer binding waits for
+// the next key of it (see readKey), as vim's timeoutlen.
+const keySeqTimeout = time.Second
+
+// keyNames are the names Bubble Tea gives keys that aren't a single
+// character ("enter", "pgup", "ctrl+u"), as tea.KeyMsg.String returns
+// them, which a binding can use as they are.
+var keyNames = func() map[string]bool {
+	names := map[string]bool{}
+	for t := tea.KeyType(-128); t < 128; t++ {
+		if name := t.String(); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// isKeyName reports whether s names a single key: one of keyNames, a
+// single character, or either with alt held ("alt+x").
+func isKeyName(s string) bool {
+	s = strings.TrimPrefix(s, "alt+")
+	return keyNames[s] || utf8.RuneCountInString(s) == 1
+}
+
+// parseKeys splits a binding (see keybindings.KeyMap) into the keys that
+// have to be pressed for it, in order, as tea.KeyMsg.String names them. A
+// binding naming one key -- "q", "ctrl+u", "pgup", " " -- is just that key.
+// Anything else is a key sequence in vim's notation: each character is a
+// key, and <...> names one that isn't a character, so "gg" is g twice,
+// "]e" is ] then e, and "<space>f" is space then f. <lt> is a literal <.
+func parseKeys(binding string) []string {
+	if isKeyName(binding) {
+		return []string{binding}
+	}
+	var keys []string
+	for rest := binding; rest != ""; {
+		if name, after, ok := cutKeyName(rest); ok {
+			keys = append(keys, name)
+			rest = after
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		keys = append(keys, string(r))
+		rest = rest[size:]
+	}
+	return keys
+}
+
+// cutKeyName reads a <...> key name from the start of s, returning the key
+// it names and the rest of s.
+func cutKeyName(s string) (key, rest string, ok bool) {
+	if !strings.HasPrefix(s, "<") {
+		return "", s, false
+	}
+	name, rest, ok := strings.Cut(s[1:], ">")
+	switch {
+	case !ok:
+		return "", s, false
+	case name == "lt":
+		return "<", rest, true
+	case name == "space":
+		return " ", rest, true
+	case len(name) > 1 && isKeyName(name):
+		return name, rest, true
+	}
+	return "", s, false
+}
+
+// formatKeys is parseKeys' inverse: the binding for pressing keys in turn.
+func formatKeys(keys []string) string {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		switch {
+		case k == "<":
+			b.WriteString("<lt>")
+		case k == " ":
+			b.WriteString("<space>")
+		case utf8.RuneCountInString(k) > 1:
+			b.WriteString("<" + k + ">")
+		default:
+			b.WriteString(k)
+		}
+	}
+	return b.String()
+}
+
+// matchKeys looks up the keys typed so far among the bindings valid for
+// scopes: action is the one bound to exactly them, checking scopes in
+// order (most specific first), if exact; longer reports whether some
+// binding goes on past them, so another key could still finish it.
+func matchKeys(km keybindings.KeyMap, scopes []keybindings.Scope, keys []string) (action keybindings.Action, exact, longer bool) {
+	for _, scope := range scopes {
+		for _, spec := range keybindings.Registry {
+			if spec.Scope != scope {
+				continue
+			}
+			for _, binding := range km[spec.Action] {
+				bound := parseKeys(binding)
+				if len(bound) < len(keys) || !slices.Equal(bound[:len(keys)], keys) {
+					continue
+				}
+				if len(bound) > len(keys) {
+					longer = true
+				} else if !exact {
+					action, exact = spec.Action, true
+				}
+			}
+		}
+	}
+	return action, exact, longer
+}
+
+// keySeqState is a key sequence being typed: the keys so far, which start
+// at least one longer binding, and which of their timeouts is current
+// (see keySeqTimeoutMsg).
+type keySeqState struct {
+	pending []string
+	seq     int
+}
+
+// keySeqTimeoutMsg is sent keySeqTimeout after a sequence's latest key.
+// Only the one for its last key (seq) does anything.
+type keySeqTimeoutMsg struct {
+	seq int
+}
+
+// timeout waits out keySeqTimeout for the sequence's latest key.
+func (k keySeqState) timeout() tea.Cmd {
+	seq := k.seq
+	return tea.Tick(keySeqTimeout, func(time.Time) tea.Msg { return keySeqTimeoutMsg{seq: seq} })
+}
+
+// readKey handles a key pressed outside any prompt or modal screen. If it
+// finishes a binding that nothing longer starts with, its action runs
+// straight away. If a longer binding does start with the keys so far, they
+// wait for the next key, up to keySeqTimeout -- so with both ] and ]e
+// bound, ] waits to see whether e follows -- and the status line shows
+// them (see renderPendingKeys). A key that doesn't carry on the sequence
+// ends it, running what the keys before it were bound to, if anything,
+// before the key is taken afresh; esc just drops it.
+func (m model) readKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "esc" && len(m.keys.pending) > 0 {
+		m.keys.pending = nil
+		m.count.digits = ""
+		return m, nil
+	}
+
+	keys := append(slices.Clone(m.keys.pending), key)
+	action, exact, longer := matchKeys(m.keyMap, activeScopes(m.focus), keys)
+	switch {
+	case longer:
+		m.keys.pending = keys
+		m.keys.seq++
+		return m, m.keys.timeout()
+	case exact:
+		m.keys.pending = nil
+		return m.runCounted(action, true)
+	case len(m.keys.pending) > 0:
+		// The action may have opened a prompt, which key then goes to.
+		next, cmd := m.finishKeys()
+		next, again := next.Update(msg)
+		return next, tea.Batch(cmd, again)
+	}
+	return m.runCounted("", false)
+}
+
+// finishKeys ends the key sequence being typed, running the action bound
+// to exactly the keys so far, if there is one.
+func (m model) finishKeys() (tea.Model, tea.Cmd) {
+	keys := m.keys.pending
+	m.keys.pending = nil
+	action, exact, _ := matchKeys(m.keyMap, activeScopes(m.focus), keys)
+	return m.runCounted(action, exact)
+}
+
+// renderPendingKeys shows the key sequence being typed, and the keys that
+// could come next, for the status line: "keys: ] (e/g)".
+func renderPendingKeys(m model) string {
+	keys := m.keys.pending
+	var next []string
+	for _, scope := range activeScopes(m.focus) {
+		for _, spec := range keybindings.Registry {
+			if spec.Scope != scope {
+				continue
+			}
+			for _, binding := range m.keyMap[spec.Action] {
+				bound := parseKeys(binding)
+				if len(bound) > len(keys) && slices.Equal(bound[:len(keys)], keys) {
+					if label := displayKey(bound[len(keys)]); !slices.Contains(next, label) {
+						next = append(next, label)
+					}
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("keys: %s (%s)", displayKey(formatKeys(keys)), strings.Join(next, "/"))
+}
+
+// scopesOverlap reports whether the keys of actions in scopes a and b are
+// ever looked up together (see activeScopes).
+func scopesOverlap(a, b keybindings.Scope) bool {
+	return a == b || a == keybindings.ScopeGlobal || b == keybindings.ScopeGlobal
+}
+
+// paneNames names the pane each pane-specific scope's keys are looked up
+// in (see activeScopes).
+var paneNames = map[keybindings.Scope]string{
+	keybindings.ScopeFilterView: "Filters pane",
+	keybindings.ScopeLogView:    "Log pane",
+}
+
+// keyTaken returns the action, other than action itself, that binding's
+// keys are already bound to in action's own scope, if any. Only one of the
+// two could ever run (see matchKeys), so the keybindings editor refuses
+// the binding rather than have it silently shadow the other.
+func keyTaken(km keybindings.KeyMap, action keybindings.Action, binding string) (keybindings.Spec, bool) {
+	spec, _ := keybindings.SpecFor(action)
+	keys := parseKeys(binding)
+	for _, other := range keybindings.Registry {
+		if other.Action == action || other.Scope != spec.Scope {
+			continue
+		}
+		for _, otherBinding := range km[other.Action] {
+			if slices.Equal(parseKeys(otherBinding), keys) {
+				return other, true
+			}
+		}
+	}
+	return keybindings.Spec{}, false
+}
+
+// keyConflicts describes the other bindings that binding, just bound to
+// action in the keybindings editor, gets in the way of or is got in the
+// way of by: one binding starting another, which makes the shorter wait a
+// second for the rest of the longer (see readKey) every time it's pressed,
+// and the same keys bound to a global action and a pane's own, which the
+// pane's takes over while it has focus. The same keys twice in one scope
+// never get this far (see keyTaken).
+func keyConflicts(km keybindings.KeyMap, action keybindings.Action, binding string) []string {
+	spec, _ := keybindings.SpecFor(action)
+	keys := parseKeys(binding)
+	label := displayKey(formatKeys(keys))
+	var conflicts []string
+	for _, other := range keybindings.Registry {
+		if !scopesOverlap(spec.Scope, other.Scope) {
+			continue
+		}
+		for _, otherBinding := range km[other.Action] {
+			if other.Action == action && otherBinding == binding {
+				continue
+			}
+			bound := parseKeys(otherBinding)
+			otherLabel := displayKey(formatKeys(bound))
+			switch {
+			case slices.Equal(bound, keys) && spec.Scope == keybindings.ScopeGlobal && other.Scope != spec.Scope:
+				conflicts = append(conflicts, fmt.Sprintf("%s does %q in the %s instead", label, other.Description, paneNames[other.Scope]))
+			case slices.Equal(bound, keys) && other.Scope != spec.Scope:
+				conflicts = append(conflicts, fmt.Sprintf("%s still does %q outside the %s", label, other.Description, paneNames[spec.Scope]))
+			case len(bound) > len(keys) && slices.Equal(bound[:len(keys)], keys):
+				conflicts = append(conflicts, fmt.Sprintf("%s starts %s (%s), so it waits for more keys", label, otherLabel, other.Description))
+			case len(bound) < len(keys) && slices.Equal(keys[:len(bound)], bound):
+				conflicts = append(conflicts, fmt.Sprintf("%s (%s) starts it, so that waits for more keys", otherLabel, other.Description))
+			}
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"reflect"
+	"skim/keybindings"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseAndFormatKeys(t *testing.T) {
+	tests := []struct {
+		binding string
+		keys    []string
+	}{
+		{"q", []string{"q"}},
+		{" ", []string{" "}},
+		{"ctrl+u", []string{"ctrl+u"}},
+		{"pgup", []string{"pgup"}},
+		{"alt+x", []string{"alt+x"}},
+		{"gg", []string{"g", "g"}},
+		{"]e", []string{"]", "e"}},
+		{"<space>f", []string{" ", "f"}},
+		{"<ctrl+w>h", []string{"ctrl+w", "h"}},
+		{"<lt>a", []string{"<", "a"}},
+		{"g+", []string{"g", "+"}},
+	}
+	for _, tt := range tests {
+		if got := parseKeys(tt.binding); !reflect.DeepEqual(got, tt.keys) {
+			t.Errorf("parseKeys(%q) = %q, want %q", tt.binding, got, tt.keys)
+		}
+		if got := formatKeys(tt.keys); got != tt.binding {
+			t.Errorf("formatKeys(%q) = %q, want %q", tt.keys, got, tt.binding)
+		}
+	}
+
+	// Not a key name, so its characters are keys.
+	if got, want := parseKeys("<nope>"), []string{"<", "n", "o", "p", "e", ">"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys(%q) = %q, want %q", "<nope>", got, want)
+	}
+}
+
+func TestKeySequenceRunsItsAction(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(20))
+	m.keyMap[keybindings.ToggleWrap] = []string{"<space>w"}
+
+	m = update(t, m, keyMsg(" "))
+	if !reflect.DeepEqual(m.keys.pending, []string{" "}) {
+		t.Fatalf("pending = %q after space, want it waiting for the rest of <space>w", m.keys.pending)
+	}
+	if status := renderStatusLine(m); !strings.Contains(status, "keys: space (w)") {
+		t.Errorf("status = %q, want the pending keys and what can follow", status)
+	}
+
+	m = update(t, m, keyMsg("w"))
+	if !m.log.Wrap || m.keys.pending != nil {
+		t.Errorf("Wrap, pending = %v, %q after <space>w, want wrapping on and nothing pending", m.log.Wrap, m.keys.pending)
+	}
+	if strings.Contains(renderStatusLine(m), "keys:") {
+		t.Errorf("status = %q, want no pending keys shown", renderStatusLine(m))
+	}
+}
+
+func TestKeySequencePrefixRunsOnTimeout(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(20))
+	m.keyMap[keybindings.ToggleCentered] = []string{"wc"}
+
+	m = update(t, m, keyMsg("w"))
+	if m.log.Wrap {
+		t.Fatal("w ran straight away, want it waiting to see whether c follows")
+	}
+	stale := keySeqTimeoutMsg{seq: m.keys.seq - 1}
+	m = update(t, m, stale)
+	if m.log.Wrap {
+		t.Fatal("an earlier key's timeout ran w")
+	}
+	m = update(t, m, keySeqTimeoutMsg{seq: m.keys.seq})
+	if !m.log.Wrap || m.log.Centered || m.keys.pending != nil {
+		t.Errorf("Wrap, Centered, pending = %v, %v, %q after the timeout, want just w run", m.log.Wrap, m.log.Centered, m.keys.pending)
+	}
+}
+
+func TestKeySequenceBrokenOffRunsPrefixThenKey(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(20))
+	m.hideUnmatched = false
+	m.keyMap[keybindings.ToggleCentered] = []string{"wc"}
+
+	m = update(t, m, keyMsg("w"), keyMsg("j"))
+	if !m.log.Wrap || m.log.Cursor != 1 {
+		t.Errorf("Wrap, Cursor = %v, %d after w then j, want w run and then j", m.log.Wrap, m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("w"), keyMsg("c"))
+	if !m.log.Wrap || !m.log.Centered {
+		t.Errorf("Wrap, Centered = %v, %v after wc, want just wc run", m.log.Wrap, m.log.Centered)
+	}
+
+	m = update(t, m, keyMsg("w"), keyMsg("esc"))
+	if !m.log.Wrap || m.keys.pending != nil {
+		t.Errorf("Wrap, pending = %v, %q after w then esc, want the keys dropped", m.log.Wrap, m.keys.pending)
+	}
+}
+
+func TestKeySequenceTakesCount(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(100))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m.keyMap[keybindings.CursorDown] = []string{"<space>j"}
+
+	m = update(t, m, keyMsg("3"), keyMsg(" "))
+	m = update(t, m, countTimeoutMsg{seq: m.count.seq})
+	if m.count.digits != "3" {
+		t.Fatalf("count = %q, want it kept while the sequence is pending", m.count.digits)
+	}
+	m = update(t, m, keyMsg("j"))
+	if m.log.Cursor != 3 {
+		t.Errorf("Cursor after 3<space>j = %d, want 3", m.log.Cursor)
+	}
+}
+
+func TestKeybindingsScreenRecordsSequenceAndNotesConflicts(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m = update(t, m, keyMsg("K"))
+	action := keybindings.Registry[m.kbCursor].Action // Quit, global
+
+	m = update(t, m, keyMsg("s"), keyMsg("g"))
+	if !m.kbRecording || !strings.Contains(m.renderKeybindingsScreen(), "press keys, then enter: g") {
+		t.Fatalf("after s g, kbRecording = %v, want it recording and showing the keys so far", m.kbRecording)
+	}
+	m = update(t, m, keyMsg("q"), keyMsg("enter"))
+	if m.kbRecording {
+		t.Error("still recording after enter")
+	}
+	want := []string{"ctrl+c", "q", "gq"}
+	if !equalStrings(m.keyMap[action], want) {
+		t.Errorf("keyMap[%v] = %v, want %v", action, m.keyMap[action], want)
+	}
+	if !strings.Contains(m.kbNotice, `g (jump to top) starts it`) {
+		t.Errorf("kbNotice = %q, want the clash with g noted", m.kbNotice)
+	}
+	if !strings.Contains(m.renderKeybindingsScreen(), m.kbNotice) {
+		t.Error("the editor doesn't show the notice")
+	}
+
+	loaded, err := keybindings.Load()
+	if err != nil {
+		t.Fatalf("keybindings.Load() returned unexpected error: %v", err)
+	}
+	if !equalStrings(loaded[action], want) {
+		t.Errorf("persisted keyMap[%v] = %v, want %v", action, loaded[action], want)
+	}
+
+	m = update(t, m, keyMsg("j"))
+	if m.kbNotice != "" {
+		t.Errorf("kbNotice = %q after moving on, want it cleared", m.kbNotice)
+	}
+}
+
+func TestKeyConflicts(t *testing.T) {
+	km := keybindings.Defaults()
+	km[keybindings.ToggleWrap] = []string{"w", "]w"}
+
+	conflicts := keyConflicts(km, keybindings.ToggleWrap, "]w")
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "] (") {
+		t.Errorf("keyConflicts(]w) = %q, want the clash with ] alone", conflicts)
+	}
+	if got := keyConflicts(km, keybindings.ToggleWrap, "w"); got != nil {
+		t.Errorf("keyConflicts(w) = %q, want none", got)
+	}
+
+	// j moves the cursor everywhere, so the Log pane's j takes it over there.
+	km[keybindings.ToggleWrap] = []string{"j"}
+	conflicts = keyConflicts(km, keybindings.ToggleWrap, "j")
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], `j still does "move cursor down" outside the Log pane`) {
+		t.Errorf("keyConflicts(j) = %q, want the global action noted", conflicts)
+	}
+	conflicts = keyConflicts(km, keybindings.CursorDown, "j")
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], `j does "wrap long lines" in the Log pane instead`) {
+		t.Errorf("keyConflicts(j) for move cursor down = %q, want the Log pane's action noted", conflicts)
+	}
+
+	// The Filters pane's keys are never looked up with the Log pane's.
+	km[keybindings.ToggleWrap] = []string{"i"} // edit_regex, Filters pane only
+	if got := keyConflicts(km, keybindings.ToggleWrap, "i"); got != nil {
+		t.Errorf("keyConflicts(i) = %q, want none", got)
+	}
+}
+
+func TestKeyTaken(t *testing.T) {
+	km := keybindings.Defaults()
+	if other, taken := keyTaken(km, keybindings.ToggleWrap, "c"); !taken || other.Action != keybindings.ToggleColumns {
+		t.Errorf("keyTaken(c) = %v, %v, want it taken by toggle columns", other.Action, taken)
+	}
+	if _, taken := keyTaken(km, keybindings.ToggleWrap, "w"); taken {
+		t.Error("keyTaken(w) = true for wrap's own key")
+	}
+	// A global action and a pane's own can share keys (see activeScopes).
+	if _, taken := keyTaken(km, keybindings.ToggleWrap, "j"); taken {
+		t.Error("keyTaken(j) = true, want the global binding left to keyConflicts")
+	}
+}
+
+func TestKeybindingsScreenRefusesKeysBoundInTheSameScope(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m = update(t, m, keyMsg("K"))
+	action := keybindings.Registry[m.kbCursor].Action // Quit, global
+	want := slices.Clone(m.keyMap[action])
+
+	for _, keys := range [][]string{{"a", "j"}, {"enter", "j"}, {"s", "j", "enter"}} {
+		m = update(t, m, keyMsg("j"), keyMsg("k")) // clear the last notice
+		for _, key := range keys {
+			m = update(t, m, keyMsg(key))
+		}
+		if !equalStrings(m.keyMap[action], want) {
+			t.Errorf("after %q, keyMap[%v] = %v, want it unchanged %v", keys, action, m.keyMap[action], want)
+		}
+		if !equalStrings(m.keyMap[keybindings.CursorDown], []string{"down", "j"}) {
+			t.Errorf("after %q, keyMap[cursor_down] = %v, want it unchanged", keys, m.keyMap[keybindings.CursorDown])
+		}
+		if !strings.Contains(m.kbNotice, `j is already bound to "move cursor down"`) {
+			t.Errorf("after %q, kbNotice = %q, want the binding refused", keys, m.kbNotice)
+		}
+	}
+	if !strings.Contains(m.renderKeybindingsScreen(), m.kbNotice) {
+		t.Error("the editor doesn't show the notice")
+	}
+}
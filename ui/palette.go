@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"fmt"
+	"skim/keybindings"
+	"skim/ui/views/inputview"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteState is the command palette (see renderPalette): every action
+// valid in the focus it was opened from (see activeScopes), narrowed down
+// by fuzzy-matching what's typed against their descriptions, with one
+// selected to run.
+type paletteState struct {
+	input   inputview.Input
+	matches []paletteMatch // the actions matching input, best first
+	cursor  int            // index into matches of the selected one
+}
+
+// paletteMatch is an action the palette lists, with where in its
+// description the typed text matched (see fuzzyMatch), to pick out.
+type paletteMatch struct {
+	spec      keybindings.Spec
+	positions []int
+}
+
+// openPalette shows the command palette with every action available.
+func (m *model) openPalette() {
+	m.showingPalette = true
+	m.palette = paletteState{}
+	m.filterPalette()
+}
+
+// filterPalette narrows the palette down to the actions whose descriptions
+// fuzzy-match what's been typed, best match first, and selects the first.
+// Ties, and everything while nothing's typed, keep Registry's order.
+func (m *model) filterPalette() {
+	p := &m.palette
+	query := p.input.Value()
+	type scored struct {
+		match paletteMatch
+		score int
+	}
+	var found []scored
+	for _, spec := range paletteActions(m.focus) {
+		score, positions, ok := fuzzyMatch(query, spec.Description)
+		if ok {
+			found = append(found, scored{paletteMatch{spec, positions}, score})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int { return b.score - a.score })
+	p.matches = p.matches[:0]
+	for _, f := range found {
+		p.matches = append(p.matches, f.match)
+	}
+	p.cursor = 0
+}
+
+// paletteActions lists the actions the palette offers in focus: those
+// its keys would reach there (see activeScopes), less the palette itself.
+func paletteActions(focus Focus) []keybindings.Spec {
+	scopes := activeScopes(focus)
+	var specs []keybindings.Spec
+	for _, spec := range keybindings.Registry {
+		if spec.Action != keybindings.CommandPalette && slices.Contains(scopes, spec.Scope) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// fuzzyMatch reports whether every character of pattern appears in text,
+// in order but not necessarily together, ignoring case -- so "jtl" finds
+// "jump to line" -- and where, as rune offsets into text. Its score ranks
+// matches: each character counts, more so at the start of a word or right
+// after the one before, so a match of whole words or runs of letters beats
+// one scattered through the text. An empty pattern matches anything.
+func fuzzyMatch(pattern, text string) (score int, positions []int, ok bool) {
+	want := []rune(strings.ToLower(pattern))
+	runes := []rune(strings.ToLower(text))
+	k := 0
+	for i, r := range runes {
+		if k == len(want) {
+			break
+		}
+		if r != want[k] {
+			continue
+		}
+		score++
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			score += 4
+		}
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += 2
+		}
+		positions = append(positions, i)
+		k++
+	}
+	return score, positions, k == len(want)
+}
+
+// updatePalette handles key presses while the command palette is open:
+// up/down (or ctrl+p/ctrl+n) pick an action, enter closes the palette and
+// runs it, esc closes it, and anything else edits the text it's searching
+// for (see inputview.Input.Update).
+func (m model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.palette
+	switch msg.String() {
+	case "esc":
+		m.showingPalette = false
+		return m, nil
+
+	case "enter":
+		m.showingPalette = false
+		if p.cursor < len(p.matches) {
+			return m.runAction(p.matches[p.cursor].spec.Action, 0)
+		}
+		return m, nil
+
+	case "up", "ctrl+p":
+		p.cursor = max(p.cursor-1, 0)
+		return m, nil
+
+	case "down", "ctrl+n":
+		p.cursor = min(p.cursor+1, max(len(p.matches)-1, 0))
+		return m, nil
+	}
+
+	before := p.input.Value()
+	if p.input.Update(msg) && p.input.Value() != before {
+		m.filterPalette()
+	}
+	return m, nil
+}
+
+// renderPalettePrompt shows what the palette is searching for in place of
+// the help bar while it's open.
+func renderPalettePrompt(m model) string {
+	return fmt.Sprintf("action: %s  (%s)", m.palette.input.View(), matchCountLabel(len(m.palette.matches)))
+}
+
+// paletteMatchStyle picks out the characters the typed text matched.
+var paletteMatchStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+
+// renderPalette renders the command palette in place of the Filters pane,
+// the same height as the results list (see resultsHeight): the matching
+// actions, scrolled to keep the selected one in view, each with the keys
+// it's bound to, or "(no key)".
+func (m model) renderPalette() string {
+	p := m.palette
+	width := m.windowWidth - focusedStyle.GetHorizontalFrameSize()
+
+	var b strings.Builder
+	header := "Command palette   type to search   up/down: select   enter: run   esc: close"
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(truncateToWidth(header, width)))
+
+	descWidth := 0
+	for _, match := range p.matches {
+		descWidth = max(descWidth, len([]rune(match.spec.Description)))
+	}
+
+	height := m.resultsHeight()
+	top := clamp(p.cursor-height/2, 0, max(len(p.matches)-height, 0))
+	for k := top; k < top+height; k++ {
+		b.WriteString("\n")
+		if k >= len(p.matches) {
+			if k == 0 {
+				b.WriteString("  (no matching actions)")
+			}
+			continue
+		}
+		match := p.matches[k]
+		cursor := "  "
+		if k == p.cursor {
+			cursor = "> "
+		}
+		keys := displayKeys(m.keyMap[match.spec.Action], ", ")
+		if keys == "" {
+			keys = "(no key)"
+		}
+		desc := highlightPositions(match.spec.Description, match.positions)
+		pad := strings.Repeat(" ", descWidth-len([]rune(match.spec.Description)))
+		b.WriteString(truncateToWidth(cursor+desc+pad+"   "+keys, width))
+	}
+
+	return focusedStyle.Width(width).Render(b.String())
+}
+
+// highlightPositions renders text with the runes at positions (ascending)
+// in paletteMatchStyle.
+func highlightPositions(text string, positions []int) string {
+	if len(positions) == 0 {
+		return text
+	}
+	var b strings.Builder
+	k := 0
+	for i, r := range []rune(text) {
+		if k < len(positions) && positions[k] == i {
+			b.WriteString(paletteMatchStyle.Render(string(r)))
+			k++
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
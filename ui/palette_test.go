@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"skim/filterfiles"
+	"skim/keybindings"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		wantOK        bool
+		wantPositions []int
+	}{
+		{"", "jump to line", true, nil},
+		{"jtl", "jump to line", true, []int{0, 5, 8}},
+		{"WRAP", "wrap long lines", true, []int{0, 1, 2, 3}},
+		{"ljt", "jump to line", false, nil},
+	}
+	for _, tt := range tests {
+		_, positions, ok := fuzzyMatch(tt.pattern, tt.text)
+		if ok != tt.wantOK || (ok && !slices.Equal(positions, tt.wantPositions)) {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, %v, want %v, %v", tt.pattern, tt.text, positions, ok, tt.wantPositions, tt.wantOK)
+		}
+	}
+
+	whole, _, _ := fuzzyMatch("line", "jump to line")
+	scattered, _, _ := fuzzyMatch("line", "show less context around matches")
+	if whole <= scattered {
+		t.Errorf("score for a whole word = %d, for scattered letters = %d, want the word ranked higher", whole, scattered)
+	}
+}
+
+func TestPaletteListsActionsForTheFocus(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "a")}
+	m := newTestModel(t, filters, "a\nb\n")
+	m = update(t, m, tea_WindowSize())
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	if !m.showingPalette {
+		t.Fatal("showingPalette = false after ctrl+p")
+	}
+	for _, match := range m.palette.matches {
+		if match.spec.Scope == keybindings.ScopeFilterView || match.spec.Action == keybindings.CommandPalette {
+			t.Errorf("palette in the Log pane lists %q, want only Log pane and global actions, less itself", match.spec.Description)
+		}
+	}
+	out := ansi.Strip(m.View())
+	if !strings.Contains(out, "search log") || !strings.Contains(out, "Command palette") {
+		t.Errorf("View() doesn't show the palette in place of the Filters pane:\n%s", out)
+	}
+
+	m = update(t, m, keyMsg("esc"), keyMsg("tab"), tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = update(t, m, typeText("edit filt")...)
+	if len(m.palette.matches) == 0 || m.palette.matches[0].spec.Action != keybindings.EditRegex {
+		t.Errorf("first match for %q in the Filters pane = %+v, want edit filter", "edit filt", m.palette.matches)
+	}
+}
+
+func TestPaletteRunsTheSelectedAction(t *testing.T) {
+	m := newTestModel(t, nil, "a\nb\n")
+	m = update(t, m, tea_WindowSize())
+	m.keyMap[keybindings.ToggleWrap] = nil // reachable all the same
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = update(t, m, typeText("wrap")...)
+	if !strings.Contains(ansi.Strip(m.renderPalette()), "wrap long lines   (no key)") {
+		t.Errorf("palette = %q, want the unbound action listed with (no key)", ansi.Strip(m.renderPalette()))
+	}
+	m = update(t, m, keyMsg("enter"))
+	if m.showingPalette || !m.log.Wrap {
+		t.Errorf("showingPalette = %v, Wrap = %v after enter, want the palette closed and wrap toggled", m.showingPalette, m.log.Wrap)
+	}
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = update(t, m, typeText("search")...)
+	m = update(t, m, keyMsg("down"), keyMsg("up"), keyMsg("enter"))
+	if !m.searching {
+		t.Error("searching = false after running search log from the palette, want its prompt open")
+	}
+}
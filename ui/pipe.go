@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"skim/ui/views/inputview"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// PipeTimeout bounds how long a command the selection is piped to may run
+// (see runPipe) before it's killed, so a command that never finishes -- one
+// waiting on a terminal it doesn't have, say -- can't leave skim waiting on
+// it forever.
+const PipeTimeout = 30 * time.Second
+
+// openPipePrompt starts the "|" prompt for a command to pipe the selected
+// lines to. The selection stays as it is while the command is typed.
+func (m *model) openPipePrompt() {
+	m.piping = true
+	m.pipeInput = inputview.Input{}
+}
+
+// updatePipeInput handles key presses while the pipe prompt is open: esc
+// cancels, enter runs the command on the selected lines, and anything else
+// edits it (see inputview.Input.Update).
+func (m model) updatePipeInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.piping = false
+		m.pipeInput.Reset()
+
+	case "enter":
+		m.piping = false
+		command := strings.TrimSpace(m.pipeInput.Value())
+		if command == "" {
+			break
+		}
+		text, n := m.selectionText()
+		if n == 0 {
+			m.saveStatus = "nothing to pipe"
+			break
+		}
+		m.log.ClearSelection()
+		m.saveStatus = fmt.Sprintf("running: %s", command)
+		return m, runPipe(command, text, PipeTimeout)
+
+	default:
+		m.pipeInput.Update(msg)
+	}
+
+	return m, nil
+}
+
+// renderPipePrompt shows the command being typed in place of the help bar.
+func renderPipePrompt(m model) string {
+	return "|" + m.pipeInput.View()
+}
+
+// pipeResultMsg carries what a command run by runPipe wrote, stdout and
+// stderr interleaved, and how it exited.
+type pipeResultMsg struct {
+	command string
+	output  string
+	err     error
+}
+
+// runPipe runs command through the shell (see shellCommand) in the
+// background with text on its stdin, reporting back with a pipeResultMsg.
+// It's killed, along with everything it started, if it runs for longer
+// than timeout.
+func runPipe(command, text string, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		c := shellCommand(ctx, command)
+		c.Stdin = strings.NewReader(text)
+		out, err := c.CombinedOutput()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("killed after %s", timeout)
+		}
+		return pipeResultMsg{command: command, output: string(out), err: err}
+	}
+}
+
+// shellWaitDelay is how long a shell command's output is still read for
+// once it's been killed (see exec.Cmd.WaitDelay), in case something it
+// started got away from killProcessGroup and still holds it open.
+const shellWaitDelay = 2 * time.Second
+
+// shellCommand returns command run through the shell: sh, or cmd on
+// Windows. Cancelling ctx kills everything the command started, not just
+// the shell (see killProcessGroup), so a pipeline's commands can't keep
+// its output open, and whoever's waiting on it, after it's gone.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	c.WaitDelay = shellWaitDelay
+	killProcessGroup(c)
+	return c
+}
+
+// outputViewState is the temporary full-screen view a piped command's
+// output opens in (see renderOutputView): nothing about it outlives
+// closing it.
+type outputViewState struct {
+	title string
+	lines []string
+	top   int // index of the first line shown
+}
+
+// openOutputView shows msg's output in the output view.
+func (m *model) openOutputView(msg pipeResultMsg) {
+	title := "| " + msg.command
+	if msg.err != nil {
+		title += "  (" + msg.err.Error() + ")"
+	}
+	output := strings.TrimRight(strings.ReplaceAll(msg.output, "\r\n", "\n"), "\n")
+	lines := []string{"(no output)"}
+	if output != "" {
+		lines = strings.Split(output, "\n")
+	}
+	for i, line := range lines {
+		lines[i] = printable(line)
+	}
+	m.showingOutput = true
+	m.output = outputViewState{title: printable(title), lines: lines}
+	m.saveStatus = ""
+}
+
+// printable makes a line of a command's output safe to draw in the TUI:
+// tabs become spaces, and escape sequences and other control characters,
+// which would move the cursor or recolor the screen under Bubble Tea, are
+// dropped.
+func printable(line string) string {
+	line = ansi.Strip(strings.ReplaceAll(line, "\t", "    "))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+// outputViewHeight is how many output lines fit under the output view's
+// two header lines.
+func (m model) outputViewHeight() int {
+	return max(m.windowHeight-2, 1)
+}
+
+// updateOutputView handles key presses in the output view: up/down and
+// pgup/pgdown scroll, g/G jump to either end, and esc/q close it.
+func (m model) updateOutputView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	height := m.outputViewHeight()
+	last := max(len(m.output.lines)-height, 0)
+	top := m.output.top
+	switch msg.String() {
+	case "esc", "q":
+		m.showingOutput = false
+		m.output = outputViewState{}
+		return m, nil
+	case "up", "k":
+		top--
+	case "down", "j":
+		top++
+	case "pgup", "b":
+		top -= height
+	case "pgdown", "f", " ":
+		top += height
+	case "g", "home":
+		top = 0
+	case "G", "end":
+		top = last
+	}
+	m.output.top = clamp(top, 0, last)
+	return m, nil
+}
+
+// renderOutputView renders the output view: a title line naming the
+// command (and how it failed, if it did), then a screenful of its output.
+// Rows are joined rather than each ended with "\n", which would make the
+// frame a line taller than the terminal (see View).
+func (m model) renderOutputView() string {
+	height := m.outputViewHeight()
+	end := min(m.output.top+height, len(m.output.lines))
+	header := fmt.Sprintf("%s  —  lines %d-%d of %d   up/down/pgup/pgdown: scroll   esc/q: close", m.output.title, m.output.top+1, end, len(m.output.lines))
+
+	rows := []string{truncateToWidth(header, m.windowWidth), ""}
+	for _, line := range m.output.lines[m.output.top:end] {
+		rows = append(rows, truncateToWidth(line, m.windowWidth))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// truncateToWidth cuts s down to width display columns, leaving it alone
+// if width isn't known yet.
+func truncateToWidth(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+// clamp returns v limited to [low, high].
+func clamp(v, low, high int) int {
+	return min(max(v, low), high)
+}
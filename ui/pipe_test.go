@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPipeSelectionOpensOutputView(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\ngamma\n")
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m.View()
+	m = update(t, m, keyMsg("V"), keyMsg("j"))
+	m.View()
+
+	m = update(t, m, keyMsg("|"))
+	if !m.piping || !strings.HasPrefix(m.renderFooter(), "|") {
+		t.Fatalf("piping = %v, footer = %q after |, want the pipe prompt", m.piping, m.renderFooter())
+	}
+	for _, r := range "tr a-z A-Z" {
+		m = update(t, m, keyMsg(string(r)))
+	}
+	newModel, cmd := m.Update(keyMsg("enter"))
+	m = newModel.(model)
+	if cmd == nil {
+		t.Fatal("enter returned no command to run the pipe")
+	}
+	m = update(t, m, cmd())
+
+	if !m.showingOutput {
+		t.Fatal("showingOutput = false after the command finished")
+	}
+	view := m.View()
+	if !strings.Contains(view, "| tr a-z A-Z") || !strings.Contains(view, "ALPHA\nBETA") || strings.Contains(view, "GAMMA") {
+		t.Errorf("output view doesn't show the piped selection's output:\n%s", view)
+	}
+
+	m = update(t, m, keyMsg("q"))
+	if m.showingOutput {
+		t.Error("showingOutput = true after q, want the output view closed")
+	}
+}
+
+func TestPipeReportsFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	msg := runPipe("echo oops >&2; exit 3", "", PipeTimeout)().(pipeResultMsg)
+	m := newTestModel(t, nil, "line\n")
+	m = update(t, m, tea_WindowSize(), msg)
+
+	view := m.View()
+	if !strings.Contains(view, "exit status 3") || !strings.Contains(view, "oops") {
+		t.Errorf("output view doesn't show the failure and its stderr:\n%s", view)
+	}
+}
+
+func TestPipeTimeoutKillsWholePipeline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	start := time.Now()
+	msg := runPipe("sleep 5 | cat", "", 200*time.Millisecond)().(pipeResultMsg)
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("runPipe took %s, want it to return soon after its 200ms timeout", took)
+	}
+	if msg.err == nil || !strings.Contains(msg.err.Error(), "killed after 200ms") {
+		t.Errorf("err = %v, want the timeout reported", msg.err)
+	}
+}
+
+func TestOutputViewScrolls(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m = update(t, m, tea_WindowSize())
+	m.openOutputView(pipeResultMsg{command: "seq 100", output: strings.Repeat("x\n", 100)})
+
+	m = update(t, m, keyMsg("G"))
+	if want := 100 - m.outputViewHeight(); m.output.top != want {
+		t.Errorf("top = %d after G, want %d", m.output.top, want)
+	}
+	m = update(t, m, keyMsg("j"))
+	if want := 100 - m.outputViewHeight(); m.output.top != want {
+		t.Errorf("top = %d after j at the end, want it to stay %d", m.output.top, want)
+	}
+	m = update(t, m, keyMsg("g"), keyMsg("k"))
+	if m.output.top != 0 {
+		t.Errorf("top = %d after g, k, want 0", m.output.top)
+	}
+}
+
+func TestOutputViewFitsWindow(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.openOutputView(pipeResultMsg{command: "seq 100", output: strings.Repeat("x\n", 100)})
+
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) > 20 {
+		t.Errorf("output view is %d lines tall, want at most the window's 20", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "| seq 100") {
+		t.Errorf("first line = %q, want the header", lines[0])
+	}
+}
+
+func TestOutputViewStripsControlCharacters(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m = update(t, m, tea_WindowSize())
+	m.openOutputView(pipeResultMsg{command: "colors", output: "\x1b[31mred\x1b[0m\a\tdone\x1b]0;title\a\n"})
+
+	if got, want := m.output.lines[0], "red    done"; got != want {
+		t.Errorf("line = %q, want %q with the escapes and control characters gone", got, want)
+	}
+	if strings.ContainsAny(m.View(), "\x1b\a") {
+		t.Error("the output view still draws escape or control characters")
+	}
+}
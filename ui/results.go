@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"skim/keybindings"
+	"skim/ui/views/filterview"
+	"skim/ui/views/logview"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultsScanChunk is how many lines each step of a results scan (see
+// scanResults) covers before reporting back, so the list fills in -- and
+// the UI keeps responding -- while a huge log is still being scanned.
+const resultsScanChunk = 50000
+
+// resultsState is the results list (see renderResults): every line
+// matching a pattern, found by a scan that runs in the background a chunk
+// at a time.
+type resultsState struct {
+	title   string        // what's being listed, e.g. "/timeout/"
+	re      regexp.Regexp // the pattern
+	lines   []string      // the log being scanned, as it was when the list opened
+	hits    []int         // indices into lines that match re, ascending
+	scanned int           // how many of lines have been scanned so far
+
+	cursor int  // index into hits of the selected match
+	moved  bool // whether cursor has been moved since the list opened
+	origin int  // the Log pane's cursor when the list opened, restored on esc
+
+	// seq numbers each scan, so a chunk arriving after the list was closed
+	// or reopened is ignored.
+	seq int
+}
+
+// resultsScanMsg carries one chunk of a results scan: the lines in
+// [from, to) that matched.
+type resultsScanMsg struct {
+	seq      int
+	from, to int
+	hits     []int
+}
+
+// scanResults scans the chunk of lines starting at from for re in the
+// background, reporting back with a resultsScanMsg.
+func scanResults(seq int, re regexp.Regexp, lines []string, from int) tea.Cmd {
+	return func() tea.Msg {
+		to := min(from+resultsScanChunk, len(lines))
+		var hits []int
+		for i := from; i < to; i++ {
+			if re.MatchString(lines[i]) {
+				hits = append(hits, i)
+			}
+		}
+		return resultsScanMsg{seq: seq, from: from, to: to, hits: hits}
+	}
+}
+
+// openResults lists every line matching the focused pane's pattern: the
+// selected filter's regex in the Filters pane, the last search in the Log
+// pane.
+func (m *model) openResults() tea.Cmd {
+	var title string
+	var re regexp.Regexp
+	switch {
+	case m.focus == FilterFocus && m.filters.Cursor < len(m.filters.Filters):
+		f := m.filters.Filters[m.filters.Cursor]
+		title = fmt.Sprintf("filter %d (%s)", m.filters.Cursor+1, f.XML.Text)
+		if f.XML.Description != "" {
+			title = fmt.Sprintf("filter %d (%s)", m.filters.Cursor+1, f.XML.Description)
+		}
+		re = f.Regex
+	case m.focus == LogFocus && m.hasSearch:
+		title = "/" + m.lastSearchText + "/"
+		re = m.lastSearch
+	default:
+		m.saveStatus = fmt.Sprintf("nothing to list: %s searches the log", displayKeys(m.keyMap[keybindings.Search], "/"))
+		return nil
+	}
+
+	m.listingResults = true
+	m.results = resultsState{
+		title:  title,
+		re:     re,
+		lines:  m.log.Lines,
+		origin: m.log.Cursor,
+		seq:    m.results.seq + 1,
+	}
+	return scanResults(m.results.seq, re, m.results.lines, 0)
+}
+
+// addResults adds a finished chunk's matches to the list and starts on the
+// next chunk, if there is one. Until the selection's been moved, it stays
+// on the first match at or after where the cursor was.
+func (m *model) addResults(msg resultsScanMsg) tea.Cmd {
+	r := &m.results
+	if !m.listingResults || msg.seq != r.seq || msg.from != r.scanned {
+		return nil
+	}
+	r.hits = append(r.hits, msg.hits...)
+	r.scanned = msg.to
+	if !r.moved {
+		r.cursor = min(sort.SearchInts(r.hits, r.origin), max(len(r.hits)-1, 0))
+	}
+	if r.scanned < len(r.lines) {
+		return scanResults(r.seq, r.re, r.lines, r.scanned)
+	}
+	return nil
+}
+
+// closeResults closes the list, abandoning its scan if it's still going.
+func (m *model) closeResults() {
+	m.listingResults = false
+	m.results = resultsState{seq: m.results.seq}
+}
+
+// resultsHeight is how many matches the results list shows at once: a
+// third of the window, but never less than the Filters pane it takes the
+// place of.
+func (m model) resultsHeight() int {
+	return max(m.windowHeight/3, filterview.VisibleHeight)
+}
+
+// updateResults handles key presses while the results list is open:
+// up/down, pgup/pgdown and g/G pick a match, previewing it in the Log
+// pane, enter jumps there and closes the list, and esc/q close it, putting
+// the cursor back where it was.
+func (m model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := &m.results
+	last := len(r.hits) - 1
+	cursor := r.cursor
+	switch msg.String() {
+	case "esc", "q":
+		m.log.Cursor = r.origin
+		m.closeResults()
+		return m, nil
+
+	case "enter":
+		if cursor <= last {
+			m.log.Cursor = r.origin
+			m.jumpTo(r.hits[cursor])
+		}
+		m.closeResults()
+		return m, nil
+
+	case "up", "k":
+		cursor--
+	case "down", "j":
+		cursor++
+	case "pgup", "b":
+		cursor -= m.resultsHeight()
+	case "pgdown", "f", " ":
+		cursor += m.resultsHeight()
+	case "g", "home":
+		cursor = 0
+	case "G", "end":
+		cursor = last
+	default:
+		return m, nil
+	}
+	if last < 0 {
+		return m, nil
+	}
+	r.cursor = clamp(cursor, 0, last)
+	r.moved = true
+	m.log.Cursor = r.hits[r.cursor]
+	return m, nil
+}
+
+// renderResults renders the results list in place of the Filters pane: a
+// title line with how many matches there are (and how far the scan's got,
+// while it's going), then a window of them, each its line number and text
+// with the match highlighted as in the Log pane.
+func (m model) renderResults() string {
+	r := m.results
+	width := m.windowWidth - focusedStyle.GetHorizontalFrameSize()
+
+	var b strings.Builder
+	count := fmt.Sprintf("%d lines", len(r.hits))
+	if len(r.hits) == 1 {
+		count = "1 line"
+	}
+	if r.scanned < len(r.lines) {
+		count += fmt.Sprintf(", scanning %d%%…", r.scanned*100/len(r.lines))
+	}
+	header := fmt.Sprintf("Matches of %s: %s   up/down: preview   enter: jump   esc/q: close", r.title, count)
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(truncateToWidth(header, width)))
+
+	height := m.resultsHeight()
+	top := clamp(r.cursor-height/2, 0, max(len(r.hits)-height, 0))
+	numberWidth := len(strconv.Itoa(len(r.lines)))
+	for k := top; k < top+height; k++ {
+		b.WriteString("\n")
+		if k >= len(r.hits) {
+			if k == 0 && r.scanned == len(r.lines) {
+				b.WriteString("  (no matches)")
+			}
+			continue
+		}
+		i := r.hits[k]
+		cursor := "  "
+		if k == r.cursor {
+			cursor = "> "
+		}
+		prefix := fmt.Sprintf("%s%*d  ", cursor, numberWidth, i+1)
+		text := logview.HighlightMatches(r.lines[i], &r.re, max(width-len(prefix), 1))
+		b.WriteString(prefix + text)
+	}
+
+	return focusedStyle.Width(width).Render(b.String())
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"reflect"
+	"skim/filterfiles"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// finishScan runs a results scan's chunks one after another until it's
+// done, as Bubble Tea would in the background.
+func finishScan(t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	for cmd != nil {
+		newModel, next := m.Update(cmd())
+		m = newModel.(model)
+		cmd = next
+	}
+	return m
+}
+
+// openResultsNow presses the list-matches key and lets its scan finish.
+func openResultsNow(t *testing.T, m model) model {
+	t.Helper()
+	newModel, cmd := m.Update(keyMsg("L"))
+	m = newModel.(model)
+	if !m.listingResults {
+		t.Fatalf("listingResults = false after L (status %q)", m.saveStatus)
+	}
+	return finishScan(t, m, cmd)
+}
+
+func TestResultsListsSearchMatches(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(40))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m = update(t, m, keyMsg("/"), keyMsg("E"), keyMsg("R"), keyMsg("R"), keyMsg("enter"))
+	m.log.Cursor = 15
+	without := len(strings.Split(m.View(), "\n"))
+
+	m = openResultsNow(t, m)
+
+	if want := []int{0, 10, 20, 30}; !reflect.DeepEqual(m.results.hits, want) {
+		t.Errorf("hits = %v, want %v", m.results.hits, want)
+	}
+	if m.results.cursor != 2 {
+		t.Errorf("results cursor = %d, want 2, the first match at or after line 16", m.results.cursor)
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "Matches of /ERR/: 4 lines") || !strings.Contains(view, "> 21  ERROR line 20") {
+		t.Errorf("View() doesn't show the results list:\n%s", view)
+	}
+	if got := len(strings.Split(m.View(), "\n")); got != without {
+		t.Errorf("View() has %d lines with the results list open, want %d (same as without it)", got, without)
+	}
+}
+
+func TestResultsPreviewJumpAndCancel(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(40))
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m = update(t, m, keyMsg("/"), keyMsg("E"), keyMsg("R"), keyMsg("R"), keyMsg("enter"))
+	m.log.Cursor = 5
+	m = openResultsNow(t, m)
+
+	m = update(t, m, keyMsg("j"))
+	if m.log.Cursor != 20 {
+		t.Errorf("Cursor = %d after j, want the previewed match on 20", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("esc"))
+	if m.listingResults || m.log.Cursor != 5 {
+		t.Errorf("listingResults = %v, Cursor = %d after esc, want the list closed and the cursor back on 5", m.listingResults, m.log.Cursor)
+	}
+
+	m = openResultsNow(t, m)
+	m = update(t, m, keyMsg("G"), keyMsg("enter"))
+	if m.listingResults || m.log.Cursor != 30 {
+		t.Errorf("listingResults = %v, Cursor = %d after G, enter, want the list closed on the last match", m.listingResults, m.log.Cursor)
+	}
+}
+
+func TestResultsListsSelectedFilterFromFiltersPane(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "line 1"), mustFilter(t, "line 3")}
+	m := newTestModel(t, filters, positionLines(40))
+	m = update(t, m, tea_WindowSize(), keyMsg("tab"), keyMsg("down"))
+
+	m = openResultsNow(t, m)
+
+	if want := []int{3, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39}; !reflect.DeepEqual(m.results.hits, want) {
+		t.Errorf("hits = %v, want the lines matching the second filter, %v", m.results.hits, want)
+	}
+	if !strings.Contains(m.results.title, "filter 2") {
+		t.Errorf("title = %q, want it to name the filter", m.results.title)
+	}
+}
+
+func TestResultsNeedSomethingToList(t *testing.T) {
+	m := newTestModel(t, nil, positionLines(10))
+	newModel, cmd := m.Update(keyMsg("L"))
+	m = newModel.(model)
+	if m.listingResults || cmd != nil || !strings.Contains(m.saveStatus, "nothing to list") {
+		t.Errorf("listingResults = %v, cmd = %v, saveStatus = %q with no search, want nothing listed", m.listingResults, cmd != nil, m.saveStatus)
+	}
+}
+
+func TestResultsScanInChunksAndIgnoresStaleOnes(t *testing.T) {
+	lines := strings.Repeat("x\n", resultsScanChunk) + "needle\n"
+	m := newTestModel(t, nil, lines)
+	m = update(t, m, keyMsg("/"), keyMsg("n"), keyMsg("e"), keyMsg("e"), keyMsg("enter"))
+
+	newModel, cmd := m.Update(keyMsg("L"))
+	m = newModel.(model)
+	first := cmd().(resultsScanMsg)
+	if first.to != resultsScanChunk || len(first.hits) != 0 {
+		t.Fatalf("first chunk = [%d, %d) with %d hits, want just the first %d lines", first.from, first.to, len(first.hits), resultsScanChunk)
+	}
+	newModel, cmd = m.Update(first)
+	m = newModel.(model)
+	if cmd == nil || !strings.Contains(ansi.Strip(m.renderResults()), "scanning") {
+		t.Fatalf("cmd = %v, list = %q after the first chunk, want another chunk under way", cmd != nil, ansi.Strip(m.renderResults()))
+	}
+	second := cmd()
+
+	m = update(t, m, keyMsg("q"))
+	m = finishScan(t, m, func() tea.Msg { return second })
+	if m.listingResults || len(m.results.hits) != 0 {
+		t.Errorf("listingResults = %v, hits = %v after a chunk for a closed list arrived, want it ignored", m.listingResults, m.results.hits)
+	}
+}
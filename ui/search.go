@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"skim/filterfiles"
+	"strings"
+	"unicode"
+)
+
+// searchOptions change how the search prompt's text is matched. Each is
+// toggled from within the prompt (see updateSearchInput) and sticks for
+// later searches until toggled back.
+type searchOptions struct {
+	smartCase bool // case-sensitive if the pattern has an uppercase letter, otherwise not
+	literal   bool // the pattern is plain text, not a regex
+	wholeWord bool // only match the pattern as a whole word
+	shownOnly bool // only search the lines the Log pane shows, not hidden ones
+}
+
+// searchOptionToggles maps the prompt's keys to the option each toggles,
+// in the order renderSearchPrompt lists them.
+var searchOptionToggles = []struct {
+	key    string
+	name   string
+	option func(*searchOptions) *bool
+}{
+	{"alt+c", "smart-case", func(o *searchOptions) *bool { return &o.smartCase }},
+	{"alt+l", "literal", func(o *searchOptions) *bool { return &o.literal }},
+	{"alt+w", "word", func(o *searchOptions) *bool { return &o.wholeWord }},
+	{"alt+v", "shown", func(o *searchOptions) *bool { return &o.shownOnly }},
+}
+
+// toggle flips the option key toggles, reporting whether key is one.
+func (o *searchOptions) toggle(key string) bool {
+	for _, t := range searchOptionToggles {
+		if t.key == key {
+			p := t.option(o)
+			*p = !*p
+			return true
+		}
+	}
+	return false
+}
+
+// names lists the options that are on, by name, for the prompt and for
+// saving in a session (see parseSearchOptions).
+func (o searchOptions) names() []string {
+	var names []string
+	for _, t := range searchOptionToggles {
+		if *t.option(&o) {
+			names = append(names, t.name)
+		}
+	}
+	return names
+}
+
+// parseSearchOptions is names in reverse. Names it doesn't know are
+// ignored, so a session from a later version still opens.
+func parseSearchOptions(s string) searchOptions {
+	var o searchOptions
+	for _, name := range strings.Split(s, ",") {
+		for _, t := range searchOptionToggles {
+			if t.name == strings.TrimSpace(name) {
+				*t.option(&o) = true
+			}
+		}
+	}
+	return o
+}
+
+// compile compiles text as a search pattern under o. Smart-case looks at
+// the text as typed: in a regex, an escape like \S or \W doesn't count as
+// an uppercase letter.
+func (o searchOptions) compile(text string) (regexp.Regexp, error) {
+	pattern := text
+	if o.literal {
+		pattern = regexp.QuoteMeta(text)
+	}
+	if o.wholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	return filterfiles.CompileRegex(pattern, o.smartCase && hasUppercase(text, !o.literal))
+}
+
+// hasUppercase reports whether text has an uppercase letter, skipping the
+// character after each backslash if it's a regex.
+func hasUppercase(text string, regex bool) bool {
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case regex && r == '\\':
+			escaped = true
+		case unicode.IsUpper(r):
+			return true
+		}
+	}
+	return false
+}
+
+// findSearch moves to the next (or, backwards, previous) line matching
+// the last search, among the shown lines only if it was made that way.
+func (m model) findSearch(backwards bool) (int, bool) {
+	switch {
+	case m.lastSearchOptions.shownOnly && backwards:
+		return m.log.FindPrevShown(m.lastSearch)
+	case m.lastSearchOptions.shownOnly:
+		return m.log.FindNextShown(m.lastSearch)
+	case backwards:
+		return m.log.FindPrev(m.lastSearch)
+	}
+	return m.log.FindNext(m.lastSearch)
+}
+
+// previewSearch is incremental search: after every edit to searchInput, it
+// compiles what's been typed so far and moves the cursor to its first match
+// after where the prompt was opened, counting its matches for the prompt
+// (see renderSearchPrompt). While the text is empty, or doesn't compile
+// yet -- half of a "[a-z]", say -- the cursor waits back at its origin,
+// and only enter reports what's wrong with it.
+func (m *model) previewSearch() {
+	m.searchErr = ""
+	m.searchPreview = nil
+	m.log.Cursor = m.searchOrigin
+	text := m.searchInput.Value()
+	if text == "" {
+		return
+	}
+	re, err := m.searchOptions.compile(text)
+	if err != nil {
+		return
+	}
+	m.searchPreview = &re
+	if m.searchOptions.shownOnly {
+		m.searchMatches = m.log.CountShownMatches(re)
+		if idx, ok := m.log.FindNextShown(re); ok {
+			m.log.Cursor = idx
+		}
+		return
+	}
+	m.searchMatches = m.log.CountMatches(re)
+	if idx, ok := m.log.FindNext(re); ok {
+		m.log.Cursor = idx
+	}
+}
+
+// searchHighlight returns the pattern whose matches the Log pane marks
+// (see logview.LogView.Highlight): the one being typed while the search
+// prompt is open, the one being listed while the results list is, and
+// otherwise the last search, if there is one.
+func (m model) searchHighlight() *regexp.Regexp {
+	switch {
+	case m.searching:
+		return m.searchPreview
+	case m.listingResults:
+		re := m.results.re
+		return &re
+	case m.hasSearch:
+		re := m.lastSearch
+		return &re
+	}
+	return nil
+}
+
+// matchCountLabel describes n matching lines for the search prompt.
+func matchCountLabel(n int) string {
+	switch n {
+	case 0:
+		return "no matches"
+	case 1:
+		return "1 match"
+	}
+	return fmt.Sprintf("%d matches", n)
+}
@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"skim/filterfiles"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestIncrementalSearchPreviewsFirstMatchAndCount(t *testing.T) {
+	m := newTestModel(t, nil, "alpha\nbeta ERROR\ngamma\ndelta error\nepsilon\n")
+	m.hideUnmatched = false
+	m.log.Cursor = 2
+
+	m = update(t, m, keyMsg("/"), keyMsg("e"), keyMsg("r"))
+	if m.log.Cursor != 3 {
+		t.Errorf("Cursor = %d while typing /er, want the first match after the origin, 3", m.log.Cursor)
+	}
+	if got := renderSearchPrompt(m); got != "/er  (2 matches)" {
+		t.Errorf("prompt = %q, want the live match count", got)
+	}
+
+	m = update(t, m, keyMsg("x"))
+	if m.log.Cursor != 2 {
+		t.Errorf("Cursor = %d with no matches, want it back at the origin, 2", m.log.Cursor)
+	}
+	if got := renderSearchPrompt(m); got != "/erx  (no matches)" {
+		t.Errorf("prompt = %q, want no matches reported", got)
+	}
+
+	m = update(t, m, keyMsg("backspace"), keyMsg("enter"))
+	if m.searching || m.log.Cursor != 3 || m.lastSearchText != "er" {
+		t.Errorf("searching = %v, Cursor = %d, lastSearchText = %q after enter, want the search done at line 3", m.searching, m.log.Cursor, m.lastSearchText)
+	}
+}
+
+func TestIncrementalSearchEscRestoresCursor(t *testing.T) {
+	m := newTestModel(t, nil, "alpha\nbeta\ngamma\ndelta\n")
+	m.hideUnmatched = false
+	m.log.Cursor = 1
+
+	m = update(t, m, keyMsg("/"), keyMsg("d"), keyMsg("e"))
+	if m.log.Cursor != 3 {
+		t.Fatalf("Cursor = %d while typing /de, want the preview on 3", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("esc"))
+	if m.log.Cursor != 1 || m.searching || m.hasSearch {
+		t.Errorf("Cursor = %d, searching = %v, hasSearch = %v after esc, want the cursor back on 1 and no search", m.log.Cursor, m.searching, m.hasSearch)
+	}
+}
+
+func TestIncrementalSearchWaitsOnIncompletePattern(t *testing.T) {
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	m.hideUnmatched = false
+
+	m = update(t, m, keyMsg("/"), keyMsg("["), keyMsg("b"))
+	if m.log.Cursor != 0 || m.searchPreview != nil || m.searchErr != "" {
+		t.Errorf("Cursor = %d, preview = %v, searchErr = %q on a half-typed class, want no preview and no error yet", m.log.Cursor, m.searchPreview, m.searchErr)
+	}
+	m = update(t, m, keyMsg("]"))
+	if m.log.Cursor != 1 || !strings.Contains(renderSearchPrompt(m), "1 match") {
+		t.Errorf("Cursor = %d, prompt = %q once the class closes, want the preview on 1", m.log.Cursor, renderSearchPrompt(m))
+	}
+}
+
+func TestSearchHighlightFollowsPromptAndLastSearch(t *testing.T) {
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	if m.searchHighlight() != nil {
+		t.Error("searchHighlight() set before any search, want nil")
+	}
+
+	m = update(t, m, keyMsg("/"), keyMsg("b"))
+	if re := m.searchHighlight(); re == nil || !re.MatchString("BETA") {
+		t.Errorf("searchHighlight() = %v while typing /b, want the pattern being typed", re)
+	}
+	m = update(t, m, keyMsg("enter"), keyMsg("/"))
+	if re := m.searchHighlight(); re != nil {
+		t.Errorf("searchHighlight() = %v with the prompt reopened and empty, want nil", re)
+	}
+	m = update(t, m, keyMsg("esc"))
+	if re := m.searchHighlight(); re == nil || re.String() != m.lastSearch.String() {
+		t.Errorf("searchHighlight() = %v after esc, want the last search", re)
+	}
+}
+
+func TestSearchPromptEditsMidPatternAndTakesPastes(t *testing.T) {
+	m := newTestModel(t, nil, "alpha\nbeta\ngamma\n")
+	m.hideUnmatched = false
+
+	m = update(t, m, keyMsg("/"))
+	m = update(t, m, typeText("alha")...)
+	m = update(t, m, keyMsg("left"), keyMsg("left"), keyMsg("p"))
+	if m.searchInput.Value() != "alpha" {
+		t.Fatalf("pattern = %q after typing mid-pattern, want %q", m.searchInput.Value(), "alpha")
+	}
+	if got := ansi.Strip(renderSearchPrompt(m)); got != "/alpha  (1 match)" {
+		t.Errorf("prompt = %q, want the whole pattern and its preview", got)
+	}
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyCtrlU}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ta|mm\n"), Paste: true})
+	if m.searchInput.Value() != "ta|mmha" || m.log.Cursor != 1 {
+		t.Errorf("pattern = %q, Cursor = %d after ctrl+u and a paste, want %q previewing beta on 1", m.searchInput.Value(), m.log.Cursor, "ta|mmha")
+	}
+}
+
+func TestSearchOptionsToggleFromThePrompt(t *testing.T) {
+	m := newTestModel(t, nil, "a.c\nabc\nABC\nabcd\nx abc y\n")
+	m.hideUnmatched = false
+	m = update(t, m, keyMsg("/"))
+	m = update(t, m, typeText("a.c")...)
+	if got := renderSearchPrompt(m); got != "/a.c  (5 matches)" {
+		t.Fatalf("prompt = %q, want every line matching the regex", got)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"alt+l", "/a.c  (1 match)  [literal]"},
+		{"alt+l", "/a.c  (5 matches)"},
+		{"alt+w", "/a.c  (4 matches)  [word]"},
+		{"alt+c", "/a.c  (4 matches)  [smart-case, word]"},
+	}
+	for _, tt := range tests {
+		m = update(t, m, keyMsg(tt.key))
+		if got := renderSearchPrompt(m); got != tt.want {
+			t.Errorf("prompt after %s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	m = update(t, m, keyMsg("ctrl+u"))
+	m = update(t, m, typeText("A.C")...)
+	if got := renderSearchPrompt(m); got != "/A.C  (1 match)  [smart-case, word]" {
+		t.Errorf("prompt = %q, want an uppercase letter to make smart-case match case", got)
+	}
+	m = update(t, m, keyMsg("enter"))
+	if m.searching || m.log.Cursor != 2 || !strings.Contains(renderStatusLine(m), "search: /A.C/ smart-case,word") {
+		t.Errorf("searching = %v, Cursor = %d, status = %q, want the search made with its options", m.searching, m.log.Cursor, renderStatusLine(m))
+	}
+
+	m = update(t, m, keyMsg("/"))
+	if got := renderSearchPrompt(m); got != "/  [smart-case, word]" {
+		t.Errorf("prompt reopened = %q, want the options kept", got)
+	}
+}
+
+func TestSmartCaseIgnoresRegexEscapes(t *testing.T) {
+	tests := []struct {
+		text  string
+		regex bool
+		want  bool
+	}{
+		{`error\s+\d`, true, false},
+		{`error\S+`, true, false},
+		{`Error\S+`, true, true},
+		{`error\S+`, false, true},
+	}
+	for _, tt := range tests {
+		if got := hasUppercase(tt.text, tt.regex); got != tt.want {
+			t.Errorf("hasUppercase(%q, %v) = %v, want %v", tt.text, tt.regex, got, tt.want)
+		}
+	}
+}
+
+func TestSearchShownLinesOnly(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR")}
+	m := newTestModel(t, filters, positionLines(40))
+	m = update(t, m, tea_WindowSize())
+	m.View() // works out which lines are shown
+
+	m = update(t, m, keyMsg("/"), keyMsg("alt+v"))
+	m = update(t, m, typeText("line")...)
+	if got := renderSearchPrompt(m); got != "/line  (4 matches)  [shown]" {
+		t.Errorf("prompt = %q, want only the shown ERROR lines counted", got)
+	}
+	m = update(t, m, keyMsg("enter"))
+	if m.log.Cursor != 10 {
+		t.Fatalf("Cursor = %d, want the next shown match, 10", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("n"))
+	if m.log.Cursor != 20 {
+		t.Errorf("Cursor after n = %d, want the next shown match, 20, not a hidden line", m.log.Cursor)
+	}
+	m = update(t, m, keyMsg("N"), keyMsg("N"))
+	if m.log.Cursor != 0 {
+		t.Errorf("Cursor after N N = %d, want 0", m.log.Cursor)
+	}
+}
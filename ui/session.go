@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"path/filepath"
+	"skim/filterfiles"
+	"skim/session"
+	"skim/timestamps"
+	inputview "skim/ui/views/inputview"
+	"strings"
+)
+
+// sessionSavePath returns where SaveSession should write: m.sessionPath if
+// skim was launched with (or has since saved) one, otherwise the log's path
+// with its extension swapped for ".skim" -- or "skim-session.skim" in the
+// working directory for a log read from stdin, which has no path to borrow.
+func (m model) sessionSavePath() string {
+	if m.sessionPath != "" {
+		return m.sessionPath
+	}
+	if m.logPath == "" || m.logPath == "-" {
+		return "skim-session.skim"
+	}
+	return strings.TrimSuffix(m.logPath, filepath.Ext(m.logPath)) + ".skim"
+}
+
+// sessionSnapshot captures everything a session file restores (see
+// applySession): the log and filter file paths, the full filter set inline
+// (including any unsaved tweaks), and the view settings and cursors.
+func (m model) sessionSnapshot() session.Session {
+	focus := session.FocusLog
+	if m.focus == FilterFocus {
+		focus = session.FocusFilters
+	}
+	s := session.Session{
+		LogPath:              m.logPath,
+		FilterFile:           m.filterFilePath,
+		HideUnmatched:        m.hideUnmatched,
+		ContextLines:         m.context.Before,
+		Line:                 m.log.Cursor + 1,
+		FilterLine:           m.filters.Cursor + 1,
+		Focus:                focus,
+		UnsavedFilterChanges: m.filtersDirty,
+		HideColumns:          len(m.log.Columns) > 0 && !m.log.Structured,
+		TimeRange:            m.log.TimeRange.String(),
+		TimeFormat:           m.log.TimeParser.Layout,
+		Filters:              filterfiles.BuildFilterSettings(m.fileMeta, m.filters.Filters),
+	}
+	if m.context.After != m.context.Before {
+		after := m.context.After
+		s.ContextAfter = &after
+	}
+	if m.hasSearch {
+		s.Search = m.lastSearchText
+		s.SearchOptions = strings.Join(m.lastSearchOptions.names(), ",")
+	}
+	if m.transform.active {
+		s.Transform = m.transform.command
+	}
+	return s
+}
+
+// applySession restores the view state sessionSnapshot captured. The
+// filters themselves are expected to already be loaded from s.Filters by
+// the caller (see run in skim.go), since they're compiled before the model
+// exists. Cursors are clamped to the current log/filter set, in case the
+// log has changed length since the session was saved, and a saved search
+// or time range that no longer parses is dropped rather than failing the
+// restore. A saved transform isn't run: a session file can come from
+// anyone, and its command runs through the shell, so it's put in the "!"
+// prompt instead, for enter to run it or esc to skip it.
+func (m *model) applySession(s session.Session) {
+	m.hideUnmatched = s.HideUnmatched
+	m.context.Before = max(s.ContextLines, 0)
+	m.context.After = m.context.Before
+	if s.ContextAfter != nil {
+		m.context.After = max(*s.ContextAfter, 0)
+	}
+	m.filtersDirty = s.UnsavedFilterChanges
+	if s.HideColumns {
+		m.log.Structured = false
+	}
+	if s.TimeFormat != "" {
+		m.log.TimeParser.Layout = s.TimeFormat
+	}
+	if m.setTimeRange(s.TimeRange) != nil {
+		m.log.TimeRange = timestamps.Range{}
+	}
+
+	if s.Search != "" {
+		opts := parseSearchOptions(s.SearchOptions)
+		if re, err := opts.compile(s.Search); err == nil {
+			m.lastSearch = re
+			m.lastSearchText = s.Search
+			m.lastSearchOptions = opts
+			m.searchOptions = opts
+			m.hasSearch = true
+		}
+	}
+
+	if s.Transform != "" {
+		m.transformPrompt = true
+		m.transformInput = inputview.New(s.Transform)
+		m.saveStatus = "the session transforms the log: enter runs the command, esc skips it"
+	}
+
+	m.log.Cursor = clampIndex(s.Line-1, m.log.GetMaxCursor())
+	m.filters.Cursor = clampIndex(s.FilterLine-1, m.filters.GetMaxCursor())
+
+	switch s.Focus {
+	case session.FocusFilters:
+		m.focus = FilterFocus
+	case session.FocusLog:
+		m.focus = LogFocus
+	}
+}
+
+// clampIndex clamps i to [0, max], or to 0 if max is negative (an empty
+// list).
+func clampIndex(i, max int) int {
+	if i > max {
+		i = max
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"path/filepath"
+	"skim/filterfiles"
+	"skim/session"
+	"skim/ui/views/logview"
+	"strings"
+	"testing"
+)
+
+func TestSessionSavePath(t *testing.T) {
+	tests := []struct {
+		name        string
+		logPath     string
+		sessionPath string
+		want        string
+	}{
+		{"explicit session path wins", "/logs/app.log", "/work/incident.skim", "/work/incident.skim"},
+		{"derived from the log's path", "/logs/app.log", "", "/logs/app.skim"},
+		{"stdin log falls back to the working directory", "-", "", "skim-session.skim"},
+		{"no log path at all", "", "", "skim-session.skim"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, nil, "line\n")
+			m.logPath = tt.logPath
+			m.sessionPath = tt.sessionPath
+			if got := m.sessionSavePath(); got != tt.want {
+				t.Errorf("sessionSavePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveSessionThenApplyRestoresState(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "a"), mustFilter(t, "b")}
+	m := newTestModel(t, filters, "a\nb\nc\nd\n")
+	m.logPath = "/logs/app.log"
+	m.sessionPath = filepath.Join(t.TempDir(), "incident.skim")
+
+	m.hideUnmatched = false
+	m = update(t, m, keyMsg("+"), keyMsg("+"), keyMsg("/"), keyMsg("c"), keyMsg("enter"))
+	m = update(t, m, keyMsg("tab"), keyMsg("down"), keyMsg("enter")) // toggle filter "b" off
+	m = update(t, m, keyMsg("S"))
+
+	if !strings.Contains(m.saveStatus, "session saved") {
+		t.Fatalf("saveStatus = %q, want it to report the session save", m.saveStatus)
+	}
+
+	s, err := session.Read(m.sessionPath)
+	if err != nil {
+		t.Fatalf("session.Read returned unexpected error: %v", err)
+	}
+	if len(s.Filters.Filters) != 2 || s.Filters.Filters[1].Enabled != "n" {
+		t.Errorf("inline filters = %+v, want both filters with the unsaved toggle captured", s.Filters.Filters)
+	}
+
+	restoredFilters, _ := filterfiles.CompileFilterRegularExpressions(s.Filters)
+	restored := newTestModel(t, restoredFilters, "a\nb\nc\nd\n")
+	restored.applySession(s)
+
+	if restored.hideUnmatched {
+		t.Error("hideUnmatched = true, want the saved false")
+	}
+	if restored.context != (logview.Context{Before: 2, After: 2}) {
+		t.Errorf("context = %+v, want 2 each side", restored.context)
+	}
+	if !restored.hasSearch || restored.lastSearchText != "c" {
+		t.Errorf("search = %q (hasSearch %v), want the saved /c/", restored.lastSearchText, restored.hasSearch)
+	}
+	if restored.log.Cursor != 2 {
+		t.Errorf("log cursor = %d, want 2 (the line the search jumped to)", restored.log.Cursor)
+	}
+	if restored.focus != FilterFocus || restored.filters.Cursor != 1 {
+		t.Errorf("focus = %v, filter cursor = %d, want FilterFocus on row 1", restored.focus, restored.filters.Cursor)
+	}
+	if !restored.filtersDirty {
+		t.Error("filtersDirty = false, want the unsaved toggle still reported as unsaved")
+	}
+}
+
+func TestSaveSessionRemembersDerivedPath(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m.logPath = filepath.Join(t.TempDir(), "app.log")
+
+	m = update(t, m, keyMsg("S"))
+
+	if want := strings.TrimSuffix(m.logPath, ".log") + ".skim"; m.sessionPath != want {
+		t.Errorf("sessionPath = %q after saving, want %q so later saves go to the same file", m.sessionPath, want)
+	}
+}
+
+func TestSaveSessionFailure(t *testing.T) {
+	m := newTestModel(t, nil, "line\n")
+	m.sessionPath = "/nonexistent/dir/incident.skim"
+
+	m = update(t, m, keyMsg("S"))
+
+	if !strings.Contains(m.saveStatus, "failed") {
+		t.Errorf("saveStatus = %q, want it to indicate failure", m.saveStatus)
+	}
+}
+
+func TestApplySessionClampsOutOfRangeCursorsAndDropsBadSearch(t *testing.T) {
+	m := newTestModel(t, nil, "one\ntwo\n")
+	m.applySession(session.Session{Line: 500, FilterLine: 3, Search: "(", ContextLines: -1})
+
+	if m.log.Cursor != 1 {
+		t.Errorf("log cursor = %d, want 1 (clamped to the last line)", m.log.Cursor)
+	}
+	if m.filters.Cursor != 0 {
+		t.Errorf("filter cursor = %d, want 0 with no filters", m.filters.Cursor)
+	}
+	if m.hasSearch {
+		t.Error("hasSearch = true, want an uncompilable saved search dropped")
+	}
+	if m.context != (logview.Context{}) {
+		t.Errorf("context = %+v, want none (negative clamped)", m.context)
+	}
+}
+
+func TestSessionRoundTripsAsymmetricContext(t *testing.T) {
+	m := newTestModel(t, nil, "a\nb\n")
+	m.context = logview.Context{Before: 20, After: 2}
+
+	s := m.sessionSnapshot()
+	if s.ContextLines != 20 || s.ContextAfter == nil || *s.ContextAfter != 2 {
+		t.Errorf("snapshot context = %d, %v, want 20 before and 2 after", s.ContextLines, s.ContextAfter)
+	}
+	restored := newTestModel(t, nil, "a\nb\n")
+	restored.applySession(s)
+	if restored.context != m.context {
+		t.Errorf("restored context = %+v, want %+v", restored.context, m.context)
+	}
+
+	m.context = logview.Context{Before: 3, After: 3}
+	if s := m.sessionSnapshot(); s.ContextAfter != nil {
+		t.Errorf("ContextAfter = %d for a symmetric context, want it left out", *s.ContextAfter)
+	}
+}
+
+func TestSessionRoundTripsSearchOptions(t *testing.T) {
+	m := newTestModel(t, nil, "a.c\nabc\n")
+	m.hideUnmatched = false
+	m = update(t, m, keyMsg("/"), keyMsg("alt+l"), keyMsg("a"), keyMsg("."), keyMsg("enter"))
+
+	s := m.sessionSnapshot()
+	if s.SearchOptions != "literal" {
+		t.Errorf("SearchOptions = %q, want literal", s.SearchOptions)
+	}
+	restored := newTestModel(t, nil, "a.c\nabc\n")
+	restored.applySession(s)
+	if !restored.lastSearchOptions.literal || restored.log.CountMatches(restored.lastSearch) != 1 {
+		t.Errorf("restored options = %+v, want /a./ searched for literally again", restored.lastSearchOptions)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package ui
+
+import "os/exec"
+
+// killProcessGroup leaves c as it is: without process groups, cancelling
+// kills just the shell, and c.WaitDelay bounds how long its output is read
+// for after that (see shellCommand).
+func killProcessGroup(c *exec.Cmd) {}
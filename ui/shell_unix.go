@@ -0,0 +1,18 @@
+//go:build unix
+
+package ui
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts c in a process group of its own and has
+// cancelling its context kill the whole group, so the commands a shell
+// pipeline starts are killed with it.
+func killProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGapThreshold and DefaultSpikeThreshold are the Log pane's gap and
+// burst detection settings (see logview.LogView's GapThreshold and
+// SpikeThreshold) when neither the filter file nor the command line sets
+// them. A minute of silence is unusual for most services that log at all,
+// and 20 lines/s over the baseline is a flood for most and noise for none.
+const (
+	DefaultGapThreshold   = time.Minute
+	DefaultSpikeThreshold = 20
+)
+
+// parseGapThreshold reads a gap threshold as a time.ParseDuration string,
+// where 0 (or "off") turns gap marking off.
+func parseGapThreshold(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" || s == "off" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("gap threshold %q: want a duration such as 30s or 5m, or 0 for off", s)
+	}
+	return d, nil
+}
+
+// parseSpikeThreshold reads a spike threshold as a whole number of lines
+// per second, where 0 (or "off") turns spike detection off.
+func parseSpikeThreshold(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("spike threshold %q: want a number of lines per second, or 0 for off", s)
+	}
+	return n, nil
+}
+
+// CheckTimelineSettings reports whether gap and spike (as given to -gap and
+// -spike; "" for not given) parse, so skim can fail startup on a typo
+// rather than silently falling back to the defaults.
+func CheckTimelineSettings(gap, spike string) error {
+	var errs []error
+	if gap != "" {
+		if _, err := parseGapThreshold(gap); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if spike != "" {
+		if _, err := parseSpikeThreshold(spike); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setTimelineThresholds applies gap and spike to the Log pane, leaving
+// whichever is "" as it was. Neither is applied unless both parse.
+func (m *model) setTimelineThresholds(gap, spike string) error {
+	g, s := m.log.GapThreshold, m.log.SpikeThreshold
+	var err error
+	if gap != "" {
+		if g, err = parseGapThreshold(gap); err != nil {
+			return err
+		}
+	}
+	if spike != "" {
+		if s, err = parseSpikeThreshold(spike); err != nil {
+			return err
+		}
+	}
+	m.log.GapThreshold, m.log.SpikeThreshold = g, s
+	return nil
+}
+
+// jumpToAnomaly moves the log cursor to the next (or, backwards, the
+// previous) gap or spike and describes it in the status line. A gap or
+// spike starting on a hidden line lands on the first shown line after it,
+// which is where its separator row is drawn.
+func (m *model) jumpToAnomaly(backwards bool) {
+	if _, ok := m.log.FirstTime(); !ok {
+		m.saveStatus = errNoTimestamps.Error()
+		return
+	}
+	find, none := m.log.FindNextAnomaly, "no more gaps or spikes"
+	if backwards {
+		find, none = m.log.FindPrevAnomaly, "no earlier gaps or spikes"
+	}
+	// Skip anything that would land back on the cursor line: going back
+	// from the line after a hidden gap would otherwise find that same gap
+	// again every time.
+	start := m.log.Cursor
+	for {
+		a, ok := find()
+		if !ok {
+			m.log.Cursor = start
+			m.saveStatus = none
+			return
+		}
+		if target := m.log.ShownAtOrAfter(a.Line); target != start {
+			m.log.Cursor = target
+			m.saveStatus = a.String()
+			return
+		}
+		m.log.Cursor = a.Line
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"bufio"
+	"path/filepath"
+	"skim/filterfiles"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextAnomalyWalksTheGapsInOrder(t *testing.T) {
+	m := newTimedModel(t)
+	m.windowWidth, m.windowHeight = 120, 40
+	m.View()
+
+	var got []string
+	for range 4 {
+		m = update(t, m, keyMsg("]"))
+		m.View()
+		got = append(got, m.saveStatus)
+	}
+	want := []string{"1m30s gap at line 2", "1m30s gap at line 4", "2m0s gap at line 5", "no more gaps or spikes"}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("statuses = %q, want %q", got, want)
+	}
+	if m.log.Cursor != 4 {
+		t.Errorf("Cursor = %d after running out of gaps, want it left on the last one (4)", m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("["))
+	if m.log.Cursor != 3 || m.saveStatus != "1m30s gap at line 4" {
+		t.Errorf("Cursor = %d, status = %q after [, want the previous gap (3)", m.log.Cursor, m.saveStatus)
+	}
+}
+
+func TestPrevAnomalySkipsAGapAmongHiddenLines(t *testing.T) {
+	// With only INFO shown, the 14:02:30 gap falls among hidden lines and
+	// is drawn (and jumped to) above 14:04's line -- so going back from
+	// there mustn't just find it again.
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "INFO")}, timedLines)
+	m.log.TimeParser.Location = time.UTC
+	m.windowWidth, m.windowHeight = 120, 40
+	m.log.Cursor = 4
+	m.View()
+
+	m = update(t, m, keyMsg("["))
+	if m.log.Cursor != 3 {
+		t.Fatalf("Cursor = %d after the first [, want 3", m.log.Cursor)
+	}
+	m.View()
+	m = update(t, m, keyMsg("["))
+	if m.log.Cursor != 3 || m.saveStatus != "no earlier gaps or spikes" {
+		t.Errorf("Cursor = %d, status = %q, want the gap before hidden line 2 skipped as already here", m.log.Cursor, m.saveStatus)
+	}
+}
+
+func TestNextAnomalyWithoutTimestamps(t *testing.T) {
+	m := newTestModel(t, nil, "no\ntimes\nhere\n")
+	m = update(t, m, keyMsg("]"))
+	if m.saveStatus != errNoTimestamps.Error() || m.log.Cursor != 0 {
+		t.Errorf("status = %q, cursor = %d, want the no-timestamps error", m.saveStatus, m.log.Cursor)
+	}
+}
+
+func TestGapSeparatorsShownByDefault(t *testing.T) {
+	m := newTimedModel(t)
+	m.windowWidth, m.windowHeight = 120, 40
+	if view := m.View(); !strings.Contains(view, "2m0s gap") {
+		t.Errorf("View() has no gap separator with the default threshold:\n%s", view)
+	}
+}
+
+func TestTimelineThresholdsFromFilterFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	scanner := bufio.NewScanner(strings.NewReader(timedLines))
+	meta := filterfiles.TextAnalysisToolSettings{GapThreshold: "100s", SpikeThreshold: "off"}
+	m := initialModel(nil, scanner, filepath.Join(t.TempDir(), "f.tat"), meta, nil)
+
+	if m.log.GapThreshold != 100*time.Second || m.log.SpikeThreshold != 0 {
+		t.Errorf("thresholds = %v, %d, want the filter file's", m.log.GapThreshold, m.log.SpikeThreshold)
+	}
+
+	scanner = bufio.NewScanner(strings.NewReader(timedLines))
+	meta = filterfiles.TextAnalysisToolSettings{GapThreshold: "soon"}
+	m = initialModel(nil, scanner, filepath.Join(t.TempDir(), "f.tat"), meta, nil)
+	if m.log.GapThreshold != DefaultGapThreshold || !strings.Contains(m.saveStatus, "filter file") {
+		t.Errorf("GapThreshold = %v, status = %q, want the default kept and the bad value reported", m.log.GapThreshold, m.saveStatus)
+	}
+}
+
+func TestSetTimelineThresholds(t *testing.T) {
+	tests := []struct {
+		gap, spike string
+		wantGap    time.Duration
+		wantSpike  int
+		wantErr    bool
+	}{
+		{"", "", DefaultGapThreshold, DefaultSpikeThreshold, false},
+		{"5m", "", 5 * time.Minute, DefaultSpikeThreshold, false},
+		{"0", "100", 0, 100, false},
+		{"off", "0", 0, 0, false},
+		{"-1s", "", DefaultGapThreshold, DefaultSpikeThreshold, true},
+		{"10s", "lots", DefaultGapThreshold, DefaultSpikeThreshold, true}, // neither applied
+	}
+	for _, tt := range tests {
+		m := newTimedModel(t)
+		err := m.setTimelineThresholds(tt.gap, tt.spike)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("setTimelineThresholds(%q, %q) error = %v, want error %v", tt.gap, tt.spike, err, tt.wantErr)
+		}
+		if m.log.GapThreshold != tt.wantGap || m.log.SpikeThreshold != tt.wantSpike {
+			t.Errorf("setTimelineThresholds(%q, %q) = %v, %d, want %v, %d", tt.gap, tt.spike,
+				m.log.GapThreshold, m.log.SpikeThreshold, tt.wantGap, tt.wantSpike)
+		}
+		if got := CheckTimelineSettings(tt.gap, tt.spike); (got != nil) != tt.wantErr {
+			t.Errorf("CheckTimelineSettings(%q, %q) = %v, want error %v", tt.gap, tt.spike, got, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"skim/timestamps"
+	"skim/ui/views/inputview"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// timePromptKind is which of the Log pane's two time prompts is open.
+type timePromptKind int
+
+const (
+	timePromptNone  timePromptKind = iota
+	timePromptJump                 // jump to the first line at or after a time ("@")
+	timePromptRange                // restrict the Log pane to a time range ("T")
+)
+
+// errNoTimestamps is reported when a time is typed into a log in which
+// LogView's TimeParser can't find a single timestamp to navigate by.
+var errNoTimestamps = errors.New("no timestamps found in this log (set timeFormat in the filter file, or -time-format)")
+
+// openTimePrompt starts a time prompt. The range prompt starts out holding
+// the current range, if any, so it can be adjusted rather than retyped.
+func (m *model) openTimePrompt(kind timePromptKind) {
+	m.timePrompt = kind
+	m.timeInput = inputview.Input{}
+	m.timeErr = ""
+	if kind == timePromptRange {
+		m.timeInput.SetValue(m.log.TimeRange.String())
+	}
+}
+
+// updateTimeInput handles key presses while a time prompt is open: esc
+// cancels, enter applies it (see jumpToTime and setTimeRange), leaving the
+// prompt open with the error shown if it doesn't parse, and anything else
+// edits it (see inputview.Input.Update).
+func (m model) updateTimeInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.timePrompt = timePromptNone
+		m.timeInput.Reset()
+		m.timeErr = ""
+
+	case "enter":
+		var err error
+		text := m.timeInput.Value()
+		switch m.timePrompt {
+		case timePromptJump:
+			if strings.TrimSpace(text) == "" {
+				break
+			}
+			err = m.jumpToTime(text)
+		case timePromptRange:
+			err = m.setTimeRange(text)
+		}
+		if err != nil {
+			m.timeErr = err.Error()
+			break
+		}
+		m.timePrompt = timePromptNone
+		m.timeErr = ""
+
+	default:
+		m.timeInput.Update(msg)
+	}
+
+	return m, nil
+}
+
+// renderTimePrompt shows the in-progress time (or its parse error) in
+// place of the help bar while a time prompt is open.
+func renderTimePrompt(m model) string {
+	prompt := "@" + m.timeInput.View()
+	if m.timePrompt == timePromptRange {
+		prompt = "time range (FROM..TO): " + m.timeInput.View()
+	}
+	if m.timeErr != "" {
+		return fmt.Sprintf("%s  (%s)", prompt, m.timeErr)
+	}
+	return prompt
+}
+
+// jumpToTime moves the log cursor to the first line at or after the time
+// in text (see timestamps.ParseQuery), whose time of day alone is taken to
+// be on the log's first day.
+func (m *model) jumpToTime(text string) error {
+	ref, ok := m.log.FirstTime()
+	if !ok {
+		return errNoTimestamps
+	}
+	t, err := timestamps.ParseQuery(text, ref, m.log.TimeParser.Location)
+	if err != nil {
+		return err
+	}
+	idx, ok := m.log.FindTime(t)
+	if !ok {
+		return fmt.Errorf("the log ends before %s", t.Format("2006-01-02 15:04:05"))
+	}
+	m.jumpTo(idx)
+	return nil
+}
+
+// setTimeRange restricts the Log pane to the range in text (see
+// timestamps.ParseRange), or lifts the restriction if text is blank. On
+// error the current range is left as it was.
+func (m *model) setTimeRange(text string) error {
+	if strings.TrimSpace(text) == "" {
+		m.log.TimeRange = timestamps.Range{}
+		return nil
+	}
+	ref, ok := m.log.FirstTime()
+	if !ok {
+		return errNoTimestamps
+	}
+	r, err := timestamps.ParseRange(text, ref, m.log.TimeParser.Location)
+	if err != nil {
+		return err
+	}
+	m.log.TimeRange = r
+	return nil
+}
+
+// timeRangeText joins -since and -until into the FROM..TO form ParseRange
+// reads, "" if neither was given.
+func timeRangeText(since, until string) string {
+	if since == "" && until == "" {
+		return ""
+	}
+	return since + ".." + until
+}
@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"bufio"
+	"skim/filterfiles"
+	"skim/session"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// timedLines is a small log spanning 14:01-14:06, with a continuation line
+// that has no timestamp of its own.
+const timedLines = "2024-01-02 14:01:00 INFO start\n" +
+	"2024-01-02 14:02:30 ERROR boom\n" +
+	"\tat Foo.bar(Foo.java:1)\n" +
+	"2024-01-02 14:04:00 INFO retry\n" +
+	"2024-01-02 14:06:00 INFO done\n"
+
+// newTimedModel is newTestModel over timedLines with every line shown and
+// times read in UTC, so the assertions don't depend on the machine's zone.
+func newTimedModel(t *testing.T) model {
+	t.Helper()
+	m := newTestModel(t, nil, timedLines)
+	m.hideUnmatched = false
+	m.log.TimeParser.Location = time.UTC
+	return m
+}
+
+// typeText returns one key message per rune of s, as typed.
+func typeText(s string) []tea.Msg {
+	msgs := make([]tea.Msg, 0, len(s))
+	for _, r := range s {
+		msgs = append(msgs, keyMsg(string(r)))
+	}
+	return msgs
+}
+
+func TestJumpToTimeMovesCursorToFirstLineAtOrAfter(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"14:04", 3},
+		{"14:03", 3}, // between lines: the next one
+		{"14:02:30", 1},
+		{"2024-01-02 14:06", 4},
+		{"9:00", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m := newTimedModel(t)
+			m = update(t, m, keyMsg("@"))
+			m = update(t, m, typeText(tt.input)...)
+			m = update(t, m, keyMsg("enter"))
+
+			if m.timePrompt != timePromptNone {
+				t.Fatalf("prompt still open with error %q", m.timeErr)
+			}
+			if m.log.Cursor != tt.want {
+				t.Errorf("Cursor = %d, want %d", m.log.Cursor, tt.want)
+			}
+		})
+	}
+}
+
+func TestJumpToTimeErrorsKeepThePromptOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   string
+		input   string
+		wantErr string
+	}{
+		{"unparseable", timedLines, "teatime", "expected a time"},
+		{"past the end", timedLines, "15:00", "the log ends before"},
+		{"no timestamps", "plain\nlines\n", "14:00", "no timestamps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, nil, tt.lines)
+			m.log.TimeParser.Location = time.UTC
+			m.log.Cursor = 1
+			m = update(t, m, keyMsg("@"))
+			m = update(t, m, typeText(tt.input)...)
+			m = update(t, m, keyMsg("enter"))
+
+			if m.timePrompt != timePromptJump || !strings.Contains(m.timeErr, tt.wantErr) {
+				t.Errorf("prompt = %v, err = %q, want the jump prompt still open with an error containing %q", m.timePrompt, m.timeErr, tt.wantErr)
+			}
+			if !strings.Contains(renderTimePrompt(m), tt.wantErr) {
+				t.Errorf("renderTimePrompt() = %q, want it to show the error", renderTimePrompt(m))
+			}
+			if m.log.Cursor != 1 {
+				t.Errorf("Cursor = %d, want it unmoved", m.log.Cursor)
+			}
+
+			m = update(t, m, keyMsg("esc"))
+			if m.timePrompt != timePromptNone || m.timeInput.Value() != "" || m.timeErr != "" {
+				t.Error("esc didn't close and reset the prompt")
+			}
+		})
+	}
+}
+
+func TestTimeRangePromptRestrictsAndClears(t *testing.T) {
+	m := newTimedModel(t)
+	m.windowWidth, m.windowHeight = 120, 40
+
+	m = update(t, m, keyMsg("T"))
+	m = update(t, m, typeText("14:02..14:05")...)
+	m = update(t, m, keyMsg("enter"))
+	m.View()
+
+	if m.timePrompt != timePromptNone {
+		t.Fatalf("prompt still open with error %q", m.timeErr)
+	}
+	if m.log.ShownCount != 3 {
+		t.Errorf("ShownCount = %d, want 3 (14:02:30, its continuation line and 14:04)", m.log.ShownCount)
+	}
+	if status := renderStatusLine(m); !strings.Contains(status, "time: 2024-01-02 14:02:00..2024-01-02 14:05:59") {
+		t.Errorf("status line = %q, want the active time range", status)
+	}
+
+	// Reopening starts from the current range, so it can be edited.
+	m = update(t, m, keyMsg("T"))
+	if m.timeInput.Value() != "2024-01-02 14:02:00..2024-01-02 14:05:59" {
+		t.Errorf("timeText = %q on reopening, want the current range", m.timeInput.Value())
+	}
+	for range m.timeInput.Value() {
+		m = update(t, m, keyMsg("backspace"))
+	}
+	m = update(t, m, keyMsg("enter"))
+	m.View()
+
+	if !m.log.TimeRange.IsZero() || m.log.ShownCount != 5 {
+		t.Errorf("TimeRange = %v, ShownCount = %d after submitting an empty range, want it cleared", m.log.TimeRange, m.log.ShownCount)
+	}
+	if strings.Contains(renderStatusLine(m), "time:") {
+		t.Error("status line still shows a time range after clearing it")
+	}
+}
+
+func TestTimeRangePromptRejectsABadRange(t *testing.T) {
+	m := newTimedModel(t)
+	m = update(t, m, keyMsg("T"))
+	m = update(t, m, typeText("14:05..14:02")...)
+	m = update(t, m, keyMsg("enter"))
+
+	if m.timePrompt != timePromptRange || m.timeErr == "" {
+		t.Errorf("prompt = %v, err = %q, want the range prompt still open with an error", m.timePrompt, m.timeErr)
+	}
+	if !m.log.TimeRange.IsZero() {
+		t.Errorf("TimeRange = %v, want it unchanged by a bad range", m.log.TimeRange)
+	}
+}
+
+func TestTimeRangeWorksWithHideUnmatched(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "INFO")}, timedLines)
+	m.log.TimeParser.Location = time.UTC
+	m.windowWidth, m.windowHeight = 120, 40
+
+	m = update(t, m, keyMsg("T"))
+	m = update(t, m, typeText("14:02..")...)
+	m = update(t, m, keyMsg("enter"))
+	m.View()
+
+	if m.log.ShownCount != 2 {
+		t.Errorf("ShownCount = %d, want 2 (the INFO lines from 14:02 on)", m.log.ShownCount)
+	}
+}
+
+func TestTimeFormatFromFilterFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	m := initialModel(nil, bufio.NewScanner(strings.NewReader("[02/Jan/2024:14:03:00 +0000] GET /\n")), "filters.tat",
+		filterfiles.TextAnalysisToolSettings{TimeFormat: "02/Jan/2006:15:04:05 -0700"}, nil)
+
+	got, ok := m.log.LineTime(0)
+	if !ok || !got.Equal(time.Date(2024, 1, 2, 14, 3, 0, 0, time.UTC)) {
+		t.Errorf("LineTime(0) = %v, %v, want the filter file's timeFormat used", got, ok)
+	}
+}
+
+func TestSessionRestoresTimeRangeAndFormat(t *testing.T) {
+	m := newTimedModel(t)
+	m.log.TimeParser.Layout = "2006-01-02 15:04:05"
+	if err := m.setTimeRange("14:02..14:05"); err != nil {
+		t.Fatalf("setTimeRange returned unexpected error: %v", err)
+	}
+
+	s := m.sessionSnapshot()
+	if s.TimeRange != "2024-01-02 14:02:00..2024-01-02 14:05:59" || s.TimeFormat != "2006-01-02 15:04:05" {
+		t.Errorf("snapshot time settings = %q, %q", s.TimeRange, s.TimeFormat)
+	}
+
+	restored := newTimedModel(t)
+	restored.applySession(s)
+	if got, want := restored.log.TimeRange, m.log.TimeRange; !got.Since.Equal(want.Since) || !got.Until.Equal(want.Until) {
+		t.Errorf("restored TimeRange = %v, want %v", got, want)
+	}
+	if restored.log.TimeParser.Layout != "2006-01-02 15:04:05" {
+		t.Errorf("restored Layout = %q", restored.log.TimeParser.Layout)
+	}
+
+	bad := newTimedModel(t)
+	bad.applySession(session.Session{TimeRange: "whenever"})
+	if !bad.log.TimeRange.IsZero() {
+		t.Errorf("TimeRange = %v, want an unparseable saved range dropped", bad.log.TimeRange)
+	}
+}
@@ -0,0 +1,302 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"skim/keybindings"
+	"skim/ui/views/inputview"
+	"skim/ui/views/logview"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transformState is the log's transformer: a shell command the whole log
+// is run through (see runTransform) -- a decoder, jq, c++filt -- whose
+// output the Log pane then shows, filters and all, in place of the lines
+// as read.
+type transformState struct {
+	command  string   // the command, "" for none
+	original []string // the log as read
+	lines    []string // command's output, nil until it's run
+	active   bool     // whether the Log pane shows lines rather than original
+
+	// oneToOne records that the command wrote exactly one line per line
+	// it was given, so line numbers, the cursor and folds carry across
+	// unchanged. When it didn't, each side keeps its own folds, the one
+	// not showing parked in folds, and nothing folded in lines is saved.
+	oneToOne bool
+	folds    []logview.Fold
+
+	// seq numbers each run, so a result arriving after a later run was
+	// started (or the transform was dropped) is ignored.
+	seq int
+
+	// cancel kills the run in progress, if any, and done is closed once
+	// it's over (see stop).
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop kills the transform's command if it's still running, so starting
+// another, dropping it or quitting doesn't leave it behind.
+func (t *transformState) stop() {
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+}
+
+// maxTransformOutput caps how much a transform's command can write (see
+// runTransform), so one that never stops -- tail -f, say -- can't take all
+// the memory there is.
+var maxTransformOutput = 512 << 20
+
+// maxTransformErrors is how much of a transform's stderr is kept, for the
+// first line of it a failure reports.
+const maxTransformErrors = 64 << 10
+
+// errTooMuchOutput is what writing past a cappedBuffer's max fails with.
+var errTooMuchOutput = errors.New("too much output")
+
+// cappedBuffer is a buffer that holds at most max bytes. Writing past that
+// fails with errTooMuchOutput and calls full, or, with truncate, just drops
+// what doesn't fit. It isn't a bytes.Buffer itself, whose ReadFrom io.Copy
+// would use instead of Write.
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	max      int
+	truncate bool
+	full     func()
+	over     bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		if b.truncate {
+			b.buf.Write(p[:max(room, 0)])
+			return len(p), nil
+		}
+		b.over = true
+		if b.full != nil {
+			b.full()
+		}
+		return 0, errTooMuchOutput
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+// transformResultMsg carries what runTransform's command wrote to stdout,
+// split into lines, and what it wrote to stderr if it failed.
+type transformResultMsg struct {
+	seq     int
+	command string
+	lines   []string
+	err     error
+}
+
+// runTransform runs command through the shell (see shellCommand) in the
+// background with lines on its stdin, one per line, reporting back with a
+// transformResultMsg, and closes done once it's over. Unlike a pipe (see
+// runPipe) there's no time limit -- a big log can take a slow decoder a
+// while, and the original stays usable until it's done -- but cancelling
+// ctx kills it, and so does writing more than maxTransformOutput.
+func runTransform(ctx context.Context, done chan<- struct{}, seq int, command string, lines []string) tea.Cmd {
+	input := strings.Join(lines, "\n") + "\n"
+	return func() tea.Msg {
+		defer close(done)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		c := shellCommand(ctx, command)
+		c.Stdin = strings.NewReader(input)
+		stdout := &cappedBuffer{max: maxTransformOutput, full: cancel}
+		stderr := &cappedBuffer{max: maxTransformErrors, truncate: true}
+		c.Stdout, c.Stderr = stdout, stderr
+		if err := c.Run(); err != nil || stdout.over {
+			switch msg := strings.TrimSpace(stderr.String()); {
+			case stdout.over:
+				err = fmt.Errorf("killed for writing over %d MB", maxTransformOutput>>20)
+			case msg != "":
+				err = fmt.Errorf("%w: %s", err, firstLine(msg))
+			}
+			return transformResultMsg{seq: seq, command: command, err: err}
+		}
+		return transformResultMsg{seq: seq, command: command, lines: splitOutputLines(stdout.String())}
+	}
+}
+
+// splitOutputLines splits a command's output into lines the way the log
+// itself was read (see bufio.ScanLines): a final newline doesn't start
+// another line, and a trailing \r is dropped.
+func splitOutputLines(out string) []string {
+	if out == "" {
+		return []string{}
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// startTransform runs the log as read through command, or, for an empty
+// command, drops the transform and goes back to the original lines.
+func (m *model) startTransform(command string) tea.Cmd {
+	command = strings.TrimSpace(command)
+	m.transform.seq++
+	m.transform.stop()
+	if command == "" {
+		if m.transform.command != "" {
+			m.showTransformed(false)
+			m.transform = transformState{seq: m.transform.seq}
+			m.saveStatus = "transform dropped: showing the original lines"
+		}
+		return nil
+	}
+	if m.transform.original == nil {
+		m.transform.original = m.log.Lines
+	}
+	m.saveStatus = fmt.Sprintf("transforming: %s", command)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.transform.cancel, m.transform.done = cancel, make(chan struct{})
+	return runTransform(ctx, m.transform.done, m.transform.seq, command, m.transform.original)
+}
+
+// stopTransform kills the transform's command, if it's still running, and
+// waits for it to go, for skim's exit: its process group doesn't get the
+// terminal's hangup (see killProcessGroup), so it could outlive skim.
+func (m *model) stopTransform() {
+	if m.transform.cancel == nil {
+		return
+	}
+	m.transform.stop()
+	select {
+	case <-m.transform.done:
+	case <-time.After(shellWaitDelay):
+	}
+}
+
+// applyTransform shows a finished transform's output, unless a later one
+// has been started since. A failed one leaves whatever was showing.
+func (m *model) applyTransform(msg transformResultMsg) {
+	if msg.seq != m.transform.seq {
+		return
+	}
+	m.transform.stop()
+	if msg.err != nil {
+		m.saveStatus = fmt.Sprintf("transform failed: %v", msg.err)
+		return
+	}
+	m.showTransformed(false)
+	m.transform.command = msg.command
+	m.transform.lines = msg.lines
+	m.transform.oneToOne = len(msg.lines) == len(m.transform.original)
+	m.showTransformed(true)
+	m.saveStatus = ""
+}
+
+// showTransformed switches the Log pane between the transformed lines and
+// the original ones. The cursor stays on the same line number when the
+// transform maps lines one-to-one; otherwise there's no telling which
+// output line came from where, so it's only clamped.
+func (m *model) showTransformed(on bool) {
+	if on == m.transform.active || m.transform.lines == nil {
+		return
+	}
+	m.transform.active = on
+	m.closeResults() // its matches are line numbers in the lines going away
+	parked := m.log.Folds()
+	if on {
+		m.log.SetLines(m.transform.lines)
+	} else {
+		m.log.SetLines(m.transform.original)
+	}
+	if !m.transform.oneToOne {
+		m.log.SetFolds(m.transform.folds)
+		m.transform.folds = parked
+		m.jumps = jumpList{} // its lines don't line up with these either
+	}
+}
+
+// toggleTransform flips between the transformed and original lines.
+func (m *model) toggleTransform() {
+	if m.transform.lines == nil {
+		m.saveStatus = fmt.Sprintf("no transform: %s runs the log through a command", displayKeys(m.keyMap[keybindings.TransformLog], "/"))
+		return
+	}
+	m.showTransformed(!m.transform.active)
+	if m.transform.active {
+		m.saveStatus = "showing the transformed lines"
+	} else {
+		m.saveStatus = "showing the original lines"
+	}
+}
+
+// foldsSavable reports whether the folds in the Log pane are the log's own
+// (see saveLogState): not while it shows a transform's output that didn't
+// map lines one-to-one, whose line numbers aren't the log's.
+func (m model) foldsSavable() bool {
+	return !m.transform.active || m.transform.oneToOne
+}
+
+// openTransformPrompt starts the "!" prompt for the transform command,
+// holding the current one, if any, so it can be adjusted rather than
+// retyped.
+func (m *model) openTransformPrompt() {
+	m.transformPrompt = true
+	m.transformInput = inputview.New(m.transform.command)
+}
+
+// updateTransformInput handles key presses while the transform prompt is
+// open: esc cancels, enter runs the log through the command -- or, emptied,
+// drops the transform -- and anything else edits it (see
+// inputview.Input.Update).
+func (m model) updateTransformInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.transformPrompt = false
+		m.transformInput.Reset()
+
+	case "enter":
+		m.transformPrompt = false
+		return m, m.startTransform(m.transformInput.Value())
+
+	default:
+		m.transformInput.Update(msg)
+	}
+
+	return m, nil
+}
+
+// renderTransformPrompt shows the command being typed in place of the
+// help bar.
+func renderTransformPrompt(m model) string {
+	return "!" + m.transformInput.View()
+}
+
+// transformStatus describes the transform for the status line, "" if
+// there's none showing.
+func transformStatus(m model) string {
+	if !m.transform.active {
+		return ""
+	}
+	s := "transformed: " + m.transform.command
+	if !m.transform.oneToOne {
+		s += fmt.Sprintf(" (%d → %d lines)", len(m.transform.original), len(m.transform.lines))
+	}
+	return s
+}
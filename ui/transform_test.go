@@ -0,0 +1,294 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"skim/logstate"
+	"skim/ui/views/logview"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runTransformNow runs command over m's log the way the "!" prompt does,
+// delivering the result straight away rather than from the background.
+func runTransformNow(t *testing.T, m model, command string) model {
+	t.Helper()
+	cmd := m.startTransform(command)
+	if cmd == nil {
+		t.Fatalf("startTransform(%q) returned no command to run", command)
+	}
+	return update(t, m, cmd())
+}
+
+func TestTransformPromptRunsCommandOverLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\ngamma\n")
+	m.hideUnmatched = false
+	m = update(t, m, tea_WindowSize())
+	m.log.Cursor = 1
+
+	m = update(t, m, keyMsg("!"))
+	if !m.transformPrompt || !strings.HasPrefix(m.renderFooter(), "!") {
+		t.Fatalf("transformPrompt = %v, footer = %q after !, want the transform prompt", m.transformPrompt, m.renderFooter())
+	}
+	for _, r := range "tr a-z A-Z" {
+		m = update(t, m, keyMsg(string(r)))
+	}
+	newModel, cmd := m.Update(keyMsg("enter"))
+	m = newModel.(model)
+	if cmd == nil {
+		t.Fatal("enter returned no command to run the transform")
+	}
+	if m.log.Lines[0] != "alpha" {
+		t.Errorf("Lines[0] = %q before the command finished, want the original line", m.log.Lines[0])
+	}
+	m = update(t, m, cmd())
+
+	if want := []string{"ALPHA", "BETA", "GAMMA"}; !reflect.DeepEqual(m.log.Lines, want) {
+		t.Errorf("Lines = %q after the transform, want %q", m.log.Lines, want)
+	}
+	if m.log.Cursor != 1 {
+		t.Errorf("Cursor = %d after a one-to-one transform, want it kept at 1", m.log.Cursor)
+	}
+	if got := transformStatus(m); got != "transformed: tr a-z A-Z" {
+		t.Errorf("transformStatus = %q, want the command without a line count", got)
+	}
+
+	m = update(t, m, keyMsg("O"))
+	if m.log.Lines[0] != "alpha" || m.transform.active {
+		t.Errorf("Lines[0] = %q, active = %v after O, want the original lines back", m.log.Lines[0], m.transform.active)
+	}
+	m = update(t, m, keyMsg("O"))
+	if m.log.Lines[0] != "ALPHA" {
+		t.Errorf("Lines[0] = %q after O again, want the transformed lines", m.log.Lines[0])
+	}
+
+	m = update(t, m, keyMsg("!"))
+	if m.transformInput.Value() != "tr a-z A-Z" {
+		t.Errorf("transformText = %q on reopening the prompt, want the current command", m.transformInput.Value())
+	}
+}
+
+func TestTransformKeepsFoldsWhenOneToOne(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, positionLines(10))
+	m.logPath = filepath.Join(t.TempDir(), "app.log")
+	m.log.SetFolds([]logview.Fold{{Start: 2, End: 5}})
+
+	m = runTransformNow(t, m, "cat")
+
+	if got := m.log.Folds(); !reflect.DeepEqual(got, []logview.Fold{{Start: 2, End: 5}}) {
+		t.Errorf("Folds() = %v after a one-to-one transform, want the fold kept", got)
+	}
+	if !m.foldsSavable() {
+		t.Error("foldsSavable() = false for a one-to-one transform, want true")
+	}
+}
+
+func TestTransformParksFoldsWhenLineCountChanges(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, positionLines(10))
+	m.logPath = filepath.Join(t.TempDir(), "app.log")
+	m.log.SetFolds([]logview.Fold{{Start: 2, End: 5}})
+
+	m = runTransformNow(t, m, "head -n 4")
+
+	if len(m.log.Lines) != 4 {
+		t.Fatalf("len(Lines) = %d after head -n 4, want 4", len(m.log.Lines))
+	}
+	if got := m.log.Folds(); len(got) != 0 {
+		t.Errorf("Folds() = %v in the transformed lines, want the log's folds parked", got)
+	}
+	if got := transformStatus(m); !strings.Contains(got, "(10 → 4 lines)") {
+		t.Errorf("transformStatus = %q, want the line counts", got)
+	}
+
+	// Folds made in output that isn't line-for-line the log's aren't saved
+	// over the log's own.
+	m.log.SetFolds([]logview.Fold{{Start: 0, End: 2}})
+	m.saveLogState()
+	if _, err := os.Stat(logstate.Path(m.logPath)); !os.IsNotExist(err) {
+		t.Errorf("state file exists after saving folds in a reshaped transform (err %v), want none", err)
+	}
+
+	m = update(t, m, keyMsg("O"))
+	if got := m.log.Folds(); !reflect.DeepEqual(got, []logview.Fold{{Start: 2, End: 5}}) {
+		t.Errorf("Folds() = %v back on the original lines, want the log's own fold", got)
+	}
+	m = update(t, m, keyMsg("O"))
+	if got := m.log.Folds(); !reflect.DeepEqual(got, []logview.Fold{{Start: 0, End: 2}}) {
+		t.Errorf("Folds() = %v back on the transformed lines, want the fold made there", got)
+	}
+}
+
+func TestTransformFailureKeepsLines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	m = runTransformNow(t, m, "echo bad input >&2; exit 2")
+
+	if m.transform.active || m.log.Lines[0] != "alpha" {
+		t.Errorf("active = %v, Lines[0] = %q after a failed transform, want the original lines", m.transform.active, m.log.Lines[0])
+	}
+	if !strings.Contains(m.saveStatus, "transform failed") || !strings.Contains(m.saveStatus, "bad input") {
+		t.Errorf("saveStatus = %q, want the failure and the command's stderr", m.saveStatus)
+	}
+}
+
+func TestTransformIgnoresStaleResult(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	stale := m.startTransform("tr a-z A-Z")
+	m = runTransformNow(t, m, "rev")
+	m = update(t, m, stale())
+
+	if m.transform.command != "rev" || m.log.Lines[0] != "ahpla" {
+		t.Errorf("command = %q, Lines[0] = %q, want the later transform's output kept", m.transform.command, m.log.Lines[0])
+	}
+}
+
+// killedBy starts a transform of m's log that would run for 5s, lets it
+// get going, then calls stop and reports whether the transform was over
+// within a second or two of that.
+func killedBy(t *testing.T, m *model, stop func()) bool {
+	t.Helper()
+	cmd := m.startTransform("sleep 5 | cat")
+	done := make(chan struct{})
+	go func() {
+		cmd()
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	stop()
+	select {
+	case <-done:
+		return true
+	case <-time.After(2 * time.Second):
+		return false
+	}
+}
+
+func TestRestartingOrDroppingTransformKillsItsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	if !killedBy(t, &m, func() { m.startTransform("cat") }) {
+		t.Error("a transform was still running after another was started, want it killed")
+	}
+	if !killedBy(t, &m, func() { m.startTransform("") }) {
+		t.Error("a transform was still running after it was dropped, want it killed")
+	}
+	if !killedBy(t, &m, m.stopTransform) {
+		t.Error("a transform was still running after stopTransform, want it killed")
+	}
+}
+
+func TestTransformOutputIsCapped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	defer func(limit int) { maxTransformOutput = limit }(maxTransformOutput)
+	maxTransformOutput = 1 << 20
+
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	m = runTransformNow(t, m, "yes")
+	if m.transform.active || !strings.Contains(m.saveStatus, "killed for writing over 1 MB") {
+		t.Errorf("active = %v, saveStatus = %q after a transform that never stops writing, want it killed", m.transform.active, m.saveStatus)
+	}
+}
+
+func TestEmptyTransformDropsIt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	m = runTransformNow(t, m, "tr a-z A-Z")
+
+	if cmd := m.startTransform("  "); cmd != nil {
+		t.Error("startTransform(\"  \") returned a command, want none")
+	}
+	if m.transform.command != "" || m.transform.active || m.log.Lines[0] != "alpha" {
+		t.Errorf("command = %q, active = %v, Lines[0] = %q after dropping the transform, want the original lines", m.transform.command, m.transform.active, m.log.Lines[0])
+	}
+
+	m = update(t, m, keyMsg("O"))
+	if !strings.Contains(m.saveStatus, "no transform") {
+		t.Errorf("saveStatus = %q after O with no transform, want a pointer to the prompt", m.saveStatus)
+	}
+}
+
+func TestSessionOffersTransformWithoutRunningIt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	m := newTestModel(t, nil, "alpha\nbeta\n")
+	m = runTransformNow(t, m, "tr a-z A-Z")
+
+	s := m.sessionSnapshot()
+	if s.Transform != "tr a-z A-Z" {
+		t.Fatalf("Transform = %q in the session, want the active command", s.Transform)
+	}
+
+	marker := filepath.Join(t.TempDir(), "ran")
+	s.Transform = "touch " + marker + "; tr a-z A-Z"
+	restored := newTestModel(t, nil, "alpha\nbeta\n")
+	restored.applySession(s)
+	if restored.initCmd != nil {
+		t.Fatal("applySession left a command to run the session's transform")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("the session's transform command ran on restore")
+	}
+	if !restored.transformPrompt || restored.transformInput.Value() != s.Transform {
+		t.Fatalf("transformPrompt, input = %v, %q, want the command offered in the prompt", restored.transformPrompt, restored.transformInput.Value())
+	}
+	if restored.log.Lines[0] != "alpha" {
+		t.Errorf("Lines[0] = %q before confirming, want the original line", restored.log.Lines[0])
+	}
+
+	newModel, cmd := restored.Update(keyMsg("enter"))
+	restored = newModel.(model)
+	if cmd == nil {
+		t.Fatal("enter in the offered prompt returned no command to run the transform")
+	}
+	restored = update(t, restored, cmd())
+	if restored.log.Lines[0] != "ALPHA" {
+		t.Errorf("Lines[0] = %q after confirming the restored transform, want %q", restored.log.Lines[0], "ALPHA")
+	}
+
+	m = update(t, m, keyMsg("O"))
+	if s := m.sessionSnapshot(); s.Transform != "" {
+		t.Errorf("Transform = %q in the session while showing the original lines, want none", s.Transform)
+	}
+}
+
+func TestSplitOutputLines(t *testing.T) {
+	tests := []struct {
+		out  string
+		want []string
+	}{
+		{"", []string{}},
+		{"a\n", []string{"a"}},
+		{"a\nb", []string{"a", "b"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"a\n\n", []string{"a", ""}},
+	}
+	for _, tt := range tests {
+		if got := splitOutputLines(tt.out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitOutputLines(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
@@ -3,13 +3,21 @@ package ui
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"skim/filterfiles"
+	"skim/history"
 	"skim/keybindings"
+	"skim/rcfile"
+	"skim/session"
+	"skim/timestamps"
+	densityview "skim/ui/views/densityview"
+	detailview "skim/ui/views/detailview"
 	filterview "skim/ui/views/filterview"
+	inputview "skim/ui/views/inputview"
 	logview "skim/ui/views/logview"
-	"strconv"
+	"slices"
 	"strings"
 
 	// We'll shorten the package name to "tea" for ease of use
@@ -48,22 +56,23 @@ func activeScopes(focus Focus) []keybindings.Scope {
 	}
 }
 
-// resolveAction finds the action bound to key that is valid for the given
-// scopes, checking scopes in order (most specific first).
+// resolveAction finds the action bound to key on its own that is valid for
+// the given scopes, checking scopes in order (most specific first). Key
+// sequences are matched a key at a time (see matchKeys).
 func resolveAction(km keybindings.KeyMap, scopes []keybindings.Scope, key string) (keybindings.Action, bool) {
-	for _, scope := range scopes {
-		for _, spec := range keybindings.Registry {
-			if spec.Scope != scope {
-				continue
-			}
-			for _, k := range km[spec.Action] {
-				if k == key {
-					return spec.Action, true
-				}
-			}
+	action, exact, _ := matchKeys(km, scopes, []string{key})
+	return action, exact
+}
+
+// jumpFilterIndex returns which filter position (0-based) one of
+// keybindings.JumpFilterActions targets, or -1 for any other action.
+func jumpFilterIndex(action keybindings.Action) int {
+	for i, a := range keybindings.JumpFilterActions {
+		if a == action {
+			return i
 		}
 	}
-	return "", false
+	return -1
 }
 
 // renderStatusLine shows whether unmatched lines are currently being
@@ -82,11 +91,25 @@ func renderStatusLine(m model) string {
 	if total > 0 {
 		line = fmt.Sprintf("line %d/%d  |  %s", m.log.Cursor+1, total, line)
 	}
-	if m.contextLines > 0 {
-		line += fmt.Sprintf("  |  context: ±%d", m.contextLines)
+	if c := m.context; c.Before == c.After && c.Before > 0 {
+		line += fmt.Sprintf("  |  context: ±%d", c.Before)
+	} else if c.Before != c.After {
+		line += fmt.Sprintf("  |  context: -%d/+%d", c.Before, c.After)
+	}
+	if !m.log.TimeRange.IsZero() {
+		line += "  |  time: " + m.log.TimeRange.String()
+	}
+	if t := transformStatus(m); t != "" {
+		line += "  |  " + t
 	}
 	if m.hasSearch {
 		line += fmt.Sprintf("  |  search: /%s/", m.lastSearchText)
+		if names := m.lastSearchOptions.names(); len(names) > 0 {
+			line += " " + strings.Join(names, ",")
+		}
+	}
+	if lo, hi, ok := m.log.Selection(); ok {
+		line += fmt.Sprintf("  |  selected: %d lines", hi-lo)
 	}
 	if m.filtersDirty {
 		line += "  |  unsaved filter changes"
@@ -97,25 +120,43 @@ func renderStatusLine(m model) string {
 	if m.startupWarning != "" {
 		line += "  |  " + m.startupWarning
 	}
+	if m.count.digits != "" {
+		line += "  |  count: " + m.count.digits
+	}
+	if len(m.keys.pending) > 0 {
+		line += "  |  " + renderPendingKeys(m)
+	}
 	return line
 }
 
 // renderSearchPrompt shows the in-progress search pattern (or its compile
-// error) in place of the help bar while the user is typing after "/".
+// error) in place of the help bar while the user is typing after "/",
+// followed by the search options that are on (see searchOptions).
 func renderSearchPrompt(m model) string {
+	prompt := fmt.Sprintf("%s/%s", m.recallPrefix(), m.searchInput.View())
 	if m.searchErr != "" {
-		return fmt.Sprintf("/%s  (invalid regex: %s)", m.searchText, m.searchErr)
+		prompt += fmt.Sprintf("  (invalid regex: %s)", m.searchErr)
+	} else if m.searchPreview != nil {
+		prompt += fmt.Sprintf("  (%s)", matchCountLabel(m.searchMatches))
+	}
+	if names := m.searchOptions.names(); len(names) > 0 {
+		prompt += fmt.Sprintf("  [%s]", strings.Join(names, ", "))
 	}
-	return fmt.Sprintf("/%s", m.searchText)
+	return prompt
 }
 
-// renderJumpLinePrompt shows the in-progress line number (or its parse
-// error) in place of the help bar while the user is typing after ":".
+// renderJumpLinePrompt shows the in-progress command line (see
+// excommand.go) in place of the help bar while the user is typing after
+// ":", followed by why it failed, if it did, or what tab could complete.
 func renderJumpLinePrompt(m model) string {
+	prompt := fmt.Sprintf("%s:%s", m.recallPrefix(), m.jumpLineInput.View())
 	if m.jumpLineErr != "" {
-		return fmt.Sprintf(":%s  (%s)", m.jumpLineText, m.jumpLineErr)
+		return fmt.Sprintf("%s  (%s)", prompt, m.jumpLineErr)
+	}
+	if len(m.completions) > 0 {
+		return fmt.Sprintf("%s  [%s]", prompt, strings.Join(m.completions, " "))
 	}
-	return fmt.Sprintf(":%s", m.jumpLineText)
+	return prompt
 }
 
 // displayKey renders a raw key string (as stored in a keybindings.KeyMap)
@@ -142,12 +183,18 @@ func displayKeys(keys []string, sep string) string {
 // bottom help bar.
 const keyBindingSep = "  |  "
 
+// maxHelpLines caps the bottom help bar's height, so showing it leaves the
+// panes room on an ordinary terminal; the keybindings screen and the
+// command palette list whatever doesn't fit.
+const maxHelpLines = 3
+
 // renderKeyBindings builds the bottom help bar, showing only the bindings
 // that are actually relevant to the currently focused pane (plus the
 // always-available global bindings), so it doesn't advertise keys that do
 // nothing in the current context. The result is wrapped to width without
 // ever splitting a single "key: description" entry across lines (see
-// packKeyBindings); width <= 0 leaves it as one unwrapped line.
+// packKeyBindings), and at most maxHelpLines long, leaving out the focused
+// pane's last entries to fit; width <= 0 leaves it as one unwrapped line.
 func renderKeyBindings(km keybindings.KeyMap, focus Focus, width int) string {
 	parts := []string{
 		fmt.Sprintf("%s: quit", strings.Join(km[keybindings.Quit], "/")),
@@ -161,32 +208,89 @@ func renderKeyBindings(km keybindings.KeyMap, focus Focus, width int) string {
 			fmt.Sprintf("%s: new filter", strings.Join(km[keybindings.NewFilter], "/")),
 			fmt.Sprintf("%s: delete filter", strings.Join(km[keybindings.DeleteFilter], "/")),
 			fmt.Sprintf("%s/%s: reorder", strings.Join(km[keybindings.MoveFilterUp], ","), strings.Join(km[keybindings.MoveFilterDown], ",")),
+			fmt.Sprintf("%s/%s: next/prev filter match", strings.Join(km[keybindings.NextFilterMatch], ","), strings.Join(km[keybindings.PrevFilterMatch], ",")),
+			fmt.Sprintf("%s: list filter matches", strings.Join(km[keybindings.ListMatches], "/")),
 		)
 	case LogFocus:
 		parts = append(parts,
 			fmt.Sprintf("%s: hide unmatched", strings.Join(km[keybindings.ToggleHideUnmatched], "/")),
+			fmt.Sprintf("%s/%s: scroll sideways", strings.Join(km[keybindings.CursorLeft], ","), strings.Join(km[keybindings.CursorRight], ",")),
+			fmt.Sprintf("%s: wrap lines", strings.Join(km[keybindings.ToggleWrap], "/")),
+			fmt.Sprintf("%s: line detail", displayKeys(km[keybindings.ToggleDetail], "/")),
+			fmt.Sprintf("%s: field columns", strings.Join(km[keybindings.ToggleColumns], "/")),
 			fmt.Sprintf("%s: search", strings.Join(km[keybindings.Search], "/")),
 			fmt.Sprintf("%s/%s: next/prev match", strings.Join(km[keybindings.SearchNext], ","), strings.Join(km[keybindings.SearchPrev], ",")),
+			fmt.Sprintf("%s: list matches", strings.Join(km[keybindings.ListMatches], "/")),
 			fmt.Sprintf("%s/%s: context lines", strings.Join(km[keybindings.IncreaseContext], ","), strings.Join(km[keybindings.DecreaseContext], ",")),
+			fmt.Sprintf("%s/%s: context before", strings.Join(km[keybindings.IncreaseContextBefore], ","), strings.Join(km[keybindings.DecreaseContextBefore], ",")),
+			fmt.Sprintf("%s/%s: context after", strings.Join(km[keybindings.IncreaseContextAfter], ","), strings.Join(km[keybindings.DecreaseContextAfter], ",")),
+			fmt.Sprintf("%s/%s: expand hidden lines (all/%d)", strings.Join(km[keybindings.ExpandHidden], ","), strings.Join(km[keybindings.ExpandHiddenStep], ","), ExpandHiddenStep),
+			fmt.Sprintf("%s: re-hide expanded", strings.Join(km[keybindings.HideRevealed], "/")),
+			fmt.Sprintf("%s: select lines", strings.Join(km[keybindings.SelectLines], "/")),
+			fmt.Sprintf("%s/%s: fold selection/unfold", strings.Join(km[keybindings.FoldSelection], ","), strings.Join(km[keybindings.Unfold], ",")),
+			fmt.Sprintf("%s: list folds", strings.Join(km[keybindings.ListFolds], "/")),
+			fmt.Sprintf("%s: copy selection", strings.Join(km[keybindings.CopySelection], "/")),
+			fmt.Sprintf("%s: pipe selection", strings.Join(km[keybindings.PipeSelection], "/")),
+			fmt.Sprintf("%s/%s: transform log/toggle original", strings.Join(km[keybindings.TransformLog], ","), strings.Join(km[keybindings.ToggleTransform], ",")),
 			fmt.Sprintf("%s/%s: jump top/bottom", strings.Join(km[keybindings.JumpToTop], ","), strings.Join(km[keybindings.JumpToBottom], ",")),
+			fmt.Sprintf("%s/%s: half page up/down", strings.Join(km[keybindings.HalfPageUp], ","), strings.Join(km[keybindings.HalfPageDown], ",")),
+			fmt.Sprintf("%s/%s: page up/down", strings.Join(km[keybindings.PageUp], ","), strings.Join(km[keybindings.PageDown], ",")),
+			fmt.Sprintf("%s: keep cursor centered", strings.Join(km[keybindings.ToggleCentered], "/")),
 			fmt.Sprintf("%s: jump to line", strings.Join(km[keybindings.JumpToLine], "/")),
+			fmt.Sprintf("%s: jump to time", strings.Join(km[keybindings.JumpToTime], "/")),
+			fmt.Sprintf("%s/%s: jump back/forward", strings.Join(km[keybindings.JumpBack], ","), strings.Join(km[keybindings.JumpForward], ",")),
+			fmt.Sprintf("%s: time range", strings.Join(km[keybindings.SetTimeRange], "/")),
+			fmt.Sprintf("%s/%s: next/prev gap or spike", strings.Join(km[keybindings.NextAnomaly], ","), strings.Join(km[keybindings.PrevAnomaly], ",")),
+			fmt.Sprintf("%s: match density", strings.Join(km[keybindings.ToggleDensity], "/")),
+			fmt.Sprintf("%s: minimap", strings.Join(km[keybindings.ToggleMinimap], "/")),
+			fmt.Sprintf("%s/%s: next/prev density bucket", strings.Join(km[keybindings.NextBucket], ","), strings.Join(km[keybindings.PrevBucket], ",")),
+			fmt.Sprintf("%s/%s: next/prev filter match", strings.Join(km[keybindings.NextFilterMatch], ","), strings.Join(km[keybindings.PrevFilterMatch], ",")),
+			fmt.Sprintf("%s-%s: next match of filter #", strings.Join(km[keybindings.JumpFilter1], ","), strings.Join(km[keybindings.JumpFilter9], ",")),
 		)
 	}
 
-	parts = append(parts,
-		fmt.Sprintf("%s: save filters", strings.Join(km[keybindings.SaveFilters], "/")),
-		fmt.Sprintf("%s: keybindings", strings.Join(km[keybindings.OpenKeybindingsScreen], "/")),
-		fmt.Sprintf("%s: hide help", strings.Join(km[keybindings.ToggleHelp], "/")),
-	)
+	tail := func(trimmed bool) []string {
+		keys := "keybindings"
+		if trimmed {
+			keys = "all keybindings"
+		}
+		return []string{
+			fmt.Sprintf("%s: save filters", strings.Join(km[keybindings.SaveFilters], "/")),
+			fmt.Sprintf("%s: save session", strings.Join(km[keybindings.SaveSession], "/")),
+			fmt.Sprintf("%s: %s", strings.Join(km[keybindings.OpenKeybindingsScreen], "/"), keys),
+			fmt.Sprintf("%s: command palette", strings.Join(km[keybindings.CommandPalette], "/")),
+			fmt.Sprintf("%s: hide help", strings.Join(km[keybindings.ToggleHelp], "/")),
+		}
+	}
 
-	return packKeyBindings(parts, width)
+	bar := packKeyBindings(append(slices.Clone(parts), tail(false)...), width)
+	if width <= 0 || strings.Count(bar, "\n") < maxHelpLines {
+		return bar
+	}
+	// Too many to show: leave out the pane's last entries, for "…" and the
+	// screens that list them all, until the rest fits.
+	for n := len(parts) - 1; n > 0; n-- {
+		trimmed := append(slices.Clone(parts[:n]), "…")
+		bar = packKeyBindings(append(trimmed, tail(true)...), width)
+		if strings.Count(bar, "\n") < maxHelpLines {
+			return bar
+		}
+	}
+	// A terminal too narrow for even that gets its first lines.
+	lines := strings.Split(bar, "\n")
+	return strings.Join(lines[:maxHelpLines], "\n")
 }
 
 // renderHelpHint is the collapsed form of the bottom help bar shown while
-// help is hidden: just enough to tell the user how to bring it back, so it
-// doesn't consume a full line of screen space by default.
+// help is hidden: just enough to tell the user how to bring it back, or to
+// find an action in the command palette instead, so it doesn't consume a
+// full line of screen space by default.
 func renderHelpHint(km keybindings.KeyMap) string {
-	return fmt.Sprintf("%s: show keybindings", strings.Join(km[keybindings.ToggleHelp], "/"))
+	hint := fmt.Sprintf("%s: show keybindings", strings.Join(km[keybindings.ToggleHelp], "/"))
+	if keys := km[keybindings.CommandPalette]; len(keys) > 0 {
+		hint += keyBindingSep + fmt.Sprintf("%s: command palette", displayKeys(keys, "/"))
+	}
+	return hint
 }
 
 // packKeyBindings joins parts with keyBindingSep, greedily wrapping onto a
@@ -234,17 +338,41 @@ type model struct {
 	focus         Focus // which view is currently in focus
 	windowWidth   int
 	windowHeight  int
-	hideUnmatched bool // whether lines are displayed that do not match an active filter
-	contextLines  int  // how many lines of context to show around a match when hideUnmatched is on
+	hideUnmatched bool            // whether lines are displayed that do not match an active filter
+	context       logview.Context // how many lines of context to show around a match when hideUnmatched is on
 	keyMap        keybindings.KeyMap
 	showHelp      bool // whether the full keybindings help bar is expanded
+	showDetail    bool // whether the Detail pane (see detailview) is shown below the Log pane
+	showDensity   bool // whether the density panel (see densityview) is shown below the Log pane
 
 	// Keybindings editor screen state
 	editingKeybindings bool
-	kbCursor           int  // which action row is selected
-	kbKeyCursor        int  // which of the selected action's keys is selected (left/right, "d" targets this one)
-	kbCapturing        bool // waiting for a keypress to bind to the selected action
-	kbAppending        bool // while kbCapturing: whether the captured key is added to the existing keys ("a") rather than replacing them ("enter")
+	kbCursor           int      // which action row is selected
+	kbKeyCursor        int      // which of the selected action's keys is selected (left/right, "d" targets this one)
+	kbCapturing        bool     // waiting for a keypress to bind to the selected action
+	kbAppending        bool     // while kbCapturing: whether the captured key is added to the existing keys ("a") rather than replacing them ("enter")
+	kbRecording        bool     // recording a key sequence to add to the selected action's keys ("s"), until enter
+	kbSequence         []string // while kbRecording: the keys recorded so far
+	kbNotice           string   // what the last binding conflicts with, if anything (see keyConflicts)
+
+	// Folds screen state (see folds.go)
+	listingFolds bool
+	foldCursor   int // which fold is selected
+
+	// Pipe prompt and output view state (see pipe.go), and where the
+	// clipboard's escape sequences are written (see clipboard.go).
+	piping        bool            // capturing a command to pipe the selected lines to
+	pipeInput     inputview.Input // the command typed so far
+	showingOutput bool            // whether the output view is open
+	output        outputViewState
+	terminal      io.Writer
+
+	// Transform prompt and state (see transform.go)
+	transformPrompt bool            // capturing the command to transform the log with
+	transformInput  inputview.Input // the command typed so far
+	transform       transformState
+
+	initCmd tea.Cmd // what Init starts with (see RunUI)
 
 	// Filter editor screen state (see filtereditor.go, colorpicker.go).
 	// Always edits m.filters.Filters[m.filters.Cursor].
@@ -252,17 +380,60 @@ type model struct {
 	filterEditor  filterEditorState
 
 	// Log search state
-	searching      bool          // capturing a search pattern from the user
-	searchText     string        // the pattern typed so far in the current input session
-	searchErr      string        // set if searchText failed to compile as a regex
-	lastSearch     regexp.Regexp // last successfully compiled search pattern
-	lastSearchText string        // raw text of lastSearch, for display (lastSearch.String() includes the (?i) prefix)
-	hasSearch      bool          // whether lastSearch is valid (n/N have something to jump to)
-
-	// Jump-to-line state
-	jumpingToLine bool   // capturing a 1-indexed line number from the user
-	jumpLineText  string // digits typed so far in the current input session
-	jumpLineErr   string // set if jumpLineText failed to parse as a line number
+	searching      bool            // capturing a search pattern from the user
+	searchInput    inputview.Input // the pattern typed so far in the current input session
+	searchErr      string          // set if searchInput failed to compile as a regex
+	lastSearch     regexp.Regexp   // last successfully compiled search pattern
+	lastSearchText string          // raw text of lastSearch, for display (lastSearch.String() includes the (?i) prefix)
+	hasSearch      bool            // whether lastSearch is valid (n/N have something to jump to)
+
+	// The options the prompt compiles with, toggled from it (see
+	// searchOptions), and the ones lastSearch was compiled with, which
+	// n/N search with (see findSearch) until another search is entered.
+	searchOptions     searchOptions
+	lastSearchOptions searchOptions
+
+	// Incremental search (see previewSearch): where the cursor was when the
+	// prompt opened, searchInput compiled as it's typed (nil while it's
+	// empty or doesn't compile), and how many lines that matches.
+	searchOrigin  int
+	searchPreview *regexp.Regexp
+	searchMatches int
+
+	// Results list state (see results.go)
+	listingResults bool
+	results        resultsState
+
+	// Where the Log pane's cursor was before its big moves (see jumplist.go)
+	jumps jumpList
+
+	// A count being typed before an action in the Log pane (see count.go)
+	count countState
+
+	// A key sequence being typed, waiting for its next key (see keyseq.go)
+	keys keySeqState
+
+	// Command palette state (see palette.go)
+	showingPalette bool
+	palette        paletteState
+
+	// Prompt history (see history.go): every prompt's earlier entries, and
+	// where the open prompt is in its own.
+	history history.History
+	recall  historyRecall
+
+	// Command line state (see excommand.go). It began as a jump-to-line
+	// prompt, and a line number is still a command.
+	jumpingToLine bool            // capturing a command line from the user
+	jumpLineInput inputview.Input // the command typed so far in the current input session
+	jumpLineErr   string          // set if the command in jumpLineInput failed
+	completions   []string        // what the last tab could have completed to, if more than one
+	sourcing      []os.FileInfo   // the startup files being run, innermost last (see runSource)
+
+	// Time prompt state (see timeprompt.go)
+	timePrompt timePromptKind  // which time prompt is open, if any
+	timeInput  inputview.Input // the time or range typed so far
+	timeErr    string          // set if timeInput failed to parse or apply
 
 	// Filter persistence state
 	filterFilePath string                               // where SaveFilters writes to
@@ -270,6 +441,13 @@ type model struct {
 	filtersDirty   bool                                 // whether filters have changed since the last save
 	saveStatus     string                               // last save attempt's outcome, shown in the status line
 
+	// Session state (see the session package): logPath is recorded in saved
+	// sessions so they know which log to reopen, and sessionPath is where
+	// SaveSession writes -- the -session file skim was launched with, or
+	// one derived from logPath (see sessionSavePath) if there wasn't one.
+	logPath     string
+	sessionPath string
+
 	// startupWarning summarizes any filters that were disabled at load time
 	// because their regex failed to compile (see filterfiles.
 	// CompileFilterRegularExpressions), so that's visible in the running UI
@@ -326,60 +504,97 @@ func initialModel(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFi
 	if err != nil {
 		keyMap = keybindings.Defaults()
 	}
+	promptHistory, _ := history.Load() // an empty history if it can't be read
+
+	columns := columnsFromSettings(fileMeta)
 
-	return model{
+	m := model{
 		filters: filterview.FilterView{
 			Filters: filters,
 			Cursor:  0,
 		},
 		log: &logview.LogView{
-			Lines:  lines,
-			Cursor: 0,
+			Lines:          lines,
+			Cursor:         0,
+			Columns:        columns,
+			Structured:     len(columns) > 0, // configured columns start shown
+			TimeParser:     timestamps.Parser{Layout: fileMeta.TimeFormat},
+			GapThreshold:   DefaultGapThreshold,
+			SpikeThreshold: DefaultSpikeThreshold,
+			Minimap:        true,
+			MarkHidden:     true,
 		},
 		focus:          LogFocus,
 		hideUnmatched:  filterfiles.HideUnmatchedByDefault(fileMeta),
 		keyMap:         keyMap,
+		history:        promptHistory,
 		filterFilePath: filterFilePath,
 		fileMeta:       fileMeta,
 		startupWarning: startupWarningSummary(warnings),
+		terminal:       os.Stdout,
 	}
+	if err := m.setTimelineThresholds(fileMeta.GapThreshold, fileMeta.SpikeThreshold); err != nil {
+		m.saveStatus = fmt.Sprintf("filter file: %v", err)
+	}
+	return m
 }
 
 // Now we'll define the Init method.
-// Init can return a Cmd that might perform some initial I/O.
-// For now, we don't need to do any I/O, so we'll return nil meaning "no command".
+// Init can return a Cmd that might perform some initial I/O: here, the
+// -transform command's run over the log, if there is one (see RunUI).
 func (m model) Init() tea.Cmd {
-	return nil
+	return m.initCmd
 }
 
 // updateKeybindingsScreen handles key presses while the keybindings editor
 // screen is open: it is either browsing the action list (and, within the
-// selected action's keys, which one is targeted by "d"), or capturing the
+// selected action's keys, which one is targeted by "d"), capturing the
 // next keypress to bind to the selected action -- replacing all of its
-// existing keys ("enter") or adding to them ("a").
+// existing keys ("enter") or adding to them ("a") -- or recording a key
+// sequence to add to them ("s"), a key at a time until enter. A new
+// binding already bound to another action in the same scope is refused
+// (see keyTaken); one that clashes with another some other way (see
+// keyConflicts) is kept, with a notice saying what it clashes with.
 func (m model) updateKeybindingsScreen(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.kbRecording {
+		switch key := msg.String(); key {
+		case "esc":
+			m.kbRecording = false
+		case "enter":
+			m.kbRecording = false
+			if len(m.kbSequence) > 0 {
+				m.addBinding(formatKeys(m.kbSequence))
+			}
+		default:
+			m.kbSequence = append(m.kbSequence, key)
+		}
+		return m, nil
+	}
+
 	if m.kbCapturing {
 		key := msg.String()
 		if key != "esc" {
 			action := keybindings.Registry[m.kbCursor].Action
-			if m.kbAppending {
-				if !containsKey(m.keyMap[action], key) {
-					m.keyMap[action] = append(m.keyMap[action], key)
-					m.kbKeyCursor = len(m.keyMap[action]) - 1
-				}
-			} else {
+			switch {
+			case m.kbAppending:
+				m.addBinding(key)
+			case m.bindingTaken(action, key):
+				// Refused, with a notice saying why.
+			default:
 				m.keyMap[action] = []string{key}
 				m.kbKeyCursor = 0
+				m.kbNotice = conflictNotice(m.keyMap, action, key)
+				// Best-effort: if we can't persist, the rebinding still applies
+				// for the rest of this session.
+				keybindings.Save(m.keyMap)
 			}
-			// Best-effort: if we can't persist, the rebinding still applies
-			// for the rest of this session.
-			keybindings.Save(m.keyMap)
 		}
 		m.kbCapturing = false
 		m.kbAppending = false
 		return m, nil
 	}
 
+	m.kbNotice = ""
 	switch msg.String() {
 	case "esc", "q":
 		m.editingKeybindings = false
@@ -415,6 +630,10 @@ func (m model) updateKeybindingsScreen(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.kbCapturing = true
 		m.kbAppending = true
 
+	case "s":
+		m.kbRecording = true
+		m.kbSequence = nil
+
 	case "d":
 		action := keybindings.Registry[m.kbCursor].Action
 		keys := m.keyMap[action]
@@ -435,6 +654,42 @@ func (m model) updateKeybindingsScreen(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// addBinding adds binding to the selected action's keys, unless it's
+// already one of them or taken by another action (see bindingTaken), and
+// selects it.
+func (m *model) addBinding(binding string) {
+	action := keybindings.Registry[m.kbCursor].Action
+	if containsKey(m.keyMap[action], binding) || m.bindingTaken(action, binding) {
+		return
+	}
+	m.keyMap[action] = append(m.keyMap[action], binding)
+	m.kbKeyCursor = len(m.keyMap[action]) - 1
+	m.kbNotice = conflictNotice(m.keyMap, action, binding)
+	// Best-effort: if we can't persist, the new binding still applies for
+	// the rest of this session.
+	keybindings.Save(m.keyMap)
+}
+
+// bindingTaken reports whether binding is already bound to another action
+// in action's scope (see keyTaken), saying which in the editor's notice.
+func (m *model) bindingTaken(action keybindings.Action, binding string) bool {
+	other, taken := keyTaken(m.keyMap, action, binding)
+	if taken {
+		m.kbNotice = fmt.Sprintf("%s is already bound to %q: unbind it there first", displayKey(binding), other.Description)
+	}
+	return taken
+}
+
+// conflictNotice sums up keyConflicts for the keybindings editor, "" if
+// there are none.
+func conflictNotice(km keybindings.KeyMap, action keybindings.Action, binding string) string {
+	conflicts := keyConflicts(km, action, binding)
+	if len(conflicts) == 0 {
+		return ""
+	}
+	return "note: " + strings.Join(conflicts, "; ")
+}
+
 // containsKey reports whether key is already among keys, so appending a
 // binding a second time is a no-op instead of creating a duplicate entry.
 func containsKey(keys []string, key string) bool {
@@ -447,102 +702,114 @@ func containsKey(keys []string, key string) bool {
 }
 
 // updateSearchInput handles key presses while a search pattern is being
-// typed (after pressing "/" in the Log pane): every printable rune is
-// appended to searchText, backspace removes the last one, esc cancels, and
-// enter compiles the pattern and jumps to its first match after the cursor.
+// typed (after pressing "/" in the Log pane): esc cancels, putting the
+// cursor back where it was, enter compiles the pattern and jumps to its
+// first match after the cursor, and anything else edits the pattern (see
+// inputview.Input.Update). In between, each edit previews the pattern's
+// first match (see previewSearch), as does recalling an earlier search
+// (up/down, ctrl+r; see recallHistory). alt+c, alt+l, alt+w and alt+v
+// toggle the search options (see searchOptions), previewing again with
+// them.
 func (m model) updateSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searchOptions.toggle(msg.String()) {
+		m.previewSearch()
+		return m, nil
+	}
+	before := m.searchInput.Value()
+	if m.recallHistory(msg, &m.searchInput) {
+		if m.searchInput.Value() != before {
+			m.previewSearch()
+		}
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "esc":
 		m.searching = false
-		m.searchText = ""
+		m.searchInput.Reset()
 		m.searchErr = ""
+		m.searchPreview = nil
+		m.log.Cursor = m.searchOrigin
+		return m, nil
 
 	case "enter":
-		if m.searchText == "" {
+		text := m.searchInput.Value()
+		m.searchPreview = nil
+		m.log.Cursor = m.searchOrigin
+		if text == "" {
 			m.searching = false
-			break
+			return m, nil
 		}
-		re, err := filterfiles.CompileRegex(m.searchText, false)
+		re, err := m.searchOptions.compile(text)
 		if err != nil {
 			// Stay in searching mode so the error actually renders (see
 			// renderSearchPrompt, only shown while m.searching), and leave
 			// any previously-successful search untouched so n/N still work
 			// with it after a failed retry.
 			m.searchErr = err.Error()
-			break
+			return m, nil
 		}
 		m.searching = false
 		m.searchErr = ""
 		m.lastSearch = re
-		m.lastSearchText = m.searchText
+		m.lastSearchText = text
+		m.lastSearchOptions = m.searchOptions
 		m.hasSearch = true
-		if idx, ok := m.log.FindNext(m.lastSearch); ok {
-			m.log.Cursor = idx
-		}
-
-	case "backspace":
-		if len(m.searchText) > 0 {
-			r := []rune(m.searchText)
-			m.searchText = string(r[:len(r)-1])
-		}
-
-	case " ":
-		m.searchText += " "
-
-	default:
-		if len(msg.Runes) > 0 {
-			m.searchText += string(msg.Runes)
+		m.remember(history.Search, text)
+		if idx, ok := m.findSearch(false); ok {
+			m.jumpTo(idx)
 		}
+		return m, nil
 	}
 
+	if m.searchInput.Update(msg) && m.searchInput.Value() != before {
+		m.previewSearch()
+	}
 	return m, nil
 }
 
-// updateJumpLineInput handles key presses while a target line number is
-// being typed (after pressing ":" in the Log pane): digits are appended to
-// jumpLineText, backspace removes the last one, esc cancels, and enter
-// parses the number and moves the log cursor to it (1-indexed, clamped to
-// the log's bounds).
+// updateJumpLineInput handles key presses while a command is being typed
+// (after pressing ":" in the Log pane): esc cancels, enter runs it (see
+// runCommand), leaving the prompt open with the error if it fails, tab
+// completes it (see completeCommand), and anything else edits it. up/down
+// and ctrl+r recall earlier commands (see recallHistory).
 func (m model) updateJumpLineInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.recallHistory(msg, &m.jumpLineInput) {
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "esc":
 		m.jumpingToLine = false
-		m.jumpLineText = ""
+		m.jumpLineInput.Reset()
 		m.jumpLineErr = ""
+		m.completions = nil
 
 	case "enter":
-		if m.jumpLineText == "" {
+		text := m.jumpLineInput.Value()
+		m.completions = nil
+		if strings.TrimSpace(text) == "" {
 			m.jumpingToLine = false
 			break
 		}
-		n, err := strconv.Atoi(m.jumpLineText)
+		cmd, err := m.runCommand(text)
 		if err != nil {
-			m.jumpLineErr = "not a number"
+			m.jumpLineErr = err.Error()
 			break
 		}
 		m.jumpingToLine = false
 		m.jumpLineErr = ""
-		target := n - 1
-		if target < 0 {
-			target = 0
-		}
-		if max := m.log.GetMaxCursor(); target > max {
-			target = max
-		}
-		if target >= 0 {
-			m.log.Cursor = target
-		}
+		m.remember(history.JumpLine, text)
+		return m, cmd
 
-	case "backspace":
-		if len(m.jumpLineText) > 0 {
-			m.jumpLineText = m.jumpLineText[:len(m.jumpLineText)-1]
-		}
+	case "tab":
+		m.jumpLineErr = ""
+		m.completeCommand()
 
 	default:
-		for _, r := range msg.Runes {
-			if r >= '0' && r <= '9' {
-				m.jumpLineText += string(r)
-			}
+		if m.jumpLineInput.Update(msg) {
+			m.jumpLineErr = ""
+			m.completions = nil
 		}
 	}
 
@@ -571,149 +838,59 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateFilterEditor(msg)
 		}
 
-		if m.searching {
-			return m.updateSearchInput(msg)
+		if m.listingFolds {
+			return m.updateFoldsScreen(msg)
 		}
 
-		if m.jumpingToLine {
-			return m.updateJumpLineInput(msg)
+		if m.showingOutput {
+			return m.updateOutputView(msg)
 		}
 
-		var view TableView
-
-		if m.focus == LogFocus {
-			view = m.log
-		} else if m.focus == FilterFocus {
-			view = &m.filters
+		if m.showingPalette {
+			return m.updatePalette(msg)
 		}
 
-		action, ok := resolveAction(m.keyMap, activeScopes(m.focus), msg.String())
-		if !ok {
-			break
+		if m.listingResults {
+			return m.updateResults(msg)
 		}
 
-		switch action {
-		case keybindings.Quit:
-			return m, tea.Quit
-
-		case keybindings.CursorUp:
-			view.CursorUp()
-
-		case keybindings.CursorDown:
-			view.CursorDown()
-
-		case keybindings.CursorLeft:
-			view.CursorLeft()
-
-		case keybindings.CursorRight:
-			view.CursorRight()
-
-		case keybindings.Toggle:
-			// Toggles the selected state for the item under the cursor.
-			// FilterView.Toggle is a no-op against an empty filter list
-			// (reachable via d), which shouldn't be reported as a change.
-			view.Toggle()
-			if m.focus == FilterFocus && len(m.filters.Filters) > 0 {
-				m.filtersDirty = true
-				m.saveStatus = ""
-			}
-
-		case keybindings.SwitchFocus:
-			m.focus += 1
-			m.focus %= MaxFocus
-
-		case keybindings.ToggleHideUnmatched:
-			m.hideUnmatched = !m.hideUnmatched
-
-		case keybindings.EditRegex:
-			if len(m.filters.Filters) > 0 {
-				m.editingFilter = true
-				m.filterEditor = filterEditorState{}
-			}
-
-		case keybindings.OpenKeybindingsScreen:
-			m.editingKeybindings = true
-			m.kbCursor = 0
-			m.kbKeyCursor = 0
-			m.kbCapturing = false
-			m.kbAppending = false
-
-		case keybindings.Search:
-			m.searching = true
-			m.searchText = ""
-			m.searchErr = ""
-
-		case keybindings.SearchNext:
-			if m.hasSearch {
-				if idx, ok := m.log.FindNext(m.lastSearch); ok {
-					m.log.Cursor = idx
-				}
-			}
-
-		case keybindings.SearchPrev:
-			if m.hasSearch {
-				if idx, ok := m.log.FindPrev(m.lastSearch); ok {
-					m.log.Cursor = idx
-				}
-			}
-
-		case keybindings.NewFilter:
-			m.filters.Add()
-			m.filtersDirty = true
-			m.saveStatus = ""
-			m.editingFilter = true
-			m.filterEditor = filterEditorState{}
-
-		case keybindings.DeleteFilter:
-			if len(m.filters.Filters) > 0 {
-				m.filters.Delete()
-				m.filtersDirty = true
-				m.saveStatus = ""
-			}
+		if m.piping {
+			return m.updatePipeInput(msg)
+		}
 
-		case keybindings.MoveFilterUp:
-			if m.filters.MoveUp() {
-				m.filtersDirty = true
-				m.saveStatus = ""
-			}
+		if m.transformPrompt {
+			return m.updateTransformInput(msg)
+		}
 
-		case keybindings.MoveFilterDown:
-			if m.filters.MoveDown() {
-				m.filtersDirty = true
-				m.saveStatus = ""
-			}
+		if m.searching {
+			return m.updateSearchInput(msg)
+		}
 
-		case keybindings.SaveFilters:
-			if err := filterfiles.WriteFilterFile(m.filterFilePath, m.fileMeta, m.filters.Filters); err != nil {
-				m.saveStatus = fmt.Sprintf("save failed: %v", err)
-			} else {
-				m.saveStatus = fmt.Sprintf("saved to %s", m.filterFilePath)
-				m.filtersDirty = false
-			}
+		if m.jumpingToLine {
+			return m.updateJumpLineInput(msg)
+		}
 
-		case keybindings.IncreaseContext:
-			m.contextLines++
+		if m.timePrompt != timePromptNone {
+			return m.updateTimeInput(msg)
+		}
 
-		case keybindings.DecreaseContext:
-			if m.contextLines > 0 {
-				m.contextLines--
+		if m.focus == LogFocus && len(m.keys.pending) == 0 && m.readCount(msg.String()) {
+			if m.heldDigit() {
+				return m, m.count.timeout()
 			}
+			return m, nil
+		}
+		return m.readKey(msg)
 
-		case keybindings.ToggleHelp:
-			m.showHelp = !m.showHelp
-
-		case keybindings.JumpToTop:
-			m.log.Cursor = 0
-
-		case keybindings.JumpToBottom:
-			if max := m.log.GetMaxCursor(); max >= 0 {
-				m.log.Cursor = max
-			}
+	case countTimeoutMsg:
+		// A count before a key sequence waits for the sequence to finish.
+		if msg.seq == m.count.seq && m.heldDigit() && len(m.keys.pending) == 0 {
+			return m.flushCount()
+		}
 
-		case keybindings.JumpToLine:
-			m.jumpingToLine = true
-			m.jumpLineText = ""
-			m.jumpLineErr = ""
+	case keySeqTimeoutMsg:
+		if msg.seq == m.keys.seq && len(m.keys.pending) > 0 {
+			return m.finishKeys()
 		}
 
 	case tea.MouseMsg:
@@ -727,7 +904,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Scrolling while a modal input (keybindings editor or search) is
 		// capturing keystrokes has no sensible target, so ignore it rather
 		// than silently moving a cursor the user can't currently see move.
-		if m.editingKeybindings || m.searching {
+		if m.editingKeybindings || m.listingFolds || m.showingOutput || m.listingResults || m.showingPalette || m.searching || m.piping || m.transformPrompt || m.timePrompt != timePromptNone {
+			break
+		}
+
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			// The Log pane is the frame's top block, so its minimap's
+			// coordinates are the frame's.
+			if k, ok := m.log.MinimapCell(msg.X, msg.Y, m.windowWidth); ok {
+				if line, ok := m.log.MinimapLine(k); ok {
+					m.log.Cursor = m.log.ShownAtOrAfter(line)
+				}
+			} else if m.layout(m.renderFooter()).showDensity {
+				m.densityClick(msg)
+			}
 			break
 		}
 
@@ -745,6 +935,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			view.CursorDown()
 		}
 
+	case clipboardMsg:
+		m.saveStatus = clipboardStatus(msg)
+
+	case pipeResultMsg:
+		m.openOutputView(msg)
+
+	case transformResultMsg:
+		m.applyTransform(msg)
+
+	case resultsScanMsg:
+		return m, m.addResults(msg)
+
 	case filterFieldEditorFinishedMsg:
 		if msg.tempFile != "" {
 			defer os.Remove(msg.tempFile)
@@ -752,7 +954,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err == nil {
 			content, err := os.ReadFile(msg.tempFile)
 			if err == nil {
-				m.filterEditor.textBuf = strings.TrimRight(string(content), "\n")
+				m.filterEditor.input.SetValue(strings.TrimRight(string(content), "\n"))
 				m.commitFilterEditorTextField()
 			}
 		}
@@ -774,6 +976,307 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// runAction does what action does when its key is pressed. count is a
+// count typed before it (see readCount), or 0 if none was: the actions in
+// countedActions repeat that many times, or for g/G, go to that line.
+func (m model) runAction(action keybindings.Action, count int) (tea.Model, tea.Cmd) {
+	var view TableView
+
+	if m.focus == LogFocus {
+		view = m.log
+	} else if m.focus == FilterFocus {
+		view = &m.filters
+	}
+	times := max(count, 1)
+
+	switch action {
+	case keybindings.Quit:
+		return m, tea.Quit
+
+	case keybindings.CursorUp:
+		for range times {
+			view.CursorUp()
+		}
+
+	case keybindings.CursorDown:
+		for range times {
+			view.CursorDown()
+		}
+
+	case keybindings.CursorLeft:
+		for range times {
+			view.CursorLeft()
+		}
+
+	case keybindings.CursorRight:
+		for range times {
+			view.CursorRight()
+		}
+
+	case keybindings.HalfPageUp:
+		m.log.MoveShown(-times * max(m.log.PageHeight()/2, 1))
+
+	case keybindings.HalfPageDown:
+		m.log.MoveShown(times * max(m.log.PageHeight()/2, 1))
+
+	case keybindings.PageUp:
+		m.log.MoveShown(-times * m.log.PageHeight())
+
+	case keybindings.PageDown:
+		m.log.MoveShown(times * m.log.PageHeight())
+
+	case keybindings.ToggleCentered:
+		m.log.Centered = !m.log.Centered
+
+	case keybindings.CommandPalette:
+		m.openPalette()
+
+	case keybindings.Toggle:
+		// Toggles the selected state for the item under the cursor.
+		// FilterView.Toggle is a no-op against an empty filter list
+		// (reachable via d), which shouldn't be reported as a change.
+		view.Toggle()
+		if m.focus == FilterFocus && len(m.filters.Filters) > 0 {
+			m.filtersDirty = true
+			m.saveStatus = ""
+		}
+
+	case keybindings.SwitchFocus:
+		m.focus += 1
+		m.focus %= MaxFocus
+
+	case keybindings.ToggleHideUnmatched:
+		m.hideUnmatched = !m.hideUnmatched
+
+	case keybindings.ToggleWrap:
+		m.log.Wrap = !m.log.Wrap
+
+	case keybindings.ToggleDetail:
+		m.showDetail = !m.showDetail
+
+	case keybindings.ToggleColumns:
+		m.toggleColumns()
+
+	case keybindings.EditRegex:
+		if len(m.filters.Filters) > 0 {
+			m.editingFilter = true
+			m.filterEditor = filterEditorState{}
+		}
+
+	case keybindings.OpenKeybindingsScreen:
+		m.editingKeybindings = true
+		m.kbCursor = 0
+		m.kbKeyCursor = 0
+		m.kbCapturing = false
+		m.kbAppending = false
+
+	case keybindings.Search:
+		m.searching = true
+		m.searchInput = inputview.Input{}
+		m.searchErr = ""
+		m.searchPreview = nil
+		m.searchOrigin = m.log.Cursor
+		m.startRecall(history.Search)
+
+	case keybindings.SearchNext:
+		if m.hasSearch {
+			if idx, ok := m.findRepeated(times, func() (int, bool) { return m.findSearch(false) }); ok {
+				m.jumpTo(idx)
+			}
+		}
+
+	case keybindings.SearchPrev:
+		if m.hasSearch {
+			if idx, ok := m.findRepeated(times, func() (int, bool) { return m.findSearch(true) }); ok {
+				m.jumpTo(idx)
+			}
+		}
+
+	case keybindings.NewFilter:
+		m.filters.Add()
+		m.filtersDirty = true
+		m.saveStatus = ""
+		m.editingFilter = true
+		m.filterEditor = filterEditorState{}
+
+	case keybindings.DeleteFilter:
+		if len(m.filters.Filters) > 0 {
+			m.filters.Delete()
+			m.filtersDirty = true
+			m.saveStatus = ""
+		}
+
+	case keybindings.MoveFilterUp:
+		if m.filters.MoveUp() {
+			m.filtersDirty = true
+			m.saveStatus = ""
+		}
+
+	case keybindings.MoveFilterDown:
+		if m.filters.MoveDown() {
+			m.filtersDirty = true
+			m.saveStatus = ""
+		}
+
+	case keybindings.SaveFilters:
+		if err := filterfiles.WriteFilterFile(m.filterFilePath, m.fileMeta, m.filters.Filters); err != nil {
+			m.saveStatus = fmt.Sprintf("save failed: %v", err)
+		} else {
+			m.saveStatus = fmt.Sprintf("saved to %s", m.filterFilePath)
+			m.filtersDirty = false
+		}
+
+	case keybindings.SaveSession:
+		path := m.sessionSavePath()
+		if err := session.Write(path, m.sessionSnapshot()); err != nil {
+			m.saveStatus = fmt.Sprintf("session save failed: %v", err)
+		} else {
+			m.saveStatus = fmt.Sprintf("session saved to %s", path)
+			m.sessionPath = path
+		}
+
+	case keybindings.NextFilterMatch:
+		if idx, ok := m.log.FindNextFilterMatch(m.filters.Filters, m.filters.Cursor); ok {
+			m.jumpTo(idx)
+		}
+
+	case keybindings.PrevFilterMatch:
+		if idx, ok := m.log.FindPrevFilterMatch(m.filters.Filters, m.filters.Cursor); ok {
+			m.jumpTo(idx)
+		}
+
+	case keybindings.JumpFilter1, keybindings.JumpFilter2, keybindings.JumpFilter3,
+		keybindings.JumpFilter4, keybindings.JumpFilter5, keybindings.JumpFilter6,
+		keybindings.JumpFilter7, keybindings.JumpFilter8, keybindings.JumpFilter9:
+		// Select the targeted filter too, so a follow-up next/prev
+		// filter match keeps stepping through the same filter's hits.
+		if n := jumpFilterIndex(action); n < len(m.filters.Filters) {
+			m.filters.Cursor = n
+			if idx, ok := m.log.FindNextFilterMatch(m.filters.Filters, n); ok {
+				m.jumpTo(idx)
+			}
+		}
+
+	case keybindings.IncreaseContext:
+		m.context.Before++
+		m.context.After++
+
+	case keybindings.DecreaseContext:
+		m.context.Before = max(m.context.Before-1, 0)
+		m.context.After = max(m.context.After-1, 0)
+
+	case keybindings.IncreaseContextBefore:
+		m.context.Before++
+
+	case keybindings.DecreaseContextBefore:
+		m.context.Before = max(m.context.Before-1, 0)
+
+	case keybindings.IncreaseContextAfter:
+		m.context.After++
+
+	case keybindings.DecreaseContextAfter:
+		m.context.After = max(m.context.After-1, 0)
+
+	case keybindings.ToggleHelp:
+		m.showHelp = !m.showHelp
+
+	case keybindings.JumpToTop, keybindings.JumpToBottom:
+		target := 0
+		switch {
+		case count > 0:
+			target = count - 1
+		case action == keybindings.JumpToBottom:
+			target = m.log.GetMaxCursor()
+		}
+		if last := m.log.GetMaxCursor(); last >= 0 {
+			m.jumpTo(clampIndex(target, last))
+		}
+
+	case keybindings.JumpBack:
+		for range times {
+			m.jumpBack()
+		}
+
+	case keybindings.JumpForward:
+		for range times {
+			m.jumpForward()
+		}
+
+	case keybindings.JumpToLine:
+		m.jumpingToLine = true
+		m.jumpLineInput = inputview.Input{}
+		m.jumpLineErr = ""
+		m.completions = nil
+		m.startRecall(history.JumpLine)
+
+	case keybindings.JumpToTime:
+		m.openTimePrompt(timePromptJump)
+
+	case keybindings.SetTimeRange:
+		m.openTimePrompt(timePromptRange)
+
+	case keybindings.NextAnomaly:
+		m.jumpToAnomaly(false)
+
+	case keybindings.PrevAnomaly:
+		m.jumpToAnomaly(true)
+
+	case keybindings.ToggleDensity:
+		m.showDensity = !m.showDensity
+
+	case keybindings.ToggleMinimap:
+		m.log.Minimap = !m.log.Minimap
+
+	case keybindings.ExpandHidden:
+		m.expandHidden(false)
+
+	case keybindings.ExpandHiddenStep:
+		m.expandHidden(true)
+
+	case keybindings.HideRevealed:
+		m.hideRevealed()
+
+	case keybindings.SelectLines:
+		m.toggleSelection()
+
+	case keybindings.ClearSelection:
+		if m.log.ClearSelection() {
+			m.saveStatus = ""
+		}
+
+	case keybindings.FoldSelection:
+		m.foldSelection()
+
+	case keybindings.Unfold:
+		m.unfold()
+
+	case keybindings.ListFolds:
+		m.openFoldsList()
+
+	case keybindings.CopySelection:
+		return m, m.copySelection()
+
+	case keybindings.PipeSelection:
+		m.openPipePrompt()
+
+	case keybindings.TransformLog:
+		m.openTransformPrompt()
+
+	case keybindings.ToggleTransform:
+		m.toggleTransform()
+
+	case keybindings.ListMatches:
+		return m, m.openResults()
+
+	case keybindings.NextBucket:
+		m.stepBucket(false)
+
+	case keybindings.PrevBucket:
+		m.stepBucket(true)
+	}
+	return m, nil
+}
+
 // renderKeybindingsScreen renders the full-screen keybindings editor: every
 // rebindable action, its current key(s), and the row under the cursor. The
 // selected row shows each key as its own cell, braced if it's the one
@@ -782,7 +1285,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) renderKeybindingsScreen() string {
 	var b strings.Builder
 
-	b.WriteString("Keybindings  —  up/down: select action   left/right: select key   enter: replace all   a: add key   d: delete key   esc/q: close\n\n")
+	b.WriteString("Keybindings  —  up/down: select action   left/right: select key   enter: replace all   a: add key   s: add key sequence   d: delete key   esc/q: close\n")
+	if m.kbNotice != "" {
+		b.WriteString(m.kbNotice)
+	}
+	b.WriteString("\n")
 
 	for i, spec := range keybindings.Registry {
 		cursor := "  "
@@ -794,6 +1301,8 @@ func (m model) renderKeybindingsScreen() string {
 		switch {
 		case m.kbCapturing && i == m.kbCursor:
 			keys = "press a key..."
+		case m.kbRecording && i == m.kbCursor:
+			keys = "press keys, then enter: " + displayKey(formatKeys(m.kbSequence))
 		case i == m.kbCursor:
 			keys = renderKeyCells(m.keyMap[spec.Action], m.kbKeyCursor)
 		default:
@@ -841,18 +1350,92 @@ func (m model) View() string {
 		return m.renderFilterEditor()
 	}
 
-	var footer string
+	if m.listingFolds {
+		return m.renderFoldsScreen()
+	}
+
+	if m.showingOutput {
+		return m.renderOutputView()
+	}
+
+	footer := m.renderFooter()
+	layout := m.layout(footer)
+
+	// Make table of filtered log lines
+	m.log.Highlight = m.searchHighlight()
+	m.log.MakeTable(m.windowWidth, layout.tableHeight, m.filters.Filters, m.hideUnmatched, m.context)
+	blocks := []string{m.paneStyle(LogFocus).Render(m.log.View())}
+
+	if layout.showDensity {
+		blocks = append(blocks, baseStyle.Render(m.renderDensity()))
+	}
+	if layout.showDetail {
+		blocks = append(blocks, baseStyle.Render(m.renderDetail(layout.detailHeight)))
+	}
+
+	switch {
+	case m.showingPalette:
+		blocks = append(blocks, m.renderPalette())
+	case m.listingResults:
+		blocks = append(blocks, m.renderResults())
+	default:
+		counts := m.log.MatchCounts(m.filters.Filters)
+		blocks = append(blocks, m.paneStyle(FilterFocus).Render(m.filters.Render(m.windowWidth, layout.tableHeight, counts)))
+	}
+	blocks = append(blocks, renderStatusLine(m), footer)
+
+	// Joined with "\n" rather than each piece getting its own trailing
+	// "\n" (which would add a blank line after the footer that Bubble
+	// Tea's line-count-based height check counts as real screen real
+	// estate): however many panes are showing -- the density panel and
+	// Detail pane come and go, and the results list or palette can take
+	// the Filters pane's place -- a trailing "\n" on the last block is one
+	// separator too many, pushing the total past windowHeight by exactly
+	// one line. Once the rendered
+	// frame is taller than the terminal, Bubble Tea has to drop/shift
+	// lines it can't scroll back to, permanently desyncing its
+	// line-by-line diff -- which shows up as some rows silently no
+	// longer updating on cursor movement, even though View() itself is
+	// computing the right content every time.
+	s := strings.Join(blocks, "\n")
+
+	// Send the UI for rendering
+	return s
+}
+
+// renderFooter renders the line under the status line: whichever prompt is
+// open, or else the help bar.
+func (m model) renderFooter() string {
 	switch {
+	case m.showingPalette:
+		return renderPalettePrompt(m)
 	case m.searching:
-		footer = renderSearchPrompt(m)
+		return renderSearchPrompt(m)
 	case m.jumpingToLine:
-		footer = renderJumpLinePrompt(m)
+		return renderJumpLinePrompt(m)
+	case m.piping:
+		return renderPipePrompt(m)
+	case m.transformPrompt:
+		return renderTransformPrompt(m)
+	case m.timePrompt != timePromptNone:
+		return renderTimePrompt(m)
 	case m.showHelp:
-		footer = renderKeyBindings(m.keyMap, m.focus, m.windowWidth)
+		return renderKeyBindings(m.keyMap, m.focus, m.windowWidth)
 	default:
-		footer = renderHelpHint(m.keyMap)
+		return renderHelpHint(m.keyMap)
 	}
+}
 
+// paneLayout is how View divides the window's height between the panes.
+type paneLayout struct {
+	tableHeight  int // the windowHeight passed to logview.MakeTable
+	showDensity  bool
+	showDetail   bool
+	detailHeight int
+}
+
+// layout works out View's paneLayout for a frame whose footer is footer.
+func (m model) layout(footer string) paneLayout {
 	// logview's height budget assumes a single-line footer. When the
 	// footer wraps onto more lines than that -- e.g. the expanded
 	// keybindings help bar on a narrower terminal -- account for the extra
@@ -873,36 +1456,87 @@ func (m model) View() string {
 	// filter pane by a row that logview's budget never shrank to
 	// compensate for, overflowing the frame by one line).
 	filterPaneLines := (1 + filterview.VisibleHeight) + baseStyle.GetVerticalFrameSize()
-	tableHeight := m.windowHeight - footerExtraLines - filterPaneLines
-
-	// Make table of filtered log lines
-	m.log.MakeTable(m.windowWidth, tableHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
-	logBlock := m.paneStyle(LogFocus).Render(m.log.Table.View())
+	if m.listingResults || m.showingPalette {
+		// The results list and the command palette take the Filters
+		// pane's place (see renderResults, renderPalette), at their own
+		// constant height.
+		filterPaneLines = (1 + m.resultsHeight()) + focusedStyle.GetVerticalFrameSize()
+	}
+	l := paneLayout{tableHeight: m.windowHeight - footerExtraLines - filterPaneLines}
+
+	// The density panel is a constant height too (see densityview.Height),
+	// and is dropped on a short terminal the same way as the Detail pane
+	// below; it's given the room first, being the smaller of the two.
+	densityPaneLines := (1 + densityview.Height()) + baseStyle.GetVerticalFrameSize()
+	l.showDensity = m.showDensity && l.tableHeight-densityPaneLines > logview.ChromeLines()
+	if l.showDensity {
+		l.tableHeight -= densityPaneLines
+	}
 
-	counts := m.log.MatchCounts(m.filters.Filters)
-	filterBlock := m.paneStyle(FilterFocus).Render(m.filters.Render(m.windowWidth, tableHeight, counts))
+	// The Detail pane, when shown, is likewise a constant height for a
+	// given window (see detailview.Height) -- it never grows or shrinks
+	// with the line under the cursor -- so it comes out of the same budget.
+	// On a terminal too short to fit it and still leave the Log pane a row
+	// of its own it's left out rather than overflowing the frame; it comes
+	// back as soon as the window is tall enough again.
+	l.detailHeight = detailview.Height(m.windowHeight)
+	detailPaneLines := (1 + l.detailHeight) + baseStyle.GetVerticalFrameSize()
+	l.showDetail = m.showDetail && l.tableHeight-detailPaneLines > logview.ChromeLines()
+	if l.showDetail {
+		l.tableHeight -= detailPaneLines
+	}
+	return l
+}
 
-	// Joined with "\n" rather than each piece getting its own trailing
-	// "\n" (which would add a blank line after the footer that Bubble
-	// Tea's line-count-based height check counts as real screen real
-	// estate): with four blocks, that's one separator too many, pushing
-	// the total past windowHeight by exactly one line. Once the rendered
-	// frame is taller than the terminal, Bubble Tea has to drop/shift
-	// lines it can't scroll back to, permanently desyncing its
-	// line-by-line diff -- which shows up as some rows silently no
-	// longer updating on cursor movement, even though View() itself is
-	// computing the right content every time.
-	s := strings.Join([]string{logBlock, filterBlock, renderStatusLine(m), footer}, "\n")
+// renderDetail renders the Detail pane's content: the line the Log pane
+// currently highlights (see logview.LogView.SelectedLine), at the width
+// left inside the pane's border. It must run after MakeTable, which is what
+// decides which line that is.
+func (m model) renderDetail(height int) string {
+	width := m.windowWidth - baseStyle.GetHorizontalFrameSize()
+	idx, ok := m.log.SelectedLine()
+	if !ok {
+		return detailview.RenderEmpty(width, height)
+	}
+	return detailview.Render(idx+1, m.log.Lines[idx], width, height)
+}
 
-	// Send the UI for rendering
-	return s
+// Options carries RunUI's session-related settings: where the log came
+// from and where SaveSession writes, plus any session to restore -- and the
+// command line's time settings, which override the session's.
+type Options struct {
+	LogPath     string           // the -log path, recorded in saved sessions
+	SessionPath string           // the -session path; "" derives one from LogPath on save
+	Session     *session.Session // state to restore at startup, or nil for a fresh start
+
+	// TimeFormat, if set, overrides the filter file's timeFormat (see
+	// timestamps.Parser.Layout). Since and Until are the -since/-until
+	// values as typed, resolved against the log's first timestamp (see
+	// timestamps.ParseRange) once it's loaded.
+	TimeFormat string
+	Since      string
+	Until      string
+
+	// GapThreshold and SpikeThreshold are the -gap/-spike values as typed
+	// (see CheckTimelineSettings), "" to keep the filter file's or the
+	// defaults.
+	GapThreshold   string
+	SpikeThreshold string
+
+	// Transform is the -transform command to run the log through once it's
+	// up (see startTransform), "" to keep the session's, if any.
+	Transform string
+
+	// Commands are the -c command lines (see runCommand), run after
+	// everything else, so they have the last word.
+	Commands []string
 }
 
 // Run the program by passing the initial model to tea.NewProgram, then run.
 // warnings carries any per-filter load warnings from filterfiles.
 // CompileFilterRegularExpressions (e.g. a disabled filter due to an invalid
 // regex) through to the running UI; pass nil if there are none.
-func RunUI(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error) {
+func RunUI(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath string, fileMeta filterfiles.TextAnalysisToolSettings, usingStdinLog bool, warnings []error, options Options) {
 	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
 	if usingStdinLog {
 		// The log's bufio.Scanner has already fully drained stdin above, so
@@ -912,8 +1546,48 @@ func RunUI(filters []filterfiles.Filter, scanner *bufio.Scanner, filterFilePath
 		opts = append(opts, tea.WithInputTTY())
 	}
 
-	p := tea.NewProgram(initialModel(filters, scanner, filterFilePath, fileMeta, warnings), opts...)
-	if _, err := p.Run(); err != nil {
+	m := initialModel(filters, scanner, filterFilePath, fileMeta, warnings)
+	m.logPath = options.LogPath
+	m.sessionPath = options.SessionPath
+	m.loadLogState()
+	// The startup file runs first, so a session, then the flags, can
+	// override the defaults it sets up.
+	startupCmd := m.runStartupFile()
+	if options.Session != nil {
+		m.applySession(*options.Session)
+	}
+	if options.TimeFormat != "" {
+		m.log.TimeParser.Layout = options.TimeFormat
+	}
+	if text := timeRangeText(options.Since, options.Until); text != "" {
+		if err := m.setTimeRange(text); err != nil {
+			m.saveStatus = fmt.Sprintf("-since/-until: %v", err)
+		}
+	}
+	if err := m.setTimelineThresholds(options.GapThreshold, options.SpikeThreshold); err != nil {
+		m.saveStatus = fmt.Sprintf("-gap/-spike: %v", err)
+	}
+	if options.Transform != "" {
+		// Given on the command line, so there's no session command to ask
+		// about (see applySession).
+		if m.transformPrompt {
+			m.transformPrompt = false
+			m.saveStatus = ""
+		}
+		m.initCmd = m.startTransform(options.Transform)
+	}
+	commands := make([]rcfile.Line, len(options.Commands))
+	for i, command := range options.Commands {
+		commands[i] = rcfile.Line{Number: i + 1, Command: command}
+	}
+	m.initCmd = tea.Batch(m.initCmd, startupCmd, m.runStartupCommands("-c", commands))
+
+	p := tea.NewProgram(m, opts...)
+	final, err := p.Run()
+	if m, ok := final.(model); ok {
+		m.stopTransform()
+	}
+	if err != nil {
 		fmt.Printf("An error occured: %v", err)
 		os.Exit(1)
 	}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"skim/filterfiles"
 	"skim/keybindings"
+	"skim/ui/views/inputview"
 	"strings"
 	"testing"
 
@@ -22,7 +23,7 @@ func TestActiveScopesDefaultsToGlobalForUnknownFocus(t *testing.T) {
 
 func TestRenderSearchPromptShowsCompileError(t *testing.T) {
 	m := newTestModel(t, nil, "line\n")
-	m.searchText = "("
+	m.searchInput = inputview.New("(")
 	m.searchErr = "missing closing paren"
 
 	got := renderSearchPrompt(m)
@@ -33,7 +34,7 @@ func TestRenderSearchPromptShowsCompileError(t *testing.T) {
 
 func TestRenderJumpLinePromptShowsError(t *testing.T) {
 	m := newTestModel(t, nil, "line\n")
-	m.jumpLineText = "x"
+	m.jumpLineInput = inputview.New("x")
 	m.jumpLineErr = "not a number"
 
 	got := renderJumpLinePrompt(m)
@@ -150,8 +151,8 @@ func TestSearchSpaceCharacterIsAppended(t *testing.T) {
 	newModel, _ = m.Update(keyMsg("b"))
 	m = newModel.(model)
 
-	if m.searchText != "a b" {
-		t.Errorf("searchText = %q, want %q", m.searchText, "a b")
+	if m.searchInput.Value() != "a b" {
+		t.Errorf("searchText = %q, want %q", m.searchInput.Value(), "a b")
 	}
 }
 
@@ -8,6 +8,8 @@ import (
 	"reflect"
 	"skim/filterfiles"
 	"skim/keybindings"
+	inputview "skim/ui/views/inputview"
+	logview "skim/ui/views/logview"
 	"strings"
 	"testing"
 	"unicode/utf8"
@@ -22,11 +24,15 @@ func keyMsg(key string) tea.KeyMsg {
 	special := map[string]tea.KeyType{
 		"up": tea.KeyUp, "down": tea.KeyDown, "left": tea.KeyLeft, "right": tea.KeyRight,
 		"enter": tea.KeyEnter, "tab": tea.KeyTab, "esc": tea.KeyEsc, "escape": tea.KeyEscape,
-		"ctrl+c": tea.KeyCtrlC, "ctrl+e": tea.KeyCtrlE, " ": tea.KeySpace,
+		"ctrl+c": tea.KeyCtrlC, "ctrl+e": tea.KeyCtrlE, "ctrl+r": tea.KeyCtrlR, "ctrl+u": tea.KeyCtrlU,
+		"ctrl+o": tea.KeyCtrlO, "ctrl+n": tea.KeyCtrlN, " ": tea.KeySpace,
 	}
 	if t, ok := special[key]; ok {
 		return tea.KeyMsg{Type: t}
 	}
+	if r, ok := strings.CutPrefix(key, "alt+"); ok {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(r), Alt: true}
+	}
 	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
 }
 
@@ -178,7 +184,7 @@ func TestRenderStatusLine(t *testing.T) {
 		if !m.hideUnmatched {
 			t.Fatal("precondition: hideUnmatched should start true")
 		}
-		m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+		m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 		out := renderStatusLine(m)
 
 		if !strings.Contains(out, "hide unmatched: ON") {
@@ -191,7 +197,7 @@ func TestRenderStatusLine(t *testing.T) {
 
 	t.Run("hide unmatched off", func(t *testing.T) {
 		m.hideUnmatched = false
-		m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+		m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 		out := renderStatusLine(m)
 
 		if !strings.Contains(out, "hide unmatched: OFF") {
@@ -596,8 +602,8 @@ func TestFilterFieldEditorFinishedMsgFromHoverInvalidRegexEntersEditModeWithErro
 	if m.filterEditor.regexErr == "" {
 		t.Error("regexErr is empty after an invalid hover-triggered external edit")
 	}
-	if m.filterEditor.textBuf != "([unclosed" {
-		t.Errorf("textBuf = %q, want the attempted text %q so the user can fix it", m.filterEditor.textBuf, "([unclosed")
+	if m.filterEditor.input.Value() != "([unclosed" {
+		t.Errorf("textBuf = %q, want the attempted text %q so the user can fix it", m.filterEditor.input.Value(), "([unclosed")
 	}
 	if m.filters.Filters[0].XML.Text != "a" {
 		t.Errorf("XML.Text = %q after invalid hover-triggered edit, want unchanged %q", m.filters.Filters[0].XML.Text, "a")
@@ -702,7 +708,7 @@ func TestFilterFieldEditorFinishedMsgWithErrorLeavesFilterUnchanged(t *testing.T
 	filters := []filterfiles.Filter{mustFilter(t, "a")}
 	m := newTestModel(t, filters, "line\n")
 	m.editingFilter = true
-	m.filterEditor = filterEditorState{cursor: fieldRegex, editingText: true, textBuf: "a"}
+	m.filterEditor = filterEditorState{cursor: fieldRegex, editingText: true, input: inputview.New("a")}
 
 	newModel, cmd := m.Update(filterFieldEditorFinishedMsg{err: os.ErrInvalid, field: fieldRegex})
 	m = newModel.(model)
@@ -1217,8 +1223,8 @@ func TestSearchOpensCapturesAndJumpsOnEnter(t *testing.T) {
 		newModel, _ = m.Update(keyMsg(string(r)))
 		m = newModel.(model)
 	}
-	if m.searchText != "bravo" {
-		t.Fatalf("searchText = %q, want %q", m.searchText, "bravo")
+	if m.searchInput.Value() != "bravo" {
+		t.Fatalf("searchText = %q, want %q", m.searchInput.Value(), "bravo")
 	}
 
 	newModel, _ = m.Update(keyMsg("enter"))
@@ -1246,8 +1252,8 @@ func TestSearchBackspaceAndEscCancel(t *testing.T) {
 	m = newModel.(model)
 	newModel, _ = m.Update(keyMsg("backspace"))
 	m = newModel.(model)
-	if m.searchText != "a" {
-		t.Fatalf("searchText after backspace = %q, want %q", m.searchText, "a")
+	if m.searchInput.Value() != "a" {
+		t.Fatalf("searchText after backspace = %q, want %q", m.searchInput.Value(), "a")
 	}
 
 	newModel, _ = m.Update(keyMsg("esc"))
@@ -1255,8 +1261,8 @@ func TestSearchBackspaceAndEscCancel(t *testing.T) {
 	if m.searching {
 		t.Error("searching still true after esc, want false")
 	}
-	if m.searchText != "" {
-		t.Errorf("searchText after esc = %q, want empty", m.searchText)
+	if m.searchInput.Value() != "" {
+		t.Errorf("searchText after esc = %q, want empty", m.searchInput.Value())
 	}
 	if m.hasSearch {
 		t.Error("hasSearch = true after esc cancel, want false (no pattern was ever submitted)")
@@ -1274,18 +1280,18 @@ func TestSearchBackspaceRemovesWholeRuneNotJustOneByte(t *testing.T) {
 		newModel, _ = m.Update(keyMsg(string(r)))
 		m = newModel.(model)
 	}
-	if m.searchText != "café" {
-		t.Fatalf("precondition: searchText = %q, want %q", m.searchText, "café")
+	if m.searchInput.Value() != "café" {
+		t.Fatalf("precondition: searchText = %q, want %q", m.searchInput.Value(), "café")
 	}
 
 	newModel, _ = m.Update(keyMsg("backspace"))
 	m = newModel.(model)
 
-	if m.searchText != "caf" {
-		t.Errorf("searchText after one backspace on %q = %q, want %q", "café", m.searchText, "caf")
+	if m.searchInput.Value() != "caf" {
+		t.Errorf("searchText after one backspace on %q = %q, want %q", "café", m.searchInput.Value(), "caf")
 	}
-	if !utf8.ValidString(m.searchText) {
-		t.Errorf("searchText %q is not valid UTF-8 after backspace", m.searchText)
+	if !utf8.ValidString(m.searchInput.Value()) {
+		t.Errorf("searchText %q is not valid UTF-8 after backspace", m.searchInput.Value())
 	}
 }
 
@@ -1530,7 +1536,7 @@ func TestRenderStatusLineShowsDirtyAndSaveStatus(t *testing.T) {
 	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "line one\n")
 	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
 	m = newModel.(model)
-	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 
 	if strings.Contains(renderStatusLine(m), "unsaved") {
 		t.Error("status line shows unsaved changes before any edit, want clean state")
@@ -1606,36 +1612,48 @@ func TestInitialModelSetsStartupWarningFromCompileWarnings(t *testing.T) {
 func TestUpdateContextLinesIncreaseDecrease(t *testing.T) {
 	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "line\n")
 
-	if m.contextLines != 0 {
-		t.Fatalf("precondition: contextLines = %d, want 0", m.contextLines)
+	if m.context != (logview.Context{}) {
+		t.Fatalf("precondition: context = %+v, want none", m.context)
 	}
 
 	newModel, _ := m.Update(keyMsg("+"))
 	m = newModel.(model)
-	if m.contextLines != 1 {
-		t.Errorf("contextLines after + = %d, want 1", m.contextLines)
+	if m.context != (logview.Context{Before: 1, After: 1}) {
+		t.Errorf("context after + = %+v, want 1 each side", m.context)
 	}
 
 	newModel, _ = m.Update(keyMsg("+"))
 	m = newModel.(model)
-	if m.contextLines != 2 {
-		t.Errorf("contextLines after second + = %d, want 2", m.contextLines)
+	if m.context != (logview.Context{Before: 2, After: 2}) {
+		t.Errorf("context after second + = %+v, want 2 each side", m.context)
 	}
 
 	newModel, _ = m.Update(keyMsg("-"))
 	m = newModel.(model)
-	if m.contextLines != 1 {
-		t.Errorf("contextLines after - = %d, want 1", m.contextLines)
+	if m.context != (logview.Context{Before: 1, After: 1}) {
+		t.Errorf("context after - = %+v, want 1 each side", m.context)
 	}
 }
 
 func TestUpdateContextLinesDoesNotGoNegative(t *testing.T) {
 	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "line\n")
 
-	newModel, _ := m.Update(keyMsg("-"))
-	m = newModel.(model)
-	if m.contextLines != 0 {
-		t.Errorf("contextLines after - at zero = %d, want unchanged 0", m.contextLines)
+	m = update(t, m, keyMsg("-"), keyMsg("b"), keyMsg("a"))
+	if m.context != (logview.Context{}) {
+		t.Errorf("context after -, b, a at zero = %+v, want unchanged", m.context)
+	}
+}
+
+func TestUpdateContextBeforeAndAfterSeparately(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "line\n")
+
+	m = update(t, m, keyMsg("B"), keyMsg("B"), keyMsg("B"), keyMsg("A"))
+	if m.context != (logview.Context{Before: 3, After: 1}) {
+		t.Errorf("context after B B B A = %+v, want 3 before, 1 after", m.context)
+	}
+	m = update(t, m, keyMsg("b"), keyMsg("a"), keyMsg("-"))
+	if m.context != (logview.Context{Before: 1}) {
+		t.Errorf("context after b a - = %+v, want 1 before, none after", m.context)
 	}
 }
 
@@ -1643,16 +1661,21 @@ func TestRenderStatusLineShowsContext(t *testing.T) {
 	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "a")}, "line one\n")
 	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
 	m = newModel.(model)
-	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 
 	if strings.Contains(renderStatusLine(m), "context:") {
 		t.Error("status line shows a context indicator at the default of 0, want none")
 	}
 
-	m.contextLines = 2
+	m.context = logview.Context{Before: 2, After: 2}
 	if !strings.Contains(renderStatusLine(m), "context: ±2") {
 		t.Errorf("status line missing context indicator, got: %q", renderStatusLine(m))
 	}
+
+	m.context = logview.Context{Before: 20}
+	if !strings.Contains(renderStatusLine(m), "context: -20/+0") {
+		t.Errorf("status line missing asymmetric context indicator, got: %q", renderStatusLine(m))
+	}
 }
 
 func TestViewShowsFilterMatchCounts(t *testing.T) {
@@ -1742,6 +1765,33 @@ func TestViewTotalHeightStaysWithinWindowWhenHelpExpands(t *testing.T) {
 	}
 }
 
+// TestHelpBarFitsAnOrdinaryTerminal checks the expanded help bar on the
+// Log pane, which has by far the most entries, at 80x24: uncapped it
+// wrapped onto ten lines or so and pushed the frame past the window.
+func TestHelpBarFitsAnOrdinaryTerminal(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "^debug")}, positionLines(100))
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.focus = LogFocus
+	m = update(t, m, keyMsg("?"))
+	if !m.showHelp {
+		t.Fatal("precondition: showHelp = false after \"?\", want true")
+	}
+
+	out := m.View()
+	if lines := strings.Split(out, "\n"); len(lines) > 24 {
+		t.Errorf("View() with help shown is %d lines, want at most the window's 24:\n%s", len(lines), out)
+	}
+	footer := m.renderFooter()
+	if n := strings.Count(footer, "\n") + 1; n > maxHelpLines {
+		t.Errorf("help bar is %d lines, want at most %d:\n%s", n, maxHelpLines, footer)
+	}
+	for _, want := range []string{"…", "K: all keybindings", "ctrl+p: command palette", "?: hide help"} {
+		if !strings.Contains(footer, want) {
+			t.Errorf("help bar missing %q, want it pointing to the full list:\n%s", want, footer)
+		}
+	}
+}
+
 // TestViewTotalHeightStaysWithinWindowInDefaultState guards against the
 // same class of bug as TestViewTotalHeightStaysWithinWindowWhenHelpExpands,
 // but for the ordinary collapsed-help state that's active almost all the
@@ -1903,8 +1953,8 @@ func TestJumpToLineOpensCapturesAndJumpsOnEnter(t *testing.T) {
 		newModel, _ = m.Update(keyMsg(string(r)))
 		m = newModel.(model)
 	}
-	if m.jumpLineText != "3" {
-		t.Fatalf("jumpLineText = %q, want %q", m.jumpLineText, "3")
+	if m.jumpLineInput.Value() != "3" {
+		t.Fatalf("jumpLineText = %q, want %q", m.jumpLineInput.Value(), "3")
 	}
 
 	newModel, _ = m.Update(keyMsg("enter"))
@@ -1950,7 +2000,7 @@ func TestJumpToLineEmptyInputJustClosesPrompt(t *testing.T) {
 	}
 }
 
-func TestJumpToLineIgnoresNonDigitRunes(t *testing.T) {
+func TestJumpToLineRejectsTextThatIsNotACommand(t *testing.T) {
 	m := newTestModel(t, nil, "one\ntwo\n")
 
 	newModel, _ := m.Update(keyMsg(":"))
@@ -1959,8 +2009,13 @@ func TestJumpToLineIgnoresNonDigitRunes(t *testing.T) {
 		newModel, _ = m.Update(keyMsg(string(r)))
 		m = newModel.(model)
 	}
-	if m.jumpLineText != "12" {
-		t.Errorf("jumpLineText = %q, want %q (non-digit rune ignored)", m.jumpLineText, "12")
+	newModel, _ = m.Update(keyMsg("enter"))
+	m = newModel.(model)
+	if !m.jumpingToLine || m.jumpLineErr == "" {
+		t.Errorf("jumpingToLine, jumpLineErr = %v, %q, want the prompt left open with an error", m.jumpingToLine, m.jumpLineErr)
+	}
+	if m.log.Cursor != 0 {
+		t.Errorf("log.Cursor = %d, want unchanged 0", m.log.Cursor)
 	}
 }
 
@@ -1975,8 +2030,8 @@ func TestJumpToLineBackspaceAndEscCancel(t *testing.T) {
 	m = newModel.(model)
 	newModel, _ = m.Update(keyMsg("backspace"))
 	m = newModel.(model)
-	if m.jumpLineText != "1" {
-		t.Fatalf("jumpLineText after backspace = %q, want %q", m.jumpLineText, "1")
+	if m.jumpLineInput.Value() != "1" {
+		t.Fatalf("jumpLineText after backspace = %q, want %q", m.jumpLineInput.Value(), "1")
 	}
 
 	newModel, _ = m.Update(keyMsg("esc"))
@@ -1984,8 +2039,8 @@ func TestJumpToLineBackspaceAndEscCancel(t *testing.T) {
 	if m.jumpingToLine {
 		t.Error("jumpingToLine still true after esc, want false")
 	}
-	if m.jumpLineText != "" {
-		t.Errorf("jumpLineText after esc = %q, want empty", m.jumpLineText)
+	if m.jumpLineInput.Value() != "" {
+		t.Errorf("jumpLineText after esc = %q, want empty", m.jumpLineInput.Value())
 	}
 	if m.log.Cursor != 0 {
 		t.Errorf("log.Cursor changed to %d after esc cancel, want unchanged 0", m.log.Cursor)
@@ -1996,7 +2051,7 @@ func TestRenderStatusLineShowsLinePosition(t *testing.T) {
 	m := newTestModel(t, nil, "one\ntwo\nthree\n")
 	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
 	m = newModel.(model)
-	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 
 	if !strings.Contains(renderStatusLine(m), "line 1/3") {
 		t.Errorf("status line missing initial position, got: %q", renderStatusLine(m))
@@ -2004,7 +2059,7 @@ func TestRenderStatusLineShowsLinePosition(t *testing.T) {
 
 	newModel, _ = m.Update(keyMsg("j"))
 	m = newModel.(model)
-	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 	if !strings.Contains(renderStatusLine(m), "line 2/3") {
 		t.Errorf("status line after moving down = %q, want it to show line 2/3", renderStatusLine(m))
 	}
@@ -2014,7 +2069,7 @@ func TestRenderStatusLineOmitsPositionOnEmptyLog(t *testing.T) {
 	m := newTestModel(t, nil, "")
 	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
 	m = newModel.(model)
-	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.contextLines)
+	m.log.MakeTable(m.windowWidth, m.windowHeight, m.filters.Filters, m.hideUnmatched, m.context)
 
 	if strings.Contains(renderStatusLine(m), "line ") {
 		t.Errorf("status line on empty log shows a position indicator, want none: %q", renderStatusLine(m))
@@ -2054,3 +2109,206 @@ func TestViewShowsActiveSearchInStatusLine(t *testing.T) {
 		t.Errorf("View() after a committed search missing the active pattern in the status line, got:\n%s", out)
 	}
 }
+
+func TestNextPrevFilterMatchUsesSelectedFilter(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "alpha"), mustFilter(t, "beta")}
+	m := newTestModel(t, filters, "alpha\nbeta one\nalpha\nbeta two\n")
+	m.hideUnmatched = false
+
+	m = update(t, m, keyMsg("tab"), keyMsg("down")) // select filter "beta" in the Filters pane
+	m = update(t, m, keyMsg("}"))
+	if m.log.Cursor != 1 {
+		t.Errorf("after } from the Filters pane, log cursor = %d, want 1 (first beta)", m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("tab"), keyMsg("}")) // same action from the Log pane
+	if m.log.Cursor != 3 {
+		t.Errorf("after } from the Log pane, log cursor = %d, want 3 (next beta)", m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("{"))
+	if m.log.Cursor != 1 {
+		t.Errorf("after {, log cursor = %d, want 1 (previous beta)", m.log.Cursor)
+	}
+}
+
+func TestNumberKeyJumpsToFilterByPosition(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "alpha"), mustFilter(t, "beta")}
+	m := newTestModel(t, filters, "alpha\nbeta one\nalpha\nbeta two\n")
+
+	// In the Log pane a digit could start a count (see readCount), so it
+	// only jumps once the count times out.
+	m = update(t, m, keyMsg("2"))
+	m = update(t, m, countTimeoutMsg{seq: m.count.seq})
+	if m.log.Cursor != 1 {
+		t.Errorf("after 2, log cursor = %d, want 1 (first hit of filter 2)", m.log.Cursor)
+	}
+	if m.filters.Cursor != 1 {
+		t.Errorf("after 2, filter cursor = %d, want 1 (filter 2 selected for follow-up })", m.filters.Cursor)
+	}
+
+	m = update(t, m, keyMsg("}"))
+	if m.log.Cursor != 3 {
+		t.Errorf("after 2 then }, log cursor = %d, want 3", m.log.Cursor)
+	}
+
+	m = update(t, m, keyMsg("9"))
+	m = update(t, m, countTimeoutMsg{seq: m.count.seq})
+	if m.log.Cursor != 3 || m.filters.Cursor != 1 {
+		t.Errorf("after 9 with only 2 filters, cursors = %d, %d, want unchanged 3, 1", m.log.Cursor, m.filters.Cursor)
+	}
+}
+
+func TestJumpFilterIndex(t *testing.T) {
+	if got := jumpFilterIndex(keybindings.JumpFilter1); got != 0 {
+		t.Errorf("jumpFilterIndex(JumpFilter1) = %d, want 0", got)
+	}
+	if got := jumpFilterIndex(keybindings.JumpFilter9); got != 8 {
+		t.Errorf("jumpFilterIndex(JumpFilter9) = %d, want 8", got)
+	}
+	if got := jumpFilterIndex(keybindings.Quit); got != -1 {
+		t.Errorf("jumpFilterIndex(Quit) = %d, want -1", got)
+	}
+}
+
+func TestToggleWrapKeepsViewWithinWindow(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, "line %d %s\n", i, strings.Repeat("payload ", 40))
+	}
+	m := newTestModel(t, nil, b.String())
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	m = update(t, m, keyMsg("w"))
+	if !m.log.Wrap {
+		t.Fatal("log.Wrap = false after pressing w, want true")
+	}
+
+	for _, key := range []string{"G", "k", "k", ":", "1", "0", "0", "enter", "g"} {
+		m = update(t, m, keyMsg(key))
+		if got := len(strings.Split(m.View(), "\n")); got > m.windowHeight {
+			t.Errorf("after %q with wrap on: View() has %d lines, exceeds windowHeight %d", key, got, m.windowHeight)
+		}
+	}
+
+	m = update(t, m, keyMsg("w"))
+	if m.log.Wrap {
+		t.Error("log.Wrap = true after pressing w again, want false")
+	}
+}
+
+func TestLogPaneRightScrollsHorizontally(t *testing.T) {
+	m := newTestModel(t, nil, strings.Repeat("x", 300)+"\n")
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	m.View() // MakeTable records the Line column width CursorRight bounds against
+
+	m = update(t, m, keyMsg("l"), keyMsg("right"))
+	if m.log.HScroll != 2*logview.HScrollStep {
+		t.Errorf("HScroll = %d after l, right, want %d", m.log.HScroll, 2*logview.HScrollStep)
+	}
+
+	m = update(t, m, keyMsg("left"))
+	if m.log.HScroll != logview.HScrollStep {
+		t.Errorf("HScroll = %d after left, want %d", m.log.HScroll, logview.HScrollStep)
+	}
+}
+
+func TestToggleDetailShowsLineUnderCursor(t *testing.T) {
+	lines := "plain first line\n" +
+		`2024-01-02 10:00:00 {"level":"error","msg":"disk full"}` + "\n" +
+		"ts=1 level=warn msg=\"slow request\"\n"
+	m := newTestModel(t, nil, lines)
+	m.hideUnmatched = false
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	if strings.Contains(m.View(), "Line 1 (text)") {
+		t.Fatal("View() shows the Detail pane before it was toggled on")
+	}
+
+	m = update(t, m, keyMsg("enter"))
+	if !m.showDetail {
+		t.Fatal("showDetail = false after pressing enter in the Log pane, want true")
+	}
+	if view := m.View(); !strings.Contains(view, "Line 1 (text)") {
+		t.Errorf("View() = %q, want the Detail pane describing line 1", view)
+	}
+
+	m = update(t, m, keyMsg("down"))
+	view := m.View()
+	if !strings.Contains(view, "Line 2 (JSON)") || !strings.Contains(view, `"disk full"`) {
+		t.Errorf("View() = %q, want the Detail pane to follow the cursor to line 2's JSON", view)
+	}
+
+	m = update(t, m, keyMsg("down"))
+	if view := m.View(); !strings.Contains(view, "Line 3 (logfmt)") || !strings.Contains(view, "slow request") {
+		t.Errorf("View() = %q, want line 3's logfmt fields", view)
+	}
+
+	m = update(t, m, keyMsg("enter"))
+	if m.showDetail {
+		t.Error("showDetail = true after pressing enter again, want false")
+	}
+}
+
+func TestToggleDetailKeepsViewWithinWindow(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, `{"n":%d,"msg":"%s"}`+"\n", i, strings.Repeat("payload ", 30))
+	}
+	m := newTestModel(t, nil, b.String())
+	m.hideUnmatched = false
+
+	for _, height := range []int{12, 30, 60} {
+		m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: height})
+		m.showDetail = false
+		without := len(strings.Split(m.View(), "\n"))
+		m = update(t, m, keyMsg("enter"))
+		for _, key := range []string{"j", "G", "w", "k", "g"} {
+			m = update(t, m, keyMsg(key))
+			if got := len(strings.Split(m.View(), "\n")); got != without {
+				t.Errorf("height %d, after %q with the Detail pane shown: View() has %d lines, want %d (same as without it)", height, key, got, without)
+			}
+		}
+		m.log.Wrap = false
+	}
+}
+
+func TestDetailPaneFollowsSnappedCursorWhenLineHidden(t *testing.T) {
+	m := newTestModel(t, []filterfiles.Filter{mustFilter(t, "keep")}, "keep one\nhidden\nkeep two\n")
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30}, keyMsg("enter"))
+	m.log.Cursor = 1 // on the hidden line; the Log pane highlights line 1 instead
+
+	if view := m.View(); !strings.Contains(view, "Line 1 (text)") {
+		t.Errorf("View() = %q, want the Detail pane to show the highlighted line 1, not hidden line 2", view)
+	}
+}
+
+func TestClickingMinimapJumps(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	m := newTestModel(t, nil, b.String())
+	m.hideUnmatched = false
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m.View()
+
+	// The minimap runs down the column inside the Log pane's right border,
+	// beside the table's rows (under the pane's top border and header).
+	rows := m.log.Table.Height()
+	m = update(t, m, tea.MouseMsg{X: 98, Y: 2 + rows - 1, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if want := 400 * (rows - 1) / rows; m.log.Cursor != want {
+		t.Errorf("Cursor = %d after clicking the minimap's last cell, want %d", m.log.Cursor, want)
+	}
+
+	m.View()
+	m = update(t, m, tea.MouseMsg{X: 50, Y: 2, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if m.log.Cursor != 400*(rows-1)/rows {
+		t.Error("clicking a log line rather than the minimap moved the cursor")
+	}
+
+	m = update(t, m, keyMsg("m"))
+	if m.log.Minimap {
+		t.Error("Minimap still on after pressing m")
+	}
+}
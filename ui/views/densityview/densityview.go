@@ -0,0 +1,164 @@
+// Package densityview renders the density panel: one sparkline per filter
+// showing where in the log its matches fall, over a shared axis of time (or
+// line position, for a log without timestamps), with a marker under the
+// part of the log the cursor is in.
+package densityview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Rows is how many filters the panel has a sparkline row for. Like the
+// Detail pane's, the panel's height never varies -- it's Rows plus the axis
+// row (see Height) whatever the filter count -- so ui.go's View can take it
+// out of the Log pane's budget up front.
+const Rows = 4
+
+// LabelWidth is how many columns at the left of each row the filter's label
+// takes; every column after it is one bucket (see Buckets).
+const LabelWidth = 14
+
+// Height returns how many content rows (excluding its header and border)
+// the panel has: Rows sparklines and the axis.
+func Height() int {
+	return Rows + 1
+}
+
+// Buckets returns how many buckets a panel width columns wide has room for:
+// one per column right of the labels.
+func Buckets(width int) int {
+	return max(width-LabelWidth, 1)
+}
+
+// BucketAt returns which bucket column x (0 at the panel's left edge, inside
+// its border) is over, and false for the label columns or past the last
+// bucket.
+func BucketAt(x, width int) (int, bool) {
+	b := x - LabelWidth
+	if b < 0 || b >= Buckets(width) {
+		return 0, false
+	}
+	return b, true
+}
+
+// Row is one filter's sparkline: Label (its description or regex) in front,
+// then Counts, one per bucket, drawn in Color.
+type Row struct {
+	Label  string
+	Color  string
+	Counts []int
+}
+
+var (
+	headerStyle = lipgloss.NewStyle().Bold(true)
+	dimStyle    = lipgloss.NewStyle().Faint(true)
+	cursorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+)
+
+// levels are the bars a bucket's count is drawn as, from empty to a row's
+// fullest bucket.
+var levels = []rune(" ▁▂▃▄▅▆▇█")
+
+// Render returns the panel's content at width columns: a header row (title,
+// plus how many filters didn't fit), exactly Rows sparkline rows -- blank
+// ones padding out fewer filters -- and the axis: axisLeft and axisRight at
+// either end, and a marker under bucket cursor (none if it's negative).
+//
+// Each row is scaled to its own fullest bucket rather than to a shared
+// maximum, so a filter with a dozen matches still shows where they are
+// next to one with thousands.
+func Render(title string, rows []Row, axisLeft, axisRight string, cursor, width int) string {
+	width = max(width, 1)
+	buckets := Buckets(width)
+
+	if hidden := len(rows) - Rows; hidden > 0 {
+		title += fmt.Sprintf(" (+%d more filters)", hidden)
+		rows = rows[:Rows]
+	}
+	out := []string{headerStyle.Render(ansi.Truncate(title, width, "…"))}
+
+	for _, r := range rows {
+		out = append(out, renderRow(r, buckets, width))
+	}
+	if len(rows) == 0 {
+		out = append(out, dimStyle.Render(ansi.Truncate("no enabled filters to chart", width, "…")))
+	}
+	for len(out) < 1+Rows {
+		out = append(out, "")
+	}
+	out = append(out, renderAxis(axisLeft, axisRight, cursor, buckets, width))
+
+	for i := range out {
+		if pad := width - ansi.StringWidth(out[i]); pad > 0 {
+			out[i] += strings.Repeat(" ", pad)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderRow draws one sparkline row: its label, padded or cut to
+// LabelWidth, then one bar per bucket.
+func renderRow(r Row, buckets, width int) string {
+	peak := 0
+	for _, c := range r.Counts {
+		peak = max(peak, c)
+	}
+
+	label := ansi.Truncate(sanitize(r.Label), LabelWidth-1, "…")
+	label += strings.Repeat(" ", LabelWidth-ansi.StringWidth(label))
+
+	bars := make([]rune, buckets)
+	for b := range bars {
+		c := 0
+		if b < len(r.Counts) {
+			c = r.Counts[b]
+		}
+		bars[b] = levels[level(c, peak)]
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(r.Color))
+	return ansi.Truncate(label+style.Render(string(bars)), width, "")
+}
+
+// level returns which of levels count is drawn as in a row whose fullest
+// bucket holds peak: 0 only for an empty bucket, so a single match is
+// never invisible next to a busy bucket.
+func level(count, peak int) int {
+	if count <= 0 || peak <= 0 {
+		return 0
+	}
+	top := len(levels) - 1
+	return max(1, (count*top+peak-1)/peak)
+}
+
+// renderAxis draws the axis row: the labels at either end of the buckets
+// and a "▲" under the cursor's bucket, which wins over label text.
+func renderAxis(left, right string, cursor, buckets, width int) string {
+	axis := []rune(strings.Repeat(" ", buckets))
+	copy(axis, []rune(left))
+	if r := []rune(right); len(r) < buckets-len([]rune(left)) {
+		copy(axis[buckets-len(r):], r)
+	}
+
+	row := strings.Repeat(" ", LabelWidth)
+	if cursor < 0 || cursor >= buckets {
+		row += dimStyle.Render(string(axis))
+	} else {
+		row += dimStyle.Render(string(axis[:cursor])) + cursorStyle.Render("▲") + dimStyle.Render(string(axis[cursor+1:]))
+	}
+	return ansi.Truncate(row, width, "")
+}
+
+// sanitize strips control characters and escape sequences from a filter
+// label, which comes straight from the filter file.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, ansi.Strip(s))
+}
@@ -0,0 +1,89 @@
+package densityview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// plainRows strips styling from Render's output and splits it into rows.
+func plainRows(s string) []string {
+	return strings.Split(ansi.Strip(s), "\n")
+}
+
+func TestRenderIsAlwaysHeaderPlusHeightRows(t *testing.T) {
+	row := Row{Label: "1 ERROR", Color: "#FF0000", Counts: []int{0, 1, 5}}
+	for _, n := range []int{0, 1, Rows, Rows + 3} {
+		rows := make([]Row, n)
+		for i := range rows {
+			rows[i] = row
+		}
+		for _, width := range []int{1, 20, 80} {
+			got := plainRows(Render("Match density", rows, "14:00:00", "14:10:00", 2, width))
+			if len(got) != 1+Height() {
+				t.Errorf("%d filters, width %d: %d rows, want %d", n, width, len(got), 1+Height())
+			}
+			for i, r := range got {
+				if w := ansi.StringWidth(r); w != width {
+					t.Errorf("%d filters, width %d: row %d is %d wide", n, width, i, w)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	rows := []Row{{Label: "1 ERROR", Color: "#FF0000", Counts: []int{0, 1, 4, 8}}}
+	got := plainRows(Render("Match density", rows, "", "", -1, LabelWidth+4))
+
+	if want := "1 ERROR        ▁▄█"; got[1] != want {
+		t.Errorf("row = %q, want %q", got[1], want)
+	}
+}
+
+func TestRenderNotesFiltersThatDontFit(t *testing.T) {
+	rows := make([]Row, Rows+2)
+	got := plainRows(Render("Match density", rows, "", "", -1, 80))
+	if !strings.Contains(got[0], "(+2 more filters)") {
+		t.Errorf("header = %q, want the 2 filters without a row noted", got[0])
+	}
+}
+
+func TestRenderAxisMarksCursorBucket(t *testing.T) {
+	got := plainRows(Render("Match density", nil, "14:00", "14:10", 20, LabelWidth+40))
+	axis := []rune(got[len(got)-1])
+
+	if axis[LabelWidth+20] != '▲' {
+		t.Errorf("axis = %q, want the marker under bucket 20", string(axis))
+	}
+	if !strings.HasPrefix(string(axis[LabelWidth:]), "14:00") || !strings.HasSuffix(string(axis), "14:10") {
+		t.Errorf("axis = %q, want the labels at either end", string(axis))
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct{ count, peak, want int }{
+		{0, 100, 0},
+		{1, 1000, 1}, // never invisible
+		{50, 100, 4},
+		{100, 100, 8},
+	}
+	for _, tt := range tests {
+		if got := level(tt.count, tt.peak); got != tt.want {
+			t.Errorf("level(%d, %d) = %d, want %d", tt.count, tt.peak, got, tt.want)
+		}
+	}
+}
+
+func TestBucketAt(t *testing.T) {
+	if _, ok := BucketAt(LabelWidth-1, 80); ok {
+		t.Error("BucketAt() = ok over the labels")
+	}
+	if b, ok := BucketAt(LabelWidth+3, 80); !ok || b != 3 {
+		t.Errorf("BucketAt(LabelWidth+3) = %d, %v, want 3", b, ok)
+	}
+	if _, ok := BucketAt(80, 80); ok {
+		t.Error("BucketAt() = ok past the panel's right edge")
+	}
+}
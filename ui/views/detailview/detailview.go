@@ -0,0 +1,267 @@
+// Package detailview renders the Detail pane: the full text of the log line
+// under the Log pane's cursor, wrapped rather than truncated, with an
+// embedded JSON payload pretty-printed and syntax-colored, or logfmt
+// key=value pairs laid out as an aligned two-column table.
+package detailview
+
+import (
+	"fmt"
+	"skim/structured"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Format identifies how Render laid out a line.
+type Format int
+
+const (
+	Plain  Format = iota // no structure recognized: just the wrapped line
+	JSON                 // an embedded JSON object/array, pretty-printed
+	Logfmt               // key=value pairs, one per row
+)
+
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "JSON"
+	case Logfmt:
+		return "logfmt"
+	default:
+		return "text"
+	}
+}
+
+// Detect reports which Format Render would use for line. JSON wins over
+// logfmt, since a JSON payload's own "key":"value" text can otherwise look
+// like a run of logfmt pairs.
+func Detect(line string) Format {
+	if _, _, _, ok := structured.FindJSON(line); ok {
+		return JSON
+	}
+	if _, _, ok := structured.ParseLogfmt(line); ok {
+		return Logfmt
+	}
+	return Plain
+}
+
+// Height returns how many content rows (excluding its header and border)
+// the Detail pane gets for a terminal windowHeight rows tall: a quarter of
+// it, but never fewer than MinHeight. Like filterview.VisibleHeight, it
+// depends only on the window -- never on the line being shown -- so the
+// pane's rendered height stays constant as the cursor moves and ui.go's
+// View can subtract it from the Log pane's budget up front.
+func Height(windowHeight int) int {
+	if h := windowHeight / 4; h > MinHeight {
+		return h
+	}
+	return MinHeight
+}
+
+// MinHeight is the fewest content rows Height ever gives the Detail pane.
+const MinHeight = 3
+
+var (
+	headerStyle  = lipgloss.NewStyle().Bold(true)
+	keyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	stringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	numberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("179"))
+	literalStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	dimStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+// Render returns the Detail pane's content for line (lineNumber is
+// 1-indexed, for the header) at width columns: a header row followed by
+// exactly height content rows. Content that doesn't fit is cut off with a
+// "… N more lines" marker on the last row; shorter content is padded with
+// blank rows, so the result's height never varies (see Height).
+func Render(lineNumber int, line string, width int, height int) string {
+	if width < 1 {
+		width = 1
+	}
+
+	format := Detect(line)
+	var body []string
+	switch format {
+	case JSON:
+		body = renderJSON(line, width)
+	case Logfmt:
+		body = renderLogfmt(line, width)
+	default:
+		body = wrap(sanitize(line), width)
+	}
+
+	header := headerStyle.Render(ansi.Truncate(fmt.Sprintf("Line %d (%s)", lineNumber, format), width, "…"))
+	return header + "\n" + strings.Join(fit(body, width, height), "\n")
+}
+
+// RenderEmpty is Render for when there's no line to show (nothing in the
+// Log pane is shown): the same header-plus-height-rows shape, blank.
+func RenderEmpty(width int, height int) string {
+	if width < 1 {
+		width = 1
+	}
+	header := headerStyle.Render(ansi.Truncate("No line selected", width, "…"))
+	return header + "\n" + strings.Join(fit(nil, width, height), "\n")
+}
+
+// fit pads or cuts rows to exactly height, each padded (or cut) to width.
+func fit(rows []string, width int, height int) []string {
+	if height < 1 {
+		height = 1
+	}
+	if len(rows) > height {
+		more := len(rows) - height + 1
+		rows = append(rows[:height-1:height-1], dimStyle.Render(ansi.Truncate(fmt.Sprintf("… %d more lines", more), width, "…")))
+	}
+	out := make([]string, height)
+	for i := range out {
+		if i < len(rows) {
+			out[i] = rows[i]
+		}
+		// Only reachable on absurdly narrow panes, where even the logfmt
+		// key column plus its separator is wider than width.
+		if ansi.StringWidth(out[i]) > width {
+			out[i] = ansi.Truncate(out[i], width, "")
+		}
+		if pad := width - ansi.StringWidth(out[i]); pad > 0 {
+			out[i] += strings.Repeat(" ", pad)
+		}
+	}
+	return out
+}
+
+// wrap word-wraps already-styled text to width, breaking mid-word only when
+// a single word is longer than width.
+func wrap(text string, width int) []string {
+	if text == "" {
+		return []string{""}
+	}
+	// Wrap leaves leading indentation alone even when it alone is wider
+	// than width; Hardwrap then breaks whatever is still too long.
+	return strings.Split(ansi.Hardwrap(ansi.Wrap(text, width, ""), width, true), "\n")
+}
+
+// sanitize expands tabs and strips escape sequences and control characters
+// that would corrupt the pane, the same treatment the Log pane gives lines.
+func sanitize(s string) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	s = ansi.Strip(s)
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// renderJSON lays out a line containing a JSON payload: the text before it
+// (usually a timestamp/level) on its own row(s), the payload pretty-printed
+// and colored, and anything after it last.
+func renderJSON(line string, width int) []string {
+	prefix, payload, suffix, _ := structured.FindJSON(line)
+
+	var rows []string
+	if p := strings.TrimSpace(sanitize(prefix)); p != "" {
+		rows = append(rows, wrap(dimStyle.Render(p), width)...)
+	}
+	for _, l := range strings.Split(structured.Indent(payload), "\n") {
+		rows = append(rows, wrap(colorizeJSON(sanitize(l)), width)...)
+	}
+	if s := strings.TrimSpace(sanitize(suffix)); s != "" {
+		rows = append(rows, wrap(dimStyle.Render(s), width)...)
+	}
+	return rows
+}
+
+// colorizeJSON syntax-colors one line of structured.Indent's output: object
+// keys, string values, numbers and true/false/null each get their own
+// style; punctuation and indentation are left plain.
+func colorizeJSON(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			end := stringEnd(line, i)
+			tok := line[i:end]
+			if strings.HasPrefix(strings.TrimLeft(line[end:], " "), ":") {
+				b.WriteString(keyStyle.Render(tok))
+			} else {
+				b.WriteString(stringStyle.Render(tok))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(line) && strings.IndexByte("0123456789.eE+-", line[end]) >= 0 {
+				end++
+			}
+			b.WriteString(numberStyle.Render(line[i:end]))
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i + 1
+			for end < len(line) && line[end] >= 'a' && line[end] <= 'z' {
+				end++
+			}
+			b.WriteString(literalStyle.Render(line[i:end]))
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the index just past the JSON string starting at
+// line[start] (a '"'), honoring backslash escapes -- or len(line) if it's
+// unterminated.
+func stringEnd(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// maxKeyWidth caps the logfmt key column, so one very long key doesn't
+// squeeze every value into a sliver of the pane.
+const maxKeyWidth = 24
+
+// renderLogfmt lays out a logfmt line as an aligned key/value table: the
+// non-pair prefix first, then one row per field, with long values wrapped
+// and their continuation rows indented under the value column.
+func renderLogfmt(line string, width int) []string {
+	prefix, fields, _ := structured.ParseLogfmt(line)
+
+	var rows []string
+	if p := sanitize(prefix); p != "" {
+		rows = append(rows, wrap(dimStyle.Render(p), width)...)
+	}
+
+	keyWidth := 0
+	for _, f := range fields {
+		keyWidth = max(keyWidth, ansi.StringWidth(sanitize(f.Key)))
+	}
+	keyWidth = min(keyWidth, maxKeyWidth, max(width/2, 1))
+	valueWidth := max(width-keyWidth-1, 1)
+
+	for _, f := range fields {
+		key := ansi.Truncate(sanitize(f.Key), keyWidth, "…")
+		key += strings.Repeat(" ", keyWidth-ansi.StringWidth(key))
+		indent := strings.Repeat(" ", keyWidth+1)
+		for j, v := range wrap(sanitize(f.Value), valueWidth) {
+			if j == 0 {
+				rows = append(rows, keyStyle.Render(key)+" "+v)
+			} else {
+				rows = append(rows, indent+v)
+			}
+		}
+	}
+	return rows
+}
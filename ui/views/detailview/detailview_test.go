@@ -0,0 +1,157 @@
+package detailview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		line string
+		want Format
+	}{
+		{`{"a":1}`, JSON},
+		{`10:00:01 INFO {"a":"b=c d=e"}`, JSON},
+		{`level=info msg=hi`, Logfmt},
+		{`just a line`, Plain},
+		{`timeout=5s`, Plain},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.line); got != tt.want {
+			t.Errorf("Detect(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHeight(t *testing.T) {
+	tests := []struct{ windowHeight, want int }{
+		{0, MinHeight},
+		{8, MinHeight},
+		{40, 10},
+		{100, 25},
+	}
+	for _, tt := range tests {
+		if got := Height(tt.windowHeight); got != tt.want {
+			t.Errorf("Height(%d) = %d, want %d", tt.windowHeight, got, tt.want)
+		}
+	}
+}
+
+// plainRows strips styling from Render's output and splits it into rows.
+func plainRows(s string) []string {
+	return strings.Split(ansi.Strip(s), "\n")
+}
+
+func TestRenderIsAlwaysHeaderPlusHeightRows(t *testing.T) {
+	lines := []string{
+		"",
+		"short",
+		strings.Repeat("word ", 200),
+		`{"a":[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20]}`,
+		"a=1 b=2",
+	}
+	for _, line := range lines {
+		for _, width := range []int{1, 10, 80} {
+			rows := plainRows(Render(1, line, width, 5))
+			if len(rows) != 6 {
+				t.Errorf("Render(%q, width %d, height 5) has %d rows, want 6", line, width, len(rows))
+			}
+			for i, row := range rows[1:] {
+				if w := ansi.StringWidth(row); w != width {
+					t.Errorf("Render(%q, width %d) row %d is %d wide, want exactly %d", line, width, i, w, width)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderWrapsPlainLineInsteadOfTruncating(t *testing.T) {
+	line := "alpha beta gamma delta epsilon"
+	rows := plainRows(Render(7, line, 14, 5))
+
+	if !strings.HasPrefix(rows[0], "Line 7 (text") {
+		t.Errorf("header = %q, want it to name line 7 and the text format", rows[0])
+	}
+	if got := strings.Join(strings.Fields(strings.Join(rows[1:], " ")), " "); got != line {
+		t.Errorf("wrapped rows rejoin to %q, want the full line %q", got, line)
+	}
+}
+
+func TestRenderPrettyPrintsJSON(t *testing.T) {
+	rows := plainRows(Render(1, `2024-01-02 10:00:00 {"level":"error","ctx":{"id":7}} done`, 60, 10))
+
+	want := []string{
+		"2024-01-02 10:00:00",
+		"{",
+		`  "level": "error",`,
+		`  "ctx": {`,
+		`    "id": 7`,
+		"  }",
+		"}",
+		"done",
+	}
+	for i, w := range want {
+		if got := strings.TrimRight(rows[1+i], " "); got != w {
+			t.Errorf("row %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestColorizeJSONStylesKeysAndValuesDifferently(t *testing.T) {
+	got := colorizeJSON(`  "ok": true, "n": -1.5e3, "s": "x\"y"`)
+
+	for _, want := range []string{
+		keyStyle.Render(`"ok"`),
+		literalStyle.Render("true"),
+		numberStyle.Render("-1.5e3"),
+		stringStyle.Render(`"x\"y"`),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("colorizeJSON() = %q, want it to contain %q", got, want)
+		}
+	}
+	if ansi.Strip(got) != `  "ok": true, "n": -1.5e3, "s": "x\"y"` {
+		t.Errorf("colorizeJSON() changed the text itself: %q", ansi.Strip(got))
+	}
+}
+
+func TestRenderAlignsLogfmtFields(t *testing.T) {
+	rows := plainRows(Render(1, `10:00 INFO level=info message="a long value that wraps" id=3`, 30, 8))
+
+	want := []string{
+		"10:00 INFO",
+		"level   info",
+		"message a long value that",
+		"        wraps",
+		"id      3",
+	}
+	for i, w := range want {
+		if got := strings.TrimRight(rows[1+i], " "); got != w {
+			t.Errorf("row %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestRenderMarksCutOffContent(t *testing.T) {
+	rows := plainRows(Render(1, `{"a":1,"b":2,"c":3,"d":4,"e":5}`, 40, 3))
+
+	if last := strings.TrimRight(rows[3], " "); last != "… 5 more lines" {
+		t.Errorf("last row = %q, want %q", last, "… 5 more lines")
+	}
+}
+
+func TestRenderStripsControlCharacters(t *testing.T) {
+	rows := plainRows(Render(1, "a\x1b[31mred\r\x07b", 20, 2))
+	if got := strings.TrimRight(rows[1], " "); got != "aredb" {
+		t.Errorf("row = %q, want control characters and escapes removed", got)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	rows := plainRows(RenderEmpty(20, 4))
+	if len(rows) != 5 || !strings.Contains(rows[0], "No line selected") {
+		t.Errorf("RenderEmpty() = %q, want a header and 4 blank rows", rows)
+	}
+}
@@ -24,7 +24,7 @@ const (
 // and windowing (scrolling) when there are more -- so the filter pane's
 // total rendered height is constant regardless of len(Filters). ui.go's
 // View relies on that constancy to size the log pane's height budget
-// correctly (see logview.MakeTable's chromeLines doc comment); if this
+// correctly (see logview.MakeTable's ChromeLines doc comment); if this
 // ever becomes variable again, that budget will desync the same way it
 // did before (see the CLAUDE.md gotcha on frames taller than the
 // terminal, and the issue this fixed).
@@ -48,10 +48,7 @@ func (v *FilterView) Toggle() {
 	case EnabledColumn:
 		filter.IsEnabled = !filter.IsEnabled
 	case CaseSensitiveColumn:
-		filter.CaseSensitive = !filter.CaseSensitive
-		if regex, err := filterfiles.CompileRegex(filter.XML.Text, filter.CaseSensitive); err == nil {
-			filter.Regex = regex
-		}
+		filter.SetCaseSensitive(!filter.CaseSensitive)
 	case ExcludingColumn:
 		filter.Excluding = !filter.Excluding
 	}
@@ -285,7 +282,18 @@ func (v *FilterView) Render(windowWidth int, windowHeight int, counts []int) str
 
 		style := filterStyle
 		style.Background(lipgloss.Color(filter.BackColor))
-		regexCell := style.Render(cell(filter.XML.Text, regexWidth))
+		regexText := filter.XML.Text
+		if filter.IsBlock() {
+			// A block filter (see filterfiles.Filter.IsBlock) shows the
+			// patterns that open and close its blocks.
+			regexText += " … " + filter.XML.EndText
+		}
+		if filter.Field != "" {
+			// A field-targeting filter (see filterfiles.Filter.Field)
+			// shows its field ahead of the pattern it matches against it.
+			regexText = fmt.Sprintf("[%s] %s", filter.Field, regexText)
+		}
+		regexCell := style.Render(cell(regexText, regexWidth))
 
 		count := 0
 		if i < len(counts) {
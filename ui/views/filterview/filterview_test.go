@@ -459,6 +459,28 @@ func TestRenderShowsDescription(t *testing.T) {
 	}
 }
 
+func TestRenderShowsTargetFieldAheadOfRegex(t *testing.T) {
+	f := mustFilter(t, "^error$", false, true, "#000000")
+	f.Field = "level"
+
+	v := FilterView{Filters: []filterfiles.Filter{f}}
+
+	if out := v.Render(120, 30, nil); !strings.Contains(out, "[level] ^error$") {
+		t.Errorf("expected the filter's field ahead of its regex, got:\n%s", out)
+	}
+}
+
+func TestRenderShowsBlockFilterEndPattern(t *testing.T) {
+	f := mustFilter(t, "^BEGIN", false, true, "#000000")
+	f.XML.EndText = "^END"
+
+	v := FilterView{Filters: []filterfiles.Filter{f}}
+
+	if out := v.Render(120, 30, nil); !strings.Contains(out, "^BEGIN … ^END") {
+		t.Errorf("expected the block filter's start and end patterns, got:\n%s", out)
+	}
+}
+
 func TestRenderShowsMatchCounts(t *testing.T) {
 	v := FilterView{
 		Filters: []filterfiles.Filter{
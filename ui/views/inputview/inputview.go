@@ -0,0 +1,209 @@
+// Package inputview is the one-line text input every prompt types into --
+// search, jump to line, time, pipe, transform, the filter editor's text
+// fields and the color picker's hex value -- so they all edit the same
+// way: readline-style cursor movement and deletion, and bracketed paste.
+package inputview
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Input is a line of text being typed and the cursor within it. The zero
+// value is an empty input that takes any text.
+type Input struct {
+	text   []rune
+	cursor int // the cursor sits before text[cursor]; len(text) is the end
+
+	// Accept, if set, reports whether r may be typed (or pasted) in; the
+	// rest are dropped, e.g. anything but digits in a line number.
+	Accept func(r rune) bool
+	// MaxLen caps the text's length in runes; 0 means no cap.
+	MaxLen int
+}
+
+// New returns an input holding value, with the cursor at its end.
+func New(value string) Input {
+	var in Input
+	in.SetValue(value)
+	return in
+}
+
+// Value returns the text typed so far.
+func (in Input) Value() string {
+	return string(in.text)
+}
+
+// Cursor returns the cursor's position, in runes from the start.
+func (in Input) Cursor() int {
+	return in.cursor
+}
+
+// SetValue replaces the text with value, putting the cursor at its end.
+// Accept and MaxLen don't apply: value is taken as it is.
+func (in *Input) SetValue(value string) {
+	in.text = []rune(value)
+	in.cursor = len(in.text)
+}
+
+// SetCursor moves the cursor to pos, in runes from the start, clamped to
+// the text.
+func (in *Input) SetCursor(pos int) {
+	in.cursor = min(max(pos, 0), len(in.text))
+}
+
+// Reset empties the input, keeping its Accept and MaxLen.
+func (in *Input) Reset() {
+	in.SetValue("")
+}
+
+// Update applies an editing key, reporting whether msg was one:
+//
+//   - typed and pasted text is inserted at the cursor; a paste's line
+//     breaks become spaces, since a prompt is a single line
+//   - left/ctrl+b and right/ctrl+f move by a character, alt+left/alt+b and
+//     alt+right/alt+f by a word, home/ctrl+a and end/ctrl+e to either end
+//   - backspace/ctrl+h and delete/ctrl+d delete a character either side of
+//     the cursor, alt+backspace and alt+d a word, ctrl+w back to the
+//     previous space, and ctrl+u and ctrl+k to the start or end
+//
+// Callers handle their own keys first, so one of these a prompt uses
+// itself -- the filter editor's ctrl+e, say -- keeps that meaning there.
+func (in *Input) Update(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "left", "ctrl+b":
+		in.cursor = max(in.cursor-1, 0)
+	case "right", "ctrl+f":
+		in.cursor = min(in.cursor+1, len(in.text))
+	case "alt+left", "ctrl+left", "alt+b":
+		in.cursor = in.wordStart()
+	case "alt+right", "ctrl+right", "alt+f":
+		in.cursor = in.wordEnd()
+	case "home", "ctrl+a":
+		in.cursor = 0
+	case "end", "ctrl+e":
+		in.cursor = len(in.text)
+
+	case "backspace", "ctrl+h":
+		in.delete(max(in.cursor-1, 0), in.cursor)
+	case "delete", "ctrl+d":
+		in.delete(in.cursor, min(in.cursor+1, len(in.text)))
+	case "alt+backspace", "ctrl+backspace":
+		in.delete(in.wordStart(), in.cursor)
+	case "alt+d", "alt+delete":
+		in.delete(in.cursor, in.wordEnd())
+	case "ctrl+w":
+		start := in.cursor
+		for start > 0 && unicode.IsSpace(in.text[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(in.text[start-1]) {
+			start--
+		}
+		in.delete(start, in.cursor)
+	case "ctrl+u":
+		in.delete(0, in.cursor)
+	case "ctrl+k":
+		in.delete(in.cursor, len(in.text))
+
+	default:
+		switch {
+		case msg.Type == tea.KeySpace:
+			in.insert([]rune{' '})
+		case msg.Type == tea.KeyRunes && msg.Paste:
+			in.insert(pastedRunes(msg.Runes))
+		case msg.Type == tea.KeyRunes && !msg.Alt:
+			in.insert(msg.Runes)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// pastedRunes is pasted text as it goes into a one-line input: a trailing
+// line break dropped, and any others turned into spaces.
+func pastedRunes(runes []rune) []rune {
+	text := strings.TrimRight(string(runes), "\r\n")
+	text = strings.ReplaceAll(text, "\r\n", " ")
+	text = strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+	return []rune(text)
+}
+
+// insert puts runes in at the cursor, and the cursor after them, leaving
+// out any Accept rejects and any past MaxLen.
+func (in *Input) insert(runes []rune) {
+	var add []rune
+	for _, r := range runes {
+		if in.Accept != nil && !in.Accept(r) {
+			continue
+		}
+		if in.MaxLen > 0 && len(in.text)+len(add) >= in.MaxLen {
+			break
+		}
+		add = append(add, r)
+	}
+	text := make([]rune, 0, len(in.text)+len(add))
+	text = append(text, in.text[:in.cursor]...)
+	text = append(text, add...)
+	in.text = append(text, in.text[in.cursor:]...)
+	in.cursor += len(add)
+}
+
+// delete removes text[from:to], leaving the cursor where it was.
+func (in *Input) delete(from, to int) {
+	if from >= to {
+		return
+	}
+	in.text = append(in.text[:from:from], in.text[to:]...)
+	in.cursor = from
+}
+
+// isWordRune reports whether r is part of a word, as word motions and
+// deletions see it.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// wordStart is where the word before the cursor starts, past anything
+// between it and the cursor.
+func (in Input) wordStart() int {
+	i := in.cursor
+	for i > 0 && !isWordRune(in.text[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(in.text[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd is where the word after the cursor ends, past anything between
+// the cursor and it.
+func (in Input) wordEnd() int {
+	i := in.cursor
+	for i < len(in.text) && !isWordRune(in.text[i]) {
+		i++
+	}
+	for i < len(in.text) && isWordRune(in.text[i]) {
+		i++
+	}
+	return i
+}
+
+// cursorStyle marks the character under the cursor.
+var cursorStyle = lipgloss.NewStyle().Reverse(true)
+
+// View renders the text with the character under the cursor in reverse
+// video. At the end of the text there's no character to mark, and nothing
+// is added -- like the prompts always have, it leaves that to the
+// terminal's own cursor rather than drawing a glyph of its own.
+func (in Input) View() string {
+	if in.cursor >= len(in.text) {
+		return string(in.text)
+	}
+	return string(in.text[:in.cursor]) + cursorStyle.Render(string(in.text[in.cursor])) + string(in.text[in.cursor+1:])
+}
@@ -0,0 +1,132 @@
+package inputview
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func key(s string) tea.KeyMsg {
+	special := map[string]tea.KeyMsg{
+		"left":          {Type: tea.KeyLeft},
+		"right":         {Type: tea.KeyRight},
+		"home":          {Type: tea.KeyHome},
+		"end":           {Type: tea.KeyEnd},
+		"backspace":     {Type: tea.KeyBackspace},
+		"delete":        {Type: tea.KeyDelete},
+		"alt+backspace": {Type: tea.KeyBackspace, Alt: true},
+		"alt+b":         {Type: tea.KeyRunes, Runes: []rune("b"), Alt: true},
+		"alt+f":         {Type: tea.KeyRunes, Runes: []rune("f"), Alt: true},
+		"alt+d":         {Type: tea.KeyRunes, Runes: []rune("d"), Alt: true},
+		"ctrl+a":        {Type: tea.KeyCtrlA},
+		"ctrl+e":        {Type: tea.KeyCtrlE},
+		"ctrl+w":        {Type: tea.KeyCtrlW},
+		"ctrl+u":        {Type: tea.KeyCtrlU},
+		"ctrl+k":        {Type: tea.KeyCtrlK},
+		" ":             {Type: tea.KeySpace},
+		"enter":         {Type: tea.KeyEnter},
+	}
+	if k, ok := special[s]; ok {
+		return k
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// edit applies keys to an input holding start, returning its text and
+// where the cursor ended up.
+func edit(start string, keys ...string) (string, int) {
+	in := New(start)
+	for _, k := range keys {
+		in.Update(key(k))
+	}
+	return in.Value(), in.Cursor()
+}
+
+func TestUpdateEditsAtTheCursor(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      string
+		keys       []string
+		want       string
+		wantCursor int
+	}{
+		{"typing appends", "ab", []string{"c", " ", "d"}, "abc d", 5},
+		{"typing inserts at the cursor", "ad", []string{"left", "b", "c"}, "abcd", 3},
+		{"left stops at the start", "ab", []string{"left", "left", "left", "x"}, "xab", 1},
+		{"home and end", "abc", []string{"home", "x", "end", "y"}, "xabcy", 5},
+		{"ctrl+a and ctrl+e", "abc", []string{"ctrl+a", "x", "ctrl+e", "y"}, "xabcy", 5},
+		{"backspace deletes before the cursor", "abc", []string{"left", "backspace"}, "ac", 1},
+		{"backspace at the start does nothing", "abc", []string{"home", "backspace"}, "abc", 0},
+		{"delete deletes under the cursor", "abc", []string{"home", "delete"}, "bc", 0},
+		{"delete at the end does nothing", "abc", []string{"delete"}, "abc", 3},
+		{"word motions", "foo.bar baz", []string{"alt+b", "alt+b", "x", "alt+f", "y"}, "foo.xbary baz", 9},
+		{"alt+backspace deletes a word", `error\s+(\d+)`, []string{"alt+backspace"}, `error\s+(\`, 10},
+		{"alt+d deletes the next word", "foo bar baz", []string{"home", "alt+f", "alt+d"}, "foo baz", 3},
+		{"ctrl+w deletes back to a space", "grep -v foo.bar  ", []string{"ctrl+w"}, "grep -v ", 8},
+		{"ctrl+u deletes to the start", "abcdef", []string{"left", "left", "ctrl+u"}, "ef", 0},
+		{"ctrl+k deletes to the end", "abcdef", []string{"left", "left", "ctrl+k"}, "abcd", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cursor := edit(tt.start, tt.keys...)
+			if got != tt.want || cursor != tt.wantCursor {
+				t.Errorf("got %q with the cursor at %d, want %q at %d", got, cursor, tt.want, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestUpdateReportsWhetherItUsedTheKey(t *testing.T) {
+	in := New("abc")
+	if in.Update(key("enter")) {
+		t.Error("Update(enter) = true, want it left to the prompt")
+	}
+	if in.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true}) {
+		t.Error("Update(alt+x) = true, want an unknown alt key ignored rather than typed")
+	}
+	if !in.Update(key("left")) {
+		t.Error("Update(left) = false, want it used")
+	}
+	if in.Value() != "abc" {
+		t.Errorf("Value() = %q, want nothing typed", in.Value())
+	}
+}
+
+func TestUpdatePastesAtTheCursor(t *testing.T) {
+	in := New("()")
+	in.Update(key("left"))
+	in.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a|b\nc\r\n"), Paste: true})
+	if in.Value() != "(a|b c)" || in.Cursor() != 6 {
+		t.Errorf("after paste: %q with the cursor at %d, want %q at 6", in.Value(), in.Cursor(), "(a|b c)")
+	}
+}
+
+func TestAcceptAndMaxLen(t *testing.T) {
+	in := Input{
+		Accept: func(r rune) bool { return r >= '0' && r <= '9' },
+		MaxLen: 4,
+	}
+	in.Update(key("1a2"))
+	in.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3 45"), Paste: true})
+	if in.Value() != "1234" {
+		t.Errorf("Value() = %q, want only digits, at most 4", in.Value())
+	}
+
+	in.Reset()
+	in.Update(key("x9"))
+	if in.Value() != "9" {
+		t.Errorf("Value() after Reset = %q, want Accept still applied", in.Value())
+	}
+}
+
+func TestViewMarksTheCursor(t *testing.T) {
+	in := New("abc")
+	if in.View() != "abc" {
+		t.Errorf("View() with the cursor at the end = %q, want the text alone", in.View())
+	}
+	in.Update(key("left"))
+	if got := ansi.Strip(in.View()); got != "abc" {
+		t.Errorf("View() = %q once styles are stripped, want the text unchanged", got)
+	}
+}
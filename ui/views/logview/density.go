@@ -0,0 +1,221 @@
+package logview
+
+import (
+	"skim/filterfiles"
+	"skim/timestamps"
+	"strconv"
+	"time"
+)
+
+// Density is how a log's filter matches are spread across it, split into
+// equal buckets -- of time when the log has timestamps, of line position
+// otherwise -- for the density panel to draw (see densityview).
+type Density struct {
+	// ByTime is whether the buckets split the span from Start to End
+	// into equal stretches of time; otherwise they split the lines
+	// counted (see Lines) into equal runs.
+	ByTime     bool
+	Start, End time.Time
+
+	// Counts[f][b] is how many lines in bucket b filter f is the
+	// highlighting match for, with f indexing the filters Density was
+	// given -- the same attribution as MatchCounts, so a row sums to the
+	// filter's count in the Filters pane (within TimeRange, if set).
+	Counts [][]int
+
+	// First[b] is the first line (an index into Lines) in bucket b, or,
+	// for an empty bucket, the first line of the next bucket that isn't
+	// -- so selecting a gap lands on whatever comes after it. It's -1
+	// only for empty buckets at the very end.
+	First []int
+
+	// Lines is how many lines the buckets cover: every line, or with a
+	// TimeRange set only the lines inside it.
+	Lines int
+
+	// lo and hi are the first and last line covered, for bucketing by
+	// position.
+	lo, hi int
+}
+
+// Buckets returns how many buckets d has.
+func (d Density) Buckets() int {
+	return len(d.First)
+}
+
+// Bucket returns which bucket line i falls into, and false if it falls
+// outside all of them (outside TimeRange, or before the log's first
+// timestamp when there's a TimeRange to be inside of).
+func (v *LogView) Bucket(d Density, i int) (int, bool) {
+	if i < 0 || i >= len(v.Lines) {
+		return 0, false
+	}
+	v.ensureTimes()
+	return d.bucket(i, v.times[i], v.TimeRange)
+}
+
+// bucket is Bucket for line i, whose time is t, with r the TimeRange d was
+// computed under.
+func (d Density) bucket(i int, t time.Time, r timestamps.Range) (int, bool) {
+	n := d.Buckets()
+	if n == 0 {
+		return 0, false
+	}
+	if !d.ByTime {
+		if i < d.lo || i > d.hi {
+			return 0, false
+		}
+		return (i - d.lo) * n / (d.hi - d.lo + 1), true
+	}
+
+	if !r.Contains(t) {
+		return 0, false
+	}
+	if t.IsZero() || !t.After(d.Start) {
+		return 0, true
+	}
+	span := d.End.Sub(d.Start)
+	if span <= 0 {
+		return 0, true
+	}
+	return min(int(float64(t.Sub(d.Start))/float64(span)*float64(n)), n-1), true
+}
+
+// BucketSpan returns how much time (ByTime) or how many lines each of d's
+// buckets covers, as a short label for the panel's header.
+func (d Density) BucketSpan() string {
+	n := d.Buckets()
+	if n == 0 {
+		return ""
+	}
+	if d.ByTime {
+		return formatGap(max(d.End.Sub(d.Start)/time.Duration(n), time.Millisecond))
+	}
+	return strconv.Itoa(max((d.Lines+n-1)/n, 1)) + " lines"
+}
+
+// densityCache holds the last Density computed, and the fingerprint of the
+// filters, log, time settings and bucket count it was computed for.
+type densityCache struct {
+	key string
+	d   Density
+}
+
+// Density splits the log into buckets and counts each filter's matches in
+// each one (see the Density type). It's bucketed by time whenever the log
+// has timestamps, and covers only TimeRange when that's set, so narrowing
+// to a time range zooms the panel in on it. Hiding unmatched lines doesn't
+// change it: like MatchCounts, it describes the log, not the view.
+//
+// The result is cached until any of its inputs change, since the panel
+// asks for it on every render; callers must not modify it.
+func (v *LogView) Density(filters []filterfiles.Filter, buckets int) Density {
+	v.ensureMatchCache(filters)
+	v.ensureTimes()
+	key := v.matchCacheKey + "|" + v.timesKey + "|" + timeRangeKey(v.TimeRange) + "|" +
+		strconv.Itoa(len(v.Lines)) + "|" + strconv.Itoa(buckets)
+	if key == v.density.key {
+		return v.density.d
+	}
+
+	d := Density{Counts: make([][]int, len(filters))}
+	if buckets > 0 {
+		d.First = make([]int, buckets)
+		for f := range d.Counts {
+			d.Counts[f] = make([]int, buckets)
+		}
+	}
+
+	// Work out the span the buckets cover first: the covered lines'
+	// earliest and latest times, or their first and last positions.
+	d.lo, d.hi = -1, -1
+	for i, t := range v.times {
+		if !v.TimeRange.Contains(t) {
+			continue
+		}
+		if d.lo < 0 {
+			d.lo = i
+		}
+		d.hi = i
+		d.Lines++
+		if t.IsZero() {
+			continue
+		}
+		if !d.ByTime || t.Before(d.Start) {
+			d.Start = t
+		}
+		if !d.ByTime || t.After(d.End) {
+			d.End = t
+		}
+		d.ByTime = true
+	}
+
+	for b := range d.First {
+		d.First[b] = -1
+	}
+	if d.Lines > 0 {
+		for i := d.lo; i <= d.hi; i++ {
+			b, ok := d.bucket(i, v.times[i], v.TimeRange)
+			if !ok {
+				continue
+			}
+			if d.First[b] < 0 {
+				d.First[b] = i
+			}
+			if f := v.matchCache[i].filterIndex; f >= 0 {
+				d.Counts[f][b]++
+			}
+		}
+	}
+	next := -1
+	for b := len(d.First) - 1; b >= 0; b-- {
+		if d.First[b] < 0 {
+			d.First[b] = next
+		}
+		next = d.First[b]
+	}
+
+	v.density = densityCache{key: key, d: d}
+	return d
+}
+
+// NextBucketStart returns the first line of the first bucket after the
+// one line i is in, and false if that's the last bucket with anything in
+// it -- where the panel's "next bucket" step goes from i.
+func (v *LogView) NextBucketStart(d Density, i int) (int, bool) {
+	b, ok := v.Bucket(d, i)
+	if !ok {
+		// Outside the covered lines: the first bucket is next if i is
+		// before them, nothing if after.
+		if d.Buckets() > 0 && d.Lines > 0 && i < d.lo {
+			return d.First[0], d.First[0] >= 0
+		}
+		return 0, false
+	}
+	for k := b + 1; k < d.Buckets(); k++ {
+		if d.First[k] > i {
+			return d.First[k], true
+		}
+	}
+	return 0, false
+}
+
+// PrevBucketStart is NextBucketStart backwards: the first line of the
+// bucket line i is in, if i isn't already it, otherwise of the bucket
+// before.
+func (v *LogView) PrevBucketStart(d Density, i int) (int, bool) {
+	b, ok := v.Bucket(d, i)
+	if !ok {
+		if d.Lines > 0 && i > d.hi {
+			b = d.Buckets() - 1
+		} else {
+			return 0, false
+		}
+	}
+	for k := b; k >= 0; k-- {
+		if d.First[k] >= 0 && d.First[k] < i {
+			return d.First[k], true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,141 @@
+package logview
+
+import (
+	"skim/filterfiles"
+	"skim/timestamps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDensityByPosition(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000"), mustFilter(t, "WARN", "#FFFF00")}
+	v := &LogView{Lines: []string{
+		"ERROR a", "ok", "ok", "ok", // bucket 0
+		"ok", "WARN b", "ok", "ok", // bucket 1
+		"ERROR c", "ERROR d", "ok", "WARN e", // bucket 2
+		"ok", "ok", "ok", "ok", // bucket 3
+	}}
+
+	d := v.Density(filters, 4)
+	if d.ByTime {
+		t.Fatal("ByTime = true for a log without timestamps")
+	}
+	wantCounts := [][]int{{1, 0, 2, 0}, {0, 1, 1, 0}}
+	for f := range wantCounts {
+		if !slices.Equal(d.Counts[f], wantCounts[f]) {
+			t.Errorf("Counts[%d] = %v, want %v", f, d.Counts[f], wantCounts[f])
+		}
+	}
+	if want := []int{0, 4, 8, 12}; !slices.Equal(d.First, want) {
+		t.Errorf("First = %v, want %v", d.First, want)
+	}
+	if got := d.BucketSpan(); got != "4 lines" {
+		t.Errorf("BucketSpan() = %q, want %q", got, "4 lines")
+	}
+	if b, ok := v.Bucket(d, 10); !ok || b != 2 {
+		t.Errorf("Bucket(10) = %d, %v, want 2", b, ok)
+	}
+}
+
+func TestDensityRowsSumToMatchCounts(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "1", "#FF0000"), mustFilter(t, "2", "#FFFF00")}
+	v := &LogView{Lines: genLines(1000)}
+
+	counts := v.MatchCounts(filters)
+	d := v.Density(filters, 37)
+	for f := range filters {
+		sum := 0
+		for _, c := range d.Counts[f] {
+			sum += c
+		}
+		if sum != counts[f] {
+			t.Errorf("filter %d: Density counts sum to %d, want MatchCounts' %d", f, sum, counts[f])
+		}
+	}
+}
+
+// densityLog has two bursts of ERROR lines ten minutes apart, with nothing
+// logged between them.
+func densityLog() *LogView {
+	return &LogView{
+		Lines: []string{
+			stamp(0, "ERROR a"),
+			stamp(1, "INFO b"),
+			stamp(2, "ERROR c"),
+			stamp(600, "ERROR d"),
+			stamp(601, "INFO e"),
+		},
+		TimeParser: timestamps.Parser{Location: time.UTC},
+	}
+}
+
+func TestDensityByTime(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000")}
+	v := densityLog()
+
+	d := v.Density(filters, 10)
+	if !d.ByTime {
+		t.Fatal("ByTime = false for a log with timestamps")
+	}
+	if want := []int{2, 0, 0, 0, 0, 0, 0, 0, 0, 1}; !slices.Equal(d.Counts[0], want) {
+		t.Errorf("Counts = %v, want %v", d.Counts[0], want)
+	}
+	// The quiet buckets in between lead to the second burst.
+	if want := []int{0, 3, 3, 3, 3, 3, 3, 3, 3, 3}; !slices.Equal(d.First, want) {
+		t.Errorf("First = %v, want %v", d.First, want)
+	}
+	if got := d.BucketSpan(); got != "1m0s" {
+		t.Errorf("BucketSpan() = %q, want %q", got, "1m0s")
+	}
+}
+
+func TestDensityZoomsToTimeRange(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000")}
+	v := densityLog()
+	v.TimeRange = timestamps.Range{Until: time.Date(2024, 1, 2, 14, 0, 2, 0, time.UTC)}
+
+	d := v.Density(filters, 2)
+	if d.Lines != 3 || !d.End.Equal(v.TimeRange.Until) {
+		t.Errorf("Lines = %d, End = %v, want only the first burst covered", d.Lines, d.End)
+	}
+	if want := []int{1, 1}; !slices.Equal(d.Counts[0], want) {
+		t.Errorf("Counts = %v, want %v", d.Counts[0], want)
+	}
+	if _, ok := v.Bucket(d, 3); ok {
+		t.Error("Bucket(3) = ok for a line outside the time range")
+	}
+}
+
+func TestDensityRecomputedWhenFiltersChange(t *testing.T) {
+	v := densityLog()
+	d := v.Density([]filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000")}, 2)
+	if d.Counts[0][0] != 2 {
+		t.Fatalf("Counts = %v", d.Counts)
+	}
+	d = v.Density([]filterfiles.Filter{mustFilter(t, "INFO", "#FF0000")}, 2)
+	if d.Counts[0][0] != 1 || d.Counts[0][1] != 1 {
+		t.Errorf("Counts = %v after changing the filter, want the INFO lines counted", d.Counts)
+	}
+}
+
+func TestNextAndPrevBucketStart(t *testing.T) {
+	v := densityLog()
+	d := v.Density(nil, 10)
+
+	if got, ok := v.NextBucketStart(d, 0); !ok || got != 3 {
+		t.Errorf("NextBucketStart(0) = %d, %v, want 3, skipping the empty buckets", got, ok)
+	}
+	if _, ok := v.NextBucketStart(d, 3); ok {
+		t.Error("NextBucketStart(3) = ok from the last bucket")
+	}
+	if got, ok := v.PrevBucketStart(d, 4); !ok || got != 3 {
+		t.Errorf("PrevBucketStart(4) = %d, %v, want the start of its own bucket", got, ok)
+	}
+	if got, ok := v.PrevBucketStart(d, 3); !ok || got != 0 {
+		t.Errorf("PrevBucketStart(3) = %d, %v, want the bucket before", got, ok)
+	}
+	if _, ok := v.PrevBucketStart(d, 0); ok {
+		t.Error("PrevBucketStart(0) = ok from the first line")
+	}
+}
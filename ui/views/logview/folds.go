@@ -0,0 +1,133 @@
+package logview
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fold is a range of lines, [Start, End) as indices into Lines, collapsed
+// by hand into a single summary row (see LogView.FoldLines).
+type Fold struct {
+	Start, End int
+}
+
+// Len returns how many lines f covers.
+func (f Fold) Len() int {
+	return f.End - f.Start
+}
+
+// foldStyle sets a fold's summary row apart from both log lines and the
+// dimmer separator rows (see separatorStyle): unlike those, it's a row the
+// cursor can sit on.
+var foldStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("110")).Bold(true)
+
+// foldRow is the summary row standing in for f, e.g. "▸ 3,000 lines folded
+// · 12–3,011", numbered as f's first line.
+func foldRow(f Fold) table.Row {
+	label := "▸ " + groupDigits(f.Len()) + " lines folded · " + groupDigits(f.Start+1) + "–" + groupDigits(f.End)
+	if f.Len() == 1 {
+		label = "▸ 1 line folded"
+	}
+	return table.Row{strconv.Itoa(f.Start + 1), foldStyle.Render(label)}
+}
+
+// Folds returns a copy of the current folds, in order.
+func (v *LogView) Folds() []Fold {
+	return append([]Fold(nil), v.folds...)
+}
+
+// SetFolds replaces every fold with folds, e.g. ones restored from the
+// log's state file: each is clipped to Lines, and empty ones dropped and
+// overlapping ones merged, so a state file saved against a log that has
+// since changed can't produce a fold MakeTable can't show.
+func (v *LogView) SetFolds(folds []Fold) {
+	v.folds = v.folds[:0]
+	for _, f := range folds {
+		v.addFold(f)
+	}
+	v.foldsVersion++
+}
+
+// FoldLines folds lines [lo, hi), merging in any fold it overlaps, and
+// returns the resulting fold, or false if the range is empty. Every line
+// in the range is folded, shown or not: a fold always appears as its
+// summary row, whatever the filters, hideUnmatched or TimeRange would
+// otherwise hide, since it was put there by hand.
+func (v *LogView) FoldLines(lo, hi int) (Fold, bool) {
+	f, ok := v.addFold(Fold{lo, hi})
+	if ok {
+		v.foldsVersion++
+	}
+	return f, ok
+}
+
+// addFold is FoldLines without the cache invalidation.
+func (v *LogView) addFold(f Fold) (Fold, bool) {
+	f.Start, f.End = max(f.Start, 0), min(f.End, len(v.Lines))
+	if f.Start >= f.End {
+		return Fold{}, false
+	}
+	kept := make([]Fold, 0, len(v.folds)+1)
+	for _, g := range v.folds {
+		if g.End <= f.Start || g.Start >= f.End {
+			kept = append(kept, g)
+			continue
+		}
+		f.Start, f.End = min(f.Start, g.Start), max(f.End, g.End)
+	}
+	k := sort.Search(len(kept), func(k int) bool { return kept[k].Start > f.Start })
+	v.folds = append(kept[:k], append([]Fold{f}, kept[k:]...)...)
+	return f, true
+}
+
+// FoldAt returns the fold line i is in, and false if it isn't in one.
+func (v *LogView) FoldAt(i int) (Fold, bool) {
+	k := sort.Search(len(v.folds), func(k int) bool { return v.folds[k].End > i })
+	if k == len(v.folds) || v.folds[k].Start > i {
+		return Fold{}, false
+	}
+	return v.folds[k], true
+}
+
+// Unfold removes the fold line i is in, returning it, or false if there
+// isn't one.
+func (v *LogView) Unfold(i int) (Fold, bool) {
+	for k, f := range v.folds {
+		if f.Start <= i && i < f.End {
+			v.folds = append(v.folds[:k], v.folds[k+1:]...)
+			v.foldsVersion++
+			return f, true
+		}
+	}
+	return Fold{}, false
+}
+
+// rowEnd returns the line after the last one shown line i's row stands
+// for: i+1, or the end of the fold i starts. It's 0 for i = -1, the start
+// of the log.
+func (v *LogView) rowEnd(i int) int {
+	if f, ok := v.FoldAt(i); ok {
+		return f.End
+	}
+	return i + 1
+}
+
+// FoldSelection folds the selected lines (see Selection), ends the
+// selection, and puts the cursor on the new fold, returning it; it returns
+// false if nothing is being selected.
+func (v *LogView) FoldSelection() (Fold, bool) {
+	lo, hi, ok := v.Selection()
+	if !ok {
+		return Fold{}, false
+	}
+	f, ok := v.FoldLines(lo, hi)
+	if !ok {
+		return Fold{}, false
+	}
+	v.selecting = false
+	v.Cursor = f.Start
+	return f, true
+}
@@ -0,0 +1,133 @@
+package logview
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFoldLinesMergesOverlappingFolds(t *testing.T) {
+	v := &LogView{Lines: genLines(100)}
+	v.FoldLines(10, 20)
+	v.FoldLines(40, 50)
+	v.FoldLines(20, 30) // touches the first without overlapping it
+
+	if f, ok := v.FoldLines(15, 45); !ok || f != (Fold{10, 50}) {
+		t.Errorf("FoldLines(15, 45) = %+v, %v, want it merged into 10-50", f, ok)
+	}
+	if got, want := v.Folds(), []Fold{{10, 50}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Folds() = %+v, want %+v", got, want)
+	}
+	if _, ok := v.FoldLines(100, 120); ok {
+		t.Error("FoldLines past the end of the log = true, want nothing folded")
+	}
+}
+
+func TestSetFoldsClipsToTheLog(t *testing.T) {
+	v := &LogView{Lines: genLines(10)}
+	v.SetFolds([]Fold{{8, 20}, {2, 4}, {3, 5}, {30, 40}})
+
+	if got, want := v.Folds(), []Fold{{2, 5}, {8, 10}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Folds() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMakeTableShowsFoldAsOneRow(t *testing.T) {
+	v := &LogView{Lines: genLines(10), MarkHidden: true}
+	v.FoldLines(2, 6)
+	v.MakeTable(100, 30, nil, false, Context{})
+
+	got := plainRows(v)
+	want := []string{
+		"1|line 1",
+		"2|line 2",
+		"3|▸ 4 lines folded · 3–6",
+		"7|line 7",
+		"8|line 8",
+		"9|line 9",
+		"10|line 10",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFoldShownWhateverHidesItsLines(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.FoldLines(8, 12)
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	got := plainRows(v)
+	want := []string{
+		"|··· 5 lines hidden ···",
+		"6|ERROR first",
+		"|··· 2 lines hidden ···",
+		"9|▸ 4 lines folded · 9–12",
+		"|··· 3 lines hidden ···",
+		"16|ERROR second",
+		"|··· 4 lines hidden ···",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	v.Cursor = 8
+	v.MakeTable(100, 30, filters, true, Context{})
+	if lo, hi, ok := v.HiddenRun(8); !ok || lo != 12 || hi != 15 {
+		t.Errorf("HiddenRun(8) = %d, %d, %v, want the run after the fold, 12-15", lo, hi, ok)
+	}
+}
+
+func TestCursorStepsOverFolds(t *testing.T) {
+	v := &LogView{Lines: genLines(10)}
+	v.FoldLines(2, 6)
+
+	v.Cursor = 1
+	if got := v.CursorDown(); got != 2 {
+		t.Errorf("CursorDown() into the fold = %d, want its first line, 2", got)
+	}
+	if got := v.CursorDown(); got != 6 {
+		t.Errorf("CursorDown() out of the fold = %d, want the line after it, 6", got)
+	}
+	if got := v.CursorUp(); got != 2 {
+		t.Errorf("CursorUp() into the fold = %d, want its first line, 2", got)
+	}
+
+	v.FoldLines(8, 10)
+	v.Cursor = 8
+	if got := v.CursorDown(); got != 8 {
+		t.Errorf("CursorDown() on a fold ending the log = %d, want it to stay put", got)
+	}
+}
+
+func TestFoldSelection(t *testing.T) {
+	v := &LogView{Lines: genLines(20)}
+	v.Cursor = 12
+	v.MakeTable(100, 30, nil, false, Context{})
+	v.StartSelection()
+	v.Cursor = 4
+	v.MakeTable(100, 30, nil, false, Context{})
+
+	if lo, hi, ok := v.Selection(); !ok || lo != 4 || hi != 13 {
+		t.Errorf("Selection() = %d, %d, %v, want 4-13, the anchor's line included", lo, hi, ok)
+	}
+	f, ok := v.FoldSelection()
+	if !ok || f != (Fold{4, 13}) {
+		t.Errorf("FoldSelection() = %+v, %v, want 4-13", f, ok)
+	}
+	if v.Selecting() || v.Cursor != 4 {
+		t.Errorf("after FoldSelection: selecting %v, cursor %d, want the selection over and the cursor on the fold", v.Selecting(), v.Cursor)
+	}
+
+	// Selecting from a fold takes in all of it.
+	v.MakeTable(100, 30, nil, false, Context{})
+	v.StartSelection()
+	v.Cursor = 2
+	v.MakeTable(100, 30, nil, false, Context{})
+	if lo, hi, _ := v.Selection(); lo != 2 || hi != 13 {
+		t.Errorf("Selection() ending on a fold = %d-%d, want 2-13", lo, hi)
+	}
+	if _, ok := v.Unfold(7); !ok || len(v.Folds()) != 0 {
+		t.Errorf("Unfold(7) = %v, folds %+v, want the fold removed", ok, v.Folds())
+	}
+}
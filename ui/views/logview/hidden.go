@@ -0,0 +1,159 @@
+package logview
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// separatorStyle dims the separator rows hidden lines and gaps are marked
+// with, so they read as annotations rather than log lines.
+var separatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Italic(true)
+
+// separator returns the row marking what lies between two consecutively
+// shown lines, prev and next (indices into Lines; -1 and len(Lines) for the
+// start and end of the log), and false if there's nothing to mark: how many
+// lines are hidden there, if MarkHidden is on, and any time gaps (see
+// GapThreshold) -- not counting the lines folded into prev, if it's a fold
+// (see FoldLines) -- e.g. "··· 4,709 lines hidden · 3m19s gap ···". A gap
+// between two adjacent lines is drawn "── 3m19s gap ──" instead, so the two
+// kinds of separator can be told apart at a glance.
+func (v *LogView) separator(prev, next int) (table.Row, bool) {
+	after := v.rowEnd(prev)
+	hidden := 0
+	if v.MarkHidden {
+		hidden = next - after
+	}
+	var gap string
+	gapOK := false
+	if v.GapThreshold > 0 && prev >= 0 && next < len(v.Lines) {
+		gap, gapOK = v.gapLabel(after-1, next)
+	}
+
+	var label string
+	switch {
+	case hidden > 0 && gapOK:
+		label = "··· " + hiddenLabel(hidden) + " · " + gap + " ···"
+	case hidden > 0:
+		label = "··· " + hiddenLabel(hidden) + " ···"
+	case gapOK:
+		label = "── " + gap + " ──"
+	default:
+		return nil, false
+	}
+	return table.Row{"", separatorStyle.Render(label)}, true
+}
+
+// hiddenLabel reads "1 line hidden" or "4,709 lines hidden".
+func hiddenLabel(n int) string {
+	if n == 1 {
+		return "1 line hidden"
+	}
+	return groupDigits(n) + " lines hidden"
+}
+
+// groupDigits formats n with commas between groups of three digits.
+func groupDigits(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// isRevealed reports whether Reveal has shown line i.
+func (v *LogView) isRevealed(i int) bool {
+	return i < len(v.revealed) && v.revealed[i]
+}
+
+// HiddenRun returns the run of hidden lines [lo, hi) next to line i as of
+// the last MakeTable: the one right after it if there is one, otherwise the
+// one right before it -- the run a separator row below or above i counts.
+// It returns false if i has shown lines (or the ends of the log) directly
+// on both sides.
+func (v *LogView) HiddenRun(i int) (lo, hi int, ok bool) {
+	k := sort.SearchInts(v.shownIndices, i)
+	if k == len(v.shownIndices) || v.shownIndices[k] != i {
+		return 0, 0, false
+	}
+	next := len(v.Lines)
+	if k+1 < len(v.shownIndices) {
+		next = v.shownIndices[k+1]
+	}
+	if after := v.rowEnd(i); next > after {
+		return after, next, true
+	}
+	prev := -1
+	if k > 0 {
+		prev = v.shownIndices[k-1]
+	}
+	if after := v.rowEnd(prev); after < i {
+		return after, i, true
+	}
+	return 0, 0, false
+}
+
+// Reveal shows lines [lo, hi) whatever the filters, hideUnmatched or
+// TimeRange would otherwise hide, until HideRevealed. It's how a hidden
+// run is expanded in place (see ExpandHidden) without widening every
+// match's context the way Context would.
+func (v *LogView) Reveal(lo, hi int) {
+	lo, hi = max(lo, 0), min(hi, len(v.Lines))
+	if lo >= hi {
+		return
+	}
+	if len(v.revealed) < len(v.Lines) {
+		v.revealed = append(v.revealed, make([]bool, len(v.Lines)-len(v.revealed))...)
+	}
+	for i := lo; i < hi; i++ {
+		v.revealed[i] = true
+	}
+	v.revealedVersion++
+}
+
+// ExpandHidden reveals the hidden run next to the selected line (see
+// HiddenRun and SelectedLine): all of it if n <= 0, otherwise up to n lines
+// at each end -- the lines closest to what's already shown, like extra
+// context for just that spot. It returns how many lines it revealed.
+func (v *LogView) ExpandHidden(n int) int {
+	i, ok := v.SelectedLine()
+	if !ok {
+		return 0
+	}
+	lo, hi, ok := v.HiddenRun(i)
+	if !ok {
+		return 0
+	}
+	if n <= 0 || hi-lo <= 2*n {
+		v.Reveal(lo, hi)
+		return hi - lo
+	}
+	// A run at the very start or end of the log only borders shown lines
+	// on one side, so that's the only end worth revealing from.
+	revealed := 0
+	if lo > 0 {
+		v.Reveal(lo, lo+n)
+		revealed += n
+	}
+	if hi < len(v.Lines) {
+		v.Reveal(hi-n, hi)
+		revealed += n
+	}
+	return revealed
+}
+
+// HideRevealed undoes every Reveal, reporting whether there was anything
+// to undo.
+func (v *LogView) HideRevealed() bool {
+	any := false
+	for i, r := range v.revealed {
+		any = any || r
+		v.revealed[i] = false
+	}
+	if any {
+		v.revealedVersion++
+	}
+	return any
+}
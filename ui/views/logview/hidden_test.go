@@ -0,0 +1,195 @@
+package logview
+
+import (
+	"skim/filterfiles"
+	"strings"
+	"testing"
+)
+
+// hiddenLog is 20 lines with ERRORs on lines 6 and 16 (indices 5 and 15),
+// hiding unmatched lines: a hidden run before, between and after them.
+func hiddenLog(t *testing.T) (*LogView, []filterfiles.Filter) {
+	t.Helper()
+	lines := genLines(20)
+	lines[5] = "ERROR first"
+	lines[15] = "ERROR second"
+	return &LogView{Lines: lines, MarkHidden: true}, []filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000")}
+}
+
+func TestMakeTableMarksHiddenRunsWithSeparatorRows(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	got := plainRows(v)
+	want := []string{
+		"|··· 5 lines hidden ···",
+		"6|ERROR first",
+		"|··· 9 lines hidden ···",
+		"16|ERROR second",
+		"|··· 4 lines hidden ···",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	v.MarkHidden = false
+	v.MakeTable(100, 30, filters, true, Context{})
+	if got := plainRows(v); len(got) != 2 {
+		t.Errorf("rows = %q, want just the two matches with MarkHidden off", got)
+	}
+}
+
+func TestMakeTableHiddenSeparatorNeverTakesTheCursor(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.Cursor = 15
+	table := v.MakeTable(100, 30, filters, true, Context{})
+
+	if got := table.SelectedRow(); got[0] != "16" {
+		t.Errorf("SelectedRow() = %v, want line 16 itself, not a separator next to it", got)
+	}
+}
+
+func TestMakeTableCombinesHiddenCountWithGap(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "INFO", "#87CEFA")}
+	v := gapLog()
+	v.MarkHidden = true
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	got := plainRows(v)
+	if len(got) != 7 || got[2] != "|··· 1 line hidden · 3m19s gap ···" {
+		t.Errorf("rows = %q, want the hidden line and the gap on one separator", got)
+	}
+}
+
+func TestGroupDigits(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{4709, "4,709"},
+		{1000000, "1,000,000"},
+	}
+	for _, tt := range tests {
+		if got := groupDigits(tt.n); got != tt.want {
+			t.Errorf("groupDigits(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestHiddenRun(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	tests := []struct {
+		line, lo, hi int
+		ok           bool
+	}{
+		{5, 6, 15, true},   // the run after it first
+		{15, 16, 20, true}, // up to the end of the log
+		{3, 0, 0, false},   // not a shown line
+	}
+	for _, tt := range tests {
+		lo, hi, ok := v.HiddenRun(tt.line)
+		if lo != tt.lo || hi != tt.hi || ok != tt.ok {
+			t.Errorf("HiddenRun(%d) = %d, %d, %v, want %d, %d, %v", tt.line, lo, hi, ok, tt.lo, tt.hi, tt.ok)
+		}
+	}
+
+	v.Reveal(6, 15)
+	v.MakeTable(100, 30, filters, true, Context{})
+	if lo, hi, ok := v.HiddenRun(5); !ok || lo != 0 || hi != 5 {
+		t.Errorf("HiddenRun(5) = %d, %d, %v, want the run before it once the one after is revealed", lo, hi, ok)
+	}
+}
+
+func TestExpandHiddenFully(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.Cursor = 5
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	if n := v.ExpandHidden(0); n != 9 {
+		t.Errorf("ExpandHidden(0) = %d, want all 9 lines between the matches", n)
+	}
+	v.MakeTable(100, 30, filters, true, Context{})
+	got := plainRows(v)
+	if len(got) != 13 || got[2] != "7|line 7" || got[len(got)-1] != "|··· 4 lines hidden ···" {
+		t.Errorf("rows = %q, want lines 7-15 shown in place", got)
+	}
+	if v.Cursor != 5 {
+		t.Errorf("Cursor = %d, want it left on line 6", v.Cursor)
+	}
+}
+
+func TestExpandHiddenByStep(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.Cursor = 5
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	if n := v.ExpandHidden(2); n != 4 {
+		t.Errorf("ExpandHidden(2) = %d, want 2 lines at each end", n)
+	}
+	v.MakeTable(100, 30, filters, true, Context{})
+	want := []string{
+		"|··· 5 lines hidden ···",
+		"6|ERROR first",
+		"7|line 7",
+		"8|line 8",
+		"|··· 5 lines hidden ···",
+		"14|line 14",
+		"15|line 15",
+		"16|ERROR second",
+		"|··· 4 lines hidden ···",
+	}
+	if got := plainRows(v); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A run too short to split is revealed whole.
+	if n := v.ExpandHidden(3); n != 5 {
+		t.Errorf("ExpandHidden(3) on 5 hidden lines = %d, want all 5", n)
+	}
+}
+
+func TestExpandHiddenAtTheEndsOfTheLog(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.Cursor = 15
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	// The trailing run only borders a shown line at its top.
+	if n := v.ExpandHidden(1); n != 1 {
+		t.Errorf("ExpandHidden(1) below the last match = %d, want 1", n)
+	}
+	v.MakeTable(100, 30, filters, true, Context{})
+	if got := plainRows(v); got[len(got)-2] != "17|line 17" || got[len(got)-1] != "|··· 3 lines hidden ···" {
+		t.Errorf("rows = %q, want line 17 revealed above the rest of the run", got)
+	}
+}
+
+func TestExpandHiddenWithNothingHidden(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.MakeTable(100, 30, filters, false, Context{})
+
+	if n := v.ExpandHidden(0); n != 0 {
+		t.Errorf("ExpandHidden(0) = %d with every line shown, want 0", n)
+	}
+}
+
+func TestHideRevealed(t *testing.T) {
+	v, filters := hiddenLog(t)
+	if v.HideRevealed() {
+		t.Error("HideRevealed() = true with nothing revealed")
+	}
+
+	v.Cursor = 5
+	v.MakeTable(100, 30, filters, true, Context{})
+	v.ExpandHidden(0)
+	if !v.HideRevealed() {
+		t.Error("HideRevealed() = false after ExpandHidden")
+	}
+	v.MakeTable(100, 30, filters, true, Context{})
+	if got := plainRows(v); len(got) != 5 {
+		t.Errorf("rows = %q, want the original separators back", got)
+	}
+}
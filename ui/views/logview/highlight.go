@@ -0,0 +1,137 @@
+package logview
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// span is a run of display columns, [start, end), in a line's display text
+// (see displayText).
+type span struct {
+	start, end int
+}
+
+// highlightSpans returns where re matches line, as display columns of its
+// display text (see displayText) rather than bytes, so they still line up
+// once the text has been scrolled, wrapped or truncated by column. re runs
+// on line as it is, the way FindNext and CountMatches run it, so a line
+// search lands on is always one it highlights, however its tabs and
+// control characters are shown. Empty matches, and matches of nothing but
+// what isn't shown, are left out: there's nothing to show for them.
+func highlightSpans(line string, re *regexp.Regexp) []span {
+	if re == nil {
+		return nil
+	}
+	matches := re.FindAllStringIndex(line, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	cols := displayColumns(line)
+	spans := make([]span, 0, len(matches))
+	for _, m := range matches {
+		if start, end := cols[m[0]], cols[m[1]]; start < end {
+			spans = append(spans, span{start, end})
+		}
+	}
+	return spans
+}
+
+// displayColumns maps each byte offset in line, and its end, to the
+// display column it falls at once line is shown as displayText shows it:
+// a tab is four columns, and escape sequences and control characters take
+// none.
+func displayColumns(line string) []int {
+	cols := make([]int, len(line)+1)
+	escapes := ansiCSIPattern.FindAllStringIndex(line, -1)
+	col := 0
+	for i := 0; i < len(line); {
+		if len(escapes) > 0 && i == escapes[0][0] {
+			for ; i < escapes[0][1]; i++ {
+				cols[i] = col
+			}
+			escapes = escapes[1:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		for j := i; j < i+size; j++ {
+			cols[j] = col
+		}
+		switch {
+		case r == '\t':
+			col += 4
+		case r >= 0x20:
+			col += ansi.StringWidth(string(r))
+		}
+		i += size
+	}
+	cols[len(line)] = col
+	return cols
+}
+
+// searchMatchStyle is the style a search match is rendered in: the line's
+// own style (its filter's colors, if it has one) reversed, so a match
+// stands out on a highlighted line and an unhighlighted one alike.
+func searchMatchStyle(style *lipgloss.Style) lipgloss.Style {
+	if style == nil {
+		return lipgloss.NewStyle().Reverse(true)
+	}
+	return style.Reverse(true)
+}
+
+// renderHighlighted is renderText for a segment of a line that may hold
+// search matches: text starts offset display columns into the line, and
+// the runs of it spans covers are rendered in searchMatchStyle, the rest in
+// style.
+func renderHighlighted(text string, offset int, spans []span, style *lipgloss.Style) string {
+	if len(spans) == 0 {
+		return renderText(text, style)
+	}
+	matchStyle := searchMatchStyle(style)
+
+	var b, run strings.Builder
+	inMatch := false
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if inMatch {
+			b.WriteString(matchStyle.Render(run.String()))
+		} else {
+			b.WriteString(renderText(run.String(), style))
+		}
+		run.Reset()
+	}
+
+	col, k := offset, 0
+	for _, r := range text {
+		for k < len(spans) && spans[k].end <= col {
+			k++
+		}
+		matched := k < len(spans) && spans[k].start <= col
+		if matched != inMatch {
+			flush()
+			inMatch = matched
+		}
+		run.WriteRune(r)
+		col += ansi.StringWidth(string(r))
+	}
+	flush()
+	return b.String()
+}
+
+// HighlightMatches returns line as the Line column shows it (see
+// displayText), truncated to width display columns, with re's matches
+// marked the way the Log pane marks search matches -- for showing a line
+// elsewhere, such as a list of matches.
+func HighlightMatches(line string, re *regexp.Regexp, width int) string {
+	text := displayText(line)
+	spans := highlightSpans(line, re)
+	if width > 0 && ansi.StringWidth(text) > width {
+		text = ansi.Truncate(text, width, "…")
+	}
+	return renderHighlighted(text, 0, spans, nil)
+}
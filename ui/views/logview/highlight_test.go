@@ -0,0 +1,138 @@
+package logview
+
+import (
+	"reflect"
+	"regexp"
+	"skim/filterfiles"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// withANSI renders styles as escape sequences for the rest of the test,
+// rather than the plain text a test's non-terminal output gets.
+func withANSI(t *testing.T) {
+	t.Helper()
+	orig := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(orig) })
+}
+
+// sgrRun matches one SGR escape sequence and the text it styles.
+var sgrRun = regexp.MustCompile("\x1b\\[([0-9;]*)m([^\x1b]*)")
+
+// reversed returns the parts of s rendered in reverse video.
+func reversed(s string) []string {
+	var parts []string
+	for _, m := range sgrRun.FindAllStringSubmatch(s, -1) {
+		for _, param := range strings.Split(m[1], ";") {
+			if param == "7" && m[2] != "" {
+				parts = append(parts, m[2])
+			}
+		}
+	}
+	return parts
+}
+
+func TestHighlightSpansCountDisplayColumns(t *testing.T) {
+	re := regexp.MustCompile("err")
+	tests := []struct {
+		name string
+		text string
+		want []span
+	}{
+		{"ascii", "an err and err", []span{{3, 6}, {11, 14}}},
+		{"wide characters before a match", "日本 err", []span{{5, 8}}},
+		{"no match", "fine", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightSpans(tt.text, re); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("highlightSpans(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if got := highlightSpans("abc", regexp.MustCompile("x*")); len(got) != 0 {
+		t.Errorf("highlightSpans with only empty matches = %v, want none", got)
+	}
+	if got := highlightSpans("abc", nil); got != nil {
+		t.Errorf("highlightSpans with no pattern = %v, want nil", got)
+	}
+}
+
+// TestHighlightSpansMatchTheLineAsSearchDoes checks highlightSpans runs
+// its pattern on the line as read, as FindNext and CountMatches do, not on
+// its display text.
+func TestHighlightSpansMatchTheLineAsSearchDoes(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		pattern string
+		want    []span
+	}{
+		{"tab before a match", "\terr", "err", []span{{4, 7}}},
+		{"match across a tab", "a\tb", `a\tb`, []span{{0, 6}}},
+		{"match across a control character", "a\x01b", "a.b", []span{{0, 2}}},
+		{"escape sequences around a match", "\x1b[31merr\x1b[0m ok", "err", []span{{0, 3}}},
+		{"match of nothing shown", "a\x01b", "\x01", nil},
+		{"no match in the display text's spaces", "a\tb", "a    b", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := regexp.MustCompile(tt.pattern)
+			got := highlightSpans(tt.line, re)
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("highlightSpans(%q, %q) = %v, want %v", tt.line, tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeTableReversesSearchMatches(t *testing.T) {
+	withANSI(t)
+	filters := []filterfiles.Filter{mustFilter(t, "timeout", "#87CEFA")}
+	v := LogView{Lines: []string{"request timeout after 30s", "ok"}, Highlight: regexp.MustCompile("(?i)TIME")}
+
+	v.MakeTable(100, 30, filters, false, Context{})
+
+	row := v.Table.Rows()[0][1]
+	if got := reversed(row); !reflect.DeepEqual(got, []string{"time"}) {
+		t.Errorf("reversed parts of %q = %q, want just the match", row, got)
+	}
+	if ansi.Strip(row) != "request timeout after 30s" {
+		t.Errorf("row text = %q, want the line unchanged", ansi.Strip(row))
+	}
+	if got := reversed(v.Table.Rows()[1][1]); len(got) != 0 {
+		t.Errorf("reversed parts of a line without a match = %q, want none", got)
+	}
+}
+
+func TestMakeTableSearchMatchesFollowScrollAndWrap(t *testing.T) {
+	withANSI(t)
+	line := strings.Repeat("x", 30) + "needle" + strings.Repeat("y", 30)
+	re := regexp.MustCompile("needle")
+
+	v := LogView{Lines: []string{line}, Highlight: re, HScroll: 28}
+	v.MakeTable(60, 30, nil, false, Context{})
+	row := v.Table.Rows()[0][1]
+	if got := reversed(row); !reflect.DeepEqual(got, []string{"needle"}) {
+		t.Errorf("scrolled: reversed parts of %q = %q, want the match where it's scrolled to", ansi.Strip(row), got)
+	}
+
+	v = LogView{Lines: []string{line}, Highlight: re, Wrap: true}
+	v.MakeTable(45, 30, nil, false, Context{})
+	var got []string
+	for _, row := range v.Table.Rows() {
+		got = append(got, reversed(row[1])...)
+	}
+	if strings.Join(got, "") != "needle" || len(got) != 2 {
+		t.Errorf("wrapped: reversed parts = %q, want the match split across the wrap", got)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"regexp"
 	"skim/filterfiles"
+	"skim/structured"
+	"skim/timestamps"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type LogView struct {
@@ -41,17 +45,175 @@ type LogView struct {
 	matchCountsKey string
 
 	// shownIndices holds the indices (into Lines, ascending) of every line
-	// that is currently shown (per hideUnmatched/contextLines) and not
+	// that is currently shown (per hideUnmatched/context) and not
 	// excluded -- i.e. exactly the lines MakeTable would otherwise have to
 	// rediscover with an O(len(Lines)) scan on every call. Built by
 	// ensureShownIndices and reused until the filter set, hideUnmatched, or
-	// contextLines actually change, so a pure cursor-movement render can
+	// context actually change, so a pure cursor-movement render can
 	// locate the cursor's row and its visible window with a binary search
 	// and a bounded walk instead of scanning every line in the log.
 	shownIndices    []int
 	shownIndicesKey string
+
+	// Wrap, when set, soft-wraps every line longer than the Line column
+	// onto as many table rows as it needs instead of truncating it (see
+	// MakeTable). HScroll is how many display columns of every line are
+	// scrolled off the left edge of the Line column (see CursorLeft/
+	// CursorRight); it's ignored while Wrap is on, since nothing is cut off.
+	Wrap    bool
+	HScroll int
+
+	// Centered keeps the cursor's line in the middle of the pane as it
+	// moves, rather than scrolling only as far as it takes to keep it in
+	// view (see scrollTop).
+	Centered bool
+
+	// Columns are the structured log fields (see structured.Parse) shown
+	// as their own table columns, in order, while Structured is on; lines
+	// that don't parse are shown raw, spanning all of them. With Structured
+	// off, or no Columns, the pane is the plain "#"/"Line" layout. Wrap and
+	// HScroll only apply to that plain layout.
+	Columns    []Column
+	Structured bool
+
+	// Highlight, when set, marks every match of it within the shown rows
+	// in reverse video, on top of whatever filter colors the line has --
+	// the Log pane's search (see the ui package's lastSearch).
+	Highlight *regexp.Regexp
+
+	// TimeParser finds each line's timestamp (see LineTime). TimeRange,
+	// when set, additionally hides every line whose time falls outside it,
+	// on top of whatever hideUnmatched/context already hide -- a
+	// line has to pass both to be shown.
+	TimeParser timestamps.Parser
+	TimeRange  timestamps.Range
+
+	// times holds each line's timestamp (same index as Lines), built by
+	// ensureTimes and reused until TimeParser or Lines changes; timesKey is
+	// the fingerprint it was built under.
+	times    []time.Time
+	timesKey string
+
+	// GapThreshold, when positive, marks every silence in the log longer
+	// than it with a separator row (see gapSeparator). SpikeThreshold, when
+	// positive, is how many lines per second above the rolling baseline
+	// (see findSpikes) make a burst. Both are also what FindNextAnomaly and
+	// FindPrevAnomaly step between; timeline caches them.
+	GapThreshold   time.Duration
+	SpikeThreshold int
+	timeline       timeline
+
+	// MarkHidden adds a separator row wherever shown lines aren't
+	// contiguous, counting the lines hidden there (see separator), which
+	// Reveal can then show in place. revealed marks the lines Reveal has
+	// shown regardless of filters (same index as Lines, nil until first
+	// used), and revealedVersion counts changes to it, for
+	// ensureShownIndices' cache key.
+	MarkHidden      bool
+	revealed        []bool
+	revealedVersion int
+
+	// folds are the line ranges collapsed into summary rows (see
+	// FoldLines), in order and never overlapping; foldsVersion counts
+	// changes to them, for ensureShownIndices' cache key. selecting and
+	// anchor are the line selection StartSelection starts.
+	folds        []Fold
+	foldsVersion int
+	selecting    bool
+	anchor       int
+
+	// density caches the last Density call's result (see Density).
+	density densityCache
+
+	// Minimap adds a one-column overview of the whole log down the right
+	// edge of the pane (see renderMinimap); minimap holds it as of the
+	// last MakeTable.
+	Minimap bool
+	minimap minimap
+
+	// Render state from the last MakeTable call, read by View: the column
+	// widths, and which of Table's rows (in Table's own row coordinates)
+	// belong to the cursor's line -- more than one when Wrap splits it.
+	numberWidth    int
+	lineWidth      int
+	columnWidths   []int // every column's width, "#" first
+	cursorRowStart int
+	cursorRowEnd   int
+	selectedLine   int   // index into Lines of the highlighted line, -1 if none
+	rowLines       []int // index into Lines of the line each of Table's rows belongs to
+
+	// longestLine caches the display width of the widest line in Lines
+	// (bounding HScroll), computed for a Lines of length longestLineOf.
+	longestLine   int
+	longestLineOf int
+}
+
+// SelectedLine returns the index into Lines of the line the last MakeTable
+// call highlighted -- which, when v.Cursor sits on a hidden or excluded
+// line, is the shown line it snapped to rather than v.Cursor itself -- and
+// false if nothing is shown.
+func (v *LogView) SelectedLine() (int, bool) {
+	if v.selectedLine < 0 || v.selectedLine >= len(v.Lines) {
+		return 0, false
+	}
+	return v.selectedLine, true
+}
+
+// Column is one structured log field shown as a Log pane column (see
+// LogView.Columns). Title defaults to Field. Width is the column's width in
+// display columns; 0 means DefaultColumnWidth, except for the last column,
+// which always stretches to fill whatever width the others leave.
+type Column struct {
+	Field string
+	Title string
+	Width int
+}
+
+// DefaultColumnWidth is the width of a Column with no Width of its own.
+const DefaultColumnWidth = 16
+
+// maxDetectedColumns caps how many fields DetectColumns picks.
+const maxDetectedColumns = 4
+
+// detectColumnsScanLimit bounds how many leading lines DetectColumns looks
+// through for a structured one, so a huge plain-text log doesn't get
+// scanned end to end just to find out it has no structure.
+const detectColumnsScanLimit = 1000
+
+// DetectColumns proposes Columns for a log with none configured: the first
+// few fields, in order, of the first line among the first
+// detectColumnsScanLimit that parses as JSON or logfmt. It returns nil if
+// none of them do.
+func (v *LogView) DetectColumns() []Column {
+	for i, line := range v.Lines {
+		if i >= detectColumnsScanLimit {
+			break
+		}
+		rec, ok := structured.Parse(line)
+		if !ok {
+			continue
+		}
+		var cols []Column
+		for _, f := range rec {
+			if len(cols) == maxDetectedColumns {
+				break
+			}
+			cols = append(cols, Column{Field: f.Key})
+		}
+		return cols
+	}
+	return nil
 }
 
+// structuredActive reports whether MakeTable lays the pane out by Columns.
+func (v *LogView) structuredActive() bool {
+	return v.Structured && len(v.Columns) > 0
+}
+
+// HScrollStep is how many display columns CursorLeft/CursorRight scroll the
+// Line column by per keypress.
+const HScrollStep = 8
+
 // matchState is one line's cached result against the current filter set.
 type matchState struct {
 	excluded    bool
@@ -62,26 +224,117 @@ func (v *LogView) Toggle() {
 	return
 }
 
+// CursorUp and CursorDown step Cursor one line, treating a fold (see
+// FoldLines) as the single row it's shown as: stepping into one lands on
+// its first line, and stepping down out of one skips past its last.
 func (v *LogView) CursorUp() int {
 	if v.Cursor > 0 {
 		v.Cursor--
+		if f, ok := v.FoldAt(v.Cursor); ok {
+			v.Cursor = f.Start
+		}
 	}
 	return v.Cursor
 }
 
 func (v *LogView) CursorDown() int {
-	if v.Cursor < v.GetMaxCursor() {
-		v.Cursor++
+	next := v.Cursor + 1
+	if f, ok := v.FoldAt(v.Cursor); ok {
+		next = f.End
+	}
+	if next <= v.GetMaxCursor() {
+		v.Cursor = next
 	}
 	return v.Cursor
 }
 
+// MoveShown moves Cursor n shown lines down (up, if n is negative),
+// stopping at the first or last, and returns it. Unlike CursorDown it steps
+// over hidden lines, so moving by a page (see PageHeight) moves by what the
+// pane shows. A cursor on a hidden line counts from the shown line it
+// snaps to (see SelectedLine).
+func (v *LogView) MoveShown(n int) int {
+	count := len(v.shownIndices)
+	if count == 0 {
+		return v.Cursor
+	}
+	row := max(sort.Search(count, func(i int) bool { return v.shownIndices[i] > v.Cursor })-1, 0)
+	v.Cursor = v.shownIndices[clamp(row+n, 0, count-1)]
+	return v.Cursor
+}
+
+// PageHeight is how many rows the pane showed as of the last MakeTable,
+// at least 1: how far a page motion moves.
+func (v *LogView) PageHeight() int {
+	return max(v.Table.Height(), 1)
+}
+
+// CursorLeft scrolls the Line column back towards the start of each line
+// by HScrollStep columns, returning the new HScroll. It's a no-op while
+// Wrap or Structured mode is on.
 func (v *LogView) CursorLeft() int {
-	return 0
+	if v.Wrap || v.structuredActive() {
+		return v.HScroll
+	}
+	v.HScroll = clamp(v.HScroll-HScrollStep, 0, v.HScroll)
+	return v.HScroll
 }
 
+// CursorRight scrolls the Line column towards the end of each line by
+// HScrollStep columns, stopping once the end of the longest line in the log
+// is in view, and returns the new HScroll. It's a no-op while Wrap or
+// Structured mode is on.
 func (v *LogView) CursorRight() int {
-	return 0
+	if v.Wrap || v.structuredActive() {
+		return v.HScroll
+	}
+	max := v.longestLineWidth() - v.lineWidth
+	if max < 0 {
+		max = 0
+	}
+	v.HScroll = clamp(v.HScroll+HScrollStep, 0, max)
+	return v.HScroll
+}
+
+// longestLineWidth returns the display width of the widest line in Lines,
+// as rendered (see displayText), caching it until Lines changes length.
+func (v *LogView) longestLineWidth() int {
+	if v.longestLineOf == len(v.Lines) && v.longestLine > 0 {
+		return v.longestLine
+	}
+	widest := 0
+	for _, line := range v.Lines {
+		if w := ansi.StringWidth(displayText(line)); w > widest {
+			widest = w
+		}
+	}
+	v.longestLine = widest
+	v.longestLineOf = len(v.Lines)
+	return widest
+}
+
+// SetLines replaces Lines, dropping everything cached about the old ones
+// -- which the caches can't always tell for themselves, since most are
+// keyed on len(Lines) and new lines can be just as many -- along with any
+// revealed lines and the selection. Cursor is clamped to the new lines;
+// folds are kept, clipped to them, since whether they still mean anything
+// is up to the caller (see SetFolds).
+func (v *LogView) SetLines(lines []string) {
+	v.Lines = lines
+	v.matchCache, v.matchCacheKey = nil, ""
+	v.matchCounts, v.matchCountsKey = nil, ""
+	v.shownIndices, v.shownIndicesKey = nil, ""
+	v.times, v.timesKey = nil, ""
+	v.timeline = timeline{}
+	v.density = densityCache{}
+	v.minimap = minimap{}
+	v.longestLine, v.longestLineOf = 0, 0
+	v.revealed = nil
+	v.revealedVersion++
+	v.selecting = false
+	v.selectedLine = -1
+	v.Cursor = clamp(v.Cursor, 0, max(len(lines)-1, 0))
+	v.SetFolds(v.Folds())
 }
 
 func (v *LogView) GetMaxCursor() int {
@@ -122,8 +375,100 @@ func (v *LogView) FindPrev(re regexp.Regexp) (int, bool) {
 	return 0, false
 }
 
+// CountMatches returns how many lines match re, hidden or not -- the lines
+// FindNext and FindPrev step between.
+func (v *LogView) CountMatches(re regexp.Regexp) int {
+	n := 0
+	for _, line := range v.Lines {
+		if re.MatchString(line) {
+			n++
+		}
+	}
+	return n
+}
+
+// FindNextShown is FindNext over only the lines the last MakeTable call
+// showed (see ShownAtOrAfter), a fold counting as its first line alone --
+// for a search that shouldn't land on anything hideUnmatched, the time
+// range or a fold is keeping out of sight.
+func (v *LogView) FindNextShown(re regexp.Regexp) (int, bool) {
+	return v.findShown(re, 1)
+}
+
+// FindPrevShown is FindNextShown in reverse, wrapping around to the end.
+func (v *LogView) FindPrevShown(re regexp.Regexp) (int, bool) {
+	return v.findShown(re, -1)
+}
+
+// CountShownMatches is CountMatches over only the lines FindNextShown and
+// FindPrevShown step between.
+func (v *LogView) CountShownMatches(re regexp.Regexp) int {
+	n := 0
+	for _, i := range v.shownIndices {
+		if re.MatchString(v.Lines[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// findShown walks shownIndices from Cursor in direction step (1 or -1),
+// wrapping around, for FindNextShown/FindPrevShown. Cursor needn't be on a
+// shown line itself; if it is, its own line comes last, as in FindNext.
+func (v *LogView) findShown(re regexp.Regexp, step int) (int, bool) {
+	n := len(v.shownIndices)
+	if n == 0 {
+		return 0, false
+	}
+	start := sort.SearchInts(v.shownIndices, v.Cursor+1) // the first shown line after Cursor
+	if step < 0 {
+		start = sort.SearchInts(v.shownIndices, v.Cursor) - 1 // the last one before it
+	}
+	for i := 0; i < n; i++ {
+		idx := v.shownIndices[((start+step*i)%n+n)%n]
+		if re.MatchString(v.Lines[idx]) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// FindNextFilterMatch returns the index of the next line, after Cursor and
+// wrapping around to the start, attributed to filters[filterIndex] -- the
+// same first-enabled-filter-wins attribution MatchCounts tallies, read from
+// the per-line match cache rather than re-running any regex. Lines hidden
+// by an excluding filter are skipped, since the cursor can't land on them.
+func (v *LogView) FindNextFilterMatch(filters []filterfiles.Filter, filterIndex int) (int, bool) {
+	return v.findFilterMatch(filters, filterIndex, 1)
+}
+
+// FindPrevFilterMatch is FindNextFilterMatch in reverse, wrapping around to
+// the end.
+func (v *LogView) FindPrevFilterMatch(filters []filterfiles.Filter, filterIndex int) (int, bool) {
+	return v.findFilterMatch(filters, filterIndex, -1)
+}
+
+// findFilterMatch walks the match cache from Cursor in direction step (1 or
+// -1), wrapping around, for FindNextFilterMatch/FindPrevFilterMatch.
+func (v *LogView) findFilterMatch(filters []filterfiles.Filter, filterIndex int, step int) (int, bool) {
+	if filterIndex < 0 || filterIndex >= len(filters) {
+		return 0, false
+	}
+	v.ensureMatchCache(filters)
+	n := len(v.matchCache)
+	for i := 1; i <= n; i++ {
+		idx := ((v.Cursor+step*i)%n + n) % n
+		ms := v.matchCache[idx]
+		if ms.filterIndex == filterIndex && !ms.excluded {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
 // filtersCacheKey builds a cheap fingerprint of filters' match-relevant
-// fields (their regex source text, enabled, and excluding state -- order
+// fields (their regex source text, target field, a block filter's end
+// regex and length cap, enabled, and excluding state -- order
 // matters too, since matching is first-enabled-filter-wins), used to detect
 // whether a cached matchState slice is still valid. It's O(filters), not
 // O(lines), so computing it on every MakeTable/MatchCounts call is fine.
@@ -132,6 +477,14 @@ func filtersCacheKey(filters []filterfiles.Filter) string {
 	for _, f := range filters {
 		b.WriteString(f.Regex.String())
 		b.WriteByte(0)
+		b.WriteString(f.Field)
+		b.WriteByte(0)
+		if f.IsBlock() {
+			b.WriteString(f.EndRegex.String())
+			b.WriteByte(0)
+			b.WriteString(strconv.Itoa(f.MaxBlockLines))
+			b.WriteByte(0)
+		}
 		if f.IsEnabled {
 			b.WriteByte('1')
 		} else {
@@ -156,11 +509,14 @@ func (v *LogView) ensureMatchCache(filters []filterfiles.Filter) {
 		return
 	}
 
+	// One pass in log order, so block filters' blocks carry from line to
+	// line (see filterfiles.Matcher).
 	cache := make([]matchState, len(v.Lines))
+	m := filterfiles.NewMatcher(filters)
 	for i, line := range v.Lines {
-		idx, _ := filterfiles.GetMatchingFilterIndex(filters, line)
+		idx, excluded := m.Next(line)
 		cache[i] = matchState{
-			excluded:    filterfiles.IsExcluded(filters, line),
+			excluded:    excluded,
 			filterIndex: idx,
 		}
 	}
@@ -200,13 +556,43 @@ var logStyle = lipgloss.NewStyle().
 	PaddingTop(0).
 	PaddingLeft(0)
 
+// Context is how many unmatched lines to show before and after each match
+// while unmatched lines are hidden (grep -B/-A style), so a match's
+// surroundings aren't lost along with everything else. A filter's own
+// ContextBefore/ContextAfter take precedence for its matches.
+type Context struct {
+	Before, After int
+}
+
+// forFilter returns c with f's overrides, if any, applied.
+func (c Context) forFilter(f filterfiles.Filter) Context {
+	if f.ContextBefore != nil {
+		c.Before = *f.ContextBefore
+	}
+	if f.ContextAfter != nil {
+		c.After = *f.ContextAfter
+	}
+	return c
+}
+
+// contextKey fingerprints context and filters' overrides of it, for
+// ensureShownIndices' cache key.
+func contextKey(filters []filterfiles.Filter, context Context) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(context.Before) + "," + strconv.Itoa(context.After))
+	for _, f := range filters {
+		c := context.forFilter(f)
+		b.WriteString(";" + strconv.Itoa(c.Before) + "," + strconv.Itoa(c.After))
+	}
+	return b.String()
+}
+
 // shownLines reports, for each line index, whether it should be rendered:
 // every line if hideUnmatched is off, otherwise any line that matches an
-// enabled filter plus up to contextLines lines immediately before/after each
-// match (grep -C style), so a hidden match's surrounding context isn't lost
-// along with it. cache must already reflect the current filter set (see
-// ensureMatchCache).
-func shownLines(cache []matchState, hideUnmatched bool, contextLines int) []bool {
+// enabled filter plus the context (see Context) around each match -- the
+// view's context, or the highlighting filter's own if it overrides it.
+// cache must already reflect filters (see ensureMatchCache).
+func shownLines(cache []matchState, filters []filterfiles.Filter, hideUnmatched bool, context Context) []bool {
 	n := len(cache)
 	shown := make([]bool, n)
 	if !hideUnmatched {
@@ -216,9 +602,15 @@ func shownLines(cache []matchState, hideUnmatched bool, contextLines int) []bool
 		return shown
 	}
 
+	// Resolved once per filter rather than once per match.
+	perFilter := make([]Context, len(filters))
+	for k, f := range filters {
+		perFilter[k] = context.forFilter(f)
+	}
 	for i, ms := range cache {
 		if ms.filterIndex >= 0 {
-			lo, hi := i-contextLines, i+contextLines
+			c := perFilter[ms.filterIndex]
+			lo, hi := i-c.Before, i+c.After
 			if lo < 0 {
 				lo = 0
 			}
@@ -234,21 +626,43 @@ func shownLines(cache []matchState, hideUnmatched bool, contextLines int) []bool
 }
 
 // ensureShownIndices (re)computes v.shownIndices -- the indices of every
-// shown, non-excluded line, in ascending order -- if the filter set,
-// hideUnmatched, or contextLines have changed (or the cache has never been
-// built) since the last call; otherwise it leaves the existing cache in
-// place. Callers must call ensureMatchCache first, since this reads
-// v.matchCache.
-func (v *LogView) ensureShownIndices(filters []filterfiles.Filter, hideUnmatched bool, contextLines int) {
-	key := v.matchCacheKey + "|" + strconv.FormatBool(hideUnmatched) + "|" + strconv.Itoa(contextLines)
+// shown, non-excluded line within TimeRange, plus any revealed line, in
+// ascending order, with each fold standing in for all of its lines as its
+// first -- if the filter set, the number of Lines, hideUnmatched, the
+// context (the view's or any filter's), TimeRange, the revealed lines or
+// the folds have changed (or the cache has never been built) since the last call;
+// otherwise it leaves the existing cache in place. Callers must call
+// ensureMatchCache first, since this reads v.matchCache.
+func (v *LogView) ensureShownIndices(filters []filterfiles.Filter, hideUnmatched bool, context Context) {
+	key := v.matchCacheKey + "|" + strconv.Itoa(len(v.matchCache)) + "|" + strconv.FormatBool(hideUnmatched) + "|" + contextKey(filters, context) +
+		"|" + strconv.Itoa(v.revealedVersion) + "|" + strconv.Itoa(v.foldsVersion)
+	restrictTime := !v.TimeRange.IsZero()
+	if restrictTime {
+		v.ensureTimes()
+		key += "|" + v.timesKey + "|" + timeRangeKey(v.TimeRange)
+	}
 	if key == v.shownIndicesKey && v.shownIndices != nil {
 		return
 	}
 
-	shown := shownLines(v.matchCache, hideUnmatched, contextLines)
+	// The time range clips shownLines' result rather than feeding into it:
+	// context is still worked out over the whole log, so an in-range line
+	// next to a match just outside the range stays visible as context,
+	// while nothing outside the range is ever shown.
+	// Revealed lines (see Reveal) are shown whatever hides them, and so are
+	// folds, as their first line alone.
+	shown := shownLines(v.matchCache, filters, hideUnmatched, context)
 	indices := make([]int, 0, len(v.Lines))
-	for i, ms := range v.matchCache {
-		if shown[i] && !ms.excluded {
+	folds := v.folds
+	for i := 0; i < len(v.matchCache); i++ {
+		if len(folds) > 0 && i == folds[0].Start {
+			indices = append(indices, i)
+			i = folds[0].End - 1
+			folds = folds[1:]
+			continue
+		}
+		ms := v.matchCache[i]
+		if (shown[i] && !ms.excluded && (!restrictTime || v.TimeRange.Contains(v.times[i]))) || v.isRevealed(i) {
 			indices = append(indices, i)
 		}
 	}
@@ -256,19 +670,210 @@ func (v *LogView) ensureShownIndices(filters []filterfiles.Filter, hideUnmatched
 	v.shownIndicesKey = key
 }
 
-// buildRow formats and, if the line has a highlighting match, styles a
-// single line into the table.Row bubbles/table will render.
-func buildRow(i int, line string, ms matchState, filters []filterfiles.Filter) table.Row {
-	lineNumber := i + 1
+// timeRangeKey fingerprints r exactly (Range.String drops sub-second
+// precision) for ensureShownIndices' cache key.
+func timeRangeKey(r timestamps.Range) string {
+	var since, until int64
+	if !r.Since.IsZero() {
+		since = r.Since.UnixNano()
+	}
+	if !r.Until.IsZero() {
+		until = r.Until.UnixNano()
+	}
+	return strconv.FormatInt(since, 10) + ".." + strconv.FormatInt(until, 10)
+}
+
+// ensureTimes (re)computes v.times if TimeParser or the number of Lines has
+// changed since the last call. A line without a timestamp of its own -- a
+// stack trace frame, the rest of a multi-line message -- takes the time of
+// the nearest stamped line above it, since that's when it was written; only
+// lines before the log's first timestamp are left with the zero time.
+func (v *LogView) ensureTimes() {
+	p := v.TimeParser
+	key := fmt.Sprintf("%s|%v|%d|%d", p.Layout, p.Location, p.Year, len(v.Lines))
+	if key == v.timesKey && len(v.times) == len(v.Lines) {
+		return
+	}
+
+	times := make([]time.Time, len(v.Lines))
+	var last time.Time
+	for i, line := range v.Lines {
+		if t, ok := p.Parse(line); ok {
+			last = t
+		}
+		times[i] = last
+	}
+	v.times = times
+	v.timesKey = key
+}
+
+// LineTime returns the time of line i (see ensureTimes for lines that
+// don't carry one themselves), and false if i is out of range or comes
+// before the log's first timestamp.
+func (v *LogView) LineTime(i int) (time.Time, bool) {
+	if i < 0 || i >= len(v.Lines) {
+		return time.Time{}, false
+	}
+	v.ensureTimes()
+	return v.times[i], !v.times[i].IsZero()
+}
+
+// FirstTime returns the log's first timestamp, and false if it has none --
+// the reference date that a time of day typed on its own (see
+// timestamps.ParseQuery) is taken to be on.
+func (v *LogView) FirstTime() (time.Time, bool) {
+	v.ensureTimes()
+	for _, t := range v.times {
+		if !t.IsZero() {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FindTime returns the index of the first line at or after t, scanning the
+// whole log regardless of filters (like FindNext) and without assuming the
+// log is sorted, since interleaved writers often aren't quite. It returns
+// false if no line is that late.
+func (v *LogView) FindTime(t time.Time) (int, bool) {
+	v.ensureTimes()
+	for i, lt := range v.times {
+		if !lt.IsZero() && !lt.Before(t) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// displayText returns line as it's shown in the Line column: tabs expanded
+// and control characters stripped (see sanitizeControlChars), but not yet
+// scrolled, wrapped, truncated or styled.
+func displayText(line string) string {
 	line = strings.ReplaceAll(line, "\t", "    ")
-	line = sanitizeControlChars(line)
+	return sanitizeControlChars(line)
+}
+
+// DisplayLine returns line i as the Line column shows it, unstyled (see
+// displayText), for showing it elsewhere.
+func (v *LogView) DisplayLine(i int) string {
+	return displayText(v.Lines[i])
+}
+
+// lineStyle returns the style a line's text is rendered in: logStyle with
+// the highlighting filter's BackColor, or nil for an unhighlighted line.
+func lineStyle(ms matchState, filters []filterfiles.Filter) *lipgloss.Style {
+	if ms.filterIndex < 0 {
+		return nil
+	}
+	style := logStyle.Background(lipgloss.Color(filters[ms.filterIndex].BackColor))
+	return &style
+}
+
+// renderText applies style (if any) to one already-fitted segment of a line.
+func renderText(text string, style *lipgloss.Style) string {
+	if style == nil {
+		return text
+	}
+	return style.Render(text)
+}
+
+// buildRow formats and, if the line has a highlighting match, styles a
+// single line into the table.Row View renders: scrolled left by hscroll
+// columns, and truncated with an ellipsis if what's left is still wider
+// than width.
+func buildRow(i int, line string, ms matchState, filters []filterfiles.Filter, highlight *regexp.Regexp, hscroll int, width int) table.Row {
+	text := displayText(line)
+	spans := highlightSpans(line, highlight)
+	if hscroll > 0 {
+		text = ansi.TruncateLeft(text, hscroll, "")
+	}
+	if width > 0 && ansi.StringWidth(text) > width {
+		text = ansi.Truncate(text, width, "…")
+	}
+	return table.Row{strconv.Itoa(i + 1), renderHighlighted(text, hscroll, spans, lineStyle(ms, filters))}
+}
+
+// buildStructuredRow is buildRow for Structured mode: one cell per column,
+// holding that field's value (blank if the line lacks it), each truncated to
+// its column's width. A line that doesn't parse comes back as a plain
+// {"#", text} row instead, which View spans across all the field columns
+// -- truncated here to spanWidth, the width that span has.
+func buildStructuredRow(i int, line string, ms matchState, filters []filterfiles.Filter, highlight *regexp.Regexp, columns []Column, widths []int, spanWidth int) table.Row {
+	style := lineStyle(ms, filters)
+	number := strconv.Itoa(i + 1)
 
-	if ms.filterIndex >= 0 {
-		style := logStyle
-		style.Background(lipgloss.Color(filters[ms.filterIndex].BackColor))
-		return table.Row{fmt.Sprintf("%d", lineNumber), style.Render(line)}
+	rec, ok := structured.Parse(line)
+	if !ok {
+		text := displayText(line)
+		spans := highlightSpans(line, highlight)
+		if spanWidth > 0 && ansi.StringWidth(text) > spanWidth {
+			text = ansi.Truncate(text, spanWidth, "…")
+		}
+		return table.Row{number, renderHighlighted(text, 0, spans, style)}
+	}
+
+	row := make(table.Row, 1+len(columns))
+	row[0] = number
+	for j, col := range columns {
+		value, _ := rec.Get(col.Field)
+		text := displayText(value)
+		spans := highlightSpans(value, highlight)
+		if w := widths[j]; w > 0 && ansi.StringWidth(text) > w {
+			text = ansi.Truncate(text, w, "…")
+		}
+		row[1+j] = renderHighlighted(text, 0, spans, style)
 	}
-	return table.Row{fmt.Sprintf("%d", lineNumber), line}
+	return row
+}
+
+// structuredLayout returns MakeTable's table.Columns for Structured mode
+// (see Column for how widths are chosen) given the width left after the
+// "#" column and all the table's padding and border.
+func (v *LogView) structuredLayout(available int) []table.Column {
+	columns := make([]table.Column, len(v.Columns))
+	used := 0
+	for j, col := range v.Columns {
+		title := col.Title
+		if title == "" {
+			title = col.Field
+		}
+		width := col.Width
+		if width <= 0 {
+			width = DefaultColumnWidth
+		}
+		if j == len(v.Columns)-1 {
+			width = max(available-used, 1)
+		}
+		used += width
+		columns[j] = table.Column{Title: title, Width: width}
+	}
+	return columns
+}
+
+// buildWrappedRows is buildRow for Wrap mode: it splits the line into as
+// many width-wide segments as it needs, returning one table.Row per
+// segment. Only the first carries the line number; the rest leave the "#"
+// column blank so a wrapped line still reads as a single log line.
+func buildWrappedRows(i int, line string, ms matchState, filters []filterfiles.Filter, highlight *regexp.Regexp, width int) []table.Row {
+	text := displayText(line)
+	spans := highlightSpans(line, highlight)
+	segments := []string{text}
+	if width > 0 && ansi.StringWidth(text) > width {
+		segments = strings.Split(ansi.Hardwrap(text, width, true), "\n")
+	}
+
+	style := lineStyle(ms, filters)
+	rows := make([]table.Row, len(segments))
+	offset := 0
+	for j, seg := range segments {
+		number := ""
+		if j == 0 {
+			number = strconv.Itoa(i + 1)
+		}
+		rows[j] = table.Row{number, renderHighlighted(seg, offset, spans, style)}
+		offset += ansi.StringWidth(seg)
+	}
+	return rows
 }
 
 // ansiCSIPattern matches ANSI/CSI escape sequences (e.g. "\x1b[33m",
@@ -338,26 +943,54 @@ func tableChromeWidth(numColumns int) int {
 	return paneBorderStyle.GetHorizontalFrameSize() + numColumns*cellPadding
 }
 
-func (v *LogView) MakeTable(windowWidth int, windowHeight int, filters []filterfiles.Filter, hideUnmatched bool, contextLines int) table.Model {
+// MakeTable builds the Log pane's rows for the current cursor position,
+// filters and view settings into v.Table, for View to render. Only a window
+// of rows around the cursor is built (see below), so the cost scales with
+// the terminal's height rather than the log's length.
+func (v *LogView) MakeTable(windowWidth int, windowHeight int, filters []filterfiles.Filter, hideUnmatched bool, context Context) table.Model {
+	if v.Minimap {
+		windowWidth -= MinimapWidth
+	}
 	numberWidth := lineNumberColumnWidth(len(v.Lines))
-	columns := []table.Column{
-		{Title: "#", Width: numberWidth},
-		{Title: "Line", Width: windowWidth - numberWidth - tableChromeWidth(2)},
+	lineWidth := windowWidth - numberWidth - tableChromeWidth(2)
+	v.numberWidth = numberWidth
+	v.lineWidth = lineWidth
+
+	structuredMode := v.structuredActive()
+	var columns []table.Column
+	if structuredMode {
+		available := windowWidth - numberWidth - tableChromeWidth(1+len(v.Columns))
+		columns = append([]table.Column{{Title: "#", Width: numberWidth}}, v.structuredLayout(available)...)
+	} else {
+		lineTitle := "Line"
+		switch {
+		case v.Wrap:
+			lineTitle = "Line (wrapped)"
+		case v.HScroll > 0:
+			lineTitle = fmt.Sprintf("Line (+%d)", v.HScroll)
+		}
+		columns = []table.Column{
+			{Title: "#", Width: numberWidth},
+			{Title: lineTitle, Width: lineWidth},
+		}
+	}
+	v.columnWidths = make([]int, len(columns))
+	for j, col := range columns {
+		v.columnWidths[j] = col.Width
 	}
 
 	v.ensureMatchCache(filters)
-	v.ensureShownIndices(filters, hideUnmatched, contextLines)
-
-	// bubbles/table's own UpdateViewport only ever renders rows in
-	// [cursor-height, cursor+height] (see its renderRow/UpdateViewport) --
-	// building a fully formatted/styled table.Row for every shown line in
-	// the whole log, only for almost all of them to never actually be
-	// joined into the rendered output, is wasted work that scales with the
-	// log's size instead of the terminal's. shownIndices (cached by
-	// ensureShownIndices, and only rebuilt when filters/hideUnmatched/
-	// contextLines change) lets us find cursorRow -- the position, among
-	// shown/non-excluded lines, of the last such line at or before
-	// v.Cursor -- with a binary search instead of an O(len(Lines)) scan.
+	v.ensureShownIndices(filters, hideUnmatched, context)
+
+	// Only the rows View can actually reach are built: building a fully
+	// formatted/styled table.Row for every shown line in the whole log,
+	// only for almost all of them to never actually be rendered, is wasted
+	// work that scales with the log's size instead of the terminal's.
+	// shownIndices (cached by ensureShownIndices, and only rebuilt when
+	// filters/hideUnmatched/context change) lets us find cursorRow --
+	// the position, among shown/non-excluded lines, of the last such line
+	// at or before v.Cursor -- with a binary search instead of an
+	// O(len(Lines)) scan.
 	shownCount := len(v.shownIndices)
 	v.ShownCount = shownCount
 
@@ -370,8 +1003,12 @@ func (v *LogView) MakeTable(windowWidth int, windowHeight int, filters []filterf
 	if rank > 0 {
 		cursorRow = rank - 1
 	}
+	v.selectedLine = -1
+	if shownCount > 0 {
+		v.selectedLine = v.shownIndices[cursorRow]
+	}
 
-	// chromeLines accounts for vertical space in the full rendered frame
+	// ChromeLines accounts for vertical space in the full rendered frame
 	// (see ui.go's View) that isn't the log pane's own table rows and isn't
 	// already subtracted out of windowHeight by the caller. ui.go's View
 	// subtracts the footer's extra wrapped lines and the filter pane's
@@ -391,18 +1028,25 @@ func (v *LogView) MakeTable(windowWidth int, windowHeight int, filters []filterf
 	// filterview.VisibleHeight) and having the caller subtract it here,
 	// the same way it already does for the footer, removes that
 	// assumption instead of just re-tuning the magic number.
-	chromeLines := paneBorderStyle.GetVerticalFrameSize() + 1 /* log header row */ + 1 /* status line */ + 1 /* footer baseline */
-	height := windowHeight - chromeLines
+	height := windowHeight - ChromeLines()
 	if height < 1 {
 		height = 1
 	}
-	start := clamp(cursorRow-height, 0, cursorRow)
-	end := clamp(cursorRow+height, cursorRow, shownCount)
 
-	rows := make([]table.Row, 0, end-start)
-	for _, i := range v.shownIndices[start:end] {
-		rows = append(rows, buildRow(i, v.Lines[i], v.matchCache[i], filters))
+	build := func(i int) []table.Row {
+		if f, ok := v.FoldAt(i); ok {
+			return []table.Row{foldRow(f)}
+		}
+		switch {
+		case structuredMode:
+			return []table.Row{buildStructuredRow(i, v.Lines[i], v.matchCache[i], filters, v.Highlight, v.Columns, v.columnWidths[1:], v.spanWidth(1))}
+		case v.Wrap:
+			return buildWrappedRows(i, v.Lines[i], v.matchCache[i], filters, v.Highlight, lineWidth)
+		default:
+			return []table.Row{buildRow(i, v.Lines[i], v.matchCache[i], filters, v.Highlight, v.HScroll, lineWidth)}
+		}
 	}
+	rows := v.rowWindow(cursorRow, height, build)
 
 	t := table.New(
 		table.WithColumns(columns),
@@ -410,12 +1054,230 @@ func (v *LogView) MakeTable(windowWidth int, windowHeight int, filters []filterf
 		table.WithFocused(true),
 		table.WithHeight(height),
 	)
-
-	// cursorRow was computed relative to the full shown set; rows only
-	// covers [start, end), so re-anchor it to the window before handing it
-	// to the table.
-	t.MoveDown(cursorRow - start)
+	t.MoveDown(v.cursorRowStart)
 
 	v.Table = t
+	if v.Minimap {
+		v.minimap.cells = v.renderMinimap(filters, t.Height())
+	}
 	return t
 }
+
+// ChromeLines is how many lines of MakeTable's windowHeight go to things
+// other than the Log pane's table rows (see MakeTable), so a windowHeight of
+// ChromeLines()+1 or less leaves room for only the single row it always
+// keeps.
+func ChromeLines() int {
+	return paneBorderStyle.GetVerticalFrameSize() + 1 /* log header row */ + 1 /* status line */ + 1 /* footer baseline */
+}
+
+// rowWindow is MakeTable's row windowing. One shown line can become
+// several rows -- Wrap splits it, and hidden lines or a time gap next to
+// it add a separator row (see separator) -- so it builds the cursor line's
+// rows plus up to height rows' worth of lines on either side of it,
+// trimming the outermost lines' rows to fit exactly, and records where the
+// cursor line's own rows (not its separators) landed for View. build
+// returns line i's rows.
+func (v *LogView) rowWindow(cursorRow int, height int, build func(i int) []table.Row) []table.Row {
+	v.cursorRowStart, v.cursorRowEnd = 0, 0
+	v.rowLines = v.rowLines[:0]
+	if len(v.shownIndices) == 0 {
+		return nil
+	}
+
+	if v.GapThreshold > 0 {
+		v.ensureTimeline()
+	}
+	last := len(v.shownIndices) - 1
+	// block returns a shown row's table rows, with how many of them are
+	// separators before and after the line itself (see separator): every
+	// line can have one above it, and the last shown line one below.
+	block := func(row int) (rows []table.Row, lead, trail int) {
+		i := v.shownIndices[row]
+		prev := -1
+		if row > 0 {
+			prev = v.shownIndices[row-1]
+		}
+		if sep, ok := v.separator(prev, i); ok {
+			rows, lead = append(rows, sep), 1
+		}
+		rows = append(rows, build(i)...)
+		if row == last {
+			if sep, ok := v.separator(i, len(v.Lines)); ok {
+				rows, trail = append(rows, sep), 1
+			}
+		}
+		return rows, lead, trail
+	}
+
+	// lines returns n copies of line row's index into Lines, one per table
+	// row it became, for rowLines.
+	lines := func(row, n int) []int {
+		out := make([]int, n)
+		for k := range out {
+			out[k] = v.shownIndices[row]
+		}
+		return out
+	}
+
+	var above []table.Row
+	var aboveLines []int
+	for row := cursorRow - 1; row >= 0 && len(above) < height; row-- {
+		rows, _, _ := block(row)
+		above = append(rows, above...)
+		aboveLines = append(lines(row, len(rows)), aboveLines...)
+	}
+	if len(above) > height {
+		above = above[len(above)-height:]
+		aboveLines = aboveLines[len(aboveLines)-height:]
+	}
+
+	current, lead, trail := block(cursorRow)
+
+	var below []table.Row
+	var belowLines []int
+	for row := cursorRow + 1; row < len(v.shownIndices) && len(current)+len(below) < height; row++ {
+		rows, _, _ := block(row)
+		below = append(below, rows...)
+		belowLines = append(belowLines, lines(row, len(rows))...)
+	}
+
+	rows := make([]table.Row, 0, len(above)+len(current)+len(below))
+	rows = append(rows, above...)
+	rows = append(rows, current...)
+	rows = append(rows, below...)
+	v.rowLines = append(v.rowLines, aboveLines...)
+	v.rowLines = append(v.rowLines, lines(cursorRow, len(current))...)
+	v.rowLines = append(v.rowLines, belowLines...)
+
+	v.cursorRowStart = len(above) + lead
+	v.cursorRowEnd = len(above) + len(current) - trail
+	return rows
+}
+
+// ShownAtOrAfter returns the first line at or after i that the last
+// MakeTable call showed, or i itself if none is -- where to put Cursor so a
+// jump to line i lands on what's visible there rather than snapping back
+// to the shown line before it.
+func (v *LogView) ShownAtOrAfter(i int) int {
+	k := sort.SearchInts(v.shownIndices, i)
+	if k == len(v.shownIndices) {
+		return i
+	}
+	return v.shownIndices[k]
+}
+
+// headerStyle, cellStyle and selectedStyle match bubbles/table's
+// DefaultStyles, which is what the Log pane used to render through (and
+// which MakeTable still builds Table with, as the row container View reads
+// from).
+var (
+	headerStyle   = table.DefaultStyles().Header
+	cellStyle     = table.DefaultStyles().Cell
+	selectedStyle = table.DefaultStyles().Selected
+)
+
+// cell pads content to an exact visible width, ANSI-aware, so already
+// styled strings (e.g. a highlighted line) aren't corrupted.
+func cell(content string, width int) string {
+	return lipgloss.NewStyle().Width(width).MaxWidth(width).Inline(true).Render(content)
+}
+
+// spanWidth returns the content width of one cell stretched across every
+// column from the j-th on, absorbing the padding between them.
+func (v *LogView) spanWidth(j int) int {
+	if j >= len(v.columnWidths) {
+		return 0
+	}
+	width := 0
+	for _, w := range v.columnWidths[j:] {
+		width += w
+	}
+	return width + (len(v.columnWidths)-1-j)*cellStyle.GetHorizontalPadding()
+}
+
+// renderRow renders one of MakeTable's rows at the current column widths. A
+// row with fewer cells than there are columns (Structured mode's raw
+// fallback, see buildStructuredRow) has its last cell span the rest.
+func (v *LogView) renderRow(row table.Row) string {
+	var b strings.Builder
+	for j, content := range row {
+		width := v.columnWidths[j]
+		if j == len(row)-1 && len(row) < len(v.columnWidths) {
+			width = v.spanWidth(j)
+		}
+		b.WriteString(cellStyle.Render(cell(content, width)))
+	}
+	return b.String()
+}
+
+// View renders the table MakeTable last built: a header row, then exactly
+// Table.Height() rows, scrolled so the cursor's whole line is on screen
+// (padding with blank rows past the end of the log, so the pane's height
+// stays constant).
+//
+// Rows are rendered by hand rather than through bubbles/table's own View,
+// which truncates every cell with a plain rune count before applying any
+// style -- counting a highlighted line's ANSI escape codes as visible
+// width, and cutting through them -- and can't show a row taller than one
+// line, which Wrap mode needs (see MakeTable).
+func (v *LogView) View() string {
+	columns := v.Table.Columns()
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = headerStyle.Render(cell(ansi.Truncate(col.Title, col.Width, "…"), col.Width))
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, header...))
+
+	rows := v.Table.Rows()
+	height := v.Table.Height()
+	if height < 0 {
+		height = 0
+	}
+
+	minimap := v.Minimap && len(v.minimap.cells) == height
+	if minimap {
+		b.WriteString(strings.Repeat(" ", MinimapWidth))
+	}
+
+	top := v.scrollTop()
+	selLo, selHi, selecting := v.Selection()
+	blank := make(table.Row, len(v.columnWidths))
+	for r := top; r < top+height; r++ {
+		b.WriteString("\n")
+		if r >= len(rows) {
+			b.WriteString(v.renderRow(blank))
+		} else {
+			row := v.renderRow(rows[r])
+			switch {
+			case r >= v.cursorRowStart && r < v.cursorRowEnd:
+				row = selectedStyle.Render(row)
+			case selecting && r < len(v.rowLines) && v.rowLines[r] >= selLo && v.rowLines[r] < selHi:
+				row = selectionStyle.Render(row)
+			}
+			b.WriteString(row)
+		}
+		if minimap {
+			b.WriteString(v.minimap.cells[r-top])
+		}
+	}
+
+	return b.String()
+}
+
+// scrollTop returns the first of Table's rows View shows: scrolled down
+// just far enough to show the cursor line's last row, unless it's taller
+// than the pane, in which case its start. With Centered, it's scrolled
+// further, to put the cursor line in the middle, unless that would leave
+// rows empty past the end of the log.
+func (v *LogView) scrollTop() int {
+	height := max(v.Table.Height(), 0)
+	top := v.cursorRowEnd - height
+	if v.Centered {
+		centered := v.cursorRowStart - (height-(v.cursorRowEnd-v.cursorRowStart))/2
+		top = max(top, min(centered, len(v.Table.Rows())-height))
+	}
+	return clamp(top, 0, v.cursorRowStart)
+}
@@ -57,7 +57,7 @@ func benchmarkMakeTable(b *testing.B, n int) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		v.MakeTable(200, 60, filters, true, 1)
+		v.MakeTable(200, 60, filters, true, Context{Before: 1, After: 1})
 	}
 }
 
@@ -78,7 +78,7 @@ func benchmarkMakeTableColdCache(b *testing.B, n int) {
 		b.StopTimer()
 		v := LogView{Lines: lines}
 		b.StartTimer()
-		v.MakeTable(200, 60, filters, true, 1)
+		v.MakeTable(200, 60, filters, true, Context{Before: 1, After: 1})
 	}
 }
 
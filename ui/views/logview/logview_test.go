@@ -2,12 +2,15 @@ package logview
 
 import (
 	"fmt"
+	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
 	"regexp"
 	"skim/filterfiles"
+	"skim/timestamps"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func mustRegex(t *testing.T, text string) regexp.Regexp {
@@ -41,20 +44,154 @@ func TestCursorUpDown(t *testing.T) {
 	}
 }
 
-func TestCursorLeftRightAreNoOps(t *testing.T) {
-	v := LogView{Lines: []string{"a"}, Cursor: 0}
+func TestCursorLeftRightScrollHorizontally(t *testing.T) {
+	v := LogView{Lines: []string{strings.Repeat("x", 100), "short"}}
+	v.MakeTable(60, 30, nil, false, Context{}) // Line column is narrower than the long line
 
 	if got := v.CursorLeft(); got != 0 {
-		t.Errorf("CursorLeft() = %d, want 0", got)
+		t.Errorf("CursorLeft() at the start = %d, want 0 (should not go negative)", got)
 	}
-	if got := v.CursorRight(); got != 0 {
-		t.Errorf("CursorRight() = %d, want 0", got)
+	if got := v.CursorRight(); got != HScrollStep {
+		t.Errorf("CursorRight() = %d, want %d", got, HScrollStep)
+	}
+
+	for i := 0; i < 100; i++ {
+		v.CursorRight()
+	}
+	if want := 100 - v.lineWidth; v.HScroll != want {
+		t.Errorf("HScroll after scrolling far right = %d, want %d (the long line's end just in view)", v.HScroll, want)
+	}
+
+	if got := v.CursorLeft(); got != 100-v.lineWidth-HScrollStep {
+		t.Errorf("CursorLeft() = %d, want %d", got, 100-v.lineWidth-HScrollStep)
 	}
 	if v.Cursor != 0 {
 		t.Errorf("Cursor changed to %d after CursorLeft/Right, want unchanged", v.Cursor)
 	}
 }
 
+func TestCursorRightNoOpWhenEverythingFits(t *testing.T) {
+	v := LogView{Lines: []string{"a"}}
+	v.MakeTable(100, 30, nil, false, Context{})
+
+	if got := v.CursorRight(); got != 0 {
+		t.Errorf("CursorRight() = %d, want 0 when no line overflows the Line column", got)
+	}
+}
+
+func TestCursorLeftRightNoOpWhileWrapped(t *testing.T) {
+	v := LogView{Lines: []string{strings.Repeat("x", 500)}, Wrap: true, HScroll: 16}
+	v.MakeTable(60, 30, nil, false, Context{})
+
+	if got := v.CursorRight(); got != 16 {
+		t.Errorf("CursorRight() while wrapped = %d, want unchanged 16", got)
+	}
+	if got := v.CursorLeft(); got != 16 {
+		t.Errorf("CursorLeft() while wrapped = %d, want unchanged 16", got)
+	}
+}
+
+func TestMakeTableHScrollShiftsLineText(t *testing.T) {
+	v := LogView{Lines: []string{"0123456789abcdefghij"}, HScroll: 10}
+	rows := v.MakeTable(100, 30, nil, false, Context{}).Rows()
+
+	if rows[0][1] != "abcdefghij" {
+		t.Errorf("row text = %q, want %q (first 10 columns scrolled off)", rows[0][1], "abcdefghij")
+	}
+	if !strings.Contains(v.View(), "Line (+10)") {
+		t.Errorf("View() header doesn't show the scroll offset:\n%s", v.View())
+	}
+}
+
+func TestMakeTableTruncatesLongLinesWithEllipsis(t *testing.T) {
+	v := LogView{Lines: []string{strings.Repeat("x", 200)}}
+	rows := v.MakeTable(60, 30, nil, false, Context{}).Rows()
+
+	if got := lipgloss.Width(rows[0][1]); got != v.lineWidth {
+		t.Errorf("row width = %d, want exactly the Line column's %d", got, v.lineWidth)
+	}
+	if !strings.HasSuffix(rows[0][1], "…") {
+		t.Errorf("row = %q, want it to end in an ellipsis", rows[0][1])
+	}
+}
+
+func TestMakeTableWrapSplitsLongLinesIntoRows(t *testing.T) {
+	long := strings.Repeat("a", 45) + strings.Repeat("b", 45)
+	v := LogView{Lines: []string{"first", long, "last"}, Wrap: true, Cursor: 1}
+	rows := v.MakeTable(60, 30, nil, false, Context{}).Rows()
+
+	// 60 - 4 ("#" column) - 6 (chrome) = 50 columns for the line.
+	if v.lineWidth != 50 {
+		t.Fatalf("precondition: lineWidth = %d, want 50", v.lineWidth)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4 (first, the long line over two rows, last): %v", len(rows), rows)
+	}
+	if rows[1][0] != "2" || rows[2][0] != "" {
+		t.Errorf("line numbers = %q, %q, want \"2\" then blank for the continuation row", rows[1][0], rows[2][0])
+	}
+	if rows[1][1]+rows[2][1] != long {
+		t.Errorf("wrapped segments %q + %q don't rejoin into the original line", rows[1][1], rows[2][1])
+	}
+	if v.cursorRowStart != 1 || v.cursorRowEnd != 3 {
+		t.Errorf("cursor rows = [%d, %d), want [1, 3) (both rows of line 2)", v.cursorRowStart, v.cursorRowEnd)
+	}
+}
+
+func TestViewWrapKeepsCursorLineVisibleAndHeightConstant(t *testing.T) {
+	lines := genLines(200)
+	lines[150] = strings.Repeat("w", 400) // wraps onto 8 rows at a 50-column Line column
+	v := LogView{Lines: lines, Wrap: true, Cursor: 150}
+
+	windowHeight := 20
+	tbl := v.MakeTable(60, windowHeight, nil, false, Context{})
+	out := v.View()
+	outLines := strings.Split(out, "\n")
+
+	if want := tbl.Height() + 1; len(outLines) != want {
+		t.Fatalf("View() rendered %d lines, want %d (header + Table.Height() rows)", len(outLines), want)
+	}
+	if !strings.Contains(outLines[len(outLines)-1], strings.Repeat("w", 50)) {
+		t.Errorf("last rendered row = %q, want the cursor line's final segment at the bottom", outLines[len(outLines)-1])
+	}
+	var sawNumber bool
+	for _, l := range outLines {
+		if strings.Contains(l, "151") {
+			sawNumber = true
+		}
+	}
+	if !sawNumber {
+		t.Errorf("View() doesn't show the cursor line's number (its first row scrolled off):\n%s", out)
+	}
+}
+
+func TestViewPadsToConstantHeightAndFillsWidth(t *testing.T) {
+	windowWidth := 100
+	v := LogView{Lines: []string{"only line"}}
+	tbl := v.MakeTable(windowWidth, 30, nil, false, Context{})
+
+	outLines := strings.Split(v.View(), "\n")
+	if want := tbl.Height() + 1; len(outLines) != want {
+		t.Errorf("View() rendered %d lines, want %d even for a one-line log", len(outLines), want)
+	}
+	want := windowWidth - paneBorderStyle.GetHorizontalFrameSize()
+	for _, line := range outLines {
+		if got := lipgloss.Width(line); got != want {
+			t.Errorf("line %q rendered at width %d, want %d", line, got, want)
+		}
+	}
+}
+
+func TestViewPinsCursorToBottomOnceScrolled(t *testing.T) {
+	v := LogView{Lines: genLines(1000), Cursor: 500}
+	tbl := v.MakeTable(100, 23, nil, false, Context{})
+
+	outLines := strings.Split(v.View(), "\n")
+	if got := outLines[tbl.Height()]; !strings.Contains(got, "line 501") {
+		t.Errorf("bottom row = %q, want the cursor's line 501 there (scrolling follows the cursor)", got)
+	}
+}
+
 func TestToggleIsNoOp(t *testing.T) {
 	v := LogView{Lines: []string{"a"}}
 	// Should not panic and should not alter any observable state.
@@ -101,7 +238,7 @@ func TestMakeTableHidesOrShowsUnmatchedLines(t *testing.T) {
 	v := LogView{Lines: []string{"debug: one", "info: two", "debug: three"}}
 
 	t.Run("hides unmatched lines", func(t *testing.T) {
-		table := v.MakeTable(100, 30, filters, true, 0)
+		table := v.MakeTable(100, 30, filters, true, Context{})
 		rows := table.Rows()
 		if len(rows) != 2 {
 			t.Fatalf("got %d rows, want 2 (only matching lines)", len(rows))
@@ -114,7 +251,7 @@ func TestMakeTableHidesOrShowsUnmatchedLines(t *testing.T) {
 	})
 
 	t.Run("shows unmatched lines", func(t *testing.T) {
-		table := v.MakeTable(100, 30, filters, false, 0)
+		table := v.MakeTable(100, 30, filters, false, Context{})
 		rows := table.Rows()
 		if len(rows) != 3 {
 			t.Fatalf("got %d rows, want 3 (all lines)", len(rows))
@@ -124,7 +261,7 @@ func TestMakeTableHidesOrShowsUnmatchedLines(t *testing.T) {
 
 func TestMakeTableLineNumbersAreOneIndexed(t *testing.T) {
 	v := LogView{Lines: []string{"first", "second"}}
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) != 2 {
@@ -145,8 +282,8 @@ func TestMakeTableLineNumberColumnWidensForLargeLineCounts(t *testing.T) {
 		lines[i] = "line"
 	}
 	v := LogView{Lines: lines, Cursor: numLines - 1}
-	tbl := v.MakeTable(100, 30, nil, false, 0)
-	view := tbl.View()
+	v.MakeTable(100, 30, nil, false, Context{})
+	view := v.View()
 
 	want := strconv.Itoa(numLines)
 	if !strings.Contains(view, want) {
@@ -160,7 +297,7 @@ func TestMakeTableLineNumberColumnWidensForLargeLineCounts(t *testing.T) {
 func TestMakeTableFillsExactlyWindowWidth(t *testing.T) {
 	windowWidth := 100
 	v := LogView{Lines: []string{"hello"}}
-	tbl := v.MakeTable(windowWidth, 30, nil, false, 0)
+	v.MakeTable(windowWidth, 30, nil, false, Context{})
 
 	// MakeTable's own render doesn't include ui.go's pane border (that's
 	// applied afterward by ui.go's paneStyle.Render) -- so its content
@@ -169,7 +306,7 @@ func TestMakeTableFillsExactlyWindowWidth(t *testing.T) {
 	// exists to preserve; a wrong offset here would either waste terminal
 	// columns or push the table wider than the pane border can absorb.
 	want := windowWidth - paneBorderStyle.GetHorizontalFrameSize()
-	for _, line := range strings.Split(tbl.View(), "\n") {
+	for _, line := range strings.Split(v.View(), "\n") {
 		if got := lipgloss.Width(line); got != want {
 			t.Errorf("line %q rendered at width %d, want %d (windowWidth - pane border)", line, got, want)
 		}
@@ -178,7 +315,7 @@ func TestMakeTableFillsExactlyWindowWidth(t *testing.T) {
 
 func TestMakeTableReplacesTabsWithSpaces(t *testing.T) {
 	v := LogView{Lines: []string{"a\tb"}}
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) != 1 {
@@ -194,7 +331,7 @@ func TestMakeTableReplacesTabsWithSpaces(t *testing.T) {
 
 func TestMakeTableSanitizesControlCharacters(t *testing.T) {
 	v := LogView{Lines: []string{"mixed CRLF-ish line\r trailing"}}
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) != 1 {
@@ -210,7 +347,7 @@ func TestMakeTableSanitizesControlCharacters(t *testing.T) {
 
 func TestMakeTableStripsAnsiEscapeSequences(t *testing.T) {
 	v := LogView{Lines: []string{"\x1b[33mWARNING\x1b[0m: something happened"}}
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) != 1 {
@@ -292,13 +429,88 @@ func TestFindNextEmptyLog(t *testing.T) {
 	}
 }
 
+func TestFindShownSkipsHiddenLines(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "keep", "#FF0000")}
+	v := LogView{Lines: []string{"keep a", "b", "keep b", "b again", "keep c"}}
+	v.MakeTable(80, 20, filters, true, Context{})
+	re := mustRegex(t, "b")
+
+	if idx, ok := v.FindNextShown(re); !ok || idx != 2 {
+		t.Errorf("FindNextShown() = %d, %v, want 2, true (line 1 is hidden)", idx, ok)
+	}
+	if n := v.CountShownMatches(re); n != 1 {
+		t.Errorf("CountShownMatches() = %d, want 1", n)
+	}
+
+	v.Cursor = 2
+	if idx, ok := v.FindPrevShown(re); !ok || idx != 2 {
+		t.Errorf("FindPrevShown() from the only shown match = %d, %v, want 2, true (wrapping back to itself)", idx, ok)
+	}
+
+	// From a hidden line, the walk starts where it would sit among the
+	// shown ones.
+	v.Cursor = 3
+	keep := mustRegex(t, "keep")
+	if idx, ok := v.FindNextShown(keep); !ok || idx != 4 {
+		t.Errorf("FindNextShown() from hidden line 3 = %d, %v, want 4, true", idx, ok)
+	}
+	if idx, ok := v.FindPrevShown(keep); !ok || idx != 2 {
+		t.Errorf("FindPrevShown() from hidden line 3 = %d, %v, want 2, true", idx, ok)
+	}
+}
+
+func TestFindNextAndPrevFilterMatchFollowAttribution(t *testing.T) {
+	// "error timeout" matches both filters, but is attributed to the first
+	// (first enabled filter wins), so it's not a hit for filter 1.
+	filters := []filterfiles.Filter{mustFilter(t, "error", "#FF0000"), mustFilter(t, "timeout", "#00FF00")}
+	v := LogView{Lines: []string{"timeout", "error timeout", "ok", "timeout again", "error"}, Cursor: 0}
+
+	idx, ok := v.FindNextFilterMatch(filters, 1)
+	if !ok || idx != 3 {
+		t.Errorf("FindNextFilterMatch(1) = %d, %v, want 3, true (skipping line 1, attributed to filter 0)", idx, ok)
+	}
+
+	v.Cursor = 3
+	idx, ok = v.FindNextFilterMatch(filters, 1)
+	if !ok || idx != 0 {
+		t.Errorf("FindNextFilterMatch(1) from the last hit = %d, %v, want 0, true (wraps)", idx, ok)
+	}
+
+	v.Cursor = 0
+	idx, ok = v.FindPrevFilterMatch(filters, 0)
+	if !ok || idx != 4 {
+		t.Errorf("FindPrevFilterMatch(0) = %d, %v, want 4, true (wraps to the end)", idx, ok)
+	}
+}
+
+func TestFindFilterMatchSkipsExcludedLinesAndBadIndices(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "error", "#FF0000"), mustExcludingFilter(t, "noise")}
+	v := LogView{Lines: []string{"error noise", "ok", "error"}, Cursor: 2}
+
+	idx, ok := v.FindNextFilterMatch(filters, 0)
+	if !ok || idx != 2 {
+		t.Errorf("FindNextFilterMatch(0) = %d, %v, want 2, true (line 0 is excluded, so only line 2 is a hit)", idx, ok)
+	}
+
+	for _, bad := range []int{-1, 2} {
+		if _, ok := v.FindNextFilterMatch(filters, bad); ok {
+			t.Errorf("FindNextFilterMatch(%d) found a match, want none for an out-of-range filter", bad)
+		}
+	}
+
+	empty := LogView{}
+	if _, ok := empty.FindPrevFilterMatch(filters, 0); ok {
+		t.Error("FindPrevFilterMatch() on an empty log found a match, want none")
+	}
+}
+
 func TestMakeTableCursorRowTracksVisibleLineWhenLinesAreHidden(t *testing.T) {
 	filters := []filterfiles.Filter{mustFilter(t, "keep", "#87CEFA")}
 	// Lines 0 and 2 are hidden by hideUnmatched; only "keep one" (1) and
 	// "keep two" (3) are visible.
 	v := LogView{Lines: []string{"drop", "keep one", "drop", "keep two"}, Cursor: 2}
 
-	table := v.MakeTable(100, 30, filters, true, 0)
+	table := v.MakeTable(100, 30, filters, true, Context{})
 
 	// Cursor (2) points at a hidden line ("drop"); the highlighted row
 	// should land on the last visible row at or before it: "keep one".
@@ -307,7 +519,7 @@ func TestMakeTableCursorRowTracksVisibleLineWhenLinesAreHidden(t *testing.T) {
 	}
 
 	v.Cursor = 3
-	table = v.MakeTable(100, 30, filters, true, 0)
+	table = v.MakeTable(100, 30, filters, true, Context{})
 	if got := table.SelectedRow(); got[1] != "keep two" {
 		t.Errorf("SelectedRow() = %v, want row for %q", got, "keep two")
 	}
@@ -336,7 +548,7 @@ func TestMakeTableHidesExcludedLinesEvenWithHideUnmatchedOff(t *testing.T) {
 
 	// hideUnmatched is off, so ordinarily every line would show; the
 	// excluding filter should still remove its match.
-	table := v.MakeTable(100, 30, filters, false, 0)
+	table := v.MakeTable(100, 30, filters, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) != 2 {
@@ -356,7 +568,7 @@ func TestMakeTableExcludingBeatsHighlightingMatch(t *testing.T) {
 	}
 	v := LogView{Lines: []string{"heartbeat: ok", "other line"}}
 
-	table := v.MakeTable(100, 30, filters, false, 0)
+	table := v.MakeTable(100, 30, filters, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) != 1 {
@@ -372,7 +584,7 @@ func TestMakeTableContextLinesShowsNeighborsOfAMatch(t *testing.T) {
 	v := LogView{Lines: []string{"info: zero", "info: one", "debug: two", "info: three", "info: four"}}
 
 	t.Run("zero context matches existing hide-unmatched behavior", func(t *testing.T) {
-		table := v.MakeTable(100, 30, filters, true, 0)
+		table := v.MakeTable(100, 30, filters, true, Context{})
 		rows := table.Rows()
 		if len(rows) != 1 {
 			t.Fatalf("got %d rows, want 1 (only the match)", len(rows))
@@ -383,7 +595,7 @@ func TestMakeTableContextLinesShowsNeighborsOfAMatch(t *testing.T) {
 	})
 
 	t.Run("context 1 includes one neighbor on each side", func(t *testing.T) {
-		table := v.MakeTable(100, 30, filters, true, 1)
+		table := v.MakeTable(100, 30, filters, true, Context{Before: 1, After: 1})
 		rows := table.Rows()
 		if len(rows) != 3 {
 			t.Fatalf("got %d rows, want 3 (match plus one line of context each side), got: %v", len(rows), rows)
@@ -398,7 +610,7 @@ func TestMakeTableContextLinesShowsNeighborsOfAMatch(t *testing.T) {
 	})
 
 	t.Run("context is clamped at the ends of the log", func(t *testing.T) {
-		table := v.MakeTable(100, 30, filters, true, 10)
+		table := v.MakeTable(100, 30, filters, true, Context{Before: 10, After: 10})
 		rows := table.Rows()
 		if len(rows) != 5 {
 			t.Fatalf("got %d rows, want 5 (the whole log, context clamped at both ends)", len(rows))
@@ -406,7 +618,7 @@ func TestMakeTableContextLinesShowsNeighborsOfAMatch(t *testing.T) {
 	})
 
 	t.Run("context is irrelevant when hideUnmatched is off", func(t *testing.T) {
-		table := v.MakeTable(100, 30, filters, false, 2)
+		table := v.MakeTable(100, 30, filters, false, Context{Before: 2, After: 2})
 		rows := table.Rows()
 		if len(rows) != 5 {
 			t.Fatalf("got %d rows, want 5 (everything already shown)", len(rows))
@@ -414,11 +626,54 @@ func TestMakeTableContextLinesShowsNeighborsOfAMatch(t *testing.T) {
 	})
 }
 
+func TestMakeTableAsymmetricContext(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "^debug", "#87CEFA")}
+	v := LogView{Lines: genLines(9)}
+	v.Lines[4] = "debug: five"
+
+	got := rowNumbers(v.MakeTable(100, 30, filters, true, Context{Before: 3, After: 1}).Rows())
+	if want := "2 3 4 5 6"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %v, want lines %s (3 before, 1 after)", got, want)
+	}
+	got = rowNumbers(v.MakeTable(100, 30, filters, true, Context{After: 2}).Rows())
+	if want := "5 6 7"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %v, want lines %s (none before, 2 after)", got, want)
+	}
+}
+
+func TestMakeTablePerFilterContextOverridesTheView(t *testing.T) {
+	before, none := 2, 0
+	errors := mustFilter(t, "^error", "#FF0000")
+	errors.ContextBefore = &before
+	noisy := mustFilter(t, "^debug", "#87CEFA")
+	noisy.ContextBefore, noisy.ContextAfter = &none, &none
+	filters := []filterfiles.Filter{errors, noisy}
+
+	v := LogView{Lines: genLines(20)}
+	v.Lines[5] = "error: six"
+	v.Lines[14] = "debug: fifteen"
+
+	// The error takes 2 lines before from its own override and 1 after from
+	// the view; the debug line gets no context at all.
+	got := rowNumbers(v.MakeTable(100, 30, filters, true, Context{Before: 1, After: 1}).Rows())
+	if want := "4 5 6 7 15"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %v, want lines %s", got, want)
+	}
+
+	// Changing an override alone must not be served from the shown-lines cache.
+	more := 4
+	filters[1].ContextAfter = &more
+	got = rowNumbers(v.MakeTable(100, 30, filters, true, Context{Before: 1, After: 1}).Rows())
+	if want := "4 5 6 7 15 16 17 18 19"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %v after raising the debug filter's context, want lines %s", got, want)
+	}
+}
+
 func TestMakeTableContextLinesIncludesUnmatchedNeighborContent(t *testing.T) {
 	filters := []filterfiles.Filter{mustFilter(t, "^debug", "#87CEFA")}
 	v := LogView{Lines: []string{"info: before", "debug: match"}}
 
-	table := v.MakeTable(100, 30, filters, true, 1)
+	table := v.MakeTable(100, 30, filters, true, Context{Before: 1, After: 1})
 	rows := table.Rows()
 	if len(rows) != 2 {
 		t.Fatalf("got %d rows, want 2", len(rows))
@@ -443,7 +698,7 @@ func TestMakeTableWindowsRowsForLargeLogs(t *testing.T) {
 	lines := genLines(1000)
 	v := LogView{Lines: lines, Cursor: 500}
 
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) >= len(lines) {
@@ -461,7 +716,7 @@ func TestMakeTableWindowNearTopOfLog(t *testing.T) {
 	lines := genLines(1000)
 	v := LogView{Lines: lines, Cursor: 0}
 
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) == 0 || rows[0][1] != "line 1" {
@@ -476,7 +731,7 @@ func TestMakeTableWindowNearBottomOfLog(t *testing.T) {
 	lines := genLines(1000)
 	v := LogView{Lines: lines, Cursor: 999}
 
-	table := v.MakeTable(100, 30, nil, false, 0)
+	table := v.MakeTable(100, 30, nil, false, Context{})
 	rows := table.Rows()
 
 	if len(rows) == 0 || rows[len(rows)-1][1] != "line 1000" {
@@ -499,7 +754,7 @@ func TestShownCountReflectsFullLogNotJustTheWindow(t *testing.T) {
 	}
 	v := LogView{Lines: lines, Cursor: 500}
 
-	v.MakeTable(100, 30, filters, true, 0)
+	v.MakeTable(100, 30, filters, true, Context{})
 
 	if v.ShownCount != 500 {
 		t.Errorf("ShownCount = %d, want 500 (half the lines match ^debug)", v.ShownCount)
@@ -522,7 +777,7 @@ func TestMakeTableWindowedCursorSnapsToVisibleLineWhenHidden(t *testing.T) {
 	// but exercised on a log large enough to actually trigger windowing.
 	v := LogView{Lines: lines, Cursor: 501}
 
-	table := v.MakeTable(100, 30, filters, true, 0)
+	table := v.MakeTable(100, 30, filters, true, Context{})
 	if got := table.SelectedRow(); got[1] != "keep this one" {
 		t.Errorf("SelectedRow() = %v, want the only visible line", got)
 	}
@@ -532,10 +787,10 @@ func TestMatchCacheReusedWhenFiltersUnchanged(t *testing.T) {
 	filters := []filterfiles.Filter{mustFilter(t, "^debug", "#87CEFA")}
 	v := LogView{Lines: []string{"debug: one", "info: two"}}
 
-	v.MakeTable(100, 30, filters, true, 0)
+	v.MakeTable(100, 30, filters, true, Context{})
 	first := v.matchCache
 
-	v.MakeTable(100, 30, filters, true, 0)
+	v.MakeTable(100, 30, filters, true, Context{})
 	second := v.matchCache
 
 	if len(first) == 0 || &first[0] != &second[0] {
@@ -547,13 +802,13 @@ func TestMatchCacheInvalidatesWhenFilterRegexChanges(t *testing.T) {
 	filters := []filterfiles.Filter{mustFilter(t, "^debug", "#87CEFA")}
 	v := LogView{Lines: []string{"debug: one", "info: two"}}
 
-	table := v.MakeTable(100, 30, filters, true, 0)
+	table := v.MakeTable(100, 30, filters, true, Context{})
 	if rows := table.Rows(); len(rows) != 1 || !strings.Contains(rows[0][1], "debug") {
 		t.Fatalf("precondition: got rows %v, want just the debug line", rows)
 	}
 
 	filters[0] = mustFilter(t, "^info", "#87CEFA")
-	table = v.MakeTable(100, 30, filters, true, 0)
+	table = v.MakeTable(100, 30, filters, true, Context{})
 	rows := table.Rows()
 	if len(rows) != 1 || !strings.Contains(rows[0][1], "info") {
 		t.Errorf("cache was not invalidated after the filter's regex changed: rows = %v", rows)
@@ -564,10 +819,10 @@ func TestMatchCacheInvalidatesWhenFilterEnabledToggles(t *testing.T) {
 	filters := []filterfiles.Filter{mustFilter(t, "^debug", "#87CEFA")}
 	v := LogView{Lines: []string{"debug: one", "info: two"}}
 
-	v.MakeTable(100, 30, filters, true, 0)
+	v.MakeTable(100, 30, filters, true, Context{})
 
 	filters[0].IsEnabled = false
-	table := v.MakeTable(100, 30, filters, true, 0)
+	table := v.MakeTable(100, 30, filters, true, Context{})
 	if rows := table.Rows(); len(rows) != 0 {
 		t.Errorf("got %d rows after disabling the only filter, want 0: %v", len(rows), rows)
 	}
@@ -625,7 +880,7 @@ func TestMakeTableExcludedLineStaysHiddenEvenAsContext(t *testing.T) {
 	}
 	v := LogView{Lines: []string{"secret: redacted", "debug: match"}}
 
-	table := v.MakeTable(100, 30, filters, true, 1)
+	table := v.MakeTable(100, 30, filters, true, Context{Before: 1, After: 1})
 	rows := table.Rows()
 
 	if len(rows) != 1 {
@@ -635,3 +890,368 @@ func TestMakeTableExcludedLineStaysHiddenEvenAsContext(t *testing.T) {
 		t.Errorf("remaining row = %v, want the matched line", rows[0])
 	}
 }
+
+func TestSelectedLineFollowsSnappedCursor(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "keep", "#ff0000")}
+	v := &LogView{Lines: []string{"keep a", "drop", "keep b", "drop"}}
+
+	tests := []struct {
+		name          string
+		cursor        int
+		hideUnmatched bool
+		want          int
+	}{
+		{"cursor on a shown line", 2, true, 2},
+		{"hidden line snaps back to the previous shown line", 1, true, 0},
+		{"hidden trailing line snaps back", 3, true, 2},
+		{"nothing hidden", 1, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v.Cursor = tt.cursor
+			v.MakeTable(80, 20, filters, tt.hideUnmatched, Context{})
+			got, ok := v.SelectedLine()
+			if !ok || got != tt.want {
+				t.Errorf("SelectedLine() = %d, %v, want %d, true", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectedLineWithNothingShown(t *testing.T) {
+	v := &LogView{Lines: []string{"a", "b"}}
+	v.MakeTable(80, 20, []filterfiles.Filter{mustExcludingFilter(t, ".")}, false, Context{})
+
+	if _, ok := v.SelectedLine(); ok {
+		t.Error("SelectedLine() ok = true with every line excluded, want false")
+	}
+}
+
+func TestMatchCacheInvalidatesWhenFilterFieldChanges(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "^a$", "#87CEFA")}
+	filters[0].Field = "x"
+	v := LogView{Lines: []string{"x=a y=b", "x=b y=a"}}
+
+	v.MakeTable(100, 30, filters, true, Context{})
+	filters[0].Field = "y"
+	table := v.MakeTable(100, 30, filters, true, Context{})
+	if rows := table.Rows(); len(rows) != 1 || rows[0][0] != "2" {
+		t.Errorf("cache was not invalidated after the filter's field changed: rows = %v", rows)
+	}
+}
+
+func TestStructuredModeRendersFieldColumns(t *testing.T) {
+	v := LogView{
+		Lines: []string{
+			`{"ts":"10:00:01","level":"info","msg":"started","request_id":"r1"}`,
+			`ts=10:00:02 level=error msg="disk full"`,
+			`panic: something that isn't structured at all`,
+		},
+		Columns:    []Column{{Field: "ts", Width: 8}, {Field: "level", Title: "Lvl", Width: 5}, {Field: "msg"}},
+		Structured: true,
+	}
+
+	tbl := v.MakeTable(80, 20, nil, false, Context{})
+
+	titles := []string{}
+	for _, c := range tbl.Columns() {
+		titles = append(titles, c.Title)
+	}
+	if want := []string{"#", "ts", "Lvl", "msg"}; strings.Join(titles, ",") != strings.Join(want, ",") {
+		t.Errorf("column titles = %v, want %v", titles, want)
+	}
+
+	rows := tbl.Rows()
+	if got := []string(rows[0]); len(got) != 4 || got[1] != "10:00:01" || got[2] != "info" || got[3] != "started" {
+		t.Errorf("JSON row = %q, want its ts/level/msg fields in columns", got)
+	}
+	if got := []string(rows[1]); len(got) != 4 || got[2] != "error" || got[3] != "disk full" {
+		t.Errorf("logfmt row = %q, want its fields in columns", got)
+	}
+	if got := []string(rows[2]); len(got) != 2 || !strings.Contains(got[1], "panic: something") {
+		t.Errorf("unparseable row = %q, want a raw {#, Line} fallback row", got)
+	}
+
+	want := 80 - paneBorderStyle.GetHorizontalFrameSize()
+	outLines := strings.Split(v.View(), "\n")
+	for _, line := range outLines {
+		if got := lipgloss.Width(line); got != want {
+			t.Errorf("line %q rendered at width %d, want %d", line, got, want)
+		}
+	}
+	if !strings.Contains(outLines[3], "panic: something that isn't structured at all") {
+		t.Errorf("fallback row = %q, want the raw line spanning the field columns untruncated", outLines[3])
+	}
+}
+
+func TestStructuredModeLeavesMissingFieldsBlankAndTruncatesValues(t *testing.T) {
+	v := LogView{
+		Lines:      []string{`{"level":"warning-but-long","msg":"x"}`},
+		Columns:    []Column{{Field: "ts", Width: 6}, {Field: "level", Width: 4}, {Field: "msg"}},
+		Structured: true,
+	}
+
+	row := v.MakeTable(60, 20, nil, false, Context{}).Rows()[0]
+	if row[1] != "" {
+		t.Errorf("ts cell = %q, want blank for a missing field", row[1])
+	}
+	if row[2] != "war…" {
+		t.Errorf("level cell = %q, want %q (truncated to its width)", row[2], "war…")
+	}
+}
+
+func TestStructuredModeOffOrWithoutColumnsIsPlainLayout(t *testing.T) {
+	for _, v := range []LogView{
+		{Lines: []string{`a=1 b=2`}, Columns: []Column{{Field: "a"}}, Structured: false},
+		{Lines: []string{`a=1 b=2`}, Structured: true},
+	} {
+		tbl := v.MakeTable(60, 20, nil, false, Context{})
+		if n := len(tbl.Columns()); n != 2 {
+			t.Errorf("Structured %v with %d Columns: got %d table columns, want the plain 2", v.Structured, len(v.Columns), n)
+		}
+	}
+}
+
+func TestStructuredModeIgnoresHorizontalScroll(t *testing.T) {
+	v := LogView{Lines: []string{"a=1 b=" + strings.Repeat("x", 300)}, Columns: []Column{{Field: "b"}}, Structured: true}
+	v.MakeTable(60, 20, nil, false, Context{})
+
+	if got := v.CursorRight(); got != 0 {
+		t.Errorf("CursorRight() = %d in Structured mode, want 0 (no-op)", got)
+	}
+}
+
+func TestDetectColumns(t *testing.T) {
+	v := LogView{Lines: []string{
+		"starting up",
+		`{"ts":"t","level":"info","msg":"m","caller":"c","extra":"e"}`,
+		`a=1 b=2`,
+	}}
+
+	got := v.DetectColumns()
+	want := []Column{{Field: "ts"}, {Field: "level"}, {Field: "msg"}, {Field: "caller"}}
+	if len(got) != len(want) {
+		t.Fatalf("DetectColumns() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("DetectColumns()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	plain := LogView{Lines: []string{"nothing", "structured here"}}
+	if cols := plain.DetectColumns(); cols != nil {
+		t.Errorf("DetectColumns() on a plain log = %+v, want nil", cols)
+	}
+}
+
+// timedLog is a small log with a multi-line entry and a line before the
+// first timestamp, parsed in UTC.
+func timedLog() *LogView {
+	return &LogView{
+		Lines: []string{
+			"preamble without a time",
+			"2024-01-02 14:01:00 INFO start",
+			"2024-01-02 14:02:30 ERROR boom",
+			"\tat Foo.bar(Foo.java:1)",
+			"2024-01-02 14:04:00 INFO retry",
+			"2024-01-02 14:06:00 INFO done",
+		},
+		TimeParser: timestamps.Parser{Location: time.UTC},
+	}
+}
+
+func utc(h, m, s int) time.Time {
+	return time.Date(2024, 1, 2, h, m, s, 0, time.UTC)
+}
+
+func TestLineTimeInheritsFromThePreviousStampedLine(t *testing.T) {
+	v := timedLog()
+
+	if _, ok := v.LineTime(0); ok {
+		t.Error("LineTime(0) ok = true for a line before the first timestamp")
+	}
+	if got, ok := v.LineTime(3); !ok || !got.Equal(utc(14, 2, 30)) {
+		t.Errorf("LineTime(3) = %v, %v, want the stack frame to take its entry's 14:02:30", got, ok)
+	}
+	if _, ok := v.LineTime(len(v.Lines)); ok {
+		t.Error("LineTime out of range ok = true")
+	}
+	if got, ok := v.FirstTime(); !ok || !got.Equal(utc(14, 1, 0)) {
+		t.Errorf("FirstTime() = %v, %v, want 14:01:00", got, ok)
+	}
+}
+
+func TestLineTimeRecomputedWhenParserChanges(t *testing.T) {
+	v := &LogView{Lines: []string{"02/01 14:00:00 a"}, TimeParser: timestamps.Parser{Location: time.UTC}}
+	if _, ok := v.LineTime(0); ok {
+		t.Fatal("auto-detection found a timestamp in a custom format")
+	}
+	v.TimeParser.Layout = "02/01 15:04:05"
+	v.TimeParser.Year = 2024
+	if got, ok := v.LineTime(0); !ok || !got.Equal(utc(14, 0, 0)) {
+		t.Errorf("LineTime(0) = %v, %v after setting a Layout, want 2024-01-02 14:00:00", got, ok)
+	}
+}
+
+func TestFindTime(t *testing.T) {
+	v := timedLog()
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want int
+		ok   bool
+	}{
+		{"exact", utc(14, 4, 0), 4, true},
+		{"between lines lands on the next one", utc(14, 3, 0), 4, true},
+		{"before the log starts", utc(9, 0, 0), 1, true},
+		{"after the log ends", utc(15, 0, 0), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := v.FindTime(tt.t)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: FindTime(%v) = %d, %v, want %d, %v", tt.name, tt.t, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func rowNumbers(rows []table.Row) []string {
+	nums := make([]string, len(rows))
+	for i, r := range rows {
+		nums[i] = r[0]
+	}
+	return nums
+}
+
+func TestMakeTableTimeRangeHidesLinesOutsideIt(t *testing.T) {
+	v := timedLog()
+
+	v.TimeRange = timestamps.Range{Since: utc(14, 2, 0), Until: utc(14, 5, 0)}
+	got := rowNumbers(v.MakeTable(100, 30, nil, false, Context{}).Rows())
+	want := []string{"3", "4", "5"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("rows = %v, want %v (the 14:02-14:05 entry and its continuation line)", got, want)
+	}
+	if v.ShownCount != 3 {
+		t.Errorf("ShownCount = %d, want 3", v.ShownCount)
+	}
+
+	v.TimeRange = timestamps.Range{Until: utc(14, 1, 30)}
+	got = rowNumbers(v.MakeTable(100, 30, nil, false, Context{}).Rows())
+	if strings.Join(got, ",") != "1,2" {
+		t.Errorf("rows = %v, want the preamble and 14:01 line for an open-start range", got)
+	}
+
+	v.TimeRange = timestamps.Range{}
+	if rows := v.MakeTable(100, 30, nil, false, Context{}).Rows(); len(rows) != len(v.Lines) {
+		t.Errorf("got %d rows after clearing the range, want all %d", len(rows), len(v.Lines))
+	}
+}
+
+func TestMakeTableTimeRangeCombinesWithHideUnmatchedAndContext(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "INFO", "#87CEFA")}
+	v := timedLog()
+	v.TimeRange = timestamps.Range{Since: utc(14, 2, 0)}
+
+	got := rowNumbers(v.MakeTable(100, 30, filters, true, Context{}).Rows())
+	if strings.Join(got, ",") != "5,6" {
+		t.Errorf("rows = %v, want only the INFO lines from 14:02 on", got)
+	}
+
+	// Context is computed over the whole log and then clipped to the
+	// range: line 3 is context for the out-of-range 14:01 match, line 4
+	// for the 14:04 one, and the preamble stays hidden.
+	got = rowNumbers(v.MakeTable(100, 30, filters, true, Context{Before: 1, After: 1}).Rows())
+	if strings.Join(got, ",") != "3,4,5,6" {
+		t.Errorf("rows = %v, want 3,4,5,6 with one line of context", got)
+	}
+}
+
+func TestMakeTableShowsWholeBlocks(t *testing.T) {
+	block := mustFilter(t, "^BEGIN", "#98FB98")
+	block.XML.EndText = "^END"
+	block.EndRegex = mustRegex(t, "^END")
+	v := LogView{Lines: []string{"noise", "BEGIN job=1", "step one", "step two", "END job=1", "noise", "BEGIN job=2"}}
+
+	got := rowNumbers(v.MakeTable(100, 30, []filterfiles.Filter{block}, true, Context{}).Rows())
+	if want := "2 3 4 5 7"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %v, want lines %s: both blocks, the second running to the end of the log", got, want)
+	}
+	if counts := v.MatchCounts([]filterfiles.Filter{block}); counts[0] != 5 {
+		t.Errorf("MatchCounts = %v, want every line in a block counted", counts)
+	}
+
+	// Changing only the end pattern must not be served from the match cache.
+	block.XML.EndText = "step one"
+	block.EndRegex = mustRegex(t, "step one")
+	got = rowNumbers(v.MakeTable(100, 30, []filterfiles.Filter{block}, true, Context{}).Rows())
+	if want := "2 3 7"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %v after changing the end pattern, want lines %s", got, want)
+	}
+}
+
+func TestSetLinesRematchesSameLengthLines(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "^debug", "#87CEFA")}
+	v := LogView{Lines: []string{"debug: one", "info: two", "info: three"}, Cursor: 2}
+	v.MakeTable(100, 30, filters, true, Context{})
+	if v.ShownCount != 1 {
+		t.Fatalf("ShownCount = %d, want 1 before SetLines", v.ShownCount)
+	}
+
+	v.SetLines([]string{"info: one", "debug: two", "debug: three"})
+	v.MakeTable(100, 30, filters, true, Context{})
+	if v.ShownCount != 2 {
+		t.Errorf("ShownCount = %d after SetLines, want 2 -- the cached matches of the old lines must be dropped", v.ShownCount)
+	}
+
+	v.SetLines([]string{"debug: only"})
+	if v.Cursor != 0 {
+		t.Errorf("Cursor = %d after SetLines shortened the log, want it clamped to 0", v.Cursor)
+	}
+}
+
+func TestMoveShownStepsOverHiddenLines(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "keep", "#FF0000")}
+	v := LogView{Lines: []string{"keep a", "b", "keep b", "b again", "keep c", "keep d"}}
+	v.MakeTable(80, 20, filters, true, Context{})
+
+	steps := []struct {
+		n    int
+		want int
+	}{
+		{1, 2},
+		{2, 5},
+		{1, 5}, // already the last
+		{-10, 0},
+	}
+	for _, step := range steps {
+		if got := v.MoveShown(step.n); got != step.want {
+			t.Errorf("MoveShown(%d) = %d, want %d", step.n, got, step.want)
+		}
+	}
+
+	v.Cursor = 3 // hidden: counts from line 2, which it shows as
+	if got := v.MoveShown(1); got != 4 {
+		t.Errorf("MoveShown(1) from hidden line 3 = %d, want 4", got)
+	}
+}
+
+func TestViewCenteredKeepsCursorMidPane(t *testing.T) {
+	v := LogView{Lines: genLines(200), Cursor: 100, Centered: true}
+	tbl := v.MakeTable(60, 20, nil, false, Context{})
+	outLines := strings.Split(v.View(), "\n")[1:] // past the header
+
+	middle := outLines[(tbl.Height()-1)/2]
+	if !strings.Contains(middle, "101") {
+		t.Errorf("middle row = %q, want the cursor's line 101 there", middle)
+	}
+
+	// Near the end there's nothing to center it over, so the pane stays full.
+	v.Cursor = 198
+	tbl = v.MakeTable(60, 20, nil, false, Context{})
+	outLines = strings.Split(v.View(), "\n")[1:]
+	if last := outLines[tbl.Height()-1]; !strings.Contains(last, "200") {
+		t.Errorf("last row = %q, want the log's last line 200 at the bottom", last)
+	}
+}
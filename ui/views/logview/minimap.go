@@ -0,0 +1,138 @@
+package logview
+
+import (
+	"skim/filterfiles"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MinimapWidth is how many columns the minimap takes from the Line column
+// when Minimap is on.
+const MinimapWidth = 1
+
+// minimap caches the minimap's per-cell dominant filters, which only change
+// with the filters, the log or the pane's height, and holds its rendered
+// cells as of the last MakeTable.
+type minimap struct {
+	key      string
+	dominant []int // per cell, the filter highlighting most of its lines, or -1
+	cells    []string
+}
+
+var (
+	// minimapTrackStyle draws cells with no highlighted lines, and
+	// minimapViewportStyle the part of the track the pane is showing.
+	minimapTrackStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	minimapViewportStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Bold(true)
+)
+
+// minimapRange returns the lines [lo, hi) minimap cell k of height covers:
+// the whole log scaled to the pane, each cell covering at least one line
+// (so a log shorter than the pane stretches rather than stopping short).
+func minimapRange(k, height, lines int) (int, int) {
+	lo := k * lines / height
+	hi := max((k+1)*lines/height, lo+1)
+	return lo, min(hi, lines)
+}
+
+// MinimapLine returns the first line minimap cell k covers, for a click on
+// it to jump to, and false if k is outside the minimap or there's none.
+func (v *LogView) MinimapLine(k int) (int, bool) {
+	height := len(v.minimap.cells)
+	if !v.Minimap || k < 0 || k >= height || len(v.Lines) == 0 {
+		return 0, false
+	}
+	lo, _ := minimapRange(k, height, len(v.Lines))
+	return lo, true
+}
+
+// MinimapCell returns which minimap cell is at column x, row y of the pane
+// as last rendered -- counted from its top-left corner, border included --
+// and false if that isn't the minimap.
+func (v *LogView) MinimapCell(x, y, paneWidth int) (int, bool) {
+	if !v.Minimap {
+		return 0, false
+	}
+	col := paneWidth - paneBorderStyle.GetBorderRightSize() - MinimapWidth
+	k := y - paneBorderStyle.GetBorderTopSize() - 1 // the header row
+	if x < col || x >= col+MinimapWidth || k < 0 || k >= len(v.minimap.cells) {
+		return 0, false
+	}
+	return k, true
+}
+
+// renderMinimap renders the minimap's height cells: the whole log scaled
+// to the pane, each cell in the color of the filter that highlights the
+// most of the lines it covers (ties going to the earlier filter, as for
+// highlighting itself), and the cells covering the lines the pane is
+// showing marked as the viewport. It's over the whole log whether or not
+// unmatched lines are hidden, so it always shows what's above and below --
+// which is the point of it when they're not.
+func (v *LogView) renderMinimap(filters []filterfiles.Filter, height int) []string {
+	if height <= 0 {
+		return nil
+	}
+	v.ensureMinimap(len(filters), height)
+
+	// The viewport: the lines View will show, from the rows it scrolls to.
+	vlo, vhi := -1, -1
+	if top := v.scrollTop(); top < len(v.rowLines) {
+		vlo = v.rowLines[top]
+		vhi = v.rowLines[min(top+height, len(v.rowLines))-1]
+	}
+
+	cells := make([]string, height)
+	for k := range cells {
+		lo, hi := minimapRange(k, height, len(v.Lines))
+		inView := vlo >= 0 && lo <= vhi && hi > vlo
+		glyph := " "
+		if inView {
+			glyph = "┃"
+		}
+		f := v.minimap.dominant[k]
+		switch {
+		case f >= 0:
+			cells[k] = logStyle.Background(lipgloss.Color(filters[f].BackColor)).Render(glyph)
+		case inView:
+			cells[k] = minimapViewportStyle.Render(glyph)
+		default:
+			cells[k] = minimapTrackStyle.Render("│")
+		}
+	}
+	return cells
+}
+
+// ensureMinimap (re)computes v.minimap.dominant if the filters, log or
+// height have changed since it was last computed. matchCache must already
+// be current (see ensureMatchCache).
+func (v *LogView) ensureMinimap(filters, height int) {
+	key := v.matchCacheKey + "|" + strconv.Itoa(len(v.Lines)) + "|" + strconv.Itoa(height)
+	if key == v.minimap.key {
+		return
+	}
+
+	dominant := make([]int, height)
+	counts := make([]int, filters)
+	for k := range dominant {
+		dominant[k] = -1
+		lo, hi := minimapRange(k, height, len(v.Lines))
+		if lo >= hi {
+			continue
+		}
+		clear(counts)
+		best := 0
+		for _, ms := range v.matchCache[lo:hi] {
+			if ms.filterIndex < 0 || ms.excluded {
+				continue
+			}
+			counts[ms.filterIndex]++
+			c := counts[ms.filterIndex]
+			if c > best || (c == best && ms.filterIndex < dominant[k]) {
+				best, dominant[k] = c, ms.filterIndex
+			}
+		}
+	}
+	v.minimap.key = key
+	v.minimap.dominant = dominant
+}
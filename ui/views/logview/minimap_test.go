@@ -0,0 +1,134 @@
+package logview
+
+import (
+	"fmt"
+	"skim/filterfiles"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// minimapTrack renders the minimap as plain text, one rune per cell, for
+// tests: '┃' for the viewport, '#' for a cell with a dominant filter, '│'
+// for the empty track.
+func (v *LogView) minimapTrack() string {
+	var b strings.Builder
+	for k, c := range v.minimap.cells {
+		switch {
+		case strings.Contains(c, "┃"):
+			b.WriteRune('┃')
+		case v.minimap.dominant[k] >= 0:
+			b.WriteRune('#')
+		default:
+			b.WriteRune('│')
+		}
+	}
+	return b.String()
+}
+
+// tailErrors is a 100-line log whose only ERROR lines are the last 20.
+func tailErrors() []string {
+	lines := make([]string, 100)
+	for i := range lines {
+		level := "INFO"
+		if i >= 80 {
+			level = "ERROR"
+		}
+		lines[i] = fmt.Sprintf("%s line %d", level, i+1)
+	}
+	return lines
+}
+
+func TestMinimapShowsMatchesBeyondTheViewport(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000")}
+	v := &LogView{Lines: tailErrors(), Minimap: true}
+	v.MakeTable(80, 16, filters, false, Context{}) // 10 rows: 10 lines per cell
+
+	if got, want := v.minimapTrack(), "┃│││││││##"; got != want {
+		t.Errorf("minimap = %q, want %q: the viewport at the top, the errors at the bottom", got, want)
+	}
+
+	v.Cursor = 99
+	v.MakeTable(80, 16, filters, false, Context{})
+	if got, want := v.minimapTrack(), "││││││││#┃"; got != want {
+		t.Errorf("minimap = %q at the end of the log, want %q", got, want)
+	}
+}
+
+func TestMinimapCoversTheWholeLogWhenHidingUnmatched(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "ERROR", "#FF0000")}
+	v := &LogView{Lines: tailErrors(), Minimap: true}
+	v.MakeTable(80, 16, filters, true, Context{})
+
+	// The ten shown ERROR lines at the top of the pane are lines 81-90.
+	if got, want := v.minimapTrack(), "││││││││┃#"; got != want {
+		t.Errorf("minimap = %q, want %q", got, want)
+	}
+}
+
+func TestMinimapDominantFilter(t *testing.T) {
+	filters := []filterfiles.Filter{
+		mustFilter(t, "ERROR", "#FF0000"),
+		mustFilter(t, "WARN", "#FFFF00"),
+		mustExcludingFilter(t, "noise"),
+	}
+	v := &LogView{Lines: []string{
+		"WARN a", "WARN b", "ERROR c", "x", // WARN wins on count
+		"WARN d", "ERROR e", "x", "x", // a tie goes to the earlier filter
+		"ERROR noise", "ERROR noise", "WARN f", "x", // excluded lines don't count
+		"x", "x", "x", "x",
+	}}
+	v.ensureMatchCache(filters)
+	v.ensureMinimap(len(filters), 4)
+
+	if got, want := fmt.Sprint(v.minimap.dominant), "[1 0 1 -1]"; got != want {
+		t.Errorf("dominant = %s, want %s", got, want)
+	}
+}
+
+func TestMinimapKeepsRowWidth(t *testing.T) {
+	v := &LogView{Lines: genLines(50)}
+	v.MakeTable(60, 20, nil, false, Context{})
+	without := strings.Split(v.View(), "\n")
+
+	v.Minimap = true
+	v.MakeTable(60, 20, nil, false, Context{})
+	with := strings.Split(v.View(), "\n")
+
+	if len(with) != len(without) {
+		t.Fatalf("View() has %d rows with the minimap, want %d", len(with), len(without))
+	}
+	for i := range with {
+		if a, b := ansi.StringWidth(with[i]), ansi.StringWidth(without[i]); a != b {
+			t.Errorf("row %d is %d wide with the minimap, want %d", i, a, b)
+		}
+	}
+	if !strings.Contains(ansi.Strip(with[1]), "┃") {
+		t.Errorf("row 1 = %q, want the viewport marker at its end", ansi.Strip(with[1]))
+	}
+}
+
+func TestMinimapCellAndLine(t *testing.T) {
+	v := &LogView{Lines: genLines(100), Minimap: true}
+	v.MakeTable(60, 16, nil, false, Context{}) // 10 cells
+
+	// Cell k is on pane row k+2 (under the border and header), in the
+	// column just inside the right border.
+	if k, ok := v.MinimapCell(58, 5, 60); !ok || k != 3 {
+		t.Errorf("MinimapCell(58, 5) = %d, %v, want 3", k, ok)
+	}
+	for _, pos := range [][2]int{{57, 5}, {58, 1}, {58, 13}} {
+		if _, ok := v.MinimapCell(pos[0], pos[1], 60); ok {
+			t.Errorf("MinimapCell(%d, %d) = ok, want only the minimap's cells", pos[0], pos[1])
+		}
+	}
+	if line, ok := v.MinimapLine(3); !ok || line != 30 {
+		t.Errorf("MinimapLine(3) = %d, %v, want 30", line, ok)
+	}
+
+	v.Minimap = false
+	if _, ok := v.MinimapCell(58, 5, 60); ok {
+		t.Error("MinimapCell() = ok with the minimap off")
+	}
+}
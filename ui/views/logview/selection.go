@@ -0,0 +1,75 @@
+package logview
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// selectionStyle marks the rows of the selected lines (see
+// StartSelection), other than the cursor's own, which keeps the usual
+// cursor highlight.
+var selectionStyle = lipgloss.NewStyle().Background(lipgloss.Color("238"))
+
+// StartSelection starts selecting lines -- to fold (see FoldSelection),
+// copy or pipe somewhere (see SelectedLines) -- anchored at the selected
+// line (see SelectedLine): the selection then runs from there to
+// wherever the cursor moves, until ClearSelection.
+func (v *LogView) StartSelection() {
+	if i, ok := v.SelectedLine(); ok {
+		v.anchor = i
+		v.selecting = true
+	}
+}
+
+// ClearSelection ends the selection StartSelection started, reporting
+// whether there was one.
+func (v *LogView) ClearSelection() bool {
+	was := v.selecting
+	v.selecting = false
+	return was
+}
+
+// Selecting reports whether a selection is in progress.
+func (v *LogView) Selecting() bool {
+	return v.selecting
+}
+
+// Selection returns the selected lines, [lo, hi), as of the last
+// MakeTable: everything from the anchor to the selected line, both ends
+// included -- a whole fold, if either end is one -- and every line between
+// them, shown or not. It returns false if nothing is being selected.
+func (v *LogView) Selection() (lo, hi int, ok bool) {
+	i, shown := v.SelectedLine()
+	if !v.selecting || !shown {
+		return 0, 0, false
+	}
+	lo, hi = min(v.anchor, i), max(v.anchor, i)
+	if f, ok := v.FoldAt(lo); ok {
+		lo = f.Start
+	}
+	return lo, v.rowEnd(hi), true
+}
+
+// SelectedLines returns the lines the selection covers as they're shown:
+// every shown line in it (see Selection), in order, with a fold standing
+// for all of its lines. With nothing selected, it's just the selected line,
+// or fold. Hidden lines between shown ones are left out, so what's copied
+// or piped is what's on screen, unfolded.
+func (v *LogView) SelectedLines() []int {
+	lo, hi, ok := v.Selection()
+	if !ok {
+		i, shown := v.SelectedLine()
+		if !shown {
+			return nil
+		}
+		lo, hi = i, v.rowEnd(i)
+	}
+	var lines []int
+	for k := sort.SearchInts(v.shownIndices, lo); k < len(v.shownIndices) && v.shownIndices[k] < hi; k++ {
+		for i, end := v.shownIndices[k], v.rowEnd(v.shownIndices[k]); i < end; i++ {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
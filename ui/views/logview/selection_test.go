@@ -0,0 +1,33 @@
+package logview
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectedLines(t *testing.T) {
+	v, filters := hiddenLog(t)
+	v.FoldLines(8, 11)
+	v.Cursor = 5
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	if got, want := v.SelectedLines(), []int{5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectedLines() with nothing selected = %v, want just the cursor line %v", got, want)
+	}
+
+	v.StartSelection()
+	v.Cursor = 15
+	v.MakeTable(100, 30, filters, true, Context{})
+	// The hidden lines between the shown ones are left out; the fold's are
+	// all in.
+	if got, want := v.SelectedLines(), []int{5, 8, 9, 10, 15}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectedLines() = %v, want %v", got, want)
+	}
+
+	v.ClearSelection()
+	v.Cursor = 8
+	v.MakeTable(100, 30, filters, true, Context{})
+	if got, want := v.SelectedLines(), []int{8, 9, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectedLines() on a fold = %v, want all of its lines %v", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package logview
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SpikeWindow is how many seconds of history a spike's rolling baseline
+// averages over (see SpikeThreshold).
+const SpikeWindow = 60
+
+// Anomaly is a place in the log where its rate changes abruptly: a gap, or
+// the first line of a burst. Line is the index into Lines it starts at.
+type Anomaly struct {
+	Line int
+
+	// Gap is how long the log was silent before Line, for a gap; 0 for a
+	// spike.
+	Gap time.Duration
+
+	// Rate and Baseline are, for a spike, how many lines were written in
+	// Line's second and the rolling average per second over the
+	// SpikeWindow seconds before it.
+	Rate     int
+	Baseline float64
+}
+
+// String describes a for the status line.
+func (a Anomaly) String() string {
+	if a.Gap > 0 {
+		return fmt.Sprintf("%s gap at line %d", formatGap(a.Gap), a.Line+1)
+	}
+	return fmt.Sprintf("spike at line %d: %d lines/s (baseline %.1f/s)", a.Line+1, a.Rate, a.Baseline)
+}
+
+// timeline caches the log's gaps and spikes (see ensureTimeline), both
+// sorted by Line.
+type timeline struct {
+	key    string
+	gaps   []Anomaly
+	spikes []Anomaly
+}
+
+// ensureTimeline (re)computes v.timeline if the line times (see ensureTimes),
+// GapThreshold or SpikeThreshold have changed since the last call.
+//
+// Both are properties of the whole log, not of what's currently shown: a
+// silence or a flood is something the service did, so hiding unmatched
+// lines doesn't make a gap out of the stretch between two matches.
+func (v *LogView) ensureTimeline() {
+	v.ensureTimes()
+	key := v.timesKey + "|" + v.GapThreshold.String() + "|" + strconv.Itoa(v.SpikeThreshold)
+	if key == v.timeline.key {
+		return
+	}
+
+	tl := timeline{key: key}
+	if v.GapThreshold > 0 {
+		var prev time.Time
+		for i, t := range v.times {
+			if t.IsZero() {
+				continue
+			}
+			if !prev.IsZero() && t.Sub(prev) > v.GapThreshold {
+				tl.gaps = append(tl.gaps, Anomaly{Line: i, Gap: t.Sub(prev)})
+			}
+			prev = t
+		}
+	}
+	if v.SpikeThreshold > 0 {
+		tl.spikes = findSpikes(v.times, v.SpikeThreshold)
+	}
+	v.timeline = tl
+}
+
+// findSpikes returns the first line of every run of seconds in which at
+// least threshold more lines were written than the average per second over
+// the SpikeWindow seconds before it (seconds with no lines counting as 0).
+// The log's first second has no history to compare against, and until a
+// full window has passed the average is over the seconds there are, so the
+// start of a log doesn't look like a burst just for starting.
+func findSpikes(times []time.Time, threshold int) []Anomaly {
+	type second struct {
+		unix  int64
+		first int // first line written in it
+		count int
+	}
+	counts := map[int64]*second{}
+	var seconds []*second
+	for i, t := range times {
+		if t.IsZero() {
+			continue
+		}
+		s := t.Unix()
+		if c, ok := counts[s]; ok {
+			c.count++
+			continue
+		}
+		c := &second{unix: s, first: i, count: 1}
+		counts[s] = c
+		seconds = append(seconds, c)
+	}
+	// Logs are mostly, not strictly, in order; the window below needs
+	// seconds in order.
+	sort.Slice(seconds, func(a, b int) bool { return seconds[a].unix < seconds[b].unix })
+
+	var spikes []Anomaly
+	lo, sum := 0, 0  // seconds[lo:hi] are the window before seconds[hi]; sum their counts
+	inSpike := false // whether the previous second was part of a spike
+	for hi, s := range seconds {
+		for lo < hi && seconds[lo].unix < s.unix-SpikeWindow {
+			sum -= seconds[lo].count
+			lo++
+		}
+		if hi == 0 {
+			sum += s.count
+			continue
+		}
+		span := min(s.unix-seconds[0].unix, SpikeWindow)
+		baseline := float64(sum) / float64(span)
+		spiking := float64(s.count)-baseline >= float64(threshold)
+		continued := inSpike && seconds[hi-1].unix == s.unix-1
+		if spiking && !continued {
+			spikes = append(spikes, Anomaly{Line: s.first, Rate: s.count, Baseline: baseline})
+		}
+		inSpike = spiking
+		sum += s.count
+	}
+	return spikes
+}
+
+// FindNextAnomaly returns the first gap or spike (see GapThreshold and
+// SpikeThreshold) that starts after Cursor, and false if there isn't one.
+// Unlike FindNext it doesn't wrap: "the next thing that went wrong" past
+// the end of the log is nothing, not the first thing again.
+func (v *LogView) FindNextAnomaly() (Anomaly, bool) {
+	v.ensureTimeline()
+	next := func(list []Anomaly) (Anomaly, bool) {
+		k := sort.Search(len(list), func(k int) bool { return list[k].Line > v.Cursor })
+		if k == len(list) {
+			return Anomaly{}, false
+		}
+		return list[k], true
+	}
+	gap, gapOK := next(v.timeline.gaps)
+	spike, spikeOK := next(v.timeline.spikes)
+	switch {
+	case gapOK && (!spikeOK || gap.Line <= spike.Line):
+		return gap, true
+	case spikeOK:
+		return spike, true
+	}
+	return Anomaly{}, false
+}
+
+// FindPrevAnomaly is FindNextAnomaly backwards: the last gap or spike that
+// starts before Cursor.
+func (v *LogView) FindPrevAnomaly() (Anomaly, bool) {
+	v.ensureTimeline()
+	prev := func(list []Anomaly) (Anomaly, bool) {
+		k := sort.Search(len(list), func(k int) bool { return list[k].Line >= v.Cursor })
+		if k == 0 {
+			return Anomaly{}, false
+		}
+		return list[k-1], true
+	}
+	gap, gapOK := prev(v.timeline.gaps)
+	spike, spikeOK := prev(v.timeline.spikes)
+	switch {
+	case gapOK && (!spikeOK || gap.Line >= spike.Line):
+		return gap, true
+	case spikeOK:
+		return spike, true
+	}
+	return Anomaly{}, false
+}
+
+// gapLabel describes the gaps between two consecutively shown lines, prev
+// and next (indices into Lines), for their separator row (see separator),
+// and returns false if there are none. A gap among lines hidden between the
+// two is still described, so hiding unmatched lines never hides an outage
+// along with them.
+func (v *LogView) gapLabel(prev, next int) (string, bool) {
+	gaps := v.timeline.gaps
+	k := sort.Search(len(gaps), func(k int) bool { return gaps[k].Line > prev })
+	if k == len(gaps) || gaps[k].Line > next {
+		return "", false
+	}
+	longest, count := gaps[k].Gap, 0
+	for ; k < len(gaps) && gaps[k].Line <= next; k++ {
+		longest = max(longest, gaps[k].Gap)
+		count++
+	}
+
+	if count > 1 {
+		return fmt.Sprintf("%d gaps, longest %s", count, formatGap(longest)), true
+	}
+	return formatGap(longest) + " gap", true
+}
+
+// formatGap renders d at a precision that suits a human reading a gap:
+// whole seconds, or milliseconds under a second.
+func formatGap(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
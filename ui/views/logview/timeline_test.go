@@ -0,0 +1,250 @@
+package logview
+
+import (
+	"fmt"
+	"skim/filterfiles"
+	"skim/timestamps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// stamp formats a line written s seconds after 14:00:00 on 2024-01-02.
+func stamp(s float64, msg string) string {
+	t := time.Date(2024, 1, 2, 14, 0, 0, 0, time.UTC).Add(time.Duration(s * float64(time.Second)))
+	return t.Format("2006-01-02 15:04:05.000") + " " + msg
+}
+
+func gapLog() *LogView {
+	return &LogView{
+		Lines: []string{
+			stamp(0, "INFO a"),
+			stamp(1, "INFO b"),
+			stamp(200, "ERROR c"), // 3m19s of silence before this
+			stamp(201, "INFO d"),
+			stamp(202, "INFO e"),
+			stamp(260, "INFO f"), // and 58s before this
+		},
+		TimeParser:   timestamps.Parser{Location: time.UTC},
+		GapThreshold: 30 * time.Second,
+	}
+}
+
+// plainRows renders MakeTable's rows' Line cells without styling.
+func plainRows(v *LogView) []string {
+	var out []string
+	for _, r := range v.Table.Rows() {
+		out = append(out, r[0]+"|"+ansi.Strip(r[len(r)-1]))
+	}
+	return out
+}
+
+func TestMakeTableMarksGapsWithSeparatorRows(t *testing.T) {
+	v := gapLog()
+	v.MakeTable(100, 30, nil, false, Context{})
+
+	got := plainRows(v)
+	want := []string{
+		"1|" + v.Lines[0],
+		"2|" + v.Lines[1],
+		"|── 3m19s gap ──",
+		"3|" + v.Lines[2],
+		"4|" + v.Lines[3],
+		"5|" + v.Lines[4],
+		"|── 58s gap ──",
+		"6|" + v.Lines[5],
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMakeTableGapSeparatorNeverTakesTheCursor(t *testing.T) {
+	v := gapLog()
+	v.Cursor = 2
+	table := v.MakeTable(100, 30, nil, false, Context{})
+
+	if got := table.SelectedRow(); got[0] != "3" {
+		t.Errorf("SelectedRow() = %v, want line 3 itself, not the separator above it", got)
+	}
+	if !strings.Contains(ansi.Strip(v.View()), "3m19s gap") {
+		t.Error("View() doesn't show the separator above the cursor line")
+	}
+}
+
+func TestMakeTableMarksGapsAmongHiddenLines(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "INFO", "#87CEFA")}
+	v := gapLog()
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	got := plainRows(v)
+	if len(got) != 7 || got[2] != "|── 3m19s gap ──" || got[3] != "4|"+v.Lines[3] {
+		t.Errorf("rows = %q, want the gap before hidden line 3 marked above line 4", got)
+	}
+
+	v.Lines = append(v.Lines[:5:5], stamp(300, "ERROR x"), stamp(400, "INFO g"))
+	v.MakeTable(100, 30, filters, true, Context{})
+	got = plainRows(v)
+	if last := got[len(got)-2]; last != "|── 2 gaps, longest 1m40s ──" {
+		t.Errorf("separator = %q, want both hidden gaps summarized", last)
+	}
+}
+
+func TestMakeTableWithoutGapThresholdHasNoSeparators(t *testing.T) {
+	v := gapLog()
+	v.GapThreshold = 0
+	v.MakeTable(100, 30, nil, false, Context{})
+
+	if rows := v.Table.Rows(); len(rows) != len(v.Lines) {
+		t.Errorf("got %d rows, want one per line with no GapThreshold", len(rows))
+	}
+}
+
+func TestMakeTableGapSeparatorsInWrapAndStructuredModes(t *testing.T) {
+	v := gapLog()
+	v.Wrap = true
+	v.MakeTable(40, 30, nil, false, Context{})
+	if !strings.Contains(strings.Join(plainRows(v), "\n"), "3m19s gap") {
+		t.Error("no gap separator in Wrap mode")
+	}
+
+	v = gapLog()
+	v.Columns = []Column{{Field: "msg"}}
+	v.Structured = true
+	v.MakeTable(100, 30, nil, false, Context{})
+	if !strings.Contains(strings.Join(plainRows(v), "\n"), "3m19s gap") {
+		t.Error("no gap separator in Structured mode")
+	}
+}
+
+func TestViewHeightConstantWithGapSeparators(t *testing.T) {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = stamp(float64(i*60), fmt.Sprintf("line %d", i))
+	}
+	v := &LogView{Lines: lines, TimeParser: timestamps.Parser{Location: time.UTC}, GapThreshold: 30 * time.Second}
+
+	for _, cursor := range []int{0, 1, 100, 199} {
+		v.Cursor = cursor
+		tbl := v.MakeTable(80, 20, nil, false, Context{})
+		view := v.View()
+		if h := strings.Count(view, "\n") + 1; h != tbl.Height()+1 {
+			t.Errorf("cursor %d: View() is %d lines, want %d", cursor, h, tbl.Height()+1)
+		}
+		if !strings.Contains(view, fmt.Sprintf("line %d", cursor)) {
+			t.Errorf("cursor %d: View() doesn't show the cursor line", cursor)
+		}
+	}
+}
+
+// burstLog has a steady line per second for two minutes, then 40 lines in
+// one second at 14:02:00, then steady again, then another burst.
+func burstLog() *LogView {
+	var lines []string
+	for s := 0; s < 120; s++ {
+		lines = append(lines, stamp(float64(s), "tick"))
+	}
+	for k := 0; k < 40; k++ {
+		lines = append(lines, stamp(120+float64(k)/40, "flood"))
+	}
+	for s := 121; s < 150; s++ {
+		lines = append(lines, stamp(float64(s), "tick"))
+	}
+	for k := 0; k < 60; k++ {
+		lines = append(lines, stamp(150+float64(k)/60, "flood again"))
+	}
+	return &LogView{Lines: lines, TimeParser: timestamps.Parser{Location: time.UTC}, SpikeThreshold: 20}
+}
+
+func TestFindNextAnomalyFindsSpikes(t *testing.T) {
+	v := burstLog()
+
+	a, ok := v.FindNextAnomaly()
+	if !ok || a.Line != 120 || a.Rate != 40 || a.Gap != 0 {
+		t.Fatalf("FindNextAnomaly() = %+v, %v, want the 40-line burst starting at line 121", a, ok)
+	}
+	if a.Baseline < 0.9 || a.Baseline > 1.1 {
+		t.Errorf("Baseline = %v, want about 1 line/s", a.Baseline)
+	}
+
+	v.Cursor = a.Line
+	a, ok = v.FindNextAnomaly()
+	if !ok || a.Line != 189 {
+		t.Fatalf("FindNextAnomaly() = %+v, %v, want the second burst at line 190", a, ok)
+	}
+
+	v.Cursor = a.Line
+	if a, ok := v.FindNextAnomaly(); ok {
+		t.Errorf("FindNextAnomaly() = %+v past the last burst, want none (no wrapping)", a)
+	}
+
+	if a, ok := v.FindPrevAnomaly(); !ok || a.Line != 120 {
+		t.Errorf("FindPrevAnomaly() = %+v, %v, want the first burst", a, ok)
+	}
+}
+
+func TestFindSpikesIgnoresTheStartOfTheLog(t *testing.T) {
+	var times []time.Time
+	start := time.Date(2024, 1, 2, 14, 0, 0, 0, time.UTC)
+	for k := 0; k < 100; k++ {
+		times = append(times, start) // a busy first second
+	}
+	times = append(times, start.Add(time.Second), start.Add(2*time.Second))
+
+	if spikes := findSpikes(times, 20); len(spikes) != 0 {
+		t.Errorf("findSpikes() = %+v, want none: the first second has nothing to compare against", spikes)
+	}
+}
+
+func TestFindNextAnomalyInterleavesGapsAndSpikes(t *testing.T) {
+	v := gapLog()
+	v.SpikeThreshold = 1000 // effectively off
+
+	var got []string
+	for {
+		a, ok := v.FindNextAnomaly()
+		if !ok {
+			break
+		}
+		got = append(got, a.String())
+		v.Cursor = a.Line
+	}
+	want := []string{"3m19s gap at line 3", "58s gap at line 6"}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("anomalies = %q, want %q", got, want)
+	}
+
+	if a, ok := v.FindPrevAnomaly(); !ok || a.Line != 2 {
+		t.Errorf("FindPrevAnomaly() = %+v, %v from the last gap, want the first", a, ok)
+	}
+}
+
+func TestAnomaliesRecomputedWhenThresholdChanges(t *testing.T) {
+	v := gapLog()
+	if a, _ := v.FindNextAnomaly(); a.Line != 2 {
+		t.Fatalf("FindNextAnomaly() = %+v, want the first gap", a)
+	}
+	v.GapThreshold = 2 * time.Minute
+	v.Cursor = 2
+	if a, ok := v.FindNextAnomaly(); ok {
+		t.Errorf("FindNextAnomaly() = %+v after raising GapThreshold past the 58s gap, want none", a)
+	}
+}
+
+func TestShownAtOrAfter(t *testing.T) {
+	filters := []filterfiles.Filter{mustFilter(t, "INFO", "#87CEFA")}
+	v := gapLog()
+	v.MakeTable(100, 30, filters, true, Context{})
+
+	if got := v.ShownAtOrAfter(2); got != 3 {
+		t.Errorf("ShownAtOrAfter(2) = %d, want 3 (line 2 is hidden)", got)
+	}
+	if got := v.ShownAtOrAfter(4); got != 4 {
+		t.Errorf("ShownAtOrAfter(4) = %d, want 4 (already shown)", got)
+	}
+	if got := v.ShownAtOrAfter(99); got != 99 {
+		t.Errorf("ShownAtOrAfter(99) = %d, want 99 when nothing after it is shown", got)
+	}
+}